@@ -0,0 +1,218 @@
+package dotignore
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteTar writes a tar archive of every file below root that matcher does
+// not ignore to w. Entries are named by their slash-separated path relative
+// to root, which is also the path passed to matcher, with a trailing slash
+// for directories, and keep their mode and modification time. Symbolic links are stored as links rather than
+// followed; sockets, devices and other special files are left out.
+//
+// Ignored directories are not descended into, so a negation pattern cannot
+// bring back a file below an ignored directory, as with Git. When matcher is
+// a RepositoryMatcher, root should be its root directory.
+//
+// The archive is finished, but w is not closed.
+func WriteTar(w io.Writer, root string, matcher Matcher) error {
+	tw := tar.NewWriter(w)
+	err := walkKept(root, matcher, func(path, name string, d fs.DirEntry) error {
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFile(tw, path)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write tar archive: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write tar archive: %w", err)
+	}
+	return nil
+}
+
+// ZipOptions configures WriteZip.
+type ZipOptions struct {
+	// Store writes files uncompressed instead of compressing them with
+	// Deflate.
+	Store bool
+
+	// IncludeEmptyDirs adds an entry for every directory that is not ignored
+	// but contains nothing that is kept. Other directories are implied by
+	// the paths of the files inside them.
+	IncludeEmptyDirs bool
+}
+
+// WriteZip writes a zip archive of every file below root that matcher does
+// not ignore to w, following the same rules as WriteTar. Symbolic links are
+// stored as links, with the target as their content. If opts is nil, files
+// are compressed and empty directories are left out.
+//
+// The archive is finished, but w is not closed.
+func WriteZip(w io.Writer, root string, matcher Matcher, opts *ZipOptions) error {
+	if opts == nil {
+		opts = &ZipOptions{}
+	}
+	method := zip.Deflate
+	if opts.Store {
+		method = zip.Store
+	}
+
+	zw := zip.NewWriter(w)
+
+	// A directory is written once the walk has moved past it without
+	// finding anything inside
+	var emptyDir fs.FileInfo
+	var emptyDirName string
+	writeEmptyDir := func() error {
+		if emptyDir == nil {
+			return nil
+		}
+		header, err := zip.FileInfoHeader(emptyDir)
+		if err != nil {
+			return err
+		}
+		header.Name = emptyDirName + "/"
+		emptyDir = nil
+		_, err = zw.CreateHeader(header)
+		return err
+	}
+
+	err := walkKept(root, matcher, func(path, name string, d fs.DirEntry) error {
+		if emptyDir != nil && strings.HasPrefix(name, emptyDirName+"/") {
+			emptyDir = nil
+		}
+		if err := writeEmptyDir(); err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if opts.IncludeEmptyDirs {
+				emptyDir, emptyDirName = info, name
+			}
+			return nil
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		header.Method = method
+		entry, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(entry, link)
+			return err
+		}
+		return copyFile(entry, path)
+	})
+	if err == nil {
+		err = writeEmptyDir()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write zip archive: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write zip archive: %w", err)
+	}
+	return nil
+}
+
+// walkKept calls fn for every directory, regular file and symbolic link
+// below root that matcher does not ignore, in lexical order, with its path
+// and its slash-separated name relative to root. Directories are matched
+// with a trailing slash, and ignored ones are skipped entirely.
+func walkKept(root string, matcher Matcher, fn func(path, name string, d fs.DirEntry) error) error {
+	if matcher == nil {
+		return errors.New("matcher cannot be nil")
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if !d.IsDir() && !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(relPath)
+
+		matchPath := name
+		if d.IsDir() {
+			matchPath += "/"
+		}
+		ignored, err := matcher.Matches(matchPath)
+		if err != nil {
+			return err
+		}
+		if ignored {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(path, name, d)
+	})
+}
+
+// copyFile copies the contents of the file at path to w.
+func copyFile(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
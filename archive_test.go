@@ -0,0 +1,217 @@
+package dotignore
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteTar(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":        "*.log\nbuild/\n!build/keep.txt\n",
+		"main.go":           "package main\n",
+		"debug.log":         "debug",
+		"build/out.bin":     "binary",
+		"build/keep.txt":    "keep",
+		"scripts/run.sh":    "#!/bin/sh\n",
+		"scripts/trace.log": "trace",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.Chmod(filepath.Join(tmpDir, "scripts", "run.sh"), 0755); err != nil {
+		t.Fatalf("Failed to change mode: %v", err)
+	}
+	hasSymlink := os.Symlink("main.go", filepath.Join(tmpDir, "link.go")) == nil
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTar(&buf, tmpDir, matcher); err != nil {
+		t.Fatalf("WriteTar failed: %v", err)
+	}
+
+	var names []string
+	contents := make(map[string]string)
+	headers := make(map[string]*tar.Header)
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", header.Name, err)
+		}
+		names = append(names, header.Name)
+		contents[header.Name] = string(data)
+		headers[header.Name] = header
+	}
+
+	expected := []string{".gitignore", "main.go", "scripts/", "scripts/run.sh"}
+	if hasSymlink {
+		expected = []string{".gitignore", "link.go", "main.go", "scripts/", "scripts/run.sh"}
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected entries %v, got %v", expected, names)
+	}
+
+	if contents["main.go"] != "package main\n" {
+		t.Errorf("Unexpected content for main.go: %q", contents["main.go"])
+	}
+	if headers["scripts/"].Typeflag != tar.TypeDir {
+		t.Errorf("Expected scripts/ to be a directory entry")
+	}
+	if mode := headers["scripts/run.sh"].FileInfo().Mode().Perm(); mode != 0755 {
+		t.Errorf("Expected scripts/run.sh to keep mode 0755, got %v", mode)
+	}
+	if hasSymlink {
+		if link := headers["link.go"]; link.Typeflag != tar.TypeSymlink || link.Linkname != "main.go" {
+			t.Errorf("Expected link.go to be a symlink to main.go, got type %c to %q", link.Typeflag, link.Linkname)
+		}
+	}
+}
+
+func TestWriteTarStrictDirectoryPatterns(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		"main.go":       "package main\n",
+		"build/out.bin": "binary",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	// Directories are matched as directories, so "build/" leaves no entry
+	matcher, err := NewPatternMatcher([]string{"build/"}, WithStrictDirectoryPatterns())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTar(&buf, tmpDir, matcher); err != nil {
+		t.Fatalf("WriteTar failed: %v", err)
+	}
+
+	var names []string
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		names = append(names, header.Name)
+	}
+	if expected := []string{"main.go"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected entries %v, got %v", expected, names)
+	}
+}
+
+func TestWriteTarErrors(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTar(&buf, "/path/that/does/not/exist", matcher); err == nil {
+		t.Error("Expected error for missing root")
+	}
+	if err := WriteTar(&buf, t.TempDir(), nil); err == nil {
+		t.Error("Expected error for nil matcher")
+	}
+}
+
+func TestWriteZip(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":     "*.log\n/cache/\n",
+		"main.go":        "package main\n",
+		"debug.log":      "debug",
+		"cache/data.bin": "data",
+		"pkg/util.go":    "package pkg\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	for _, dir := range []string{"empty", "nested/empty", "logs-only"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "logs-only", "a.log"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     *ZipOptions
+		method   uint16
+		expected []string
+	}{
+		{
+			name:     "Default options",
+			method:   zip.Deflate,
+			expected: []string{".gitignore", "main.go", "pkg/util.go"},
+		},
+		{
+			name:     "Store with empty directories",
+			opts:     &ZipOptions{Store: true, IncludeEmptyDirs: true},
+			method:   zip.Store,
+			expected: []string{".gitignore", "empty/", "logs-only/", "main.go", "nested/empty/", "pkg/util.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteZip(&buf, tmpDir, matcher, tt.opts); err != nil {
+				t.Fatalf("WriteZip failed: %v", err)
+			}
+
+			zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatalf("Failed to read archive: %v", err)
+			}
+
+			var names []string
+			for _, file := range zr.File {
+				names = append(names, file.Name)
+				if file.FileInfo().IsDir() {
+					continue
+				}
+				if file.Method != tt.method {
+					t.Errorf("Expected method %d for %s, got %d", tt.method, file.Name, file.Method)
+				}
+				if file.Name == "main.go" {
+					rc, err := file.Open()
+					if err != nil {
+						t.Fatalf("Failed to open %s: %v", file.Name, err)
+					}
+					data, err := io.ReadAll(rc)
+					rc.Close()
+					if err != nil || string(data) != "package main\n" {
+						t.Errorf("Unexpected content for main.go: %q, %v", data, err)
+					}
+				}
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("Expected entries %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package dotignore
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ResticExcludes translates the patterns of the matcher into patterns for a
+// restic exclude file, one per element, in the order restic evaluates them.
+// Write them to a file, one per line, and pass it to restic with
+// --exclude-file=FILE. root is the absolute path of the directory the
+// patterns are relative to, since restic matches patterns against absolute
+// paths.
+//
+// Like ignore files, restic lets the last matching pattern decide, so the
+// order is kept, and negations keep their "!". Each pattern becomes the
+// globs of ToGlobs, prefixed with root, whose wildcard characters are
+// escaped; "$" is written as "$$" because restic expands environment
+// variables in exclude files.
+//
+// Some behavior cannot be expressed in restic's pattern syntax:
+//   - restic does not descend into excluded directories, so a negation cannot
+//     re-include a file below an excluded directory, as with WithStrictNegation
+//   - directory-only patterns also exclude files of the same name
+//   - case-insensitive matchers produce lowercase patterns, which should be
+//     passed with --iexclude-file instead
+//   - SyntaxHelm patterns starting with "!" ignore every path they do not
+//     match and are left out
+func (p *PatternMatcher) ResticExcludes(root string) []string {
+	var excludes []string
+	for _, glob := range p.ToGlobs() {
+		excludes = append(excludes, resticPattern(root, "", glob))
+	}
+	return excludes
+}
+
+// ResticExcludes translates the rules of every loaded ignore file into a
+// single list of restic exclude patterns, as described for
+// PatternMatcher.ResticExcludes, rooted at the root directory of the
+// repository. Patterns from ignore files in subdirectories are anchored to
+// their directory, and the built-in exclusions of .git directories and
+// skipped nested repositories are included.
+//
+// As with RsyncFilterRules, NestedRepositoryScope is not reproduced and
+// patterns of ignore files above the root are kept only if they match a
+// name at any depth.
+func (rm *RepositoryMatcher) ResticExcludes() []string {
+	var excludes []string
+	rm.exportPatterns(func(matcher *PatternMatcher, pattern ignorePattern, dir, _ string) {
+		for _, glob := range matcher.appendGlobs(nil, pattern) {
+			excludes = append(excludes, resticPattern(rm.rootDir, dir, glob))
+		}
+	})
+
+	// Built-in exclusions come last so that no negation overrides them
+	for _, dir := range rm.skippedNestedDirs() {
+		excludes = append(excludes, resticPattern(rm.rootDir, "", dir))
+	}
+	if !rm.config.IncludeGitDir {
+		excludes = append(excludes, resticPattern(rm.rootDir, "", "**/"+gitDirName))
+	}
+	return excludes
+}
+
+// resticPattern converts a glob returned by ToGlobs for an ignore file in
+// the slash-separated directory dir into a restic pattern below root.
+func resticPattern(root, dir, glob string) string {
+	prefix := ""
+	if strings.HasPrefix(glob, "!") {
+		prefix, glob = "!", glob[1:]
+	}
+	root = escapeLiteralPath(strings.TrimSuffix(filepath.ToSlash(root), "/"))
+	path := root + "/" + joinRsyncPath(escapeLiteralPath(dir), glob)
+
+	// filepath.Match negates a class with "^" rather than "!"
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path):
+			b.WriteString(path[i : i+2])
+			i++
+		case path[i] == '[' && i+1 < len(path) && path[i+1] == '!':
+			b.WriteString("[^")
+			i++
+		case path[i] == '$':
+			b.WriteString("$$")
+		default:
+			b.WriteByte(path[i])
+		}
+	}
+	return prefix + b.String()
+}
+
+// BorgPatterns translates the patterns of the matcher into rules for a
+// borgbackup patterns file, one per element, in the order borg evaluates
+// them. Write them to a file, one per line, and pass it to borg create with
+// --patterns-from=FILE. root is the path of the directory the patterns are
+// relative to as borg archives it, such as "home/user/project" for
+// "borg create REPO::NAME /home/user/project"; a leading slash is dropped
+// and wildcard characters in it match literally.
+//
+// borg applies the first rule that matches while ignore files apply the
+// last, so the rules are emitted in reverse pattern order, using borg's "sh:"
+// style for the globs of ToGlobs. Negations become "+" rules. Other patterns
+// become "!" rules, which keep borg out of excluded directories, unless the
+// matcher has negations; then they become "-" rules so that borg still looks
+// for re-included files below excluded directories.
+//
+// Some behavior cannot be expressed in borg's pattern syntax:
+//   - directory-only patterns also exclude files of the same name
+//   - case-insensitive matchers produce lowercase rules, which borg still
+//     matches case-sensitively
+//   - SyntaxHelm patterns starting with "!" ignore every path they do not
+//     match and are left out
+func (p *PatternMatcher) BorgPatterns(root string) []string {
+	exclude := "! "
+	if !p.rules.Load().negationFree {
+		exclude = "- "
+	}
+	var rules []string
+	for _, glob := range p.ToGlobs() {
+		rules = append(rules, borgRule(root, "", glob, exclude))
+	}
+	reverseStrings(rules)
+	return rules
+}
+
+// BorgPatterns translates the rules of every loaded ignore file into a
+// single list of borg pattern rules, as described for
+// PatternMatcher.BorgPatterns, for the root directory of the repository
+// archived by its absolute path. Patterns from ignore files in
+// subdirectories are anchored to their directory, and the built-in
+// exclusions of .git directories and skipped nested repositories are
+// included.
+//
+// As with RsyncFilterRules, NestedRepositoryScope is not reproduced and
+// patterns of ignore files above the root are kept only if they match a
+// name at any depth.
+func (rm *RepositoryMatcher) BorgPatterns() []string {
+	type exported struct {
+		glob string
+		dir  string
+	}
+	var globs []exported
+	negationFree := true
+	rm.exportPatterns(func(matcher *PatternMatcher, pattern ignorePattern, dir, _ string) {
+		negationFree = negationFree && !pattern.negate
+		for _, glob := range matcher.appendGlobs(nil, pattern) {
+			globs = append(globs, exported{glob, dir})
+		}
+	})
+
+	exclude := "! "
+	if !negationFree {
+		exclude = "- "
+	}
+	var rules []string
+	for _, glob := range globs {
+		rules = append(rules, borgRule(rm.rootDir, glob.dir, glob.glob, exclude))
+	}
+
+	// Built-in exclusions take precedence over every pattern
+	nested := rm.skippedNestedDirs()
+	for i := len(nested) - 1; i >= 0; i-- {
+		rules = append(rules, borgRule(rm.rootDir, "", nested[i], "! "))
+	}
+	if !rm.config.IncludeGitDir {
+		rules = append(rules, borgRule(rm.rootDir, "", "**/"+gitDirName, "! "))
+	}
+
+	reverseStrings(rules)
+	return rules
+}
+
+// borgRule converts a glob returned by ToGlobs for an ignore file in the
+// slash-separated directory dir into a borg rule below root, using exclude
+// as the rule type unless the glob is a negation.
+func borgRule(root, dir, glob, exclude string) string {
+	ruleType := exclude
+	if strings.HasPrefix(glob, "!") {
+		ruleType, glob = "+ ", glob[1:]
+	}
+	path := joinRsyncPath(escapeLiteralPath(dir), glob)
+	if root = strings.Trim(filepath.ToSlash(root), "/"); root != "" && root != "." {
+		path = escapeLiteralPath(root) + "/" + path
+	}
+
+	// borg's shell patterns have no escapes; a class matches a wildcard
+	// literally
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] != '\\' || i+1 == len(path) {
+			b.WriteByte(path[i])
+			continue
+		}
+		i++
+		if strings.IndexByte("*?[", path[i]) >= 0 {
+			b.WriteString("[" + path[i:i+1] + "]")
+		} else {
+			b.WriteByte(path[i])
+		}
+	}
+	return ruleType + "sh:" + b.String()
+}
+
+// escapeLiteralPath escapes the characters of the slash-separated path that
+// a glob would treat as wildcards, so that the path matches only itself.
+func escapeLiteralPath(path string) string {
+	if !strings.ContainsAny(path, `*?[\`) {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if strings.IndexByte(`*?[\`, path[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
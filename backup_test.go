@@ -0,0 +1,158 @@
+package dotignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResticExcludes(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		opts     []Option
+		root     string
+		expected []string
+	}{
+		{
+			name:     "Order and negation",
+			patterns: []string{"*.log", "!important.log", "/dist"},
+			expected: []string{
+				"/srv/app/**/*.log", "/srv/app/**/*.log/**",
+				"!/srv/app/**/important.log", "!/srv/app/**/important.log/**",
+				"/srv/app/dist", "/srv/app/dist/**",
+			},
+		},
+		{
+			name:     "Escapes",
+			patterns: []string{"[!a]b.txt", "$HOME"},
+			expected: []string{
+				"/srv/app/**/[^a]b.txt", "/srv/app/**/[^a]b.txt/**",
+				"/srv/app/**/$$HOME", "/srv/app/**/$$HOME/**",
+			},
+		},
+		{
+			name:     "Wildcards in root",
+			patterns: []string{"/dist"},
+			root:     "/srv/app[1]*?/",
+			expected: []string{`/srv/app\[1]\*\?/dist`, `/srv/app\[1]\*\?/dist/**`},
+		},
+		{
+			name:     "Docker syntax",
+			patterns: []string{"**/*.go", "!cmd"},
+			opts:     []Option{WithSyntax(SyntaxDocker)},
+			expected: []string{"/srv/app/**/*.go", "/srv/app/**/*.go/**", "!/srv/app/cmd", "!/srv/app/cmd/**"},
+		},
+		{
+			name:     "No patterns",
+			patterns: []string{"# comment", ""},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := NewPatternMatcher(tt.patterns, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+			root := tt.root
+			if root == "" {
+				root = "/srv/app/"
+			}
+			if excludes := matcher.ResticExcludes(root); !reflect.DeepEqual(excludes, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, excludes)
+			}
+		})
+	}
+}
+
+func TestBorgPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		opts     []Option
+		root     string
+		expected []string
+	}{
+		{
+			name:     "Without negations",
+			patterns: []string{"*.log", "/dist"},
+			root:     "/srv/app",
+			expected: []string{
+				"! sh:srv/app/dist/**", "! sh:srv/app/dist",
+				"! sh:srv/app/**/*.log/**", "! sh:srv/app/**/*.log",
+			},
+		},
+		{
+			name:     "With negations",
+			patterns: []string{"*.log", "!important.log"},
+			root:     ".",
+			expected: []string{
+				"+ sh:**/important.log/**", "+ sh:**/important.log",
+				"- sh:**/*.log/**", "- sh:**/*.log",
+			},
+		},
+		{
+			name:     "Escaped wildcard",
+			patterns: []string{"/a\\?b"},
+			opts:     []Option{WithBackslashEscapes()},
+			expected: []string{"! sh:a[?]b"},
+		},
+		{
+			name:     "Wildcards in root",
+			patterns: []string{"/dist"},
+			root:     "/srv/app[1]*?",
+			expected: []string{"! sh:srv/app[[]1][*][?]/dist/**", "! sh:srv/app[[]1][*][?]/dist"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := NewPatternMatcher(tt.patterns, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+			if rules := matcher.BorgPatterns(tt.root); !reflect.DeepEqual(rules, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, rules)
+			}
+		})
+	}
+}
+
+func TestRepositoryMatcher_BackupExports(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":     "*.log\n",
+		"app/.gitignore": "!keep.log\n/dist\n",
+		"app/main.go":    "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	root := filepath.ToSlash(matcher.RootDir())
+
+	expectedRestic := []string{
+		root + "/**/*.log", root + "/**/*.log/**",
+		"!" + root + "/app/**/keep.log", "!" + root + "/app/**/keep.log/**",
+		root + "/app/dist", root + "/app/dist/**",
+		root + "/**/.git",
+	}
+	if excludes := matcher.ResticExcludes(); !reflect.DeepEqual(excludes, expectedRestic) {
+		t.Errorf("ResticExcludes() = %q, want %q", excludes, expectedRestic)
+	}
+
+	borgRoot := root[1:]
+	expectedBorg := []string{
+		"! sh:" + borgRoot + "/**/.git",
+		"- sh:" + borgRoot + "/app/dist/**", "- sh:" + borgRoot + "/app/dist",
+		"+ sh:" + borgRoot + "/app/**/keep.log/**", "+ sh:" + borgRoot + "/app/**/keep.log",
+		"- sh:" + borgRoot + "/**/*.log/**", "- sh:" + borgRoot + "/**/*.log",
+	}
+	if rules := matcher.BorgPatterns(); !reflect.DeepEqual(rules, expectedBorg) {
+		t.Errorf("BorgPatterns() = %q, want %q", rules, expectedBorg)
+	}
+}
@@ -0,0 +1,80 @@
+package dotignore
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/codeglyph/go-dotignore/v2/internal"
+)
+
+// compileCacheLimit bounds the number of patterns kept in compileCache.
+// Reaching it empties the cache, so a process that parses an unbounded
+// stream of distinct patterns does not grow without bound.
+const compileCacheLimit = 4096
+
+// compileKey identifies a normalized pattern in compileCache.
+type compileKey struct {
+	pattern string
+	syntax  Syntax
+}
+
+// compiledPattern is the result of compiling a pattern. Exactly one of the
+// fields is non-nil.
+type compiledPattern struct {
+	glob         *internal.Glob
+	regexPattern *regexp.Regexp
+}
+
+// compileCache holds the compiled form of every pattern compiled so far, so
+// that identical lines, which are common across the nested ignore files of
+// a monorepo, share one compiled matcher. Globs and regular expressions are
+// immutable and safe for concurrent use, so sharing them is safe.
+var compileCache = struct {
+	sync.RWMutex
+	entries map[compileKey]compiledPattern
+}{entries: make(map[compileKey]compiledPattern)}
+
+// compilePattern compiles a normalized pattern of the given syntax, falling
+// back to a regular expression for constructs the glob engine cannot
+// reproduce exactly. Exactly one of the returned matchers is non-nil.
+// Patterns compiled before are served from compileCache.
+func compilePattern(pattern string, syntax Syntax) (*internal.Glob, *regexp.Regexp, error) {
+	key := compileKey{pattern: pattern, syntax: syntax}
+
+	compileCache.RLock()
+	cached, ok := compileCache.entries[key]
+	compileCache.RUnlock()
+	if ok {
+		return cached.glob, cached.regexPattern, nil
+	}
+
+	glob, regexPattern, err := compileUncached(pattern, syntax)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	compileCache.Lock()
+	if len(compileCache.entries) >= compileCacheLimit {
+		compileCache.entries = make(map[compileKey]compiledPattern)
+	}
+	compileCache.entries[key] = compiledPattern{glob: glob, regexPattern: regexPattern}
+	compileCache.Unlock()
+	return glob, regexPattern, nil
+}
+
+// compileUncached compiles a pattern without consulting compileCache.
+func compileUncached(pattern string, syntax Syntax) (*internal.Glob, *regexp.Regexp, error) {
+	compileGlob, buildRegex := internal.CompileGlob, internal.BuildRegex
+	if syntax == SyntaxDocker || syntax == SyntaxHelm {
+		compileGlob, buildRegex = internal.CompileDockerGlob, internal.BuildDockerRegex
+	}
+
+	if glob, ok := compileGlob(pattern); ok {
+		return glob, nil, nil
+	}
+	regexPattern, err := buildRegex(pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, regexPattern, nil
+}
@@ -0,0 +1,64 @@
+package dotignore
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompileCacheSharesPatterns(t *testing.T) {
+	// The same lines, as found in the ignore files of two packages
+	lines := "node_modules/\n*.log\n" + strings.Repeat("?", 64) + "\n"
+	first, err := NewPatternMatcherFromReader(strings.NewReader(lines))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	second, err := NewPatternMatcherFromReader(strings.NewReader(lines))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	a, b := first.rules.Load().patterns, second.rules.Load().patterns
+	for i := range a {
+		if a[i].glob != b[i].glob || a[i].regexPattern != b[i].regexPattern {
+			t.Errorf("pattern %q was compiled twice", a[i].text)
+		}
+	}
+	if a[2].regexPattern == nil {
+		t.Fatalf("pattern %q should use the regular expression fallback", a[2].text)
+	}
+
+	// Syntaxes compile the same text differently and must not share
+	docker, err := NewPatternMatcher([]string{"*.log"}, WithSyntax(SyntaxDocker))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if docker.rules.Load().patterns[0].glob == a[1].glob {
+		t.Error("Docker and Git patterns share a compiled matcher")
+	}
+
+	// Sharing does not change what the matchers match
+	for _, m := range []*PatternMatcher{first, second} {
+		if result, _ := m.Matches("web/node_modules/pkg/index.js"); !result {
+			t.Error("Matches(web/node_modules/pkg/index.js) = false, want true")
+		}
+		if result, _ := m.Matches("a.tmp"); result {
+			t.Error("Matches(a.tmp) = true, want false")
+		}
+	}
+}
+
+func TestCompileCacheLimit(t *testing.T) {
+	for i := 0; i < compileCacheLimit+10; i++ {
+		if _, _, err := compilePattern(fmt.Sprintf("limit-%d.txt", i), SyntaxGit); err != nil {
+			t.Fatalf("compilePattern() failed: %v", err)
+		}
+	}
+
+	compileCache.RLock()
+	size := len(compileCache.entries)
+	compileCache.RUnlock()
+	if size > compileCacheLimit {
+		t.Errorf("compile cache holds %d patterns, want at most %d", size, compileCacheLimit)
+	}
+}
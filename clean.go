@@ -0,0 +1,145 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// CleanOptions configures Clean.
+type CleanOptions struct {
+	// Force removes the paths. Without it Clean only reports what it would
+	// remove, like "git clean -n".
+	Force bool
+}
+
+// Clean removes the files and directories below root that matcher ignores,
+// like "git clean -fdX", and returns their slash-separated paths relative
+// to root, in walk order. Directories end in "/" and are reported in place
+// of their contents. Paths are passed to matcher relative to root, with a
+// trailing slash for directories; for a RepositoryMatcher, root should be
+// its root directory.
+//
+// Clean only reports the paths unless opts.Force is set, so a nil opts is a
+// dry run. When removing, it never follows symbolic links, never removes
+// root itself, and leaves .git and every directory containing one (a nested
+// repository) untouched. A directory is only removed if it is ignored and
+// everything in it was removed first, so a file re-included by a negation
+// pattern survives along with its parents. If removal fails, the paths
+// removed so far are returned with the error.
+func Clean(root string, matcher Matcher, opts *CleanOptions) ([]string, error) {
+	if opts == nil {
+		opts = &CleanOptions{}
+	}
+	c := cleaner{matcher: matcher, opts: opts}
+	if err := c.clean(root); err != nil {
+		return c.removed, fmt.Errorf("failed to clean %q: %w", root, err)
+	}
+	return c.removed, nil
+}
+
+// cleaner implements Clean.
+type cleaner struct {
+	matcher Matcher
+	opts    *CleanOptions
+	removed []string
+}
+
+// clean checks root and cleans the tree below it.
+func (c *cleaner) clean(root string) error {
+	if c.matcher == nil {
+		return errors.New("matcher cannot be nil")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	if filepath.Dir(absRoot) == absRoot {
+		return errors.New("refusing to clean the root of a file system")
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%q is not a directory", root)
+	}
+
+	_, err = c.cleanDir(root, "")
+	return err
+}
+
+// cleanDir cleans the directory at path, named name relative to the root,
+// and reports whether everything in it was removed.
+func (c *cleaner) cleanDir(path, name string) (bool, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+
+	all := true
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			all = false
+			continue
+		}
+		entryPath := filepath.Join(path, entry.Name())
+		entryName := joinSegment(name, entry.Name())
+
+		matchPath := entryName
+		if entry.IsDir() {
+			matchPath += "/"
+		}
+		ignored, err := c.matcher.Matches(matchPath)
+		if err != nil {
+			return false, err
+		}
+
+		if !entry.IsDir() {
+			if !ignored {
+				all = false
+				continue
+			}
+			if err := c.remove(entryPath, entryName); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		if _, err := os.Lstat(filepath.Join(entryPath, ".git")); err == nil {
+			all = false
+			continue
+		}
+
+		// The contents are reported on their own unless the whole directory
+		// goes, in which case it replaces them
+		mark := len(c.removed)
+		emptied, err := c.cleanDir(entryPath, entryName)
+		if err != nil {
+			return false, err
+		}
+		if ignored && emptied {
+			if err := c.remove(entryPath, entryName+"/"); err != nil {
+				return false, err
+			}
+			c.removed = append(c.removed[:mark], entryName+"/")
+			continue
+		}
+		all = false
+	}
+	return all, nil
+}
+
+// remove records name and, with Force, removes the file, link or empty
+// directory at path.
+func (c *cleaner) remove(path, name string) error {
+	if c.opts.Force {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	c.removed = append(c.removed, name)
+	return nil
+}
@@ -0,0 +1,159 @@
+package dotignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	files := map[string]string{
+		".gitignore":           "*.log\nbuild/\ncache/\n!cache/keep.txt\nvendor/\n",
+		"main.go":              "package main\n",
+		"debug.log":            "debug",
+		"build/out.bin":        "binary",
+		"build/sub/obj.o":      "object",
+		"cache/data.bin":       "data",
+		"cache/keep.txt":       "keep",
+		"src/trace.log":        "trace",
+		"src/app.go":           "package src\n",
+		"vendor/lib/.git/HEAD": "ref: refs/heads/main\n",
+		"vendor/lib/lib.go":    "package lib\n",
+	}
+	tmpDir := createTestRepo(t, files)
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	expected := []string{"build/", "cache/data.bin", "debug.log", "src/trace.log"}
+
+	// Without Force nothing is removed
+	for _, opts := range []*CleanOptions{nil, {}} {
+		removed, err := Clean(tmpDir, matcher, opts)
+		if err != nil {
+			t.Fatalf("Clean() dry run failed: %v", err)
+		}
+		if !reflect.DeepEqual(removed, expected) {
+			t.Errorf("Clean() dry run = %v, want %v", removed, expected)
+		}
+		for path := range files {
+			if _, err := os.Stat(filepath.Join(tmpDir, path)); err != nil {
+				t.Errorf("Dry run removed %s", path)
+			}
+		}
+	}
+
+	removed, err := Clean(tmpDir, matcher, &CleanOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Clean() failed: %v", err)
+	}
+	if !reflect.DeepEqual(removed, expected) {
+		t.Errorf("Clean() = %v, want %v", removed, expected)
+	}
+
+	kept := []string{".gitignore", "main.go", "cache/keep.txt", "src/app.go", "vendor/lib/.git/HEAD", "vendor/lib/lib.go"}
+	for _, path := range kept {
+		if _, err := os.Stat(filepath.Join(tmpDir, path)); err != nil {
+			t.Errorf("Expected %s to be kept: %v", path, err)
+		}
+	}
+	for _, path := range []string{"build", "cache/data.bin", "debug.log", "src/trace.log"} {
+		if _, err := os.Lstat(filepath.Join(tmpDir, path)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", path)
+		}
+	}
+
+	// A second run finds nothing left
+	removed, err = Clean(tmpDir, matcher, &CleanOptions{Force: true})
+	if err != nil || len(removed) != 0 {
+		t.Errorf("Second Clean() = %v, %v, want nothing", removed, err)
+	}
+}
+
+func TestCleanStrictDirectoryPatterns(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		"main.go":         "package main\n",
+		"build/out.bin":   "binary",
+		"build/sub/obj.o": "object",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	// Directories are matched as directories, so build/ goes as a whole
+	matcher, err := NewPatternMatcher([]string{"build/"}, WithStrictDirectoryPatterns())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	removed, err := Clean(tmpDir, matcher, &CleanOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Clean() failed: %v", err)
+	}
+	if !reflect.DeepEqual(removed, []string{"build/"}) {
+		t.Errorf("Clean() = %v, want [build/]", removed)
+	}
+	if _, err := os.Lstat(filepath.Join(tmpDir, "build")); !os.IsNotExist(err) {
+		t.Errorf("Expected build to be removed")
+	}
+}
+
+func TestCleanSymlink(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "target.log"), []byte("target"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	root := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "linked.log")); err != nil {
+		t.Skipf("Symbolic links are not supported: %v", err)
+	}
+
+	matcher, err := NewPatternMatcher([]string{"*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	removed, err := Clean(root, matcher, &CleanOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Clean() failed: %v", err)
+	}
+	if !reflect.DeepEqual(removed, []string{"linked.log"}) {
+		t.Errorf("Clean() = %v, want [linked.log]", removed)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "target.log")); err != nil {
+		t.Errorf("Clean() removed the target of a link: %v", err)
+	}
+}
+
+func TestCleanErrors(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	if _, err := Clean(t.TempDir(), nil, nil); err == nil {
+		t.Error("Expected error for nil matcher")
+	}
+	if _, err := Clean("/path/that/does/not/exist", matcher, nil); err == nil {
+		t.Error("Expected error for missing root")
+	}
+	if _, err := Clean(string(filepath.Separator), matcher, nil); err == nil {
+		t.Error("Expected error for the root of the file system")
+	}
+
+	// A directory that cannot be removed leaves its removed contents
+	// reported
+	tmpDir := createTestRepo(t, map[string]string{"cache/a.tmp": ""})
+	defer os.RemoveAll(tmpDir)
+	refilling := MatcherFunc(func(path string) (bool, error) {
+		if path == "cache/a.tmp" {
+			return true, os.WriteFile(filepath.Join(tmpDir, "cache", "b.tmp"), nil, 0o644)
+		}
+		return path == "cache/", nil
+	})
+	removed, err := Clean(tmpDir, refilling, &CleanOptions{Force: true})
+	if err == nil || !reflect.DeepEqual(removed, []string{"cache/a.tmp"}) {
+		t.Errorf("Clean() = %q, %v, want [cache/a.tmp] and an error", removed, err)
+	}
+}
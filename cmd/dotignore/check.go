@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/codeglyph/go-dotignore/v2"
+)
+
+// checkOptions holds the flags of the check command.
+type checkOptions struct {
+	root        string
+	verbose     bool
+	nonMatching bool
+	quiet       bool
+	stdin       bool
+	nullTerm    bool
+}
+
+// runCheck implements "dotignore check". It exits with 0 if at least one path
+// is ignored and 1 if none is, as git check-ignore does.
+func runCheck(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var opts checkOptions
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&opts.root, "root", "", "repository root `directory` (default: the work tree containing the current directory)")
+	flags.BoolVar(&opts.verbose, "v", false, "show the source file, line and pattern that matched")
+	flags.BoolVar(&opts.verbose, "verbose", false, "same as -v")
+	flags.BoolVar(&opts.nonMatching, "n", false, "with -v, also show paths that match no pattern")
+	flags.BoolVar(&opts.nonMatching, "non-matching", false, "same as -n")
+	flags.BoolVar(&opts.quiet, "q", false, "print nothing, only set the exit status")
+	flags.BoolVar(&opts.quiet, "quiet", false, "same as -q")
+	flags.BoolVar(&opts.stdin, "stdin", false, "read paths from standard input, one per line")
+	flags.BoolVar(&opts.nullTerm, "z", false, "separate input and output records with NUL")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitUsage
+	}
+
+	paths := flags.Args()
+	switch {
+	case opts.quiet && opts.verbose:
+		fmt.Fprintln(stderr, "dotignore check: cannot use -q with -v")
+		return exitUsage
+	case opts.nonMatching && !opts.verbose:
+		fmt.Fprintln(stderr, "dotignore check: -n is only valid with -v")
+		return exitUsage
+	case opts.stdin && len(paths) > 0:
+		fmt.Fprintln(stderr, "dotignore check: cannot specify paths with -stdin")
+		return exitUsage
+	case !opts.stdin && len(paths) == 0:
+		fmt.Fprintln(stderr, "dotignore check: no path specified")
+		return exitUsage
+	}
+
+	matcher, err := newCheckMatcher(opts.root)
+	if err != nil {
+		fmt.Fprintf(stderr, "dotignore check: %v\n", err)
+		return exitFatal
+	}
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+
+	ignored := 0
+	check := func(path string) error {
+		isIgnored, err := checkPath(out, matcher, path, opts)
+		if isIgnored {
+			ignored++
+		}
+		return err
+	}
+
+	if opts.stdin {
+		scanner := bufio.NewScanner(stdin)
+		if opts.nullTerm {
+			scanner.Split(scanNull)
+		}
+		for scanner.Scan() {
+			if err := check(scanner.Text()); err != nil {
+				fmt.Fprintf(stderr, "dotignore check: %v\n", err)
+				return exitFatal
+			}
+			// Flush after every path so check can be driven interactively
+			if err := out.Flush(); err != nil {
+				fmt.Fprintf(stderr, "dotignore check: %v\n", err)
+				return exitFatal
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintf(stderr, "dotignore check: failed to read paths: %v\n", err)
+			return exitFatal
+		}
+	} else {
+		for _, path := range paths {
+			if err := check(path); err != nil {
+				fmt.Fprintf(stderr, "dotignore check: %v\n", err)
+				return exitFatal
+			}
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		fmt.Fprintf(stderr, "dotignore check: %v\n", err)
+		return exitFatal
+	}
+	if ignored == 0 {
+		return 1
+	}
+	return 0
+}
+
+// newCheckMatcher creates the matcher for root or, if root is empty, for
+// the Git work tree containing the current directory, as git check-ignore
+// uses.
+func newCheckMatcher(root string) (*dotignore.RepositoryMatcher, error) {
+	if root == "" {
+		return dotignore.NewRepositoryMatcherFromPath(".")
+	}
+	return dotignore.NewRepositoryMatcher(root)
+}
+
+// checkPath prints the result for a single path and reports whether it
+// counts as ignored. Like git, a path matching a negation pattern is printed
+// and counted in verbose mode.
+func checkPath(w io.Writer, matcher *dotignore.RepositoryMatcher, path string, opts checkOptions) (bool, error) {
+	// Paths are relative to the working directory, as with git
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve %q: %w", path, err)
+	}
+	result, err := matcher.MatchDetail(absPath)
+	if err != nil {
+		return false, err
+	}
+
+	shown := result.Ignored
+	if opts.verbose {
+		shown = result.Matched || result.Ignored
+	}
+	if opts.quiet {
+		return shown, nil
+	}
+
+	sep := "\n"
+	if opts.nullTerm {
+		sep = "\x00"
+	}
+
+	switch {
+	case !opts.verbose:
+		if shown {
+			fmt.Fprint(w, path, sep)
+		}
+	case shown || opts.nonMatching:
+		line := ""
+		if result.Matched {
+			line = fmt.Sprint(result.Line)
+		}
+		if opts.nullTerm {
+			fmt.Fprint(w, result.Source, sep, line, sep, result.Pattern, sep, path, sep)
+		} else {
+			fmt.Fprintf(w, "%s:%s:%s\t%s%s", result.Source, line, result.Pattern, path, sep)
+		}
+	}
+	return shown, nil
+}
+
+// scanNull is a bufio.SplitFunc that splits input at NUL bytes.
+func scanNull(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
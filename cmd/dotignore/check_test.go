@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createRepo writes files into a temporary directory and returns its path.
+func createRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for path, content := range files {
+		fullPath := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	return root
+}
+
+func TestCheck(t *testing.T) {
+	root := createRepo(t, map[string]string{
+		".gitignore":     "*.log\n/build/\n",
+		"app/.gitignore": "!keep.log\ndist/\n",
+	})
+	path := func(rel string) string {
+		return filepath.Join(root, filepath.FromSlash(rel))
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		expected string
+		exitCode int
+	}{
+		{
+			name:     "Ignored paths",
+			args:     []string{path("a.log"), path("main.go"), path("build/out")},
+			expected: path("a.log") + "\n" + path("build/out") + "\n",
+		},
+		{
+			name:     "Nothing ignored",
+			args:     []string{path("main.go")},
+			exitCode: 1,
+		},
+		{
+			name: "Verbose",
+			args: []string{"-v", path("a.log"), path("app/keep.log"), path("main.go")},
+			expected: ".gitignore:1:*.log\t" + path("a.log") + "\n" +
+				"app/.gitignore:1:!keep.log\t" + path("app/keep.log") + "\n",
+		},
+		{
+			name: "Verbose non-matching",
+			args: []string{"-v", "-n", path("app/dist/x.js"), path("main.go")},
+			expected: "app/.gitignore:2:dist/\t" + path("app/dist/x.js") + "\n" +
+				"::\t" + path("main.go") + "\n",
+		},
+		{
+			name:     "Quiet",
+			args:     []string{"-q", path("a.log")},
+			expected: "",
+		},
+		{
+			name:     "Stdin",
+			args:     []string{"-stdin"},
+			stdin:    path("a.log") + "\n" + path("main.go") + "\n",
+			expected: path("a.log") + "\n",
+		},
+		{
+			name:     "Stdin with NUL separators",
+			args:     []string{"--stdin", "-z", "--verbose"},
+			stdin:    path("a.log") + "\x00",
+			expected: ".gitignore\x001\x00*.log\x00" + path("a.log") + "\x00",
+		},
+		{
+			name:     "No paths",
+			args:     nil,
+			exitCode: exitUsage,
+		},
+		{
+			name:     "Non-matching without verbose",
+			args:     []string{"-n", path("a.log")},
+			exitCode: exitUsage,
+		},
+		{
+			name:     "Path outside repository",
+			args:     []string{filepath.Dir(root)},
+			exitCode: exitFatal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"check", "-root", root}, tt.args...)
+			exitCode := run(args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+			if exitCode != tt.exitCode {
+				t.Fatalf("Expected exit code %d, got %d (stderr: %s)", tt.exitCode, exitCode, stderr.String())
+			}
+			if tt.exitCode <= 1 && stdout.String() != tt.expected {
+				t.Errorf("Expected output %q, got %q", tt.expected, stdout.String())
+			}
+		})
+	}
+}
+
+func TestCheckWorkTree(t *testing.T) {
+	root := createRepo(t, map[string]string{
+		".git/HEAD":      "ref: refs/heads/main\n",
+		".gitignore":     "*.log\n",
+		"sub/.gitignore": "x.tmp\n",
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	// Without -root, the work tree is found from the current directory, so
+	// sources and paths outside it are reported as git reports them
+	if err := os.Chdir(filepath.Join(root, "sub")); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	var stdout, stderr bytes.Buffer
+	exitCode := run([]string{"check", "-v", "x.tmp", "../a.log"}, strings.NewReader(""), &stdout, &stderr)
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", exitCode, stderr.String())
+	}
+	expected := "sub/.gitignore:1:x.tmp\tx.tmp\n.gitignore:1:*.log\t../a.log\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected output %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if exitCode := run([]string{"frobnicate"}, strings.NewReader(""), &stdout, &stderr); exitCode != exitUsage {
+		t.Errorf("Expected exit code %d, got %d", exitUsage, exitCode)
+	}
+	if !strings.Contains(stderr.String(), "unknown command") {
+		t.Errorf("Expected unknown command error, got %q", stderr.String())
+	}
+}
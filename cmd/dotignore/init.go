@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/codeglyph/go-dotignore/v2"
+)
+
+// runInit implements "dotignore init", which writes a .gitignore built from
+// the named templates.
+func runInit(args []string, stdout, stderr io.Writer) int {
+	var output string
+	var force, list bool
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&output, "o", ".gitignore", "write to `file`, or to standard output if it is -")
+	flags.BoolVar(&force, "f", false, "overwrite the file if it exists")
+	flags.BoolVar(&list, "list", false, "list the available templates")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitUsage
+	}
+
+	if list {
+		fmt.Fprintln(stdout, strings.Join(dotignore.Templates(), "\n"))
+		return 0
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "dotignore init: no template specified (see -list)")
+		return exitUsage
+	}
+
+	content, err := dotignore.RenderTemplates(flags.Args()...)
+	if err != nil {
+		fmt.Fprintf(stderr, "dotignore init: %v\n", err)
+		return exitUsage
+	}
+
+	if output == "-" {
+		if _, err := io.WriteString(stdout, content); err != nil {
+			fmt.Fprintf(stderr, "dotignore init: %v\n", err)
+			return exitFatal
+		}
+		return 0
+	}
+
+	mode := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		mode |= os.O_EXCL
+	}
+	file, err := os.OpenFile(output, mode, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		fmt.Fprintf(stderr, "dotignore init: %s already exists (use -f to overwrite)\n", output)
+		return exitFatal
+	}
+	if err == nil {
+		_, err = io.WriteString(file, content)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "dotignore init: %v\n", err)
+		return exitFatal
+	}
+	return 0
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codeglyph/go-dotignore/v2"
+)
+
+func TestInit(t *testing.T) {
+	output := filepath.Join(t.TempDir(), ".gitignore")
+	want, err := dotignore.RenderTemplates("go", "macos")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if exitCode := run([]string{"init", "-o", output, "go", "macos"}, strings.NewReader(""), &stdout, &stderr); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", exitCode, stderr.String())
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != want {
+		t.Errorf("Expected file contents:\n%s\ngot:\n%s", want, content)
+	}
+
+	// An existing file is only replaced with -f
+	if exitCode := run([]string{"init", "-o", output, "node"}, strings.NewReader(""), &stdout, &stderr); exitCode != exitFatal {
+		t.Errorf("Expected exit code %d for existing file, got %d", exitFatal, exitCode)
+	}
+	if exitCode := run([]string{"init", "-o", output, "-f", "node"}, strings.NewReader(""), &stdout, &stderr); exitCode != 0 {
+		t.Fatalf("Expected exit code 0 with -f, got %d (stderr: %s)", exitCode, stderr.String())
+	}
+	if content, _ := os.ReadFile(output); !strings.HasPrefix(string(content), "### node ###\n") {
+		t.Errorf("Expected -f to overwrite the file, got:\n%s", content)
+	}
+
+	stdout.Reset()
+	if exitCode := run([]string{"init", "-o", "-", "rust"}, strings.NewReader(""), &stdout, &stderr); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", exitCode, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "target/\n") {
+		t.Errorf("Expected the rust template on stdout, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if exitCode := run([]string{"init", "-list"}, strings.NewReader(""), &stdout, &stderr); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d", exitCode)
+	}
+	if got := strings.Fields(stdout.String()); strings.Join(got, ",") != strings.Join(dotignore.Templates(), ",") {
+		t.Errorf("Expected -list to print %v, got %v", dotignore.Templates(), got)
+	}
+}
+
+func TestInitErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if exitCode := run([]string{"init"}, strings.NewReader(""), &stdout, &stderr); exitCode != exitUsage {
+		t.Errorf("Expected exit code %d without templates, got %d", exitUsage, exitCode)
+	}
+	if exitCode := run([]string{"init", "-o", "-", "unknown"}, strings.NewReader(""), &stdout, &stderr); exitCode != exitUsage {
+		t.Errorf("Expected exit code %d for unknown template, got %d", exitUsage, exitCode)
+	}
+}
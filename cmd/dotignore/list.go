@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeglyph/go-dotignore/v2"
+)
+
+// runList implements "dotignore list", which prints every file under the
+// root that is not ignored, or only the ignored ones with -ignored. Paths are
+// printed relative to the root with forward slashes, as Git prints them, on
+// every platform.
+func runList(args []string, stdout, stderr io.Writer) int {
+	var ignored, nullTerm bool
+	var excludes patternList
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&ignored, "ignored", false, "print ignored files instead of the files that are kept")
+	flags.BoolVar(&nullTerm, "null", false, "separate paths with NUL instead of newline, for xargs -0")
+	flags.BoolVar(&nullTerm, "0", false, "same as -null")
+	flags.Var(&excludes, "exclude", "also ignore files matching `pattern`, overriding the ignore files (repeatable)")
+	flags.Var(&excludes, "e", "same as -exclude")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitUsage
+	}
+
+	root := "."
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		root = flags.Arg(0)
+	default:
+		fmt.Fprintln(stderr, "dotignore list: too many arguments")
+		return exitUsage
+	}
+
+	config := dotignore.DefaultRepositoryConfig()
+	config.ExtraPatterns = excludes
+	matcher, err := dotignore.NewRepositoryMatcherWithConfig(root, config)
+	if err != nil {
+		fmt.Fprintf(stderr, "dotignore list: %v\n", err)
+		return exitFatal
+	}
+
+	sep := "\n"
+	if nullTerm {
+		sep = "\x00"
+	}
+
+	out := bufio.NewWriter(stdout)
+	err = filepath.WalkDir(matcher.RootDir(), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Git metadata is not part of the work tree, so it is listed in
+		// neither mode, as with "git ls-files"
+		if entry.Name() == ".git" && entry.IsDir() {
+			return filepath.SkipDir
+		}
+		if entry.IsDir() || entry.Name() == ".git" {
+			return nil
+		}
+
+		isIgnored, err := matcher.Matches(path)
+		if err != nil {
+			return err
+		}
+		if isIgnored != ignored {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(matcher.RootDir(), path)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(out, filepath.ToSlash(relPath), sep)
+		return err
+	})
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "dotignore list: %v\n", err)
+		return exitFatal
+	}
+	return 0
+}
+
+// patternList collects the values of a repeatable flag.
+type patternList []string
+
+// String returns the patterns separated by commas.
+func (l *patternList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set appends a pattern.
+func (l *patternList) Set(pattern string) error {
+	*l = append(*l, pattern)
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	root := createRepo(t, map[string]string{
+		".gitignore":          "*.log\n/build/\n",
+		"app/.gitignore":      "!keep.log\n",
+		"app/keep.log":        "",
+		"app/main.go":         "",
+		"build/out.bin":       "",
+		"debug.log":           "",
+		"README.md":           "",
+		".git/config":         "",
+		"docs/guide/index.md": "",
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		sep      string
+		expected []string
+	}{
+		{
+			name:     "Kept files",
+			sep:      "\n",
+			expected: []string{".gitignore", "README.md", "app/.gitignore", "app/keep.log", "app/main.go", "docs/guide/index.md"},
+		},
+		{
+			name:     "Ignored files",
+			args:     []string{"-ignored"},
+			sep:      "\n",
+			expected: []string{"build/out.bin", "debug.log"},
+		},
+		{
+			name:     "Extra patterns",
+			args:     []string{"-e", "*.md", "--exclude", "!debug.log"},
+			sep:      "\n",
+			expected: []string{".gitignore", "app/.gitignore", "app/keep.log", "app/main.go", "debug.log"},
+		},
+		{
+			name:     "NUL separated",
+			args:     []string{"--null", "--ignored"},
+			sep:      "\x00",
+			expected: []string{"build/out.bin", "debug.log"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append(append([]string{"list"}, tt.args...), root)
+			if exitCode := run(args, strings.NewReader(""), &stdout, &stderr); exitCode != 0 {
+				t.Fatalf("Expected exit code 0, got %d (stderr: %s)", exitCode, stderr.String())
+			}
+
+			got := strings.Split(strings.TrimSuffix(stdout.String(), tt.sep), tt.sep)
+			for i := range got {
+				got[i] = filepath.ToSlash(got[i])
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestListErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if exitCode := run([]string{"list", "a", "b"}, strings.NewReader(""), &stdout, &stderr); exitCode != exitUsage {
+		t.Errorf("Expected exit code %d for too many arguments, got %d", exitUsage, exitCode)
+	}
+	if exitCode := run([]string{"list", "/path/that/does/not/exist"}, strings.NewReader(""), &stdout, &stderr); exitCode != exitFatal {
+		t.Errorf("Expected exit code %d for missing root, got %d", exitFatal, exitCode)
+	}
+}
@@ -0,0 +1,73 @@
+// Command dotignore inspects ignore rules from the command line.
+//
+// Usage:
+//
+//	dotignore check [-root dir] [-v] [-n] [-q] [-stdin] [-z] [path...]
+//	dotignore list [-ignored] [-null] [-e pattern]... [root]
+//	dotignore init [-o file] [-f] [-list] [template...]
+//
+// The check command reports which paths are ignored by the .gitignore files
+// of a repository. Its flags, output format and exit status follow
+// "git check-ignore", so the two can be compared directly. Without -root, the
+// repository is the Git work tree containing the current directory.
+//
+// The list command prints every file under the root that is not ignored, or
+// with -ignored every file that is, one slash-separated path per line
+// relative to the root. Patterns given with -e are applied on top of the
+// ignore files, like those of "git ls-files -x".
+//
+// The init command writes a .gitignore combining built-in templates, such as
+// "dotignore init go macos". Run "dotignore init -list" to see them all.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes follow git: 128 for fatal errors and 129 for usage errors.
+const (
+	exitFatal = 128
+	exitUsage = 129
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "check":
+		return runCheck(args[1:], stdin, stdout, stderr)
+	case "list":
+		return runList(args[1:], stdout, stderr)
+	case "init":
+		return runInit(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "dotignore: unknown command %q\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `usage: dotignore <command> [flags] [args]
+
+Commands:
+  check    report whether paths are ignored (like git check-ignore)
+  list     print the files that are kept, or ignored with -ignored
+  init     write a .gitignore from built-in templates
+
+Run "dotignore <command> -h" for the flags of a command.
+`)
+}
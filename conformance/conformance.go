@@ -0,0 +1,83 @@
+// Package conformance checks ignore matchers against Git's own behavior.
+//
+// CompareWithGit runs "git check-ignore" over a set of cases and reports the
+// paths on which a matcher disagrees with Git, so that the matchers of
+// github.com/codeglyph/go-dotignore/v2, or custom engines built on the same
+// interface, can be verified in continuous integration wherever Git is
+// installed.
+package conformance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Case is a repository to compare decisions in: a set of ignore files and
+// the paths to check against them.
+type Case struct {
+	// Name identifies the case in reported divergences.
+	Name string
+
+	// Files maps slash-separated paths, relative to the repository root, to
+	// the contents of the files to create, typically .gitignore files.
+	Files map[string]string
+
+	// Paths lists the slash-separated paths to check, relative to the
+	// repository root. Each path is created as an empty file, or as a
+	// directory if it ends in "/", unless Files already creates it.
+	Paths []string
+}
+
+// Divergence describes a path on which a matcher and Git disagree.
+type Divergence struct {
+	// Case is the name of the case the path belongs to.
+	Case string
+
+	// Path is the path as listed in the case.
+	Path string
+
+	// Git reports whether Git ignores the path.
+	Git bool
+
+	// GitPattern is the pattern that decided the path for Git, in the form
+	// "source:line:pattern" printed by "git check-ignore -v", or "" if no
+	// pattern matched.
+	GitPattern string
+
+	// Matcher reports whether the matcher ignores the path.
+	Matcher bool
+
+	// Err is set by VerifyCorpus if the matcher rejected the patterns of
+	// the case, which Git accepts, or failed to match the path. Matcher is
+	// false in that case.
+	Err error
+}
+
+// String describes the divergence on a single line.
+func (d Divergence) String() string {
+	pattern := d.GitPattern
+	if pattern == "" {
+		pattern = "no pattern"
+	}
+	if d.Err != nil {
+		return fmt.Sprintf("%s: %s: git ignored=%v (%s), matcher failed: %v", d.Case, d.Path, d.Git, pattern, d.Err)
+	}
+	return fmt.Sprintf("%s: %s: git ignored=%v (%s), matcher ignored=%v", d.Case, d.Path, d.Git, pattern, d.Matcher)
+}
+
+// sortedFiles returns the names in files in lexical order, so that
+// directories are created before the files inside them.
+func sortedFiles(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isDir reports whether a case path names a directory.
+func isDir(path string) bool {
+	return strings.HasSuffix(path, "/")
+}
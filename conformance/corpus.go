@@ -0,0 +1,139 @@
+package conformance
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/codeglyph/go-dotignore/v2"
+)
+
+//go:generate go run gen_corpus.go
+
+//go:embed corpus/wildmatch.txt
+var wildmatchCorpus string
+
+// CorpusCase is a set of patterns and a path with the decision Git makes
+// for it.
+type CorpusCase struct {
+	// Name identifies the case, as the corpus name and line number.
+	Name string
+
+	// Patterns are the lines of the .gitignore file at the repository root.
+	Patterns []string
+
+	// Path is the slash-separated path of a file, relative to the root.
+	Path string
+
+	// Ignored reports whether Git ignores the path.
+	Ignored bool
+}
+
+// Case returns the case as a repository that CompareWithGit can check.
+func (c CorpusCase) Case() Case {
+	return Case{
+		Name:  c.Name,
+		Files: map[string]string{".gitignore": strings.Join(c.Patterns, "\n") + "\n"},
+		Paths: []string{c.Path},
+	}
+}
+
+// WildmatchCorpus returns cases derived from the wildmatch tests of Git
+// (t/t3070-wildmatch.sh): each pairs one of their patterns, as the only line
+// of a .gitignore file, with one of their paths. The expected decisions were
+// recorded by running "git check-ignore", so they include the gitignore
+// rules layered on top of wildmatch, such as a pattern without a slash
+// matching at any depth.
+func WildmatchCorpus() []CorpusCase {
+	cases, err := ParseCorpus(strings.NewReader(wildmatchCorpus), "wildmatch")
+	if err != nil {
+		panic(fmt.Sprintf("conformance: invalid built-in corpus: %v", err))
+	}
+	return cases
+}
+
+// ParseCorpus reads cases in the format of the built-in corpus: one case per
+// line, holding "ignored" or "kept", a pattern and a path, separated by
+// tabs. Blank lines and lines starting with "#" are skipped. Cases are named
+// after name and their line number.
+func ParseCorpus(reader io.Reader, name string) ([]CorpusCase, error) {
+	var cases []CorpusCase
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected 3 tab-separated fields, got %d", lineNum, len(fields))
+		}
+		var ignored bool
+		switch fields[0] {
+		case "ignored":
+			ignored = true
+		case "kept":
+		default:
+			return nil, fmt.Errorf("line %d: expected \"ignored\" or \"kept\", got %q", lineNum, fields[0])
+		}
+		cases = append(cases, CorpusCase{
+			Name:     fmt.Sprintf("%s:%d", name, lineNum),
+			Patterns: []string{fields[1]},
+			Path:     fields[2],
+			Ignored:  ignored,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+// NewPatternsMatcherFunc creates the matcher to verify for a list of
+// patterns.
+type NewPatternsMatcherFunc func(patterns []string) (dotignore.Matcher, error)
+
+// VerifyCorpus checks the matcher created by newMatcher for the patterns of
+// each case and returns the cases whose path it decides differently from
+// Git, or for which it fails, in order. Git is not needed.
+//
+// If newMatcher is nil, a dotignore.PatternMatcher is verified, with
+// WithStrictNegation and WithGitStrict set to follow Git.
+func VerifyCorpus(cases []CorpusCase, newMatcher NewPatternsMatcherFunc) []Divergence {
+	if newMatcher == nil {
+		newMatcher = newPatternMatcher
+	}
+
+	var divergences []Divergence
+	for _, c := range cases {
+		ignored, err := verifyCase(c, newMatcher)
+		if err != nil || ignored != c.Ignored {
+			divergences = append(divergences, Divergence{Case: c.Name, Path: c.Path, Git: c.Ignored, Matcher: ignored, Err: err})
+		}
+	}
+	return divergences
+}
+
+// verifyCase reports whether the matcher created for the patterns of c
+// ignores its path.
+func verifyCase(c CorpusCase, newMatcher NewPatternsMatcherFunc) (bool, error) {
+	matcher, err := newMatcher(c.Patterns)
+	if err != nil {
+		return false, fmt.Errorf("failed to create matcher: %w", err)
+	}
+	ignored, err := matcher.Matches(c.Path)
+	if err != nil {
+		return false, fmt.Errorf("failed to match: %w", err)
+	}
+	return ignored, nil
+}
+
+// newPatternMatcher creates the default matcher verified against a corpus.
+func newPatternMatcher(patterns []string) (dotignore.Matcher, error) {
+	return dotignore.NewPatternMatcher(patterns, dotignore.WithStrictNegation(), dotignore.WithGitStrict())
+}
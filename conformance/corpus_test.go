@@ -0,0 +1,163 @@
+package conformance
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codeglyph/go-dotignore/v2"
+)
+
+func TestParseCorpus(t *testing.T) {
+	input := "# comment\n\nignored\t*.log\tapp.log\r\nkept\t/build\tsrc/build\n"
+	cases, err := ParseCorpus(strings.NewReader(input), "test")
+	if err != nil {
+		t.Fatalf("ParseCorpus() failed: %v", err)
+	}
+	want := []CorpusCase{
+		{Name: "test:3", Patterns: []string{"*.log"}, Path: "app.log", Ignored: true},
+		{Name: "test:4", Patterns: []string{"/build"}, Path: "src/build", Ignored: false},
+	}
+	if !reflect.DeepEqual(cases, want) {
+		t.Errorf("ParseCorpus() = %+v, want %+v", cases, want)
+	}
+
+	for _, invalid := range []string{"ignored\t*.log\n", "maybe\t*.log\tapp.log\n"} {
+		if _, err := ParseCorpus(strings.NewReader(invalid), "test"); err == nil {
+			t.Errorf("ParseCorpus(%q) succeeded, want an error", invalid)
+		}
+	}
+}
+
+// corpusKey identifies a corpus case by its pattern and path, which unlike
+// its line number survive edits to the corpus.
+type corpusKey struct {
+	pattern, path string
+}
+
+// knownDivergences lists the wildmatch cases PatternMatcher decides
+// differently from Git.
+var knownDivergences = []corpusKey{
+	{`[[::]ab]`, "[ab]"},
+	{`[[:digit:][:upper:][:spaci:]]`, "1"},
+	{`a[]b`, "a[]b"},
+	{`ab[`, "ab["},
+}
+
+func TestVerifyWildmatchCorpus(t *testing.T) {
+	cases := WildmatchCorpus()
+	if len(cases) < 100 {
+		t.Fatalf("WildmatchCorpus() returned %d cases, want at least 100", len(cases))
+	}
+
+	keys := make(map[string]corpusKey, len(cases))
+	for _, c := range cases {
+		keys[c.Name] = corpusKey{strings.Join(c.Patterns, "\n"), c.Path}
+	}
+	known := make(map[corpusKey]bool, len(knownDivergences))
+	for _, key := range knownDivergences {
+		known[key] = true
+	}
+	for _, d := range VerifyCorpus(cases, nil) {
+		key := keys[d.Case]
+		if !known[key] {
+			t.Errorf("New divergence: %v", d)
+		}
+		delete(known, key)
+	}
+	for key := range known {
+		t.Errorf("Pattern %q, path %q no longer diverges; remove it from knownDivergences", key.pattern, key.path)
+	}
+}
+
+func TestVerifyCorpusReportsErrors(t *testing.T) {
+	cases := []CorpusCase{
+		{Name: "ok", Patterns: []string{"*.log"}, Path: "app.log", Ignored: true},
+		{Name: "wrong", Patterns: []string{"*.log"}, Path: "main.go", Ignored: true},
+		{Name: "invalid", Patterns: []string{"!"}, Path: "main.go", Ignored: false},
+	}
+	divergences := VerifyCorpus(cases, func(patterns []string) (dotignore.Matcher, error) {
+		return dotignore.NewPatternMatcher(patterns)
+	})
+	if len(divergences) != 2 || divergences[0].Case != "wrong" || divergences[1].Case != "invalid" || divergences[1].Err == nil {
+		t.Errorf("VerifyCorpus() = %+v, want divergences for the wrong and invalid cases", divergences)
+	}
+}
+
+func TestWildmatchCorpusMatchesGit(t *testing.T) {
+	if !GitAvailable() {
+		t.Skip("git is not installed")
+	}
+	if testing.Short() {
+		t.Skip("creates a repository per case")
+	}
+
+	corpus := WildmatchCorpus()
+	cases := make([]Case, len(corpus))
+	for i, c := range corpus {
+		cases[i] = c.Case()
+	}
+
+	// Each case is checked in order against a matcher reporting the
+	// recorded decision
+	next := 0
+	recorded := func(string) (dotignore.Matcher, error) {
+		ignored := corpus[next].Ignored
+		next++
+		return dotignore.MatcherFunc(func(string) (bool, error) { return ignored, nil }), nil
+	}
+
+	divergences, err := CompareWithGit(cases, recorded)
+	if err != nil {
+		t.Fatalf("CompareWithGit() failed: %v", err)
+	}
+	for _, d := range divergences {
+		t.Errorf("Recorded decision differs from git: %v", d)
+	}
+}
+
+// directoryCases exercise how patterns matching a directory apply to the
+// paths beneath it, which the wildmatch corpus, with one pattern per case,
+// leaves out. TestDirectoryCasesMatchGit checks the recorded decisions.
+var directoryCases = []CorpusCase{
+	{Name: "negated directory", Patterns: []string{"*.log", "!b/"}, Path: "b/a.log", Ignored: true},
+	{Name: "negated directory, deeper", Patterns: []string{"*.log", "!b/"}, Path: "b/c/a.log", Ignored: true},
+	{Name: "negation below a wildcard", Patterns: []string{"*", "!src/"}, Path: "src/a.go", Ignored: true},
+	{Name: "re-included directory", Patterns: []string{"b/", "!*/"}, Path: "b/a.log", Ignored: false},
+	{Name: "anchored wildcard directory", Patterns: []string{"/f*"}, Path: "foo/a.log", Ignored: true},
+	{Name: "anchored wildcard, nested", Patterns: []string{"/f*"}, Path: "src/foo/a.log", Ignored: false},
+	{Name: "wildcard in a directory", Patterns: []string{"b/*"}, Path: "b/build/x", Ignored: true},
+	{Name: "double star directory", Patterns: []string{"**/cache"}, Path: "a/cache/x", Ignored: true},
+}
+
+func TestVerifyDirectoryCases(t *testing.T) {
+	for _, d := range VerifyCorpus(directoryCases, nil) {
+		t.Errorf("Divergence: %v", d)
+	}
+}
+
+func TestDirectoryCasesMatchGit(t *testing.T) {
+	if !GitAvailable() {
+		t.Skip("git is not installed")
+	}
+
+	cases := make([]Case, len(directoryCases))
+	for i, c := range directoryCases {
+		cases[i] = c.Case()
+	}
+
+	next := 0
+	recorded := func(string) (dotignore.Matcher, error) {
+		ignored := directoryCases[next].Ignored
+		next++
+		return dotignore.MatcherFunc(func(string) (bool, error) { return ignored, nil }), nil
+	}
+
+	divergences, err := CompareWithGit(cases, recorded)
+	if err != nil {
+		t.Fatalf("CompareWithGit() failed: %v", err)
+	}
+	for _, d := range divergences {
+		t.Errorf("Recorded decision differs from git: %v", d)
+	}
+}
@@ -0,0 +1,205 @@
+package conformance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeglyph/go-dotignore/v2"
+)
+
+// ErrGitNotFound is returned by CompareWithGit when the git command cannot
+// be found.
+var ErrGitNotFound = errors.New("git command not found")
+
+// NewMatcherFunc creates the matcher to compare for the repository at root.
+type NewMatcherFunc func(root string) (dotignore.Matcher, error)
+
+// GitAvailable reports whether the git command can be found, so tests can
+// skip comparisons where it is not installed.
+func GitAvailable() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
+// CompareWithGit creates a temporary Git repository for each case, asks
+// "git check-ignore" whether each of its paths is ignored, and returns the
+// paths on which the matcher created by newMatcher disagrees, in case and
+// path order.
+//
+// If newMatcher is nil, a dotignore.RepositoryMatcher is compared, with
+// CheckFileTypes, StrictNegation and GitStrict set to follow Git as closely as
+// possible. Git runs without the user's global and system configuration, so
+// their excludes files and settings do not affect the result.
+func CompareWithGit(cases []Case, newMatcher NewMatcherFunc) ([]Divergence, error) {
+	if !GitAvailable() {
+		return nil, ErrGitNotFound
+	}
+	if newMatcher == nil {
+		newMatcher = newRepositoryMatcher
+	}
+
+	var divergences []Divergence
+	for _, c := range cases {
+		found, err := compareCase(c, newMatcher)
+		if err != nil {
+			return nil, fmt.Errorf("case %q: %w", c.Name, err)
+		}
+		divergences = append(divergences, found...)
+	}
+	return divergences, nil
+}
+
+// newRepositoryMatcher creates the default matcher compared with Git.
+func newRepositoryMatcher(root string) (dotignore.Matcher, error) {
+	return dotignore.NewRepositoryMatcherWithConfig(root, &dotignore.RepositoryConfig{
+		CheckFileTypes: true,
+		StrictNegation: true,
+		GitStrict:      true,
+	})
+}
+
+// compareCase compares the decisions of Git and the matcher for one case.
+func compareCase(c Case, newMatcher NewMatcherFunc) ([]Divergence, error) {
+	root, err := os.MkdirTemp("", "dotignore-conformance-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repository: %w", err)
+	}
+	defer os.RemoveAll(root)
+
+	if _, err := runGit(root, nil, "init", "--quiet"); err != nil {
+		return nil, err
+	}
+	if err := createFiles(root, c); err != nil {
+		return nil, err
+	}
+
+	decisions, err := checkIgnore(root, c.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher, err := newMatcher(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create matcher: %w", err)
+	}
+
+	var divergences []Divergence
+	for _, path := range c.Paths {
+		ignored, err := matcher.Matches(strings.TrimSuffix(path, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to match %q: %w", path, err)
+		}
+		decision := decisions[path]
+		if ignored != decision.ignored {
+			divergences = append(divergences, Divergence{
+				Case:       c.Name,
+				Path:       path,
+				Git:        decision.ignored,
+				GitPattern: decision.pattern,
+				Matcher:    ignored,
+			})
+		}
+	}
+	return divergences, nil
+}
+
+// createFiles writes the files of c under root and creates its paths.
+func createFiles(root string, c Case) error {
+	for _, name := range sortedFiles(c.Files) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create %q: %w", name, err)
+		}
+		if err := os.WriteFile(path, []byte(c.Files[name]), 0o644); err != nil {
+			return fmt.Errorf("failed to create %q: %w", name, err)
+		}
+	}
+
+	for _, name := range c.Paths {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if isDir(name) {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return fmt.Errorf("failed to create %q: %w", name, err)
+			}
+			continue
+		}
+		if _, err := os.Lstat(path); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create %q: %w", name, err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			return fmt.Errorf("failed to create %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// gitDecision is Git's verdict on a path.
+type gitDecision struct {
+	ignored bool
+	pattern string // "source:line:pattern", or "" if no pattern matched
+}
+
+// checkIgnore runs "git check-ignore -v" on paths in the repository at root.
+func checkIgnore(root string, paths []string) (map[string]gitDecision, error) {
+	var input bytes.Buffer
+	for _, path := range paths {
+		input.WriteString(path)
+		input.WriteByte(0)
+	}
+
+	output, err := runGit(root, &input, "check-ignore", "--verbose", "--non-matching", "--no-index", "--stdin", "-z")
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		// Exit status 1 only means that no path is ignored
+		return nil, err
+	}
+
+	// With -z, each path is reported as source, line, pattern and path,
+	// each terminated by NUL
+	fields := strings.Split(string(output), "\x00")
+	decisions := make(map[string]gitDecision, len(paths))
+	for i := 0; i+3 < len(fields); i += 4 {
+		source, line, pattern, path := fields[i], fields[i+1], fields[i+2], fields[i+3]
+		if source == "" {
+			decisions[path] = gitDecision{}
+			continue
+		}
+		decisions[path] = gitDecision{
+			ignored: !strings.HasPrefix(pattern, "!"),
+			pattern: source + ":" + line + ":" + pattern,
+		}
+	}
+	return decisions, nil
+}
+
+// runGit runs git with args in dir, isolated from the user's configuration,
+// and returns its standard output, even if it fails.
+func runGit(dir string, stdin *bytes.Buffer, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_CONFIG_GLOBAL="+os.DevNull,
+		"HOME="+dir,
+		"XDG_CONFIG_HOME="+filepath.Join(dir, ".git", "xdg"),
+	)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return output, fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
@@ -0,0 +1,87 @@
+package conformance
+
+import (
+	"testing"
+
+	"github.com/codeglyph/go-dotignore/v2"
+)
+
+func TestCompareWithGit(t *testing.T) {
+	if !GitAvailable() {
+		t.Skip("git is not installed")
+	}
+
+	cases := []Case{
+		{
+			Name:  "wildcards",
+			Files: map[string]string{".gitignore": "*.log\n!important.log\n/build/\n"},
+			Paths: []string{"app.log", "src/app.log", "important.log", "build/", "build/out.bin", "src/build/", "main.go"},
+		},
+		{
+			Name: "nested",
+			Files: map[string]string{
+				".gitignore":     "*.tmp\n",
+				"web/.gitignore": "!keep.tmp\ndist/\n",
+			},
+			Paths: []string{"a.tmp", "web/a.tmp", "web/keep.tmp", "keep.tmp", "web/dist/", "web/dist/app.js", "dist/"},
+		},
+		{
+			Name:  "directory patterns",
+			Files: map[string]string{".gitignore": "cache/\n"},
+			Paths: []string{"cache", "sub/cache/", "sub/cache/x"},
+		},
+		{
+			Name:  "negated directories",
+			Files: map[string]string{".gitignore": "*.log\n!b/\n"},
+			Paths: []string{"a.log", "b/a.log", "b", "b/c/a.log", "b/main.go"},
+		},
+		{
+			Name:  "negation below a wildcard",
+			Files: map[string]string{".gitignore": "*\n!src/\n"},
+			Paths: []string{"src/a.go", "src", "main.go"},
+		},
+		{
+			Name:  "re-included directories",
+			Files: map[string]string{".gitignore": "b/\n!*/\nx\n"},
+			Paths: []string{"b/a.log", "b", "b/c/d", "b/x/y"},
+		},
+		{
+			Name:  "wildcard directories",
+			Files: map[string]string{".gitignore": "/f*\nb/*\n"},
+			Paths: []string{"foo/a.log", "foo", "foo/bar/a.log", "src/foo/a.log", "b/build/x", "b/build", "b/main.go"},
+		},
+	}
+
+	divergences, err := CompareWithGit(cases, nil)
+	if err != nil {
+		t.Fatalf("CompareWithGit() failed: %v", err)
+	}
+	for _, d := range divergences {
+		t.Errorf("Divergence: %v", d)
+	}
+}
+
+func TestCompareWithGitReportsDivergences(t *testing.T) {
+	if !GitAvailable() {
+		t.Skip("git is not installed")
+	}
+
+	// A matcher that ignores nothing disagrees wherever Git ignores a path
+	ignoreNothing := func(root string) (dotignore.Matcher, error) {
+		return dotignore.MatcherFunc(func(string) (bool, error) { return false, nil }), nil
+	}
+	cases := []Case{{
+		Name:  "logs",
+		Files: map[string]string{".gitignore": "*.log\n"},
+		Paths: []string{"app.log", "main.go"},
+	}}
+
+	divergences, err := CompareWithGit(cases, ignoreNothing)
+	if err != nil {
+		t.Fatalf("CompareWithGit() failed: %v", err)
+	}
+	want := Divergence{Case: "logs", Path: "app.log", Git: true, GitPattern: ".gitignore:1:*.log"}
+	if len(divergences) != 1 || divergences[0] != want {
+		t.Errorf("CompareWithGit() = %+v, want [%+v]", divergences, want)
+	}
+}
@@ -0,0 +1,168 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CopyOptions configures CopyTree.
+type CopyOptions struct {
+	// Symlinks copies symbolic links as links. By default a link to a
+	// regular file is copied as a file with the contents of its target,
+	// and other links are left out.
+	Symlinks bool
+
+	// DryRun walks the tree and reports the paths that would be copied to
+	// OnCopy without writing anything.
+	DryRun bool
+
+	// OnCopy, if set, is called with the slash-separated path, relative to
+	// the source, of every directory, file and link as it is copied.
+	OnCopy func(path string)
+}
+
+// CopyTree copies every file below src that matcher does not ignore to the
+// same path below dst, following the same rules as WriteTar: the path
+// relative to src is passed to matcher, and ignored directories are not
+// descended into. Files and directories keep their permission bits; dst and
+// the directories inside it are created as needed, and existing files are
+// overwritten. dst must not be src or lie inside it. If opts is nil,
+// symbolic links are followed as described for CopyOptions.Symlinks.
+func CopyTree(dst, src string, matcher Matcher, opts *CopyOptions) error {
+	if opts == nil {
+		opts = &CopyOptions{}
+	}
+	if err := copyTree(dst, src, matcher, opts); err != nil {
+		return fmt.Errorf("failed to copy %q to %q: %w", src, dst, err)
+	}
+	return nil
+}
+
+// copyTree implements CopyTree.
+func copyTree(dst, src string, matcher Matcher, opts *CopyOptions) error {
+	if matcher == nil {
+		return errors.New("matcher cannot be nil")
+	}
+
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+	if absDst == absSrc || strings.HasPrefix(absDst, absSrc+string(filepath.Separator)) {
+		return errors.New("destination is inside the source")
+	}
+
+	rootInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !rootInfo.IsDir() {
+		return fmt.Errorf("%q is not a directory", src)
+	}
+
+	// Directories are made writable while they are filled and get their
+	// own mode once the walk is done
+	type createdDir struct {
+		path string
+		mode fs.FileMode
+	}
+	dirs := []createdDir{{dst, rootInfo.Mode().Perm()}}
+	if !opts.DryRun {
+		if err := os.MkdirAll(dst, 0o700|rootInfo.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	err = walkKept(src, matcher, func(path, name string, d fs.DirEntry) error {
+		target := filepath.Join(dst, filepath.FromSlash(name))
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 && !opts.Symlinks {
+			// Copy the file the link points to, if it is one
+			if info, err = os.Stat(path); err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+		}
+
+		if opts.OnCopy != nil {
+			opts.OnCopy(name)
+		}
+		if opts.DryRun {
+			return nil
+		}
+
+		switch {
+		case info.IsDir():
+			dirs = append(dirs, createdDir{target, info.Mode().Perm()})
+			return os.MkdirAll(target, 0o700|info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := removeExisting(target); err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyRegularFile(target, path, info.Mode().Perm())
+		}
+	})
+	if err != nil || opts.DryRun {
+		return err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyRegularFile copies the contents of the file at src to a file at dst
+// with the given permission bits, replacing whatever is at dst.
+func copyRegularFile(dst, src string, perm fs.FileMode) error {
+	// Writing through a link at dst would change a file outside the copy
+	if err := removeExisting(dst); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if err := copyFile(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// The mode given to OpenFile is subject to the umask
+	return os.Chmod(dst, perm)
+}
+
+// removeExisting removes the file or symbolic link at path, if any.
+// Directories are left in place.
+func removeExisting(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil || info.IsDir() {
+		return err
+	}
+	return os.Remove(path)
+}
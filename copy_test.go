@@ -0,0 +1,130 @@
+package dotignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCopyTree(t *testing.T) {
+	src := createTestRepo(t, map[string]string{
+		".gitignore":        "*.log\nbuild/\n!build/keep.txt\n",
+		"main.go":           "package main\n",
+		"debug.log":         "debug",
+		"build/out.bin":     "binary",
+		"build/keep.txt":    "keep",
+		"scripts/run.sh":    "#!/bin/sh\n",
+		"scripts/trace.log": "trace",
+	})
+	defer os.RemoveAll(src)
+
+	if err := os.Chmod(filepath.Join(src, "scripts", "run.sh"), 0755); err != nil {
+		t.Fatalf("Failed to change mode: %v", err)
+	}
+	hasSymlink := os.Symlink("main.go", filepath.Join(src, "link.go")) == nil
+
+	matcher, err := NewRepositoryMatcher(src)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	expected := []string{".gitignore", "main.go", "scripts", "scripts/run.sh"}
+	if hasSymlink {
+		expected = []string{".gitignore", "link.go", "main.go", "scripts", "scripts/run.sh"}
+	}
+
+	// A dry run reports the paths without writing anything
+	dst := filepath.Join(t.TempDir(), "copy")
+	var reported []string
+	if err := CopyTree(dst, src, matcher, &CopyOptions{DryRun: true, OnCopy: func(path string) {
+		reported = append(reported, path)
+	}}); err != nil {
+		t.Fatalf("CopyTree() dry run failed: %v", err)
+	}
+	if !reflect.DeepEqual(reported, expected) {
+		t.Errorf("dry run reported %v, want %v", reported, expected)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("dry run created %s", dst)
+	}
+
+	for _, symlinks := range []bool{false, true} {
+		dst := filepath.Join(t.TempDir(), "copy")
+		if err := CopyTree(dst, src, matcher, &CopyOptions{Symlinks: symlinks}); err != nil {
+			t.Fatalf("CopyTree() failed: %v", err)
+		}
+
+		var copied []string
+		err := filepath.Walk(dst, func(path string, info os.FileInfo, err error) error {
+			if err == nil && path != dst {
+				rel, _ := filepath.Rel(dst, path)
+				copied = append(copied, filepath.ToSlash(rel))
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Failed to walk the copy: %v", err)
+		}
+		sort.Strings(copied)
+		if !reflect.DeepEqual(copied, expected) {
+			t.Errorf("CopyTree() copied %v, want %v", copied, expected)
+		}
+
+		if data, _ := os.ReadFile(filepath.Join(dst, "main.go")); string(data) != "package main\n" {
+			t.Errorf("Unexpected content for main.go: %q", data)
+		}
+		if info, err := os.Stat(filepath.Join(dst, "scripts", "run.sh")); err != nil || info.Mode().Perm() != 0755 {
+			t.Errorf("Expected scripts/run.sh to keep mode 0755, got %v, %v", info, err)
+		}
+		if hasSymlink {
+			info, err := os.Lstat(filepath.Join(dst, "link.go"))
+			if err != nil {
+				t.Fatalf("link.go was not copied: %v", err)
+			}
+			if isLink := info.Mode()&os.ModeSymlink != 0; isLink != symlinks {
+				t.Errorf("link.go is a link = %v, want %v", isLink, symlinks)
+			}
+		}
+	}
+}
+
+func TestCopyTreeStrictDirectoryPatterns(t *testing.T) {
+	src := createTestRepo(t, map[string]string{
+		"main.go":       "package main\n",
+		"build/out.bin": "binary",
+	})
+	defer os.RemoveAll(src)
+
+	matcher, err := NewPatternMatcher([]string{"build/"}, WithStrictDirectoryPatterns())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "copy")
+	if err := CopyTree(dst, src, matcher, nil); err != nil {
+		t.Fatalf("CopyTree() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "build")); !os.IsNotExist(err) {
+		t.Errorf("CopyTree() created the ignored directory build: %v", err)
+	}
+}
+
+func TestCopyTreeErrors(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	src := t.TempDir()
+	if err := CopyTree(filepath.Join(t.TempDir(), "copy"), "/path/that/does/not/exist", matcher, nil); err == nil {
+		t.Error("Expected error for missing source")
+	}
+	if err := CopyTree(filepath.Join(t.TempDir(), "copy"), src, nil, nil); err == nil {
+		t.Error("Expected error for nil matcher")
+	}
+	if err := CopyTree(filepath.Join(src, "copy"), src, matcher, nil); err == nil {
+		t.Error("Expected error for a destination inside the source")
+	}
+}
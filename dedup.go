@@ -0,0 +1,227 @@
+package dotignore
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PatternLocation identifies a pattern line in an ignore file.
+type PatternLocation struct {
+	// Source is the path of the ignore file, in the form used by
+	// MatchResult.Source.
+	Source string
+	// Line is the 1-based line number of the pattern in Source.
+	Line int
+}
+
+// DuplicatePattern is a pattern written identically in more than one place
+// among the ignore files of a repository, such as a "node_modules/" line
+// repeated in every package of a monorepo.
+type DuplicatePattern struct {
+	// Pattern is the pattern as written at its first location.
+	Pattern string
+	// Locations lists every occurrence, in the order of IgnoreFilePaths.
+	Locations []PatternLocation
+	// SharedBytes approximates the memory that the compile cache already
+	// saves by giving all occurrences one compiled matcher.
+	SharedBytes int
+	// SavingsBytes approximates the memory held by the parsed copies beyond
+	// the first, which sharing one parsed pattern would save.
+	SavingsBytes int
+}
+
+// RedundantPattern is a pattern of an ignore file that never changes whether
+// a path is ignored, because a pattern evaluated before it, in an enclosing
+// directory or at a lower precedence level, already ignores every path it
+// matches.
+type RedundantPattern struct {
+	PatternLocation
+	// Pattern is the line as written.
+	Pattern string
+	// Kind is IssueDuplicate if the earlier pattern is identical, or
+	// IssueShadowed if it is broader, such as "*.log" for "debug.log".
+	Kind IssueKind
+	// CoveredBy is the location of the earlier pattern.
+	CoveredBy PatternLocation
+}
+
+// DedupReport describes the repetition among the patterns of the ignore
+// files of a repository and the memory it costs, as returned by
+// RepositoryMatcher.DedupReport.
+type DedupReport struct {
+	// Patterns is the total number of patterns in the ignore files.
+	Patterns int
+	// Distinct is the number of distinct patterns among them.
+	Distinct int
+	// Duplicates lists the patterns found in more than one place, those
+	// with the most occurrences first.
+	Duplicates []DuplicatePattern
+	// Redundant lists the patterns that can be removed without changing
+	// the outcome for any path, in the order of IgnoreFilePaths.
+	Redundant []RedundantPattern
+	// SharedBytes is the total of the SharedBytes of Duplicates.
+	SharedBytes int
+	// SavingsBytes approximates the memory that removing the Redundant
+	// patterns and sharing one parsed copy of every remaining duplicate
+	// would save.
+	SavingsBytes int
+}
+
+// patternKey identifies patterns that are parsed alike.
+type patternKey struct {
+	pattern                         string
+	negate, directory, rootRelative bool
+}
+
+// DedupReport reports the patterns repeated across the ignore files of the
+// repository and those made redundant by patterns in enclosing directories,
+// with estimates of the memory involved. Override patterns are not included.
+//
+// Like Lint, the check for redundant patterns is conservative: a pattern is
+// only reported if it certainly has no effect. Only patterns without a
+// slash, which apply alike at any depth, are taken from the ignore files of
+// enclosing directories, and a negation in one of those files prevents the
+// check for the files beneath it. It must not be called concurrently with
+// Reload.
+func (rm *RepositoryMatcher) DedupReport() DedupReport {
+	var report DedupReport
+
+	type group struct {
+		duplicate DuplicatePattern
+		parsed    int
+		redundant int
+	}
+	groups := make(map[patternKey]*group)
+	var order []patternKey
+
+	for _, dir := range rm.sortedDirs() {
+		for _, file := range rm.matchers[dir] {
+			source := rm.sourcePath(file.path)
+			patterns := file.matcher.rules.Load().patterns
+			redundant := rm.redundantPatterns(dir, file)
+
+			for _, pattern := range patterns {
+				report.Patterns++
+				key := patternKey{pattern.pattern, pattern.negate, pattern.isDirectory, pattern.isRootRelative}
+				g := groups[key]
+				if g == nil {
+					g = &group{
+						duplicate: DuplicatePattern{Pattern: pattern.text, SharedBytes: -pattern.compiledSize()},
+						parsed:    pattern.parsedSize(),
+					}
+					groups[key] = g
+					order = append(order, key)
+				}
+				g.duplicate.Locations = append(g.duplicate.Locations, PatternLocation{Source: source, Line: pattern.line})
+				g.duplicate.SharedBytes += pattern.compiledSize()
+
+				if covered, ok := redundant[pattern.line]; ok {
+					covered.PatternLocation = PatternLocation{Source: source, Line: pattern.line}
+					covered.Pattern = pattern.text
+					report.Redundant = append(report.Redundant, covered)
+					g.redundant++
+				}
+			}
+		}
+	}
+
+	report.Distinct = len(groups)
+	for _, key := range order {
+		g := groups[key]
+		n := len(g.duplicate.Locations)
+
+		// One parsed copy remains unless every occurrence is redundant
+		kept := 1
+		if g.redundant == n {
+			kept = 0
+		}
+		report.SavingsBytes += g.parsed * (n - kept)
+
+		if n < 2 {
+			continue
+		}
+		g.duplicate.SavingsBytes = g.parsed * (n - 1)
+		report.Duplicates = append(report.Duplicates, g.duplicate)
+		report.SharedBytes += g.duplicate.SharedBytes
+	}
+	sort.SliceStable(report.Duplicates, func(i, j int) bool {
+		return len(report.Duplicates[i].Locations) > len(report.Duplicates[j].Locations)
+	})
+	return report
+}
+
+// redundantPatterns checks the patterns of file, an ignore file of dir,
+// against the patterns evaluated before them, and returns the redundant
+// ones by line, with their Kind and CoveredBy set.
+func (rm *RepositoryMatcher) redundantPatterns(dir string, file *ignoreFile) map[int]RedundantPattern {
+	if file.matcher.options.syntax != SyntaxGit {
+		return nil
+	}
+	relDir, err := filepath.Rel(rm.rootDir, dir)
+	if err != nil {
+		return nil
+	}
+
+	// Patterns of other files are renumbered in evaluation order, so that
+	// the line reported by lintPattern locates them
+	var prior []ignorePattern
+	var locations []PatternLocation
+	priorFiles := true
+	for level := 0; level <= file.level && priorFiles; level++ {
+		for _, applying := range rm.applyingDirs(filepath.ToSlash(relDir)) {
+			for _, other := range rm.matchers[applying] {
+				if other.level != level {
+					continue
+				}
+				if other == file {
+					priorFiles = false
+					break
+				}
+				if other.matcher.options.syntax != SyntaxGit {
+					continue
+				}
+				for _, pattern := range other.matcher.rules.Load().patterns {
+					switch {
+					case applying == dir:
+					case pattern.negate:
+						// It may re-include paths beneath dir, even through
+						// the components of dir itself
+						return nil
+					case pattern.isRootRelative || strings.Contains(pattern.pattern, "/"):
+						// Anchored to another directory
+						continue
+					}
+					locations = append(locations, PatternLocation{Source: rm.sourcePath(other.path), Line: pattern.line})
+					pattern.line = len(locations)
+					prior = append(prior, pattern)
+				}
+			}
+			if !priorFiles {
+				break
+			}
+		}
+	}
+	if len(prior) == 0 {
+		return nil
+	}
+
+	redundant := make(map[int]RedundantPattern)
+	for _, pattern := range file.matcher.rules.Load().patterns {
+		line := pattern.line
+		pattern.line = len(prior) + 1
+		issue, found := lintPattern(prior, pattern)
+		prior = append(prior, pattern)
+		locations = append(locations, PatternLocation{Source: rm.sourcePath(file.path), Line: line})
+
+		// Repetition within the file itself is for Lint to report
+		if !found || issue.RelatedLine == 0 || locations[issue.RelatedLine-1].Source == rm.sourcePath(file.path) {
+			continue
+		}
+		if issue.Kind != IssueDuplicate && issue.Kind != IssueShadowed {
+			continue
+		}
+		redundant[line] = RedundantPattern{Kind: issue.Kind, CoveredBy: locations[issue.RelatedLine-1]}
+	}
+	return redundant
+}
@@ -0,0 +1,63 @@
+package dotignore
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestRepositoryMatcher_DedupReport(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":       "node_modules/\n*.log\n",
+		"app/.gitignore":   "node_modules/\ndebug.log\n!keep.log\n*.log\n",
+		"lib/.gitignore":   "node_modules/\n/build/\n",
+		"web/.gitignore":   "!important.log\n",
+		"web/a/.gitignore": "node_modules/\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcher() failed: %v", err)
+	}
+
+	report := matcher.DedupReport()
+	if report.Patterns != 10 || report.Distinct != 6 {
+		t.Errorf("DedupReport() has %d patterns, %d distinct, want 10, 6", report.Patterns, report.Distinct)
+	}
+
+	if len(report.Duplicates) != 2 {
+		t.Fatalf("Duplicates = %+v, want node_modules/ and *.log", report.Duplicates)
+	}
+	nodeModules := report.Duplicates[0]
+	expected := []PatternLocation{
+		{".gitignore", 1}, {"app/.gitignore", 1}, {"lib/.gitignore", 1}, {"web/a/.gitignore", 1},
+	}
+	if nodeModules.Pattern != "node_modules/" || !reflect.DeepEqual(nodeModules.Locations, expected) {
+		t.Errorf("Duplicates[0] = %+v, want node_modules/ at %v", nodeModules, expected)
+	}
+	if nodeModules.SharedBytes <= 0 || nodeModules.SavingsBytes <= 0 {
+		t.Errorf("Duplicates[0] shares %d bytes and would save %d, want positive", nodeModules.SharedBytes, nodeModules.SavingsBytes)
+	}
+	if report.Duplicates[1].Pattern != "*.log" {
+		t.Errorf("Duplicates[1] = %+v, want *.log", report.Duplicates[1])
+	}
+
+	// The *.log after the negation in app/.gitignore is needed again, and
+	// the negation in web/.gitignore keeps web/a/.gitignore from the check
+	expectedRedundant := []RedundantPattern{
+		{PatternLocation{"app/.gitignore", 1}, "node_modules/", IssueDuplicate, PatternLocation{".gitignore", 1}},
+		{PatternLocation{"app/.gitignore", 2}, "debug.log", IssueShadowed, PatternLocation{".gitignore", 2}},
+		{PatternLocation{"lib/.gitignore", 1}, "node_modules/", IssueDuplicate, PatternLocation{".gitignore", 1}},
+	}
+	if !reflect.DeepEqual(report.Redundant, expectedRedundant) {
+		t.Errorf("Redundant = %+v, want %+v", report.Redundant, expectedRedundant)
+	}
+
+	if report.SharedBytes != nodeModules.SharedBytes+report.Duplicates[1].SharedBytes {
+		t.Errorf("SharedBytes = %d, want the total of Duplicates", report.SharedBytes)
+	}
+	if report.SavingsBytes <= nodeModules.SavingsBytes {
+		t.Errorf("SavingsBytes = %d, want more than the %d of node_modules/", report.SavingsBytes, nodeModules.SavingsBytes)
+	}
+}
@@ -0,0 +1,198 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codeglyph/go-dotignore/v2/internal"
+)
+
+// PatternChange describes a class of paths whose ignore status differs
+// between two sets of patterns.
+type PatternChange struct {
+	// Path is an example of the paths in the class, derived from the
+	// patterns that decide it.
+	Path string
+
+	// Ignored reports whether the new patterns ignore Path. The old patterns
+	// decide the opposite.
+	Ignored bool
+
+	// Before and After are the patterns that decide Path in the old and new
+	// sets, as written, or "" if no pattern matches it.
+	Before string
+	After  string
+}
+
+// DiffPatterns parses two lists of patterns with opts and reports how
+// changing from before to after affects which paths are ignored. See
+// DiffMatchers.
+func DiffPatterns(before, after []string, opts ...Option) ([]PatternChange, error) {
+	old, err := NewPatternMatcher(before, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse old patterns: %w", err)
+	}
+	updated, err := NewPatternMatcher(after, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse new patterns: %w", err)
+	}
+	return DiffMatchers(old, updated)
+}
+
+// DiffMatchers reports how replacing the patterns of before with those of
+// after affects which paths are ignored, rather than which lines changed.
+//
+// Example paths are derived from every pattern of both matchers: the
+// pattern with its wildcards filled in, a path inside it, and for patterns
+// that match at any depth the same beneath a subdirectory. Each path whose
+// status differs is reported once per pair of deciding patterns, so a
+// PatternChange stands for the class of paths those patterns decide. Changes
+// are ordered by the depth of their example paths, then alphabetically.
+// Paths no example covers are not compared.
+func DiffMatchers(before, after *PatternMatcher) ([]PatternChange, error) {
+	if before == nil || after == nil {
+		return nil, errors.New("matchers to compare cannot be nil")
+	}
+	if before.options.syntax != after.options.syntax {
+		return nil, fmt.Errorf("cannot compare %v patterns with %v patterns", before.options.syntax, after.options.syntax)
+	}
+
+	// Matching counts hits, which comparing should not disturb
+	if before.options.trackHits {
+		before = before.Clone()
+	}
+	if after.options.trackHits {
+		after = after.Clone()
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, matcher := range []*PatternMatcher{before, after} {
+		for _, pattern := range matcher.rules.Load().patterns {
+			for _, example := range examplePaths(pattern) {
+				if !seen[example] {
+					seen[example] = true
+					paths = append(paths, example)
+				}
+			}
+		}
+	}
+	// Shallower paths make clearer examples, so they are compared first
+	sort.Slice(paths, func(i, j int) bool {
+		di, dj := strings.Count(paths[i], "/"), strings.Count(paths[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return paths[i] < paths[j]
+	})
+
+	type decision struct {
+		before, after string
+		ignored       bool
+	}
+	reported := make(map[decision]bool)
+	var changes []PatternChange
+	for _, example := range paths {
+		wasIgnored, err := before.Matches(example)
+		if err != nil {
+			return nil, err
+		}
+		ignored, err := after.Matches(example)
+		if err != nil {
+			return nil, err
+		}
+		if ignored == wasIgnored {
+			continue
+		}
+
+		d := decision{ignored: ignored}
+		if d.before, err = decidingText(before, example); err != nil {
+			return nil, err
+		}
+		if d.after, err = decidingText(after, example); err != nil {
+			return nil, err
+		}
+		if reported[d] {
+			continue
+		}
+		reported[d] = true
+		changes = append(changes, PatternChange{Path: example, Ignored: ignored, Before: d.before, After: d.after})
+	}
+	return changes, nil
+}
+
+// decidingText returns the text of the pattern deciding file, or "".
+func decidingText(p *PatternMatcher, file string) (string, error) {
+	pattern, ok, err := p.matchDetail(file, kindUnknown, true)
+	if err != nil || !ok {
+		return "", err
+	}
+	return pattern.text, nil
+}
+
+// examplePaths returns paths that pattern matches: its glob with the
+// wildcards filled in, a path beneath it, and for patterns that are not
+// anchored the same inside a subdirectory.
+func examplePaths(pattern ignorePattern) []string {
+	name := exampleName(pattern.pattern)
+	if name == "" {
+		return nil
+	}
+	paths := []string{name, name + "/file"}
+	if !pattern.isRootRelative {
+		paths = append(paths, "dir/"+name, "dir/"+name+"/file")
+	}
+	return paths
+}
+
+// exampleName returns a path matched by glob: "*" and "?" become "x", a
+// character class becomes one of its members, "**/" is dropped and a
+// trailing "**" becomes "x".
+func exampleName(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				i += 2
+				continue
+			}
+			for i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+			}
+			b.WriteByte('x')
+		case '?':
+			b.WriteByte('x')
+		case '[':
+			class, size, err := internal.ParseClass(glob[i:])
+			if size == 0 || err != nil {
+				b.WriteByte(c)
+				continue
+			}
+			b.WriteByte(classMember(class))
+			i += size - 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteByte(glob[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.Trim(b.String(), "/")
+}
+
+// classMember returns a letter or digit matched by class, or 'x' if it
+// matches none.
+func classMember(class internal.Class) byte {
+	const candidates = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-."
+	for i := 0; i < len(candidates); i++ {
+		if class.Match(rune(candidates[i])) {
+			return candidates[i]
+		}
+	}
+	return 'x'
+}
@@ -0,0 +1,99 @@
+package dotignore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffPatterns(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []string
+		after  []string
+		opts   []Option
+		want   []PatternChange
+	}{
+		{
+			name:   "Identical",
+			before: []string{"*.log", "build/"},
+			after:  []string{"*.log", "build/"},
+		},
+		{
+			name:   "Reordered without effect",
+			before: []string{"*.log", "build/"},
+			after:  []string{"build/", "*.log", "*.log"},
+		},
+		{
+			name:   "Added pattern",
+			before: []string{"*.log"},
+			after:  []string{"*.log", "/dist/"},
+			want: []PatternChange{
+				{Path: "dist", Ignored: true, After: "/dist/"},
+			},
+		},
+		{
+			name:   "Removed negation",
+			before: []string{"*.log", "!important.log"},
+			after:  []string{"*.log"},
+			want: []PatternChange{
+				{Path: "important.log", Ignored: true, Before: "!important.log", After: "*.log"},
+			},
+		},
+		{
+			name:   "Narrowed wildcard",
+			before: []string{"*.tmp"},
+			after:  []string{"cache/*.tmp"},
+			opts:   []Option{WithoutSubpathHeuristic()},
+			want: []PatternChange{
+				{Path: "x.tmp", Ignored: false, Before: "*.tmp"},
+			},
+		},
+		{
+			name:   "Character class",
+			before: []string{"log[0-9].txt"},
+			after:  []string{},
+			want: []PatternChange{
+				{Path: "log0.txt", Ignored: false, Before: "log[0-9].txt"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffPatterns(tt.before, tt.after, tt.opts...)
+			if err != nil {
+				t.Fatalf("DiffPatterns() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffPatterns() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffMatchersErrors(t *testing.T) {
+	git := MustNewPatternMatcher([]string{"*.log"})
+	docker := MustNewPatternMatcher([]string{"*.log"}, WithSyntax(SyntaxDocker))
+
+	if _, err := DiffMatchers(git, nil); err == nil {
+		t.Error("Expected an error for a nil matcher")
+	}
+	if _, err := DiffMatchers(git, docker); err == nil {
+		t.Error("Expected an error for matchers of different syntaxes")
+	}
+	if _, err := DiffPatterns([]string{"!"}, nil); err == nil {
+		t.Error("Expected an error for invalid patterns")
+	}
+}
+
+func TestDiffMatchersKeepsHitCounts(t *testing.T) {
+	before := MustNewPatternMatcher([]string{"*.log"}, WithHitTracking())
+	after := MustNewPatternMatcher([]string{"*.tmp"}, WithHitTracking())
+
+	if _, err := DiffMatchers(before, after); err != nil {
+		t.Fatalf("DiffMatchers() failed: %v", err)
+	}
+	if unused := before.UnusedPatterns(); len(unused) != 1 {
+		t.Errorf("Expected comparing to leave hit counts untouched, got unused %v", unused)
+	}
+}
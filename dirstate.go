@@ -0,0 +1,226 @@
+package dotignore
+
+import "strings"
+
+// DirState holds what is known about the patterns of a PatternMatcher for
+// the paths beneath one directory, so that a walk can match the entries of
+// the directory without evaluating every pattern for each of them. Patterns
+// anchored to the root whose literal leading segments differ from the
+// directory are dropped, and a pattern matching every path beneath the
+// directory decides the outcome in place of all the patterns before it.
+// With WithStrictNegation, the state of an ignored directory decides for
+// everything beneath it.
+//
+// A DirState is obtained for a directory with PatternMatcher.DirState and
+// for each of its subdirectories with Child, so that a walk only does the
+// work for the new path component at each level:
+//
+//	root, _ := matcher.DirState("")
+//	src, _ := root.Child("src")
+//	ignored, _ := src.Matches("main.go", false)
+//
+// A DirState matches against the patterns the matcher had when the state of
+// the directory at the top was obtained; later changes, such as AddPatterns,
+// are not seen. It is safe for concurrent use.
+type DirState struct {
+	matcher  *PatternMatcher
+	rules    *ruleSet
+	dir      string // slash-separated, relative to the matcher; "" for the top
+	depth    int    // number of components of dir
+	decider  int    // index of the last pattern matching every path beneath dir, or -1
+	residual []int  // indices of the later patterns that may match beneath dir, ascending
+	excluded bool   // dir or a parent is excluded by decider, with strict negation
+}
+
+// DirState returns the state of the patterns for the paths beneath dir,
+// which is interpreted as for MatchesPath; "" and "." stand for the top of
+// the tree.
+func (p *PatternMatcher) DirState(dir string) (*DirState, error) {
+	rules := p.rules.Load()
+	state := &DirState{
+		matcher:  p,
+		rules:    rules,
+		decider:  -1,
+		residual: make([]int, len(rules.patterns)),
+	}
+	for i := range state.residual {
+		state.residual[i] = i
+	}
+
+	dir, ok, err := p.normalizePath(dir)
+	if !ok || err != nil {
+		return state, err
+	}
+	for _, name := range strings.Split(dir, "/") {
+		if name == "" {
+			continue
+		}
+		if state, err = state.Child(name); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// Dir returns the directory of the state, slash-separated and relative to
+// the matcher, or "" for the top of the tree.
+func (s *DirState) Dir() string {
+	return s.dir
+}
+
+// Child returns the state of the subdirectory name of the directory. name
+// must be a plain name, without separators.
+func (s *DirState) Child(name string) (*DirState, error) {
+	if err := checkSegment(name); err != nil {
+		return nil, err
+	}
+	if s.matcher.options.caseInsensitive {
+		name = strings.ToLower(name)
+	}
+
+	child := &DirState{
+		matcher:  s.matcher,
+		rules:    s.rules,
+		dir:      joinSegment(s.dir, name),
+		depth:    s.depth + 1,
+		decider:  s.decider,
+		excluded: s.excluded,
+	}
+	if s.excluded {
+		return child, nil
+	}
+
+	// Git does not descend into excluded directories
+	if s.matcher.options.strictNegation {
+		i, err := s.decide(child.dir, kindDir, false)
+		if err != nil {
+			return nil, err
+		}
+		if i >= 0 && !s.rules.patterns[i].negate {
+			child.decider = i
+			child.excluded = true
+			return child, nil
+		}
+	}
+
+	// Traced and counted patterns are evaluated for every path
+	tracking := s.matcher.options.trace != nil
+	for _, i := range s.residual {
+		pattern := s.rules.patterns[i]
+		switch {
+		case !tracking && pattern.hits == nil && coversDescendants(child.dir, pattern, s.matcher.options):
+			child.decider = i
+			child.residual = child.residual[:0]
+		case pattern.isRootRelative && !pattern.inverted && !literalSegmentMatches(pattern.pattern, s.depth, name):
+			// Cannot match beneath the directory, as in patternIndex
+		default:
+			child.residual = append(child.residual, i)
+		}
+	}
+
+	// Without negations the decider keeps every path beneath ignored; only
+	// the log needs the pattern that matches last
+	if child.decider >= 0 && s.rules.negationFree && s.matcher.options.logger == nil {
+		child.residual = nil
+	}
+	return child, nil
+}
+
+// Matches reports whether the entry name of the directory is ignored, given
+// whether it is a directory. name must be a plain name, without separators.
+func (s *DirState) Matches(name string, isDir bool) (bool, error) {
+	if err := checkSegment(name); err != nil {
+		return false, err
+	}
+	if s.matcher.options.caseInsensitive {
+		name = strings.ToLower(name)
+	}
+
+	file := joinSegment(s.dir, name)
+	kind := kindFile
+	if isDir {
+		kind = kindDir
+	}
+
+	i := s.decider
+	if !s.excluded {
+		var err error
+		if i, err = s.decide(file, kind, true); err != nil {
+			return false, err
+		}
+	} else if s.matcher.options.trackHits || s.matcher.options.trace != nil {
+		// The patterns matching the entry itself still count the query
+		if _, err := s.matcher.decide(s.rules, file, kind, true); err != nil {
+			return false, err
+		}
+	}
+	if s.matcher.options.logger != nil {
+		s.matcher.logDecision(s.rules, file, i)
+	}
+	if i < 0 {
+		return false, nil
+	}
+	return !s.rules.patterns[i].negate, nil
+}
+
+// decide applies the remaining patterns to file, a path beneath the
+// directory, and returns the index of the pattern that decides whether it
+// is ignored, or -1 if none does. Like PatternMatcher.decide, it tries the
+// patterns from the last unless hits or traces must see all of them, and
+// hides the lookup from them unless observe is set.
+func (s *DirState) decide(file string, kind pathKind, observe bool) (int, error) {
+	if !observe || (!s.matcher.options.trackHits && s.matcher.options.trace == nil) {
+		for j := len(s.residual) - 1; j >= 0; j-- {
+			i := s.residual[j]
+			isMatch, err := s.matcher.testPattern(file, kind, s.rules.patterns[i])
+			if isMatch || err != nil {
+				return i, err
+			}
+		}
+		return s.decider, nil
+	}
+
+	last := s.decider
+	for _, i := range s.residual {
+		isMatch, err := s.matcher.applyPattern(file, kind, s.rules.patterns[i])
+		if err != nil {
+			return -1, err
+		}
+		if isMatch {
+			last = i
+		}
+	}
+	return last, nil
+}
+
+// joinSegment appends name to the slash-separated dir.
+func joinSegment(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// literalSegmentMatches reports whether the literal leading segment of
+// pattern at the given depth, as listed by literalSegments, is name. It
+// reports true if pattern has no literal segment at that depth.
+func literalSegmentMatches(pattern string, depth int, name string) bool {
+	for {
+		end := strings.IndexByte(pattern, '/')
+		segment := pattern
+		if end >= 0 {
+			segment = pattern[:end]
+		}
+		if segment == "" || strings.ContainsAny(segment, `*?[\`) {
+			return true
+		}
+		if depth == 0 {
+			return segment == name
+		}
+		if end < 0 {
+			return true
+		}
+		pattern = pattern[end+1:]
+		depth--
+	}
+}
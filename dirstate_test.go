@@ -0,0 +1,149 @@
+package dotignore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDirState(t *testing.T) {
+	patterns := []string{
+		"*.log", "!keep.log", "build/", "/dist", "!/dist/keep.txt", "/docs/*.md",
+		"node_modules", "out/gen", "**/tmp/**", "/a/b/c/", "!vendor/**/*.go", "vendor/",
+	}
+	paths := []string{
+		"app.log", "keep.log", "build", "build/x/keep.log", "dist/a.js", "dist/keep.txt",
+		"docs/a.md", "docs/sub/a.md", "web/node_modules/pkg/index.js", "pkg/out/gen/v1.go",
+		"src/tmp/x", "a/b/c/d.txt", "a/b/d.txt", "vendor/lib/a.go", "vendor/lib/a.txt",
+		"Build/App.LOG", "main.go",
+	}
+	syntaxes := []Option{WithSyntax(SyntaxGit), WithSyntax(SyntaxDocker), WithSyntax(SyntaxHelm), WithSyntax(SyntaxGcloud)}
+	options := [][]Option{nil, {WithStrictNegation()}, {WithGitStrict()}, {WithCaseInsensitive()}, {WithHitTracking()}}
+
+	for _, syntax := range syntaxes {
+		for _, opts := range options {
+			matcher, err := NewPatternMatcher(patterns, append([]Option{syntax, WithSkipInvalidPatterns(nil)}, opts...)...)
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+			root, err := matcher.DirState("")
+			if err != nil {
+				t.Fatalf("DirState() failed: %v", err)
+			}
+
+			// Entries agree with MatchesPath on their full path
+			for _, path := range paths {
+				segs := strings.Split(path, "/")
+				state := root
+				for i, name := range segs {
+					for _, isDir := range []bool{false, true} {
+						if i < len(segs)-1 && !isDir {
+							continue
+						}
+						want, err := matcher.MatchesPath(strings.Join(segs[:i+1], "/"), isDir)
+						if err != nil {
+							t.Fatalf("MatchesPath() failed: %v", err)
+						}
+						got, err := state.Matches(name, isDir)
+						if err != nil {
+							t.Fatalf("Matches() failed: %v", err)
+						}
+						if got != want {
+							t.Errorf("DirState(%q).Matches(%q, %v) = %v, MatchesPath() = %v (%d options)",
+								state.Dir(), name, isDir, got, want, len(opts))
+						}
+					}
+					if i < len(segs)-1 {
+						if state, err = state.Child(name); err != nil {
+							t.Fatalf("Child(%q) failed: %v", name, err)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDirStateResidual(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"/docs/*.md", "*.log", "build/", "!keep.log", "/build/gen/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		dir      string
+		decider  int
+		residual []int
+	}{
+		{"", -1, []int{0, 1, 2, 3, 4}},
+		{"docs", -1, []int{0, 1, 2, 3}},
+		{"src", -1, []int{1, 2, 3}},
+		{"build", 2, []int{3, 4}},
+		{"build/x", 2, []int{3}},
+		{"build/gen/x", 4, []int{}},
+		{"./src/app.log", 1, []int{2, 3}},
+	}
+	for _, tt := range tests {
+		state, err := matcher.DirState(tt.dir)
+		if err != nil {
+			t.Fatalf("DirState(%q) failed: %v", tt.dir, err)
+		}
+		if state.decider != tt.decider || !reflect.DeepEqual(append([]int{}, state.residual...), tt.residual) {
+			t.Errorf("DirState(%q) decider = %d, residual = %v, want %d, %v", tt.dir, state.decider, state.residual, tt.decider, tt.residual)
+		}
+	}
+
+	// Strict negation stops at an excluded directory
+	strict, err := NewPatternMatcher([]string{"build/", "!keep.log"}, WithStrictNegation())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	state, err := strict.DirState("build/sub")
+	if err != nil {
+		t.Fatalf("DirState() failed: %v", err)
+	}
+	if !state.excluded || len(state.residual) != 0 {
+		t.Errorf("DirState(build/sub) excluded = %v, residual = %v, want excluded", state.excluded, state.residual)
+	}
+	if ignored, err := state.Matches("keep.log", false); !ignored || err != nil {
+		t.Errorf("Matches(keep.log) = %v, %v, want true", ignored, err)
+	}
+
+	root, _ := matcher.DirState("")
+	for _, name := range []string{"", ".", "..", "a/b", `a\b`} {
+		if _, err := root.Child(name); err == nil {
+			t.Errorf("Child(%q) should fail", name)
+		}
+		if _, err := root.Matches(name, false); err == nil {
+			t.Errorf("Matches(%q) should fail", name)
+		}
+	}
+
+	based, err := NewPatternMatcher(nil, WithBasePath("/repo"))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if _, err := based.DirState("/elsewhere"); err == nil {
+		t.Error("DirState() outside the base path should fail")
+	}
+	if state, err := based.DirState("/repo/src"); err != nil || state.Dir() != "src" {
+		t.Errorf("DirState(/repo/src) = %v, %v, want src", state, err)
+	}
+}
+
+func BenchmarkDirState(b *testing.B) {
+	matcher, err := NewPatternMatcher([]string{"*.log", "build/", "**/*.test.js", "!important.log", "/docs/*.md", "/vendor/"})
+	if err != nil {
+		b.Fatalf("Failed to create matcher: %v", err)
+	}
+	state, err := matcher.DirState("src/components")
+	if err != nil {
+		b.Fatalf("DirState() failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = state.Matches("component.js", false)
+		_, _ = state.Matches("component.test.js", false)
+	}
+}
@@ -0,0 +1,122 @@
+package dotignore
+
+import "strings"
+
+// DockerignoreWarning describes a pattern whose .dockerignore translation
+// does not behave exactly like the original, as returned by ToDockerignore.
+type DockerignoreWarning struct {
+	PatternLocation
+	// Pattern is the pattern as written.
+	Pattern string
+	// Message explains the difference.
+	Message string
+}
+
+// ToDockerignore translates the patterns of the matcher into the lines of
+// an equivalent .dockerignore file for a build context rooted at the
+// directory the patterns are relative to, and reports the patterns that
+// cannot be represented exactly. It helps keep a build context as small as
+// the set of files Git would track.
+//
+// Docker anchors every pattern at the root of the context and lets "**"
+// match any number of directories, so each pattern becomes the globs of
+// ToGlobs, such as "**/*.log" and "**/*.log/**" for "*.log". Negations keep
+// their "!", and since Docker also lets the last matching line decide, the
+// order is kept.
+//
+// Docker does not tell directories from files, so a directory-only pattern
+// also excludes files of the same name, which is reported as a warning.
+// SyntaxHelm patterns starting with "!" cannot be represented and are left
+// out with a warning. Case-insensitive matchers produce lowercase lines,
+// which Docker matches case-sensitively. The Source of each warning is
+// empty.
+func (p *PatternMatcher) ToDockerignore() ([]string, []DockerignoreWarning) {
+	var lines []string
+	var warnings []DockerignoreWarning
+	for _, pattern := range p.rules.Load().patterns {
+		lines, warnings = p.appendDockerignore(lines, warnings, pattern, "", "")
+	}
+	return lines, warnings
+}
+
+// ToDockerignore translates the rules of every loaded ignore file into the
+// lines of a single .dockerignore file for a build context rooted at the
+// root directory of the repository, as described for
+// PatternMatcher.ToDockerignore. Patterns from ignore files in
+// subdirectories are anchored to their directory, and the built-in
+// exclusions of .git directories and skipped nested repositories, which
+// Docker would otherwise send with the context, come last.
+//
+// As with RsyncFilterRules, NestedRepositoryScope is not reproduced and
+// patterns of ignore files above the root are kept only if they match a
+// name at any depth.
+func (rm *RepositoryMatcher) ToDockerignore() ([]string, []DockerignoreWarning) {
+	var lines []string
+	var warnings []DockerignoreWarning
+	rm.exportPatterns(func(matcher *PatternMatcher, pattern ignorePattern, dir, source string) {
+		lines, warnings = matcher.appendDockerignore(lines, warnings, pattern, dir, source)
+	})
+
+	for _, dir := range rm.skippedNestedDirs() {
+		lines = append(lines, dir)
+	}
+	if !rm.config.IncludeGitDir {
+		lines = append(lines, "**/"+gitDirName)
+	}
+	return lines, warnings
+}
+
+// appendDockerignore appends the .dockerignore lines equivalent to pattern,
+// read from the ignore file source in the slash-separated directory dir ("" for
+// the root), and a warning if they are not exact.
+func (p *PatternMatcher) appendDockerignore(lines []string, warnings []DockerignoreWarning, pattern ignorePattern, dir, source string) ([]string, []DockerignoreWarning) {
+	warn := func(message string) {
+		warnings = append(warnings, DockerignoreWarning{
+			PatternLocation: PatternLocation{Source: source, Line: pattern.line},
+			Pattern:         pattern.text,
+			Message:         message,
+		})
+	}
+
+	if pattern.inverted {
+		warn("excludes every path it does not match, which .dockerignore cannot express; left out")
+		return lines, warnings
+	}
+	if pattern.isDirectory {
+		warn("only matches directories in the original, but also matches files in .dockerignore")
+	}
+	for _, glob := range p.appendGlobs(nil, pattern) {
+		lines = append(lines, dockerignoreLine(dir, glob))
+	}
+	return lines, warnings
+}
+
+// dockerignoreLine converts a glob returned by ToGlobs for an ignore file in
+// the slash-separated directory dir into a .dockerignore line.
+func dockerignoreLine(dir, glob string) string {
+	prefix := ""
+	if strings.HasPrefix(glob, "!") {
+		prefix, glob = "!", glob[1:]
+	}
+	glob = joinRsyncPath(dir, glob)
+
+	// Docker matches with filepath.Match, which negates a class with "^"
+	// and gives braces no meaning
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case glob[i] == '\\' && i+1 < len(glob) && (glob[i+1] == '{' || glob[i+1] == '}'):
+			b.WriteByte(glob[i+1])
+			i++
+		case glob[i] == '\\' && i+1 < len(glob):
+			b.WriteString(glob[i : i+2])
+			i++
+		case glob[i] == '[' && i+1 < len(glob) && glob[i+1] == '!':
+			b.WriteString("[^")
+			i++
+		default:
+			b.WriteByte(glob[i])
+		}
+	}
+	return prefix + b.String()
+}
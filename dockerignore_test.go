@@ -0,0 +1,116 @@
+package dotignore
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestToDockerignore(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		opts     []Option
+		expected []string
+		warnings []int
+	}{
+		{
+			name:     "Floating and anchored",
+			patterns: []string{"*.log", "/dist", "docs/*.md"},
+			expected: []string{"**/*.log", "**/*.log/**", "dist", "dist/**", "**/docs/*.md"},
+		},
+		{
+			name:     "Negation and directory",
+			patterns: []string{"build/", "!build/keep.txt"},
+			expected: []string{"**/build", "**/build/**", "!**/build/keep.txt", "!**/build/keep.txt/**"},
+			warnings: []int{1},
+		},
+		{
+			name:     "Classes and braces",
+			patterns: []string{"[!a]b", "{x}"},
+			expected: []string{"**/[^a]b", "**/[^a]b/**", "**/{x}", "**/{x}/**"},
+		},
+		{
+			name:     "Inverted helm pattern",
+			patterns: []string{"!*.yaml", "tmp/"},
+			opts:     []Option{WithSyntax(SyntaxHelm)},
+			expected: []string{"**/tmp", "**/tmp/**"},
+			warnings: []int{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := NewPatternMatcher(tt.patterns, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+			lines, warnings := matcher.ToDockerignore()
+			if !reflect.DeepEqual(lines, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, lines)
+			}
+			var warned []int
+			for _, w := range warnings {
+				warned = append(warned, w.Line)
+			}
+			if !reflect.DeepEqual(warned, tt.warnings) {
+				t.Errorf("Expected warnings for lines %v, got %+v", tt.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestToDockerignoreMatchesOriginal(t *testing.T) {
+	patterns := []string{"*.log", "!keep.log", "/dist", "node_modules", "docs/**/*.tmp", "a?c", "[xy].txt"}
+	paths := []string{
+		"app.log", "src/app.log", "keep.log", "src/keep.log", "dist", "dist/a.js",
+		"src/dist", "node_modules/x/y.js", "web/node_modules/z.js", "docs/a.tmp",
+		"docs/sub/b.tmp", "src/docs/c.tmp", "abc", "x/abc/d", "x.txt", "z.txt", "main.go",
+	}
+
+	git, err := NewPatternMatcher(patterns)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	lines, warnings := git.ToDockerignore()
+	if len(warnings) != 0 {
+		t.Errorf("Unexpected warnings: %+v", warnings)
+	}
+	docker, err := NewPatternMatcher(lines, WithSyntax(SyntaxDocker))
+	if err != nil {
+		t.Fatalf("Failed to parse %q: %v", lines, err)
+	}
+
+	for _, path := range paths {
+		want, err := git.Matches(path)
+		if err != nil {
+			t.Fatalf("Matches(%q) failed: %v", path, err)
+		}
+		if got, err := docker.Matches(path); err != nil || got != want {
+			t.Errorf(".dockerignore Matches(%q) = %v, %v, want %v", path, got, err, want)
+		}
+	}
+}
+
+func TestRepositoryMatcher_ToDockerignore(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":     "*.log\n",
+		"app/.gitignore": "cache/\n",
+		"app/main.go":    "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	lines, warnings := matcher.ToDockerignore()
+
+	expected := []string{"**/*.log", "**/*.log/**", "app/**/cache", "app/**/cache/**", "**/.git"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected %q, got %q", expected, lines)
+	}
+	if len(warnings) != 1 || warnings[0].Source != "app/.gitignore" || warnings[0].Line != 1 || warnings[0].Pattern != "cache/" {
+		t.Errorf("Unexpected warnings: %+v", warnings)
+	}
+}
@@ -0,0 +1,299 @@
+package dotignore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// LineKind classifies a line of a Document.
+type LineKind int
+
+const (
+	// LineBlank marks a line containing nothing but whitespace.
+	LineBlank LineKind = iota + 1
+
+	// LineComment marks a line starting with "#".
+	LineComment
+
+	// LinePattern marks any other line, including one that is not a valid
+	// pattern.
+	LinePattern
+)
+
+// String returns the name of the line kind.
+func (k LineKind) String() string {
+	switch k {
+	case LineBlank:
+		return "blank"
+	case LineComment:
+		return "comment"
+	case LinePattern:
+		return "pattern"
+	default:
+		return fmt.Sprintf("LineKind(%d)", int(k))
+	}
+}
+
+// DocumentLine is a line of a Document.
+type DocumentLine struct {
+	// Line is the 1-based line number.
+	Line int
+	// Text is the line as written, without its line terminator.
+	Text string
+	// Kind classifies the line.
+	Kind LineKind
+}
+
+// Document is an editable ignore file. Unlike a PatternMatcher it keeps
+// comments, blank lines, whitespace and line terminators, so that writing
+// it back reproduces the input byte for byte except where it was edited.
+// Inserted lines use the line terminator of the first line of the input.
+//
+// A Document is not safe for concurrent use.
+type Document struct {
+	bom     bool
+	lines   []documentLine
+	newline string
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+type documentLine struct {
+	text string
+	eol  string // "\n", "\r\n", "\r", or "" for a last line without a terminator
+}
+
+// NewDocument returns an empty Document.
+func NewDocument() *Document {
+	return &Document{newline: "\n"}
+}
+
+// NewDocumentFromReader reads an ignore file into a Document.
+func NewDocumentFromReader(reader io.Reader) (*Document, error) {
+	if reader == nil {
+		return nil, errors.New("reader cannot be nil")
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	return parseDocument(content), nil
+}
+
+// NewDocumentFromFile reads the ignore file at filePath into a Document.
+func NewDocumentFromFile(filePath string) (*Document, error) {
+	if filePath == "" {
+		return nil, errors.New("file path cannot be empty")
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %q: %w", filePath, err)
+	}
+	return parseDocument(content), nil
+}
+
+// parseDocument splits content into lines, keeping their terminators.
+func parseDocument(content []byte) *Document {
+	d := NewDocument()
+	if bytes.HasPrefix(content, utf8BOM) {
+		d.bom = true
+		content = content[len(utf8BOM):]
+	}
+
+	newlineSeen := false
+	for len(content) > 0 {
+		line := documentLine{text: string(content)}
+		if i := bytes.IndexAny(content, "\r\n"); i >= 0 {
+			line.text, line.eol = string(content[:i]), string(content[i])
+			if bytes.HasPrefix(content[i:], []byte("\r\n")) {
+				line.eol = "\r\n"
+			}
+			content = content[i+len(line.eol):]
+			if !newlineSeen {
+				d.newline, newlineSeen = line.eol, true
+			}
+		} else {
+			content = nil
+		}
+		d.lines = append(d.lines, line)
+	}
+	return d
+}
+
+// Lines returns the lines of the document in order.
+func (d *Document) Lines() []DocumentLine {
+	lines := make([]DocumentLine, len(d.lines))
+	for i, line := range d.lines {
+		lines[i] = DocumentLine{Line: i + 1, Text: line.text, Kind: lineKind(line.text)}
+	}
+	return lines
+}
+
+// Patterns returns the pattern lines of the document in order, without
+// surrounding whitespace.
+func (d *Document) Patterns() []string {
+	var patterns []string
+	for _, line := range d.lines {
+		if lineKind(line.text) == LinePattern {
+			patterns = append(patterns, patternText(line.text))
+		}
+	}
+	return patterns
+}
+
+// Contains reports whether the document has a pattern line that equals
+// pattern, ignoring surrounding whitespace.
+func (d *Document) Contains(pattern string) bool {
+	pattern = patternText(pattern)
+	for _, line := range d.lines {
+		if lineKind(line.text) == LinePattern && patternText(line.text) == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// Add appends pattern to the end of the document unless the document already
+// contains it, and reports whether it was added.
+func (d *Document) Add(pattern string) (bool, error) {
+	if lineKind(pattern) != LinePattern {
+		return false, fmt.Errorf("%q is not a pattern", pattern)
+	}
+	if d.Contains(pattern) {
+		return false, nil
+	}
+	return true, d.Insert(len(d.lines)+1, pattern)
+}
+
+// Remove removes every pattern line that equals pattern, ignoring
+// surrounding whitespace, and returns how many were removed. Comments and
+// blank lines around them are kept.
+func (d *Document) Remove(pattern string) int {
+	pattern = patternText(pattern)
+	kept := d.lines[:0]
+	for _, line := range d.lines {
+		if lineKind(line.text) != LinePattern || patternText(line.text) != pattern {
+			kept = append(kept, line)
+		}
+	}
+	removed := len(d.lines) - len(kept)
+	d.lines = kept
+	return removed
+}
+
+// Insert inserts lines before the 1-based line number line. A line number
+// one past the last line appends them to the document. Lines may be
+// patterns, comments or blank.
+func (d *Document) Insert(line int, lines ...string) error {
+	if line < 1 || line > len(d.lines)+1 {
+		return fmt.Errorf("line %d is out of range", line)
+	}
+	for _, text := range lines {
+		if strings.ContainsAny(text, "\r\n") {
+			return fmt.Errorf("line %q contains a line terminator", text)
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	// A last line without a terminator needs one before lines follow it
+	if line > len(d.lines) && line > 1 && d.lines[line-2].eol == "" {
+		d.lines[line-2].eol = d.newline
+	}
+	inserted := make([]documentLine, len(lines))
+	for i, text := range lines {
+		inserted[i] = documentLine{text: text, eol: d.newline}
+	}
+	d.lines = append(d.lines[:line-1], append(inserted, d.lines[line-1:]...)...)
+	return nil
+}
+
+// Delete removes the line with the given 1-based line number.
+func (d *Document) Delete(line int) error {
+	if line < 1 || line > len(d.lines) {
+		return fmt.Errorf("line %d is out of range", line)
+	}
+	d.lines = append(d.lines[:line-1], d.lines[line:]...)
+	return nil
+}
+
+// Bytes returns the contents of the document.
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// String returns the contents of the document.
+func (d *Document) String() string {
+	return string(d.Bytes())
+}
+
+// WriteTo writes the contents of the document to w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	write := func(s string) error {
+		n, err := io.WriteString(w, s)
+		written += int64(n)
+		return err
+	}
+
+	if d.bom {
+		if err := write(string(utf8BOM)); err != nil {
+			return written, err
+		}
+	}
+	for _, line := range d.lines {
+		if err := write(line.text + line.eol); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// WriteFile writes the contents of the document to the file at filePath,
+// creating it if necessary. An existing file keeps its permissions.
+func (d *Document) WriteFile(filePath string) error {
+	if err := os.WriteFile(filePath, d.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write file %q: %w", filePath, err)
+	}
+	return nil
+}
+
+// Matcher returns a PatternMatcher for the patterns of the document. Line
+// numbers reported by the matcher, including those of a ParseError, are
+// line numbers of the document.
+func (d *Document) Matcher(opts ...Option) (*PatternMatcher, error) {
+	lines := make([]string, len(d.lines))
+	for i, line := range d.lines {
+		lines[i] = line.text
+	}
+	return NewPatternMatcher(lines, opts...)
+}
+
+// lineKind classifies a line the way parseIgnorePattern does.
+func lineKind(text string) LineKind {
+	text = patternText(text)
+	switch {
+	case text == "":
+		return LineBlank
+	case strings.HasPrefix(text, "#"):
+		return LineComment
+	default:
+		return LinePattern
+	}
+}
+
+// patternText returns text without the whitespace that parseIgnorePattern
+// ignores.
+func patternText(text string) string {
+	return trimTrailingSpaces(strings.TrimLeftFunc(text, unicode.IsSpace))
+}
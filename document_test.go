@@ -0,0 +1,212 @@
+package dotignore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"*.log",
+		"*.log\n",
+		"# comment\n\n  /build/  \n!keep.log\n",
+		"\xEF\xBB\xBF*.tmp\r\nnode_modules/\r\n",
+		"mixed\r\nendings\nno-final-newline",
+		"lone\rcarriage\r\rreturns\r",
+		"\n\n\n",
+	}
+
+	for _, input := range inputs {
+		doc, err := NewDocumentFromReader(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("NewDocumentFromReader(%q) failed: %v", input, err)
+		}
+		if got := doc.String(); got != input {
+			t.Errorf("Round trip of %q produced %q", input, got)
+		}
+	}
+}
+
+func TestDocumentLines(t *testing.T) {
+	doc, err := NewDocumentFromReader(strings.NewReader("# build\n\n  /build/  \n\\#literal\n!keep.log"))
+	if err != nil {
+		t.Fatalf("NewDocumentFromReader failed: %v", err)
+	}
+
+	want := []DocumentLine{
+		{Line: 1, Text: "# build", Kind: LineComment},
+		{Line: 2, Text: "", Kind: LineBlank},
+		{Line: 3, Text: "  /build/  ", Kind: LinePattern},
+		{Line: 4, Text: "\\#literal", Kind: LinePattern},
+		{Line: 5, Text: "!keep.log", Kind: LinePattern},
+	}
+	if got := doc.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %+v, want %+v", got, want)
+	}
+	if got, want := doc.Patterns(), []string{"/build/", "\\#literal", "!keep.log"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Patterns() = %q, want %q", got, want)
+	}
+	if !doc.Contains(" /build/") || doc.Contains("build/") || doc.Contains("# build") {
+		t.Error("Contains() does not compare pattern lines without surrounding whitespace")
+	}
+}
+
+func TestDocumentEdits(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		edit  func(*Document) error
+		want  string
+	}{
+		{
+			name:  "Add appends with the document's line terminator",
+			input: "# deps\r\nvendor/\r\n",
+			edit: func(d *Document) error {
+				_, err := d.Add("*.log")
+				return err
+			},
+			want: "# deps\r\nvendor/\r\n*.log\r\n",
+		},
+		{
+			name:  "Add terminates an unterminated last line",
+			input: "vendor/",
+			edit: func(d *Document) error {
+				_, err := d.Add("*.log")
+				return err
+			},
+			want: "vendor/\n*.log\n",
+		},
+		{
+			name:  "Add to an empty document",
+			input: "",
+			edit: func(d *Document) error {
+				_, err := d.Add("*.log")
+				return err
+			},
+			want: "*.log\n",
+		},
+		{
+			name:  "Add skips an existing pattern",
+			input: "# logs\n  *.log\n",
+			edit: func(d *Document) error {
+				added, err := d.Add("*.log")
+				if added {
+					return errors.New("pattern added twice")
+				}
+				return err
+			},
+			want: "# logs\n  *.log\n",
+		},
+		{
+			name:  "Insert before a line",
+			input: "# logs\n*.log\n",
+			edit: func(d *Document) error {
+				return d.Insert(2, "# temporary files", "*.tmp", "")
+			},
+			want: "# logs\n# temporary files\n*.tmp\n\n*.log\n",
+		},
+		{
+			name:  "Remove keeps comments and other lines",
+			input: "# logs\n*.log\r\nbuild/\n  *.log\n",
+			edit: func(d *Document) error {
+				if removed := d.Remove("*.log"); removed != 2 {
+					return errors.New("wrong number of patterns removed")
+				}
+				return nil
+			},
+			want: "# logs\nbuild/\n",
+		},
+		{
+			name:  "Delete a line",
+			input: "a\nb\nc",
+			edit: func(d *Document) error {
+				return d.Delete(2)
+			},
+			want: "a\nc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewDocumentFromReader(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("NewDocumentFromReader failed: %v", err)
+			}
+			if err := tt.edit(doc); err != nil {
+				t.Fatalf("Edit failed: %v", err)
+			}
+			if got := doc.String(); got != tt.want {
+				t.Errorf("Document is %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentEditErrors(t *testing.T) {
+	doc := NewDocument()
+	if err := doc.Insert(2, "*.log"); err == nil {
+		t.Error("Insert past the end succeeded")
+	}
+	if err := doc.Insert(1, "a\nb"); err == nil {
+		t.Error("Insert of a line with a terminator succeeded")
+	}
+	if err := doc.Delete(1); err == nil {
+		t.Error("Delete of a missing line succeeded")
+	}
+	if _, err := doc.Add("# comment"); err == nil {
+		t.Error("Add of a comment succeeded")
+	}
+	if _, err := NewDocumentFromReader(nil); err == nil {
+		t.Error("NewDocumentFromReader(nil) succeeded")
+	}
+}
+
+func TestDocumentFileAndMatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(path, []byte("# generated\n*.log\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := NewDocumentFromFile(path)
+	if err != nil {
+		t.Fatalf("NewDocumentFromFile failed: %v", err)
+	}
+	if _, err := doc.Add("!keep.log"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := doc.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(content), "# generated\n*.log\n!keep.log\n"; got != want {
+		t.Errorf("File contains %q, want %q", got, want)
+	}
+
+	matcher, err := doc.Matcher()
+	if err != nil {
+		t.Fatalf("Matcher failed: %v", err)
+	}
+	for file, want := range map[string]bool{"debug.log": true, "keep.log": false} {
+		if got, err := matcher.Matches(file); err != nil || got != want {
+			t.Errorf("Matches(%q) = %v, %v, want %v", file, got, err, want)
+		}
+	}
+
+	// Parse errors report line numbers of the document
+	if err := doc.Insert(1, "!"); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	var parseErr *ParseError
+	if _, err := doc.Matcher(); !errors.As(err, &parseErr) || parseErr.Line != 1 {
+		t.Errorf("Matcher() error = %v, want a ParseError at line 1", err)
+	}
+}
@@ -37,54 +37,129 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
 
 	"github.com/codeglyph/go-dotignore/v2/internal"
 )
 
 type ignorePattern struct {
+	text           string // pattern line as written, without surrounding whitespace
+	line           int    // 1-based line number within the parsed input
 	pattern        string
-	regexPattern   *regexp.Regexp
-	isDirectory    bool // true if pattern ends with /
+	glob           *internal.Glob // nil if the pattern needs regexPattern
+	regexPattern   *regexp.Regexp // fallback for patterns the glob engine cannot handle
+	isDirectory    bool           // true if pattern ends with /
 	negate         bool
-	hasWildcard    bool // true if pattern contains wildcards
-	isRootRelative bool // true if pattern starts with / (matches only at root level)
+	hasWildcard    bool           // true if pattern contains wildcards
+	isRootRelative bool           // true if pattern is anchored to the root: starts with /, or contains one with WithGitStrict
+	inverted       bool           // SyntaxHelm "!" pattern, which ignores the paths it does not match
+	hits           *atomic.Uint64 // number of matched paths; nil unless hit tracking is enabled
 }
 
+// pathKind records what is known about the type of a path being matched.
+type pathKind uint8
+
+const (
+	kindUnknown pathKind = iota // directory patterns also match same-named files
+	kindFile                    // a file, symbolic link or other non-directory
+	kindDir                     // a directory
+)
+
 // PatternMatcher provides methods to parse, store, and evaluate ignore patterns against file paths.
+//
+// A PatternMatcher is safe for concurrent use. Matching reads an immutable
+// snapshot of the patterns without locking, and methods that change the
+// patterns, such as AddPatterns, build a new snapshot and swap it in
+// atomically, so a concurrent match sees either all of a change or none of it.
 type PatternMatcher struct {
-	ignorePatterns []ignorePattern
+	mu      sync.Mutex // serializes changes to the patterns
+	rules   atomic.Pointer[ruleSet]
+	options matcherOptions
+}
+
+// ruleSet is an immutable snapshot of the patterns of a PatternMatcher.
+type ruleSet struct {
+	patterns     []ignorePattern
+	index        *patternIndex // nil if no pattern can be indexed
+	negationFree bool          // no pattern is a negation, so no match can be undone
+}
+
+// newRuleSet builds the snapshot of patterns written in the given syntax.
+func newRuleSet(patterns []ignorePattern, syntax Syntax) *ruleSet {
+	rules := &ruleSet{patterns: patterns, index: buildPatternIndex(patterns, syntax), negationFree: true}
+	for _, pattern := range patterns {
+		if pattern.negate {
+			rules.negationFree = false
+			break
+		}
+	}
+	return rules
+}
+
+// newPatternMatcher returns a PatternMatcher for parsed patterns.
+func newPatternMatcher(patterns []ignorePattern, options matcherOptions) *PatternMatcher {
+	p := &PatternMatcher{options: options}
+	p.setPatterns(patterns)
+	return p
 }
 
 // NewPatternMatcher initializes a new PatternMatcher instance from a list of string patterns.
-func NewPatternMatcher(patterns []string) (*PatternMatcher, error) {
-	ignorePatterns, err := buildIgnorePatterns(patterns)
+// Optional behavior can be enabled by passing one or more Option values.
+func NewPatternMatcher(patterns []string, opts ...Option) (*PatternMatcher, error) {
+	options := buildOptions(opts)
+	ignorePatterns, err := parsePatterns(patterns, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build ignore patterns: %w", err)
 	}
-	return &PatternMatcher{
-		ignorePatterns: ignorePatterns,
-	}, nil
+	return newPatternMatcher(ignorePatterns, options), nil
+}
+
+// MustNewPatternMatcher is like NewPatternMatcher but panics if a pattern is
+// invalid. It simplifies initializing package-level variables with a fixed
+// list of patterns.
+func MustNewPatternMatcher(patterns []string, opts ...Option) *PatternMatcher {
+	matcher, err := NewPatternMatcher(patterns, opts...)
+	if err != nil {
+		panic("dotignore: " + err.Error())
+	}
+	return matcher
 }
 
 // NewPatternMatcherFromReader initializes a new PatternMatcher instance from an io.Reader.
-func NewPatternMatcherFromReader(reader io.Reader) (*PatternMatcher, error) {
+func NewPatternMatcherFromReader(reader io.Reader, opts ...Option) (*PatternMatcher, error) {
 	if reader == nil {
 		return nil, errors.New("reader cannot be nil")
 	}
 
-	patterns, err := internal.ReadLines(reader)
+	patterns, err := buildOptions(opts).readLines(reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse patterns from reader: %w", err)
 	}
-	return NewPatternMatcher(patterns)
+	return NewPatternMatcher(patterns, opts...)
+}
+
+// MustNewPatternMatcherFromReader is like NewPatternMatcherFromReader but
+// panics if the patterns cannot be read or parsed. It is intended for
+// patterns embedded in the program, such as with go:embed.
+func MustNewPatternMatcherFromReader(reader io.Reader, opts ...Option) *PatternMatcher {
+	matcher, err := NewPatternMatcherFromReader(reader, opts...)
+	if err != nil {
+		panic("dotignore: " + err.Error())
+	}
+	return matcher
 }
 
 // NewPatternMatcherFromFile reads a file containing ignore patterns and returns a PatternMatcher instance.
-func NewPatternMatcherFromFile(filePath string) (*PatternMatcher, error) {
+func NewPatternMatcherFromFile(filePath string, opts ...Option) (*PatternMatcher, error) {
 	if filePath == "" {
 		return nil, errors.New("file path cannot be empty")
 	}
@@ -95,172 +170,779 @@ func NewPatternMatcherFromFile(filePath string) (*PatternMatcher, error) {
 	}
 	defer fileReader.Close()
 
-	patterns, err := internal.ReadLines(fileReader)
+	patterns, err := buildOptions(opts).readLines(fileReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse patterns from file %q: %w", filePath, err)
 	}
-	return NewPatternMatcher(patterns)
+	opts = append(opts[:len(opts):len(opts)], withIncludeDir(filepath.Dir(filePath)))
+	matcher, err := NewPatternMatcher(patterns, opts...)
+	if err != nil {
+		setParseErrorFile(err, filePath)
+		return nil, err
+	}
+	return matcher, nil
+}
+
+// NewPatternMatcherFromFS creates a new PatternMatcher from the file called
+// name in fsys, such as an embed.FS holding a default ignore list. name
+// follows the rules of fs.Open: it is slash-separated and unrooted.
+func NewPatternMatcherFromFS(fsys fs.FS, name string, opts ...Option) (*PatternMatcher, error) {
+	if fsys == nil {
+		return nil, errors.New("file system cannot be nil")
+	}
+
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %q: %w", name, err)
+	}
+	defer file.Close()
+
+	patterns, err := buildOptions(opts).readLines(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse patterns from file %q: %w", name, err)
+	}
+	opts = append(opts[:len(opts):len(opts)], withIncludeFS(fsys, path.Dir(name)))
+	matcher, err := NewPatternMatcher(patterns, opts...)
+	if err != nil {
+		setParseErrorFile(err, name)
+		return nil, err
+	}
+	return matcher, nil
 }
 
 // Matches checks if the given file path matches any of the ignore patterns in the PatternMatcher.
-// It returns true if the file should be ignored, false otherwise.
+// It returns true if the file should be ignored, false otherwise. A path
+// ending in a slash, such as "build/", is taken to be a directory.
+//
+// Matching a clean, slash-separated path performs zero allocations, amortized
+// over many calls. Paths that need cleaning, use backslashes, or contain upper
+// case letters in a case-insensitive matcher are copied once while normalizing.
 func (p *PatternMatcher) Matches(file string) (bool, error) {
-	if file == "" {
-		return false, nil
+	kind := p.options.kindOf(file)
+	file, ok, err := p.normalizePath(file)
+	if !ok || err != nil {
+		return false, err
 	}
 
-	// Clean and normalize the path
-	file = filepath.Clean(file)
-	if file == "." || file == "./" {
+	return p.matchesInternal(p.rules.Load(), file, kind)
+}
+
+// MatchesPath reports whether file is ignored, like Matches, given whether
+// it is a directory. Directory patterns such as "build/" then match only
+// directories and the paths beneath them, as in Git, rather than also a file
+// named build.
+func (p *PatternMatcher) MatchesPath(file string, isDir bool) (bool, error) {
+	file, ok, err := p.normalizePath(file)
+	if !ok || err != nil {
+		return false, err
+	}
+
+	kind := kindFile
+	if isDir {
+		kind = kindDir
+	}
+	return p.matchesInternal(p.rules.Load(), file, kind)
+}
+
+// IsIgnored reports whether file is ignored, like MatchesPath, for callers
+// that have no use for an error. The only paths MatchesPath fails for are
+// those outside the base path set with WithBasePath, which IsIgnored reports
+// as not ignored.
+func (p *PatternMatcher) IsIgnored(file string, isDir bool) bool {
+	ignored, err := p.MatchesPath(file, isDir)
+	return ignored && err == nil
+}
+
+// MustMatch is like MatchesPath but panics if file cannot be matched, such
+// as a path outside the base path.
+func (p *PatternMatcher) MustMatch(file string, isDir bool) bool {
+	ignored, err := p.MatchesPath(file, isDir)
+	if err != nil {
+		panic("dotignore: " + err.Error())
+	}
+	return ignored
+}
+
+// MatchesSegments reports whether the path made of segs, the names of its
+// components from the top down, is ignored, given whether it is a
+// directory. It suits walks that already keep track of the components of
+// the current path: the segments are matched as a relative path without the
+// cleaning, separator conversion and base path handling of MatchesPath.
+//
+// Each segment must be a plain name; an empty segment, ".", ".." or a name
+// containing a slash or backslash is an error.
+//
+// Every call joins segs into a new path and matches it against all the
+// patterns. A walk that matches many entries should keep a DirState for
+// each directory instead, which matches an entry against only the patterns
+// its directory leaves undecided and skips excluded directories outright.
+func (p *PatternMatcher) MatchesSegments(segs []string, isDir bool) (bool, error) {
+	if len(segs) == 0 {
 		return false, nil
 	}
+	for _, seg := range segs {
+		if err := checkSegment(seg); err != nil {
+			return false, err
+		}
+	}
 
-	// Convert backslashes to forward slashes for consistent matching
-	// Use explicit conversion to handle all cases
-	file = strings.ReplaceAll(file, "\\", "/")
+	file := strings.Join(segs, "/")
+	if p.options.caseInsensitive {
+		file = strings.ToLower(file)
+	}
+	kind := kindFile
+	if isDir {
+		kind = kindDir
+	}
+	return p.matchesInternal(p.rules.Load(), file, kind)
+}
 
-	return p.matchesInternal(file)
+// checkSegment returns an error unless seg is a plain name that can be a
+// component of a path.
+func checkSegment(seg string) error {
+	if seg == "" || seg == "." || seg == ".." || strings.ContainsAny(seg, `/\`) {
+		return fmt.Errorf("invalid path segment %q", seg)
+	}
+	return nil
 }
 
-// MatchesWithTracking checks if the given file path matches any patterns and also
-// returns whether any pattern (including negation patterns) matched at all.
-// This is useful for hierarchical matching where we need to know if a .gitignore
-// file had any applicable patterns.
+// MatchesWith reports whether file is ignored, like Matches, as if extra
+// were appended to the matcher's patterns for this one query. The extra
+// patterns take precedence over the matcher's own and are parsed with the
+// same options, but the matcher itself is not changed, so MatchesWith is
+// safe to call concurrently with other queries and with AddPatterns. With
+// WithHitTracking, the matcher's own patterns count the query as for
+// Matches, while the extra patterns count nothing.
 //
-// Returns: (shouldIgnore bool, anyPatternMatched bool, error)
+// The extra patterns are parsed and indexed on every call. To apply the same
+// patterns to many paths, use Clone and AddPatterns instead.
+func (p *PatternMatcher) MatchesWith(file string, extra []string) (bool, error) {
+	options := p.options
+	options.trackHits = false
+	added, err := parsePatterns(extra, options)
+	if err != nil {
+		return false, fmt.Errorf("failed to build ignore patterns: %w", err)
+	}
+
+	kind := p.options.kindOf(file)
+	file, ok, err := p.normalizePath(file)
+	if !ok || err != nil {
+		return false, err
+	}
+
+	rules := p.rules.Load()
+	if len(added) == 0 {
+		return p.matchesInternal(rules, file, kind)
+	}
+	if err := p.options.checkPatternCount(len(rules.patterns) + len(added)); err != nil {
+		return false, err
+	}
+	patterns := append(rules.patterns[:len(rules.patterns):len(rules.patterns)], added...)
+	return p.matchesInternal(newRuleSet(patterns, p.options.syntax), file, kind)
+}
+
+// MatchWithDetail reports whether file is ignored, like Matches, and whether
+// a pattern decided the outcome. decided is false when no pattern matches
+// file, in which case ignored is false as well, and true when the last
+// matching pattern is a negation that re-includes file.
+//
+// This lets several matchers be layered, as RepositoryMatcher does with the
+// ignore files of nested directories: each matcher that decides overrides
+// the outcome of the ones before it, and one that does not decide leaves it
+// unchanged.
+func (p *PatternMatcher) MatchWithDetail(file string) (ignored, decided bool, err error) {
+	kind := p.options.kindOf(file)
+	file, ok, err := p.normalizePath(file)
+	if !ok || err != nil {
+		return false, false, err
+	}
+
+	rules := p.rules.Load()
+	i, err := p.resolve(rules, file, kind, true)
+	if err != nil {
+		return false, false, err
+	}
+	if p.options.logger != nil {
+		p.logDecision(rules, file, i)
+	}
+	if i < 0 {
+		return false, false, nil
+	}
+	return !rules.patterns[i].negate, true, nil
+}
+
+// MatchesWithTracking reports whether file is ignored and whether any pattern
+// matched it.
+//
+// Deprecated: Use MatchWithDetail, which returns the same values.
 func (p *PatternMatcher) MatchesWithTracking(file string) (bool, bool, error) {
+	return p.MatchWithDetail(file)
+}
+
+// matchDetail returns the pattern that decides whether file, of the given
+// kind, is ignored, and false if no pattern matches it. Hit tracking and
+// tracing only see the query if observe is set.
+func (p *PatternMatcher) matchDetail(file string, kind pathKind, observe bool) (ignorePattern, bool, error) {
+	file, ok, err := p.normalizePath(file)
+	if !ok || err != nil {
+		return ignorePattern{}, false, err
+	}
+
+	rules := p.rules.Load()
+	i, err := p.resolve(rules, file, kind, observe)
+	if err != nil || i < 0 {
+		return ignorePattern{}, false, err
+	}
+	return rules.patterns[i], true, nil
+}
+
+// normalizePath converts file into the slash-separated form that patterns are
+// matched against. It reports false if there is nothing to match, such as for
+// an empty path or the current directory.
+func (p *PatternMatcher) normalizePath(file string) (string, bool, error) {
 	if file == "" {
-		return false, false, nil
+		return "", false, nil
 	}
 
-	// Clean and normalize the path
-	file = filepath.Clean(file)
-	if file == "." || file == "./" {
-		return false, false, nil
+	// Clean the path, convert backslashes to forward slashes unless they are
+	// ordinary characters and strip any Windows volume, so that C:\repo\x
+	// and /repo/x are matched alike
+	original := file
+	var volume string
+	if p.options.backslashSeparates() {
+		volume, file = splitPath(file)
+	} else {
+		volume, file = splitLiteralPath(file)
 	}
 
-	// Convert backslashes to forward slashes for consistent matching
-	file = strings.ReplaceAll(file, "\\", "/")
+	// Absolute paths are interpreted relative to the base path, if any
+	if p.options.basePath != "" && strings.HasPrefix(file, "/") {
+		relPath, ok := p.options.relativeToBase(volume, file)
+		if !ok {
+			return "", false, fmt.Errorf("path %q is outside base path %q", original, p.options.basePath)
+		}
+		file = relPath
+	}
 
-	matched := false
-	anyPatternMatched := false
+	if file == "" || file == "." {
+		return "", false, nil
+	}
 
-	for _, pattern := range p.ignorePatterns {
-		isMatch, err := p.matchPattern(file, pattern)
-		if err != nil {
-			return false, false, fmt.Errorf("error matching pattern %q against file %q: %w", pattern.pattern, file, err)
+	if p.options.caseInsensitive {
+		file = strings.ToLower(file)
+	}
+
+	return file, true, nil
+}
+
+// AddPatterns parses patterns and appends them to the matcher, giving them
+// precedence over the existing patterns. If any pattern is invalid, none of
+// them are added. It is safe to call concurrently with Matches.
+func (p *PatternMatcher) AddPatterns(patterns []string) error {
+	added, err := parsePatterns(patterns, p.options)
+	if err != nil {
+		return fmt.Errorf("failed to build ignore patterns: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.appendPatterns(added)
+}
+
+// RemovePatterns removes every pattern whose line, ignoring surrounding
+// whitespace, equals one of patterns, and returns how many were removed.
+// It is safe to call concurrently with Matches.
+func (p *PatternMatcher) RemovePatterns(patterns []string) int {
+	remove := make(map[string]bool, len(patterns))
+	for _, pattern := range patterns {
+		remove[patternText(pattern)] = true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current := p.rules.Load().patterns
+	kept := make([]ignorePattern, 0, len(current))
+	for _, pattern := range current {
+		if !remove[pattern.text] {
+			kept = append(kept, pattern)
 		}
+	}
+	removed := len(current) - len(kept)
+	p.setPatterns(kept)
+	return removed
+}
 
-		if isMatch {
-			anyPatternMatched = true
-			matched = !pattern.negate
+// SetPatterns replaces all patterns of the matcher with patterns. If any
+// pattern is invalid, the matcher is left unchanged. It is safe to call
+// concurrently with Matches.
+func (p *PatternMatcher) SetPatterns(patterns []string) error {
+	replacement, err := parsePatterns(patterns, p.options)
+	if err != nil {
+		return fmt.Errorf("failed to build ignore patterns: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setPatterns(replacement)
+	return nil
+}
+
+// Clone returns an independent copy of the matcher. Patterns added to or
+// removed from the copy do not affect the original, and vice versa.
+func (p *PatternMatcher) Clone() *PatternMatcher {
+	rules := p.rules.Load()
+	ignorePatterns := append([]ignorePattern(nil), rules.patterns...)
+	for i, pattern := range ignorePatterns {
+		if pattern.hits != nil {
+			ignorePatterns[i].hits = new(atomic.Uint64)
+			ignorePatterns[i].hits.Store(pattern.hits.Load())
 		}
 	}
 
-	return matched, anyPatternMatched, nil
+	// The index refers to patterns by position, so it can be shared
+	clone := &PatternMatcher{options: p.options}
+	clone.rules.Store(&ruleSet{patterns: ignorePatterns, index: rules.index, negationFree: rules.negationFree})
+	return clone
 }
 
-func buildIgnorePatterns(patterns []string) ([]ignorePattern, error) {
-	var ignorePatterns []ignorePattern
+// Merge appends the patterns of other to the matcher, preserving their order.
+// The merged patterns take precedence over the existing ones, exactly as if
+// they had been listed after them. No patterns are parsed again.
+//
+// Both matchers must use the same Syntax. To extend a shared base matcher
+// without modifying it, merge into a Clone.
+func (p *PatternMatcher) Merge(other *PatternMatcher) error {
+	if other == nil {
+		return errors.New("matcher to merge cannot be nil")
+	}
+	if p.options.syntax != other.options.syntax {
+		return fmt.Errorf("cannot merge %v patterns into a %v matcher", other.options.syntax, p.options.syntax)
+	}
 
-	for i, pattern := range patterns {
-		pattern = strings.TrimSpace(pattern)
+	merged := append([]ignorePattern(nil), other.rules.Load().patterns...)
+	p.options.attachHitCounters(merged)
 
-		// Skip empty lines and comments
-		if pattern == "" || strings.HasPrefix(pattern, "#") {
-			continue
-		}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.appendPatterns(merged)
+}
+
+// setPatterns publishes a new snapshot of patterns with a freshly built
+// index. Except in constructors, the caller must hold p.mu.
+func (p *PatternMatcher) setPatterns(patterns []ignorePattern) {
+	p.rules.Store(newRuleSet(patterns, p.options.syntax))
+}
 
-		// Handle escaped negation (\!) before checking for actual negation
-		// In gitignore, \! at the start means "match files literally starting with !"
-		isNegation := false
-		if strings.HasPrefix(pattern, `\!`) {
-			// Escaped negation - remove the backslash, keep the !
-			pattern = pattern[1:] // Remove the backslash
-			isNegation = false
-		} else if strings.HasPrefix(pattern, "!") {
-			// Actual negation pattern
-			if len(pattern) == 1 {
-				return nil, fmt.Errorf("invalid pattern at line %d: single '!' is not allowed", i+1)
+// appendPatterns publishes a snapshot holding the current patterns followed
+// by added, unless that would exceed the pattern limit. The backing array of
+// the current patterns, which snapshots share, is left untouched. The caller
+// must hold p.mu.
+func (p *PatternMatcher) appendPatterns(added []ignorePattern) error {
+	current := p.rules.Load().patterns
+	if err := p.options.checkPatternCount(len(current) + len(added)); err != nil {
+		return err
+	}
+	p.setPatterns(append(current[:len(current):len(current)], added...))
+	return nil
+}
+
+func buildIgnorePatterns(patterns []string, options matcherOptions) ([]ignorePattern, error) {
+	return buildPatterns(patterns, options, parseIgnorePattern)
+}
+
+// buildPatterns parses each of lines with parse. Invalid lines are skipped if
+// the options allow it.
+func buildPatterns(lines []string, options matcherOptions, parse func(string, int, matcherOptions) (ignorePattern, bool, error)) ([]ignorePattern, error) {
+	var ignorePatterns []ignorePattern
+
+	for i, raw := range lines {
+		ignorePattern, ok, err := parse(raw, i+1, options)
+		if err != nil {
+			if options.skipInvalid(err) {
+				continue
 			}
-			pattern = pattern[1:]
-			isNegation = true
+			return nil, err
 		}
+		if ok {
+			ignorePatterns = append(ignorePatterns, ignorePattern)
+		}
+	}
+
+	return ignorePatterns, nil
+}
+
+// escapedMarks resolves the escapes of the characters that start comments
+// and negations, which are kept when backslashes are path separators.
+var escapedMarks = strings.NewReplacer(`\#`, "#", `\!`, "!")
 
-		// Convert backslashes to forward slashes for consistent handling
-		// filepath.ToSlash might not handle all cases, so we'll be explicit
+// parseIgnorePattern parses a single gitignore line found at the given line
+// number. It reports false for blank lines and comments.
+func parseIgnorePattern(raw string, line int, options matcherOptions) (ignorePattern, bool, error) {
+	pattern := trimTrailingSpaces(strings.TrimLeftFunc(raw, unicode.IsSpace))
+
+	// Skip empty lines and comments
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return ignorePattern{}, false, nil
+	}
+	text := pattern
+
+	// Handle escaped negation (\!) before checking for actual negation
+	// In gitignore, \! at the start means "match files literally starting with !"
+	isNegation := false
+	if strings.HasPrefix(pattern, `\!`) {
+		// Escaped negation - remove the backslash, keep the !
+		pattern = pattern[1:] // Remove the backslash
+		isNegation = false
+	} else if strings.HasPrefix(pattern, "!") {
+		// Actual negation pattern
+		if len(pattern) == 1 {
+			return ignorePattern{}, false, newParseError(raw, line, ErrInvalidNegation)
+		}
+		pattern = pattern[1:]
+		isNegation = true
+	}
+
+	// Git never matches a pattern that ends in a backslash escaping nothing
+	if options.backslashEscapes && hasTrailingEscape(pattern) {
+		return ignorePattern{}, false, nil
+	}
+
+	// Escaped spaces are literal spaces; resolve them before backslashes
+	// are treated as path separators below
+	pattern = strings.ReplaceAll(pattern, `\ `, " ")
+
+	// Convert backslashes to forward slashes for consistent handling, unless
+	// they are escape characters as in Git
+	// filepath.ToSlash might not handle all cases, so we'll be explicit
+	if !options.backslashEscapes {
+		// An escaped "#" or "!" is still literal anywhere in the pattern
+		pattern = escapedMarks.Replace(pattern)
 		pattern = strings.ReplaceAll(pattern, "\\", "/")
+	}
+
+	// Check if pattern is root-relative (starts with /)
+	// In gitignore, leading / means pattern is anchored to root
+	isRootRelative := strings.HasPrefix(pattern, "/")
+	if isRootRelative {
+		pattern = strings.TrimPrefix(pattern, "/")
+	}
+
+	// Check if pattern is for directories only (after normalization)
+	isDirectory := strings.HasSuffix(pattern, "/")
+	if isDirectory {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	// Git anchors any pattern with a slash other than the trailing one
+	if options.gitStrict && strings.Contains(pattern, "/") {
+		isRootRelative = true
+	}
+
+	// Validate pattern is not empty after processing
+	if pattern == "" {
+		return ignorePattern{}, false, newParseError(raw, line, ErrEmptyPattern)
+	}
+
+	// Case-insensitive matchers compare lowercased patterns and paths
+	if options.caseInsensitive {
+		pattern = strings.ToLower(pattern)
+	}
+
+	if options.strictDoubleStar {
+		pattern = collapseDoubleStars(pattern)
+	}
+
+	// Check if pattern contains wildcards
+	hasWildcard := containsWildcard(pattern)
+
+	glob, regexPattern, err := compilePattern(pattern, SyntaxGit)
+	if err != nil {
+		return ignorePattern{}, false, newParseError(raw, line, fmt.Errorf("malformed pattern: %w", err))
+	}
+
+	return ignorePattern{
+		text:           text,
+		line:           line,
+		pattern:        pattern,
+		glob:           glob,
+		regexPattern:   regexPattern,
+		isDirectory:    isDirectory,
+		negate:         isNegation,
+		hasWildcard:    hasWildcard,
+		isRootRelative: isRootRelative,
+	}, true, nil
+}
 
-		// Check if pattern is root-relative (starts with /)
-		// In gitignore, leading / means pattern is anchored to root
-		isRootRelative := strings.HasPrefix(pattern, "/")
-		if isRootRelative {
-			pattern = strings.TrimPrefix(pattern, "/")
+// hasTrailingEscape reports whether pattern ends in an odd number of
+// backslashes, the last of which escapes nothing.
+func hasTrailingEscape(pattern string) bool {
+	n := len(pattern) - len(strings.TrimRight(pattern, `\`))
+	return n%2 == 1
+}
+
+// collapseDoubleStars rewrites each run of "*" that makes up a whole path
+// component to "**" and every other run to a single "*", so that "**" is
+// left only where Git lets it match across directories.
+func collapseDoubleStars(pattern string) string {
+	if !strings.Contains(pattern, "**") {
+		return pattern
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			// An escaped "*" is literal
+			b.WriteByte('\\')
+			if i+1 < len(pattern) {
+				i++
+				b.WriteByte(pattern[i])
+			}
+		case '*':
+			end := i
+			for end < len(pattern) && pattern[end] == '*' {
+				end++
+			}
+			if end-i >= 2 && (i == 0 || pattern[i-1] == '/') && (end == len(pattern) || pattern[end] == '/') {
+				b.WriteString("**")
+			} else {
+				b.WriteByte('*')
+			}
+			i = end - 1
+		default:
+			b.WriteByte(pattern[i])
 		}
+	}
+	return b.String()
+}
 
-		// Check if pattern is for directories only (after normalization)
-		isDirectory := strings.HasSuffix(pattern, "/")
-		if isDirectory {
-			pattern = strings.TrimSuffix(pattern, "/")
+// trimTrailingSpaces removes trailing spaces from a pattern line unless they
+// are escaped with a backslash, as described in the gitignore specification.
+// For example, `foo\ ` keeps its escaped trailing space while `foo  ` becomes `foo`.
+// Other whitespace, such as a trailing tab, is part of the pattern, as in Git.
+func trimTrailingSpaces(pattern string) string {
+	lastSpace := -1
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case ' ':
+			if lastSpace < 0 {
+				lastSpace = i
+			}
+		case '\\':
+			// Skip the escaped character; it is never trimmed
+			i++
+			lastSpace = -1
+		default:
+			lastSpace = -1
 		}
+	}
+	if lastSpace >= 0 {
+		return pattern[:lastSpace]
+	}
+	return pattern
+}
 
-		// Validate pattern is not empty after processing
-		if pattern == "" {
-			return nil, fmt.Errorf("invalid pattern at line %d: pattern cannot be empty", i+1)
+// matchesInternal performs the actual pattern matching logic for a file of
+// the given kind
+func (p *PatternMatcher) matchesInternal(rules *ruleSet, file string, kind pathKind) (bool, error) {
+	i, err := p.resolve(rules, file, kind, true)
+	if err != nil {
+		return false, err
+	}
+	if p.options.logger != nil {
+		p.logDecision(rules, file, i)
+	}
+	if i < 0 {
+		return false, nil
+	}
+	return !rules.patterns[i].negate, nil
+}
+
+// resolve returns the index of the pattern that decides whether file is
+// ignored, or -1 if no pattern matches. With strict negation, a pattern
+// excluding a parent directory decides for everything beneath it. Unless
+// observe is set, the query is hidden from hit tracking and tracing, as for
+// lookups made by the package itself.
+func (p *PatternMatcher) resolve(rules *ruleSet, file string, kind pathKind, observe bool) (int, error) {
+	if !p.options.strictNegation {
+		return p.decide(rules, file, kind, observe)
+	}
+
+	i, err := p.excludingParent(rules, file)
+	if err != nil {
+		return -1, err
+	}
+	if i < 0 {
+		return p.decide(rules, file, kind, observe)
+	}
+	// The patterns matching file itself still count the query
+	if observe && (p.options.trackHits || p.options.trace != nil) {
+		if _, err := p.decide(rules, file, kind, true); err != nil {
+			return -1, err
 		}
+	}
+	return i, nil
+}
+
+// decide applies the patterns to file and returns the index of the last
+// matching pattern, which decides the outcome, or -1 if none matches.
+// Patterns that the index rules out are skipped.
+//
+// Since a later pattern overrides every earlier one, the patterns are tried
+// from the last, stopping at the first match. Hit tracking and tracing see
+// every pattern, in order, so they are applied in full from the first when
+// observe is set.
+func (p *PatternMatcher) decide(rules *ruleSet, file string, kind pathKind, observe bool) (int, error) {
+	if !observe || (!p.options.trackHits && p.options.trace == nil) {
+		return p.decideBackwards(rules, file, kind)
+	}
+
+	last := -1
 
-		// Check if pattern contains wildcards
-		hasWildcard := strings.ContainsAny(pattern, "*?")
+	if rules.index == nil {
+		for i, pattern := range rules.patterns {
+			isMatch, err := p.applyPattern(file, kind, pattern)
+			if err != nil {
+				return -1, err
+			}
+			if isMatch {
+				last = i
+			}
+		}
+		return last, nil
+	}
 
-		// Build regex pattern
-		regexPattern, err := internal.BuildRegex(pattern)
+	candidates := rules.index.candidates(file)
+	for i, ok := candidates.next(); ok; i, ok = candidates.next() {
+		isMatch, err := p.applyPattern(file, kind, rules.patterns[i])
 		if err != nil {
-			return nil, fmt.Errorf("failed to build regex for pattern %q at line %d: %w", pattern, i+1, err)
+			return -1, err
+		}
+		if isMatch {
+			last = i
 		}
+	}
+	return last, nil
+}
 
-		ignorePatterns = append(ignorePatterns, ignorePattern{
-			pattern:        pattern,
-			regexPattern:   regexPattern,
-			isDirectory:    isDirectory,
-			negate:         isNegation,
-			hasWildcard:    hasWildcard,
-			isRootRelative: isRootRelative,
-		})
+// decideBackwards implements decide by trying the patterns from the last,
+// without recording hits or traces.
+func (p *PatternMatcher) decideBackwards(rules *ruleSet, file string, kind pathKind) (int, error) {
+	if rules.index == nil {
+		for i := len(rules.patterns) - 1; i >= 0; i-- {
+			isMatch, err := p.testPattern(file, kind, rules.patterns[i])
+			if isMatch || err != nil {
+				return i, err
+			}
+		}
+		return -1, nil
 	}
 
-	return ignorePatterns, nil
+	candidates := rules.index.candidates(file)
+	for i, ok := candidates.prev(); ok; i, ok = candidates.prev() {
+		isMatch, err := p.testPattern(file, kind, rules.patterns[i])
+		if isMatch || err != nil {
+			return i, err
+		}
+	}
+	return -1, nil
 }
 
-// matchesInternal performs the actual pattern matching logic
-func (p *PatternMatcher) matchesInternal(file string) (bool, error) {
-	matched := false
-
-	for _, pattern := range p.ignorePatterns {
-		isMatch, err := p.matchPattern(file, pattern)
+// excludingParent returns the index of the pattern excluding the nearest
+// excluded parent directory of file, or -1 if no parent is excluded. Git
+// does not descend into excluded directories, so nothing beneath them can be
+// re-included by a negation pattern. The parent directories are not queried,
+// so they are hidden from hit tracking and tracing.
+func (p *PatternMatcher) excludingParent(rules *ruleSet, file string) (int, error) {
+	for i := 0; i < len(file); i++ {
+		if file[i] != '/' || i == 0 {
+			continue
+		}
+		decider, err := p.decideBackwards(rules, file[:i], kindDir)
 		if err != nil {
-			return false, fmt.Errorf("error matching pattern %q against file %q: %w", pattern.pattern, file, err)
+			return -1, err
 		}
-
-		if isMatch {
-			matched = !pattern.negate
+		if decider >= 0 && !rules.patterns[decider].negate {
+			return decider, nil
 		}
 	}
+	return -1, nil
+}
+
+// applyPattern matches file against a single pattern and records the hit.
+func (p *PatternMatcher) applyPattern(file string, kind pathKind, pattern ignorePattern) (bool, error) {
+	var start time.Time
+	if p.options.trace != nil {
+		start = time.Now()
+	}
+
+	isMatch, err := p.testPattern(file, kind, pattern)
+	if err != nil {
+		return false, err
+	}
+	if p.options.trace != nil {
+		p.options.trace(TraceEvent{
+			Path:     file,
+			Pattern:  pattern.text,
+			Line:     pattern.line,
+			Matched:  isMatch,
+			Negate:   pattern.negate,
+			Duration: time.Since(start),
+		})
+	}
+	if isMatch && pattern.hits != nil {
+		pattern.hits.Add(1)
+	}
+	return isMatch, nil
+}
 
-	return matched, nil
+// testPattern matches file against a single pattern without recording
+// anything.
+func (p *PatternMatcher) testPattern(file string, kind pathKind, pattern ignorePattern) (bool, error) {
+	isMatch, err := p.matchPattern(file, kind, pattern)
+	if err != nil {
+		return false, fmt.Errorf("error matching pattern %q against file %q: %w", pattern.pattern, file, err)
+	}
+	return isMatch, nil
 }
 
 // matchPattern checks if a file matches a specific pattern
-func (p *PatternMatcher) matchPattern(file string, pattern ignorePattern) (bool, error) {
+func (p *PatternMatcher) matchPattern(file string, kind pathKind, pattern ignorePattern) (bool, error) {
+	if p.options.syntax == SyntaxHelm {
+		return matchHelmPattern(file, kind, pattern), nil
+	}
+	if p.options.strictGit() {
+		return matchGitPattern(file, kind, pattern, p.options.matchesBeneath()), nil
+	}
+
+	// A directory pattern matches a known non-directory only through one of
+	// its parent directories
+	if kind == kindFile && pattern.isDirectory {
+		for i := 1; i < len(file); i++ {
+			if file[i] != '/' {
+				continue
+			}
+			if isMatch, err := p.matchPattern(file[:i], kindDir, pattern); isMatch || err != nil {
+				return isMatch, err
+			}
+		}
+		return false, nil
+	}
+
+	if p.options.syntax == SyntaxDocker {
+		return matchDockerPattern(file, pattern), nil
+	}
 	if pattern.isRootRelative {
 		return matchRootRelativePattern(file, pattern), nil
 	}
-	if pattern.regexPattern.MatchString(file) {
+	if pattern.matchString(file) {
 		return true, nil
 	}
 	if pattern.isDirectory && matchDirectoryPattern(file, pattern) {
 		return true, nil
 	}
-	if pattern.hasWildcard && matchWildcardSubpaths(file, pattern) {
+	if pattern.hasWildcard && !p.options.noSubpathHeuristic && matchWildcardSubpaths(file, pattern) {
 		return true, nil
 	}
 	if strings.Contains(pattern.pattern, "/") {
@@ -269,21 +951,102 @@ func (p *PatternMatcher) matchPattern(file string, pattern ignorePattern) (bool,
 	return matchSimplePattern(file, pattern), nil
 }
 
+// matchGitPattern implements matchPattern with WithGitStrict. A pattern
+// matches a path it matches itself, as matchGitPath decides. Unless beneath
+// is false, as with strict negation where the excluded parent decides, an
+// excluding pattern also matches every path beneath a directory it matches.
+func matchGitPattern(file string, kind pathKind, pattern ignorePattern, beneath bool) bool {
+	if strings.HasSuffix(file, "/") {
+		file, kind = file[:len(file)-1], kindDir
+	}
+	if matchGitPath(file, kind, pattern) {
+		return true
+	}
+	if pattern.negate || !beneath {
+		return false
+	}
+	for i := 1; i < len(file); i++ {
+		if file[i] == '/' && matchGitPath(file[:i], kindDir, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGitPath reports whether pattern matches file itself, as Git matches
+// it: an anchored pattern against the whole path and any other pattern
+// against its last component. A directory pattern does not match a path
+// known to be a file.
+func matchGitPath(file string, kind pathKind, pattern ignorePattern) bool {
+	if pattern.isDirectory && kind == kindFile {
+		return false
+	}
+	if !pattern.isRootRelative {
+		file = file[strings.LastIndexByte(file, '/')+1:]
+	}
+	return pattern.matchString(file)
+}
+
+// matchString reports whether the pattern matches the whole of s.
+func (ip ignorePattern) matchString(s string) bool {
+	if ip.glob != nil {
+		return ip.glob.Match(s)
+	}
+	return ip.regexPattern.MatchString(s)
+}
+
+// literal returns the pattern with its backslash escapes resolved, for
+// comparing it with paths verbatim.
+func (ip ignorePattern) literal() string {
+	if strings.IndexByte(ip.pattern, '\\') < 0 {
+		return ip.pattern
+	}
+	return unescapePattern(ip.pattern)
+}
+
+// containsWildcard reports whether pattern contains a "*" or "?" that is not
+// escaped with a backslash.
+func containsWildcard(pattern string) bool {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '*', '?':
+			return true
+		}
+	}
+	return false
+}
+
+// unescapePattern removes the backslashes that escape the characters of
+// pattern. A trailing backslash escapes nothing and is kept.
+func unescapePattern(pattern string) string {
+	var b strings.Builder
+	b.Grow(len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '\\' && i+1 < len(pattern) {
+			i++
+		}
+		b.WriteByte(pattern[i])
+	}
+	return b.String()
+}
+
 // matchRootRelativePattern handles patterns anchored to the root (starting with /).
 func matchRootRelativePattern(file string, pattern ignorePattern) bool {
-	if pattern.regexPattern.MatchString(file) {
+	if pattern.matchString(file) {
 		return true
 	}
+	literal := pattern.literal()
 	if pattern.isDirectory {
-		dirName := pattern.pattern
-		return file == dirName || file == dirName+"/" || strings.HasPrefix(file, dirName+"/")
+		return file == literal || file == literal+"/" || strings.HasPrefix(file, literal+"/")
 	}
-	return file == pattern.pattern || strings.HasPrefix(file, pattern.pattern+"/")
+	return file == literal || strings.HasPrefix(file, literal+"/")
 }
 
 // matchDirectoryPattern handles directory-only patterns (trailing /).
 func matchDirectoryPattern(file string, pattern ignorePattern) bool {
-	dirName := pattern.pattern
+	dirName := pattern.literal()
 	if file == dirName {
 		return true
 	}
@@ -293,44 +1056,44 @@ func matchDirectoryPattern(file string, pattern ignorePattern) bool {
 	return len(file) == len(dirName)+1 && file[len(file)-1] == '/' && file[:len(dirName)] == dirName
 }
 
-// matchWildcardSubpaths tries the pattern against all sub-paths of file.
+// matchWildcardSubpaths tries the pattern against all sub-paths of file, i.e.
+// every suffix of file that starts at a path component boundary.
 func matchWildcardSubpaths(file string, pattern ignorePattern) bool {
-	parts := strings.Split(file, "/")
-	for i := 0; i < len(parts); i++ {
-		if pattern.regexPattern.MatchString(strings.Join(parts[i:], "/")) {
-			return true
-		}
-	}
-	for i := 1; i < len(parts); i++ {
-		combined := strings.Join(parts[:i], "/") + "/" + strings.Join(parts[i:], "/")
-		if pattern.regexPattern.MatchString(combined) {
+	for i := 0; i < len(file); i++ {
+		if (i == 0 || file[i-1] == '/') && pattern.matchString(file[i:]) {
 			return true
 		}
 	}
-	return false
+	// A trailing slash leaves an empty final sub-path
+	return strings.HasSuffix(file, "/") && pattern.matchString("")
 }
 
 // matchPathSeparatorPattern handles patterns that contain a path separator.
 func matchPathSeparatorPattern(file string, pattern ignorePattern) bool {
-	if file == pattern.pattern {
+	literal := pattern.literal()
+	if file == literal {
 		return true
 	}
-	patternLen := len(pattern.pattern)
-	if len(file) > patternLen && file[patternLen] == '/' && file[:patternLen] == pattern.pattern {
+	patternLen := len(literal)
+	if len(file) > patternLen && file[patternLen] == '/' && file[:patternLen] == literal {
 		return true
 	}
-	if len(file) > patternLen && file[len(file)-patternLen:] == pattern.pattern && file[len(file)-patternLen-1] == '/' {
+	if len(file) > patternLen && file[len(file)-patternLen:] == literal && file[len(file)-patternLen-1] == '/' {
 		return true
 	}
-	return strings.Contains(file, "/"+pattern.pattern+"/")
+	return strings.Contains(file, "/"+literal+"/")
 }
 
 // matchSimplePattern handles patterns without path separators by checking each path component.
 func matchSimplePattern(file string, pattern ignorePattern) bool {
-	for _, part := range strings.Split(file, "/") {
-		if pattern.regexPattern.MatchString(part) {
+	for {
+		end := strings.IndexByte(file, '/')
+		if end < 0 {
+			return pattern.matchString(file)
+		}
+		if pattern.matchString(file[:end]) {
 			return true
 		}
+		file = file[end+1:]
 	}
-	return false
 }
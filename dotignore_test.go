@@ -1,9 +1,15 @@
 package dotignore
 
 import (
+	"bufio"
+	"errors"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"unicode/utf16"
 )
 
 func TestNewPatternMatcherFromFile(t *testing.T) {
@@ -62,6 +68,63 @@ ignore.so
 	}
 }
 
+func TestIgnoreFileFormats(t *testing.T) {
+	lines := []string{"# build output", "build/", "*.log", "!keep.log", "docs/*.md"}
+	utf16LE := func(s string) string {
+		var b strings.Builder
+		b.WriteString("\xFF\xFE")
+		for _, unit := range utf16.Encode([]rune(s)) {
+			b.WriteByte(byte(unit))
+			b.WriteByte(byte(unit >> 8))
+		}
+		return b.String()
+	}
+	fixtures := map[string]string{
+		"LF":                   strings.Join(lines, "\n") + "\n",
+		"CRLF":                 strings.Join(lines, "\r\n") + "\r\n",
+		"CR":                   strings.Join(lines, "\r") + "\r",
+		"CRLF with BOM":        "\xEF\xBB\xBF" + strings.Join(lines, "\r\n") + "\r\n",
+		"CRLF without newline": strings.Join(lines, "\r\n"),
+		"UTF-16LE CRLF":        utf16LE(strings.Join(lines, "\r\n") + "\r\n"),
+		"Mixed":                strings.Join(lines[:2], "\r\n") + "\r\n" + strings.Join(lines[2:], "\n") + "\r",
+	}
+	expected := map[string]bool{
+		"build/out.bin": true,
+		"app.log":       true,
+		"keep.log":      false,
+		"docs/guide.md": true,
+		"main.go":       false,
+	}
+
+	for name, content := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			tmpDir := createTestRepo(t, map[string]string{".gitignore": content})
+			defer os.RemoveAll(tmpDir)
+
+			matcher, err := NewPatternMatcherFromFile(filepath.Join(tmpDir, ".gitignore"))
+			if err != nil {
+				t.Fatalf("Failed to parse ignore file: %v", err)
+			}
+			if count := len(matcher.Patterns()); count != len(lines)-1 {
+				t.Errorf("Parsed %d patterns, want %d", count, len(lines)-1)
+			}
+			repo, err := NewRepositoryMatcher(tmpDir)
+			if err != nil {
+				t.Fatalf("NewRepositoryMatcher() failed: %v", err)
+			}
+
+			for file, want := range expected {
+				if result, err := matcher.Matches(file); err != nil || result != want {
+					t.Errorf("PatternMatcher.Matches(%q) = %v, %v, want %v", file, result, err, want)
+				}
+				if result, err := repo.Matches(file); err != nil || result != want {
+					t.Errorf("RepositoryMatcher.Matches(%q) = %v, %v, want %v", file, result, err, want)
+				}
+			}
+		})
+	}
+}
+
 func TestMatches(t *testing.T) {
 	patterns := []string{
 		"**",          // Match everything
@@ -129,7 +192,7 @@ func TestMatches(t *testing.T) {
 
 func TestBuildIgnorePatterns(t *testing.T) {
 	patterns := []string{"docs", "config", "", "# comment"}
-	ignorePatterns, err := buildIgnorePatterns(patterns)
+	ignorePatterns, err := buildIgnorePatterns(patterns, matcherOptions{})
 	if err != nil {
 		t.Fatalf("buildIgnorePatterns failed: %v", err)
 	}
@@ -151,7 +214,7 @@ func TestBuildIgnorePatterns(t *testing.T) {
 
 func TestBuildIgnorePatternsStripEmptyPatterns(t *testing.T) {
 	patterns := []string{"docs", "config", "", "   ", "# comment"}
-	ignorePatterns, err := buildIgnorePatterns(patterns)
+	ignorePatterns, err := buildIgnorePatterns(patterns, matcherOptions{})
 	if err != nil {
 		t.Fatalf("buildIgnorePatterns failed: %v", err)
 	}
@@ -164,7 +227,7 @@ func TestBuildIgnorePatternsStripEmptyPatterns(t *testing.T) {
 
 func TestBuildIgnorePatternsExceptionFlag(t *testing.T) {
 	patterns := []string{"docs", "!docs/README.md"}
-	ignorePatterns, err := buildIgnorePatterns(patterns)
+	ignorePatterns, err := buildIgnorePatterns(patterns, matcherOptions{})
 	if err != nil {
 		t.Fatalf("buildIgnorePatterns failed: %v", err)
 	}
@@ -184,7 +247,7 @@ func TestBuildIgnorePatternsExceptionFlag(t *testing.T) {
 
 func TestBuildIgnorePatternsLeadingSpaceTrimmed(t *testing.T) {
 	patterns := []string{"docs", "  !docs/README.md"}
-	ignorePatterns, err := buildIgnorePatterns(patterns)
+	ignorePatterns, err := buildIgnorePatterns(patterns, matcherOptions{})
 	if err != nil {
 		t.Fatalf("buildIgnorePatterns failed: %v", err)
 	}
@@ -200,7 +263,7 @@ func TestBuildIgnorePatternsLeadingSpaceTrimmed(t *testing.T) {
 
 func TestBuildIgnorePatternsTrailingSpaceTrimmed(t *testing.T) {
 	patterns := []string{"docs", "!docs/README.md  "}
-	ignorePatterns, err := buildIgnorePatterns(patterns)
+	ignorePatterns, err := buildIgnorePatterns(patterns, matcherOptions{})
 	if err != nil {
 		t.Fatalf("buildIgnorePatterns failed: %v", err)
 	}
@@ -214,9 +277,86 @@ func TestBuildIgnorePatternsTrailingSpaceTrimmed(t *testing.T) {
 	}
 }
 
+func TestEscapedTrailingSpaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		file     string
+		expected bool
+	}{
+		{"Unescaped trailing spaces are trimmed", "foo  ", "foo", true},
+		{"Unescaped trailing spaces do not match literally", "foo  ", "foo  ", false},
+		{"Escaped trailing space is kept", `foo\ `, "foo ", true},
+		{"Escaped trailing space does not match trimmed name", `foo\ `, "foo", false},
+		{"Escaped space followed by unescaped spaces", `foo\   `, "foo ", true},
+		{"Two escaped trailing spaces", `foo\ \ `, "foo  ", true},
+		{"Escaped space inside pattern", `my\ file.txt`, "my file.txt", true},
+		{"Trailing tab is kept", "foo\t", "foo\t", true},
+		{"Trailing tab does not match trimmed name", "foo\t", "foo", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := NewPatternMatcher([]string{tt.pattern})
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+
+			result, err := matcher.Matches(tt.file)
+			if err != nil {
+				t.Errorf("Error matching file %q: %v", tt.file, err)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("Pattern %q, file %q: expected %v, got %v", tt.pattern, tt.file, tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestWithBackslashEscapes(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		options  []Option
+		file     string
+		expected bool
+	}{
+		{"Escaped brackets match literally", `foo\[bar\].txt`, []Option{WithBackslashEscapes()}, "foo[bar].txt", true},
+		{"Escaped brackets are not a class", `foo\[bar\].txt`, []Option{WithBackslashEscapes()}, "foob.txt", false},
+		{"Escaped star matches only a star", `\*.log`, []Option{WithBackslashEscapes()}, "*.log", true},
+		{"Escaped star is not a wildcard", `\*.log`, []Option{WithBackslashEscapes()}, "app.log", false},
+		{"Escaped directory matches its contents", `logs\[1\]/`, []Option{WithBackslashEscapes()}, "logs[1]/today.txt", true},
+		{"Escaped anchored path", `/out\?/data`, []Option{WithBackslashEscapes()}, "out?/data/file", true},
+		{"Windows paths are converted only on Windows", `foo\[bar\].txt`, []Option{WithBackslashEscapes()}, `src\foo[bar].txt`, os.PathSeparator == '\\'},
+		{"Escaped backslash matches a name", `a\\b`, []Option{WithBackslashEscapes()}, `a\b`, os.PathSeparator != '\\'},
+		{"Trailing backslash matches nothing", `a\`, []Option{WithBackslashEscapes()}, `a\`, false},
+		{"Git-strict mode implies escapes", `foo\[bar\].txt`, []Option{WithGitStrict()}, "foo[bar].txt", true},
+		{"Backslashes are separators by default", `build\out`, nil, "build/out", true},
+		{"Escapes are separators by default", `foo\[bar\].txt`, nil, "foo[bar].txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := NewPatternMatcher([]string{tt.pattern}, tt.options...)
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+
+			result, err := matcher.Matches(tt.file)
+			if err != nil {
+				t.Fatalf("Error matching file %q: %v", tt.file, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Pattern %q, file %q: expected %v, got %v", tt.pattern, tt.file, tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestBuildIgnorePatternsErrorSingleException(t *testing.T) {
 	patterns := []string{"!"}
-	_, err := buildIgnorePatterns(patterns)
+	_, err := buildIgnorePatterns(patterns, matcherOptions{})
 	if err == nil {
 		t.Error("Expected error for single exclamation point pattern")
 	}
@@ -229,7 +369,7 @@ func TestBuildIgnorePatternsErrorSingleException(t *testing.T) {
 
 func TestBuildIgnorePatternsFolderSplit(t *testing.T) {
 	patterns := []string{"docs/config/CONFIG.md"}
-	ignorePatterns, err := buildIgnorePatterns(patterns)
+	ignorePatterns, err := buildIgnorePatterns(patterns, matcherOptions{})
 	if err != nil {
 		t.Fatalf("buildIgnorePatterns failed: %v", err)
 	}
@@ -245,83 +385,751 @@ func TestBuildIgnorePatternsFolderSplit(t *testing.T) {
 	}
 }
 
-func TestDirectoryPatterns(t *testing.T) {
-	patterns := []string{
-		"build/",    // Directory only
-		"*.tmp",     // File pattern
-		"src/test/", // Nested directory
+func TestDirectoryPatterns(t *testing.T) {
+	patterns := []string{
+		"build/",    // Directory only
+		"*.tmp",     // File pattern
+		"src/test/", // Nested directory
+	}
+
+	matcher, err := NewPatternMatcher(patterns)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file     string
+		expected bool
+		reason   string
+	}{
+		{"build/", true, "build/ directory should match build/ pattern"},
+		{"build/file.txt", true, "files in build directory should be matched"},
+		{"file.tmp", true, "should match *.tmp pattern"},
+		{"src/test/", true, "should match src/test/ directory pattern"},
+		{"src/test/file.js", true, "files in src/test should be matched"},
+		{"other/", false, "other/ directory should not match"},
+		{"file.txt", false, "regular .txt files should not match"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			result, err := matcher.Matches(test.file)
+			if err != nil {
+				t.Errorf("Error matching file %s: %v", test.file, err)
+				return
+			}
+			if result != test.expected {
+				t.Errorf("File %s: expected %v, got %v (%s)", test.file, test.expected, result, test.reason)
+			}
+		})
+	}
+}
+
+func TestStrictDirectoryPatterns(t *testing.T) {
+	patterns := []string{"build/", "src/test/", "*.tmp"}
+
+	matcher, err := NewPatternMatcher(patterns)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	strict, err := NewPatternMatcher(patterns, WithStrictDirectoryPatterns())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file         string
+		isDir        bool
+		expectPath   bool
+		expectStrict bool
+	}{
+		{"build", true, true, false},
+		{"build", false, false, false},
+		{"build/", true, true, true},
+		{"build/file.txt", false, true, true},
+		{"src/test", false, false, false},
+		{"src/test", true, true, false},
+		{"src/test/a.js", false, true, true},
+		{"cache.tmp", false, true, true},
+		{"cache.tmp", true, true, true},
+	}
+
+	for _, tt := range tests {
+		result, err := matcher.MatchesPath(tt.file, tt.isDir)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", tt.file, err)
+		}
+		if result != tt.expectPath {
+			t.Errorf("MatchesPath(%q, %v) = %v, want %v", tt.file, tt.isDir, result, tt.expectPath)
+		}
+
+		// Without a trailing slash, the strict matcher treats paths as files
+		result, err = strict.Matches(tt.file)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", tt.file, err)
+		}
+		if result != tt.expectStrict {
+			t.Errorf("Strict Matches(%q) = %v, want %v", tt.file, result, tt.expectStrict)
+		}
+	}
+}
+
+func TestIsIgnored(t *testing.T) {
+	base := filepath.Join(os.TempDir(), "project")
+	matcher, err := NewPatternMatcher([]string{"build/", "*.log", "!keep.log"}, WithBasePath(base))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file     string
+		isDir    bool
+		expected bool
+	}{
+		{"build", true, true},
+		{"build", false, false},
+		{"app.log", false, true},
+		{"keep.log", false, false},
+		{filepath.Join(base, "debug.log"), false, true},
+		{filepath.Join(os.TempDir(), "other", "debug.log"), false, false}, // outside the base path
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		if result := matcher.IsIgnored(tt.file, tt.isDir); result != tt.expected {
+			t.Errorf("IsIgnored(%q, %v) = %v, want %v", tt.file, tt.isDir, result, tt.expected)
+		}
+	}
+
+	if !matcher.MustMatch("app.log", false) {
+		t.Error("MustMatch(app.log) = false, want true")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustMatch() of a path outside the base path did not panic")
+		}
+	}()
+	matcher.MustMatch(filepath.Join(os.TempDir(), "other", "debug.log"), false)
+}
+
+func TestMatchesSegments(t *testing.T) {
+	patterns := []string{"build/", "*.log", "!keep.log", "/docs/*.md", "src/gen/"}
+	for _, opts := range [][]Option{nil, {WithGitStrict(), WithStrictNegation()}, {WithCaseInsensitive()}} {
+		matcher, err := NewPatternMatcher(patterns, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+
+		// Segments agree with MatchesPath on the joined path
+		for _, path := range []string{"build", "build/out.bin", "app.log", "logs/keep.log", "docs/a.md",
+			"sub/docs/a.md", "src/gen", "src/gen/x.go", "Build", "App.LOG", "main.go"} {
+			for _, isDir := range []bool{false, true} {
+				want, err := matcher.MatchesPath(path, isDir)
+				if err != nil {
+					t.Fatalf("MatchesPath(%q) failed: %v", path, err)
+				}
+				got, err := matcher.MatchesSegments(strings.Split(path, "/"), isDir)
+				if err != nil {
+					t.Fatalf("MatchesSegments(%q) failed: %v", path, err)
+				}
+				if got != want {
+					t.Errorf("MatchesSegments(%q, %v) = %v, MatchesPath() = %v", path, isDir, got, want)
+				}
+			}
+		}
+	}
+
+	matcher, err := NewPatternMatcher(patterns)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if result, err := matcher.MatchesSegments(nil, false); result || err != nil {
+		t.Errorf("MatchesSegments(nil) = %v, %v, want false", result, err)
+	}
+	for _, segs := range [][]string{{"a", ""}, {"."}, {"a", ".."}, {"a/b"}, {`a\b`}} {
+		if _, err := matcher.MatchesSegments(segs, false); err == nil {
+			t.Errorf("MatchesSegments(%q) should fail", segs)
+		}
+	}
+}
+
+func TestNegationPatterns(t *testing.T) {
+	patterns := []string{
+		"*.log",          // Ignore all .log files
+		"!important.log", // But keep important.log
+		"temp/",          // Ignore temp directory
+		"!temp/keep.txt", // But keep temp/keep.txt
+	}
+
+	matcher, err := NewPatternMatcher(patterns)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file     string
+		expected bool
+		reason   string
+	}{
+		{"app.log", true, "should match *.log pattern"},
+		{"important.log", false, "should be negated by !important.log"},
+		{"temp/cache.txt", true, "should match temp/ directory pattern"},
+		{"temp/keep.txt", false, "should be negated by !temp/keep.txt"},
+		{"other.txt", false, "should not match any pattern"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			result, err := matcher.Matches(test.file)
+			if err != nil {
+				t.Errorf("Error matching file %s: %v", test.file, err)
+				return
+			}
+			if result != test.expected {
+				t.Errorf("File %s: expected %v, got %v (%s)", test.file, test.expected, result, test.reason)
+			}
+		})
+	}
+}
+
+func TestStrictNegation(t *testing.T) {
+	patterns := []string{
+		"build/",
+		"!build/keep.txt",
+		"*.log",
+		"!important.log",
+	}
+
+	lenient, err := NewPatternMatcher(patterns)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	strict, err := NewPatternMatcher(patterns, WithStrictNegation())
+	if err != nil {
+		t.Fatalf("Failed to create strict matcher: %v", err)
+	}
+
+	tests := []struct {
+		file          string
+		expectLenient bool
+		expectStrict  bool
+		reason        string
+	}{
+		{"build/keep.txt", false, true, "parent directory build/ is excluded"},
+		{"build/other.txt", true, true, "matched by build/"},
+		{"important.log", false, false, "negation applies when no parent is excluded"},
+		{"logs/important.log", false, false, "logs/ itself is not excluded"},
+		{"src/app.log", true, true, "matched by *.log"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			result, err := lenient.Matches(tt.file)
+			if err != nil {
+				t.Fatalf("Error matching file %s: %v", tt.file, err)
+			}
+			if result != tt.expectLenient {
+				t.Errorf("Default mode, file %q: expected %v, got %v", tt.file, tt.expectLenient, result)
+			}
+
+			result, err = strict.Matches(tt.file)
+			if err != nil {
+				t.Fatalf("Error matching file %s: %v", tt.file, err)
+			}
+			if result != tt.expectStrict {
+				t.Errorf("Strict mode, file %q: expected %v, got %v (%s)", tt.file, tt.expectStrict, result, tt.reason)
+			}
+		})
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.LOG", "Build/", "!build/Keep.txt"}, WithCaseInsensitive())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file     string
+		expected bool
+	}{
+		{"debug.log", true},
+		{"DEBUG.Log", true},
+		{"BUILD/output.bin", true},
+		{"build/keep.TXT", false},
+		{"src/main.go", false},
+	}
+
+	for _, tt := range tests {
+		result, err := matcher.Matches(tt.file)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", tt.file, err)
+		}
+		if result != tt.expected {
+			t.Errorf("File %q: expected %v, got %v", tt.file, tt.expected, result)
+		}
+	}
+
+	dockerMatcher, err := NewPatternMatcher([]string{"Dist"}, WithSyntax(SyntaxDocker), WithCaseInsensitive())
+	if err != nil {
+		t.Fatalf("Failed to create docker matcher: %v", err)
+	}
+	if result, _ := dockerMatcher.Matches("dist/app.js"); !result {
+		t.Error("Expected case-insensitive docker matcher to match dist/app.js")
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	base := t.TempDir()
+	matcher, err := NewPatternMatcher([]string{"/build/", "*.tmp"}, WithBasePath(base))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file     string
+		expected bool
+	}{
+		{filepath.Join(base, "build", "out.bin"), true},
+		{filepath.Join(base, "src", "cache.tmp"), true},
+		{filepath.Join(base, "src", "main.go"), false},
+		{"build/out.bin", true},
+		{base, false},
+	}
+
+	for _, tt := range tests {
+		result, err := matcher.Matches(tt.file)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", tt.file, err)
+		}
+		if result != tt.expected {
+			t.Errorf("File %q: expected %v, got %v", tt.file, tt.expected, result)
+		}
+	}
+
+	outside := filepath.Join(filepath.Dir(base), "elsewhere", "cache.tmp")
+	if _, err := matcher.Matches(outside); err == nil {
+		t.Errorf("Expected error for path outside base path: %s", outside)
+	}
+}
+
+func TestWithoutSubpathHeuristic(t *testing.T) {
+	patterns := []string{"docs/*.md"}
+
+	legacy, err := NewPatternMatcher(patterns)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	anchored, err := NewPatternMatcher(patterns, WithoutSubpathHeuristic())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file           string
+		expectLegacy   bool
+		expectAnchored bool
+	}{
+		{"docs/index.md", true, true},
+		{"site/docs/index.md", true, false},
+		{"docs/api/index.md", false, false},
+	}
+
+	for _, tt := range tests {
+		result, err := legacy.Matches(tt.file)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", tt.file, err)
+		}
+		if result != tt.expectLegacy {
+			t.Errorf("Default mode, file %q: expected %v, got %v", tt.file, tt.expectLegacy, result)
+		}
+
+		result, err = anchored.Matches(tt.file)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", tt.file, err)
+		}
+		if result != tt.expectAnchored {
+			t.Errorf("Without subpath heuristic, file %q: expected %v, got %v", tt.file, tt.expectAnchored, result)
+		}
+	}
+}
+
+func TestGitStrict(t *testing.T) {
+	patterns := []string{"src/test", "docs/*.md", "**/tmp/cache", "/dist", "logs/", "*.log", "!keep/important.log"}
+
+	legacy, err := NewPatternMatcher(patterns)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	strict, err := NewPatternMatcher(patterns, WithGitStrict())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file         string
+		expectLegacy bool
+		expectStrict bool
+	}{
+		{"src/test", true, true},
+		{"src/test/unit.go", true, true},
+		{"foo/src/test", true, false},
+		{"foo/src/test/unit.go", true, false},
+		{"docs/index.md", true, true},
+		{"site/docs/index.md", true, false},
+		{"tmp/cache", true, true},
+		{"a/b/tmp/cache", true, true},
+		{"dist/app.js", true, true},
+		{"web/dist", false, false},
+		{"logs/app.txt", true, true},
+		{"web/logs/app.txt", true, true},
+		{"web/app.log", true, true},
+		{"keep/important.log", false, false},
+		{"web/keep/important.log", false, true},
+	}
+
+	for _, tt := range tests {
+		result, err := legacy.Matches(tt.file)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", tt.file, err)
+		}
+		if result != tt.expectLegacy {
+			t.Errorf("Default mode, file %q: expected %v, got %v", tt.file, tt.expectLegacy, result)
+		}
+
+		result, err = strict.Matches(tt.file)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", tt.file, err)
+		}
+		if result != tt.expectStrict {
+			t.Errorf("Git-strict mode, file %q: expected %v, got %v", tt.file, tt.expectStrict, result)
+		}
+	}
+
+	for _, pattern := range strict.Patterns() {
+		want := pattern.Text() != "*.log" && pattern.Text() != "logs/"
+		if pattern.RootRelative() != want {
+			t.Errorf("Pattern %q: RootRelative() = %v, want %v", pattern.Text(), pattern.RootRelative(), want)
+		}
+	}
+}
+
+func TestGitStrictDirectories(t *testing.T) {
+	tests := []struct {
+		name         string
+		patterns     []string
+		file         string
+		expectLegacy bool
+		expectStrict bool
+	}{
+		{"Negated directory re-includes only itself", []string{"*.log", "!b/"}, "b/a.log", false, true},
+		{"Negated directory itself", []string{"b", "!b/"}, "b", false, false},
+		{"Negation does not reach beneath", []string{"*", "!src/"}, "src/a.go", false, true},
+		{"Negated file", []string{"*.log", "!keep.log"}, "app/keep.log", false, false},
+		{"Anchored wildcard directory", []string{"/f*"}, "foo/a.log", false, true},
+		{"Wildcard beneath a directory", []string{"b/*"}, "b/build/x", false, true},
+		{"Unanchored wildcard directory", []string{"f*"}, "src/foo/a.go", true, true},
+		{"Wildcard does not cross a slash", []string{"/f*"}, "src/foo", false, false},
+		{"Double star directory", []string{"**/cache"}, "a/cache/x", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, mode := range []struct {
+				options []Option
+				expect  bool
+			}{{nil, tt.expectLegacy}, {[]Option{WithGitStrict()}, tt.expectStrict}} {
+				matcher, err := NewPatternMatcher(tt.patterns, mode.options...)
+				if err != nil {
+					t.Fatalf("Failed to create matcher: %v", err)
+				}
+				result, err := matcher.Matches(tt.file)
+				if err != nil {
+					t.Fatalf("Error matching file %s: %v", tt.file, err)
+				}
+				if result != mode.expect {
+					t.Errorf("Patterns %q, file %q, strict %v: expected %v, got %v", tt.patterns, tt.file, mode.options != nil, mode.expect, result)
+				}
+			}
+		})
+	}
+}
+
+func TestStrictDoubleStar(t *testing.T) {
+	tests := []struct {
+		pattern      string
+		file         string
+		expectLegacy bool
+		expectStrict bool
+	}{
+		{"foo**bar", "foobazbar", true, true},
+		{"foo**bar", "foo/baz/bar", true, false},
+		{"/a**", "abc", true, true},
+		{"/a**", "ab/c.txt", true, false},
+		{"/src/**.go", "src/main.go", true, true},
+		{"/src/**.go", "src/cmd/main.go", true, false},
+		{"foo/**/bar", "foo/x/y/bar", true, true},
+		{"foo/***/bar", "foo/x/y/bar", true, true},
+		{"**/logs", "a/b/logs", true, true},
+		{"/build/**", "build/a/b.o", true, true},
+	}
+
+	for _, tt := range tests {
+		legacy, err := NewPatternMatcher([]string{tt.pattern})
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+		strict, err := NewPatternMatcher([]string{tt.pattern}, WithStrictDoubleStar())
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+
+		result, err := legacy.Matches(tt.file)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", tt.file, err)
+		}
+		if result != tt.expectLegacy {
+			t.Errorf("Default mode, pattern %q, file %q: expected %v, got %v", tt.pattern, tt.file, tt.expectLegacy, result)
+		}
+
+		result, err = strict.Matches(tt.file)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", tt.file, err)
+		}
+		if result != tt.expectStrict {
+			t.Errorf("Strict mode, pattern %q, file %q: expected %v, got %v", tt.pattern, tt.file, tt.expectStrict, result)
+		}
+	}
+}
+
+func TestAddRemovePatterns(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log", "build/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	check := func(step string, expected map[string]bool) {
+		t.Helper()
+		for file, want := range expected {
+			result, err := matcher.Matches(file)
+			if err != nil {
+				t.Errorf("%s: error matching file %s: %v", step, file, err)
+				continue
+			}
+			if result != want {
+				t.Errorf("%s: file %q: expected %v, got %v", step, file, want, result)
+			}
+		}
+	}
+
+	if err := matcher.AddPatterns([]string{"!debug.log", "*.tmp"}); err != nil {
+		t.Fatalf("AddPatterns() failed: %v", err)
+	}
+	check("after AddPatterns", map[string]bool{
+		"app.log":   true,
+		"debug.log": false,
+		"cache.tmp": true,
+	})
+
+	if err := matcher.AddPatterns([]string{"*.bak", "!"}); err == nil {
+		t.Error("AddPatterns() with an invalid pattern should fail")
+	}
+	check("after failed AddPatterns", map[string]bool{
+		"file.bak": false,
+	})
+
+	if removed := matcher.RemovePatterns([]string{"  !debug.log", "missing"}); removed != 1 {
+		t.Errorf("RemovePatterns() removed %d patterns, want 1", removed)
+	}
+	check("after RemovePatterns", map[string]bool{
+		"debug.log": true,
+		"cache.tmp": true,
+	})
+
+	if err := matcher.SetPatterns([]string{"dist/"}); err != nil {
+		t.Fatalf("SetPatterns() failed: %v", err)
+	}
+	check("after SetPatterns", map[string]bool{
+		"app.log":     false,
+		"build/x.js":  false,
+		"dist/app.js": true,
+	})
+}
+
+func TestAddPatternsConcurrentWithMatches(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if err := matcher.AddPatterns([]string{"*.tmp"}); err != nil {
+				t.Errorf("AddPatterns() failed: %v", err)
+				return
+			}
+			matcher.RemovePatterns([]string{"*.tmp"})
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		result, err := matcher.Matches("app.log")
+		if err != nil || !result {
+			t.Fatalf("Matches(app.log) = %v, %v; want true, nil", result, err)
+		}
 	}
+	<-done
+}
 
-	matcher, err := NewPatternMatcher(patterns)
+func TestMatchesWith(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log", "build/"}, WithHitTracking())
 	if err != nil {
 		t.Fatalf("Failed to create matcher: %v", err)
 	}
 
 	tests := []struct {
-		file     string
+		name     string
+		path     string
+		extra    []string
 		expected bool
-		reason   string
 	}{
-		{"build/", true, "build/ directory should match build/ pattern"},
-		{"build/file.txt", true, "files in build directory should be matched"},
-		{"file.tmp", true, "should match *.tmp pattern"},
-		{"src/test/", true, "should match src/test/ directory pattern"},
-		{"src/test/file.js", true, "files in src/test should be matched"},
-		{"other/", false, "other/ directory should not match"},
-		{"file.txt", false, "regular .txt files should not match"},
+		{"no extra patterns", "app.log", nil, true},
+		{"extra pattern ignores", "cache.tmp", []string{"*.tmp"}, true},
+		{"extra negation re-includes", "debug.log", []string{"!debug.log"}, false},
+		{"extra negation of other file", "app.log", []string{"!debug.log"}, true},
+		{"own pattern still applies", "build/out.js", []string{"*.tmp"}, true},
+		{"comments only", "cache.tmp", []string{"# *.tmp", ""}, false},
 	}
 
-	for _, test := range tests {
-		t.Run(test.file, func(t *testing.T) {
-			result, err := matcher.Matches(test.file)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := matcher.MatchesWith(tt.path, tt.extra)
 			if err != nil {
-				t.Errorf("Error matching file %s: %v", test.file, err)
-				return
+				t.Fatalf("MatchesWith(%q, %q) failed: %v", tt.path, tt.extra, err)
 			}
-			if result != test.expected {
-				t.Errorf("File %s: expected %v, got %v (%s)", test.file, test.expected, result, test.reason)
+			if result != tt.expected {
+				t.Errorf("MatchesWith(%q, %q) = %v, want %v", tt.path, tt.extra, result, tt.expected)
 			}
 		})
 	}
-}
 
-func TestNegationPatterns(t *testing.T) {
-	patterns := []string{
-		"*.log",          // Ignore all .log files
-		"!important.log", // But keep important.log
-		"temp/",          // Ignore temp directory
-		"!temp/keep.txt", // But keep temp/keep.txt
+	if _, err := matcher.MatchesWith("app.log", []string{"[z-a]"}); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("MatchesWith() with an invalid pattern = %v, want ErrInvalidPattern", err)
 	}
 
-	matcher, err := NewPatternMatcher(patterns)
+	// The matcher itself is left unchanged
+	if got := len(matcher.Patterns()); got != 2 {
+		t.Errorf("matcher has %d patterns after MatchesWith, want 2", got)
+	}
+	if result, _ := matcher.Matches("cache.tmp"); result {
+		t.Error("Matches(cache.tmp) = true after MatchesWith, want false")
+	}
+	if unused := matcher.UnusedPatterns(); len(unused) != 0 {
+		t.Errorf("UnusedPatterns() = %v, want none", unused)
+	}
+
+	// Only the matcher's own patterns count hits
+	if counts := matcher.HitCounts(); len(counts) != 2 || counts[0] != 3 || counts[1] != 1 {
+		t.Errorf("HitCounts() = %v, want [3 1]", counts)
+	}
+}
+
+func TestSetPatternsConcurrentSnapshots(t *testing.T) {
+	// Both sets leave build/keep.txt unignored, but the patterns of one
+	// evaluated with the index of the other would ignore it
+	sets := [][]string{
+		{"/build/", "!build/keep.txt"},
+		{"*.tmp", "/dist/"},
+	}
+	matcher, err := NewPatternMatcher(sets[0])
 	if err != nil {
 		t.Fatalf("Failed to create matcher: %v", err)
 	}
 
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			if err := matcher.SetPatterns(sets[i%2]); err != nil {
+				t.Errorf("SetPatterns() failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		result, err := matcher.Matches("build/keep.txt")
+		if err != nil || result {
+			t.Fatalf("Matches(build/keep.txt) = %v, %v; want false, nil", result, err)
+		}
+	}
+	<-done
+}
+
+func TestCloneAndMerge(t *testing.T) {
+	base, err := NewPatternMatcher([]string{"*.log", ".env"})
+	if err != nil {
+		t.Fatalf("Failed to create base matcher: %v", err)
+	}
+	project, err := NewPatternMatcher([]string{"dist/", "!audit.log"})
+	if err != nil {
+		t.Fatalf("Failed to create project matcher: %v", err)
+	}
+
+	merged := base.Clone()
+	if err := merged.Merge(project); err != nil {
+		t.Fatalf("Merge() failed: %v", err)
+	}
+
 	tests := []struct {
-		file     string
-		expected bool
-		reason   string
+		file       string
+		wantBase   bool
+		wantMerged bool
 	}{
-		{"app.log", true, "should match *.log pattern"},
-		{"important.log", false, "should be negated by !important.log"},
-		{"temp/cache.txt", true, "should match temp/ directory pattern"},
-		{"temp/keep.txt", false, "should be negated by !temp/keep.txt"},
-		{"other.txt", false, "should not match any pattern"},
+		{"app.log", true, true},
+		{"audit.log", true, false},
+		{".env", true, true},
+		{"dist/app.js", false, true},
+		{"main.go", false, false},
 	}
 
-	for _, test := range tests {
-		t.Run(test.file, func(t *testing.T) {
-			result, err := matcher.Matches(test.file)
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			result, err := base.Matches(tt.file)
 			if err != nil {
-				t.Errorf("Error matching file %s: %v", test.file, err)
-				return
+				t.Fatalf("Error matching file %s: %v", tt.file, err)
 			}
-			if result != test.expected {
-				t.Errorf("File %s: expected %v, got %v (%s)", test.file, test.expected, result, test.reason)
+			if result != tt.wantBase {
+				t.Errorf("Base matcher, file %q: expected %v, got %v", tt.file, tt.wantBase, result)
+			}
+
+			result, err = merged.Matches(tt.file)
+			if err != nil {
+				t.Fatalf("Error matching file %s: %v", tt.file, err)
+			}
+			if result != tt.wantMerged {
+				t.Errorf("Merged matcher, file %q: expected %v, got %v", tt.file, tt.wantMerged, result)
 			}
 		})
 	}
+
+	// Merging a matcher into itself doubles its patterns without deadlocking
+	if err := merged.Merge(merged); err != nil {
+		t.Errorf("Merge() with itself failed: %v", err)
+	}
+
+	if err := merged.Merge(nil); err == nil {
+		t.Error("Merge(nil) should fail")
+	}
+
+	docker, err := NewPatternMatcher([]string{"*.md"}, WithSyntax(SyntaxDocker))
+	if err != nil {
+		t.Fatalf("Failed to create docker matcher: %v", err)
+	}
+	if err := merged.Merge(docker); err == nil {
+		t.Error("Merge() across syntaxes should fail")
+	}
 }
 
 func TestEmptyAndCommentPatterns(t *testing.T) {
@@ -402,6 +1210,46 @@ func TestNewPatternMatcherErrors(t *testing.T) {
 	}
 }
 
+func TestMustNewPatternMatcher(t *testing.T) {
+	matcher := MustNewPatternMatcher([]string{"*.log"}, WithCaseInsensitive())
+	if ignored, err := matcher.Matches("DEBUG.LOG"); err != nil || !ignored {
+		t.Errorf("Matches(%q) = %v, %v, want true", "DEBUG.LOG", ignored, err)
+	}
+	matcher = MustNewPatternMatcherFromReader(strings.NewReader("*.log\n!keep.log\n"))
+	if ignored, err := matcher.Matches("keep.log"); err != nil || ignored {
+		t.Errorf("Matches(%q) = %v, %v, want false", "keep.log", ignored, err)
+	}
+
+	tests := []struct {
+		name string
+		new  func()
+	}{
+		{
+			name: "Invalid pattern",
+			new:  func() { MustNewPatternMatcher([]string{"!"}) },
+		},
+		{
+			name: "Invalid pattern from reader",
+			new:  func() { MustNewPatternMatcherFromReader(strings.NewReader("*.log\n!\n")) },
+		},
+		{
+			name: "Nil reader",
+			new:  func() { MustNewPatternMatcherFromReader(nil) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			tt.new()
+		})
+	}
+}
+
 func TestNewPatternMatcherFromReaderErrors(t *testing.T) {
 	t.Run("Nil reader", func(t *testing.T) {
 		_, err := NewPatternMatcherFromReader(nil)
@@ -411,6 +1259,73 @@ func TestNewPatternMatcherFromReaderErrors(t *testing.T) {
 	})
 }
 
+func TestMaxLineLength(t *testing.T) {
+	input := "*.log\n/" + strings.Repeat("a", 100) + "\n"
+
+	if _, err := NewPatternMatcherFromReader(strings.NewReader(input), WithMaxLineLength(50)); !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("Expected an error wrapping bufio.ErrTooLong, got %v", err)
+	}
+
+	matcher, err := NewPatternMatcherFromReader(strings.NewReader(input), WithMaxLineLength(101))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ignored, err := matcher.Matches(strings.Repeat("a", 100)); err != nil || !ignored {
+		t.Errorf("Expected the long pattern to match, got %v, %v", ignored, err)
+	}
+}
+
+func TestMaxPatterns(t *testing.T) {
+	if _, err := NewPatternMatcher([]string{"*.log", "# comment", "", "*.tmp"}, WithMaxPatterns(2)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := NewPatternMatcher([]string{"*.log", "*.tmp", "*.bak"}, WithMaxPatterns(2)); !errors.Is(err, ErrTooManyPatterns) {
+		t.Errorf("Expected an error wrapping ErrTooManyPatterns, got %v", err)
+	}
+
+	matcher, err := NewPatternMatcher([]string{"*.log"}, WithMaxPatterns(2))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := matcher.AddPatterns([]string{"*.tmp", "*.bak"}); !errors.Is(err, ErrTooManyPatterns) {
+		t.Errorf("Expected AddPatterns to fail with ErrTooManyPatterns, got %v", err)
+	}
+	if got := len(matcher.Patterns()); got != 1 {
+		t.Errorf("Expected the failed AddPatterns to leave 1 pattern, got %d", got)
+	}
+
+	other := MustNewPatternMatcher([]string{"*.tmp", "*.bak"})
+	if err := matcher.Merge(other); !errors.Is(err, ErrTooManyPatterns) {
+		t.Errorf("Expected Merge to fail with ErrTooManyPatterns, got %v", err)
+	}
+}
+
+func TestMaxPatternLength(t *testing.T) {
+	long := "/" + strings.Repeat("a", 100)
+	comment := "# " + strings.Repeat("a", 100)
+
+	matcher, err := NewPatternMatcher([]string{comment, "  *.log  "}, WithMaxPatternLength(5))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ignored, _ := matcher.Matches("app.log"); !ignored {
+		t.Error("Expected app.log to be ignored")
+	}
+
+	_, err = NewPatternMatcher([]string{"*.log", long}, WithMaxPatternLength(100))
+	if !errors.Is(err, ErrPatternTooLong) {
+		t.Fatalf("Expected an error wrapping ErrPatternTooLong, got %v", err)
+	}
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 {
+		t.Errorf("Expected a ParseError for line 2, got %v", err)
+	}
+
+	if _, err := NewPatternMatcher([]string{long}, WithMaxPatternLength(101)); err != nil {
+		t.Errorf("Unexpected error at the limit: %v", err)
+	}
+}
+
 func TestNewPatternMatcherFromFileErrors(t *testing.T) {
 	t.Run("Empty filepath", func(t *testing.T) {
 		_, err := NewPatternMatcherFromFile("")
@@ -427,6 +1342,58 @@ func TestNewPatternMatcherFromFileErrors(t *testing.T) {
 	})
 }
 
+func TestNewPatternMatcherFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults/.gitignore": {Data: []byte("# defaults\n*.log\n!keep.log\n")},
+		"broken/.gitignore":   {Data: []byte("*.tmp\n!\n")},
+	}
+
+	matcher, err := NewPatternMatcherFromFS(fsys, "defaults/.gitignore", WithCaseInsensitive())
+	if err != nil {
+		t.Fatalf("NewPatternMatcherFromFS failed: %v", err)
+	}
+	for path, expected := range map[string]bool{"debug.log": true, "DEBUG.LOG": true, "keep.log": false, "main.go": false} {
+		if got, err := matcher.Matches(path); err != nil || got != expected {
+			t.Errorf("Matches(%q) = %v, %v, want %v", path, got, err, expected)
+		}
+	}
+
+	var parseErr *ParseError
+	if _, err := NewPatternMatcherFromFS(fsys, "broken/.gitignore"); !errors.As(err, &parseErr) || parseErr.File != "broken/.gitignore" || parseErr.Line != 2 {
+		t.Errorf("Expected a ParseError at broken/.gitignore:2, got %v", err)
+	}
+	if _, err := NewPatternMatcherFromFS(fsys, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist for a missing file, got %v", err)
+	}
+	if _, err := NewPatternMatcherFromFS(nil, "defaults/.gitignore"); err == nil {
+		t.Error("Expected error for nil file system")
+	}
+}
+
+func TestMatchWithDetail(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log", "!keep.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file    string
+		ignored bool
+		decided bool
+	}{
+		{"debug.log", true, true},
+		{"keep.log", false, true},
+		{"main.go", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		ignored, decided, err := matcher.MatchWithDetail(tt.file)
+		if err != nil || ignored != tt.ignored || decided != tt.decided {
+			t.Errorf("MatchWithDetail(%q) = %v, %v, %v, want %v, %v", tt.file, ignored, decided, err, tt.ignored, tt.decided)
+		}
+	}
+}
+
 func TestMatchesEdgeCases(t *testing.T) {
 	patterns := []string{"*.txt", "!important.txt", "temp/"}
 	matcher, err := NewPatternMatcher(patterns)
@@ -560,6 +1527,96 @@ func TestEscapedNegation(t *testing.T) {
 	}
 }
 
+func TestBracketExpressions(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		file     string
+		expected bool
+	}{
+		{"file[]]", "file]", true},
+		{"file[]]", "file", false},
+		{"v[a-].txt", "v-.txt", true},
+		{"v[-a].txt", "va.txt", true},
+		{"v[-a].txt", "vb.txt", false},
+		{"[!.]*", ".hidden", false},
+		{"[!.]*", "visible", true},
+		{"log[^0-9]", "logs", true},
+		{"log[^0-9]", "log1", false},
+		{"a[/]b", "a/b", false},
+		{"[[:upper:]]*.md", "README.md", true},
+		{"[[:upper:]]*.md", "notes.md", false},
+	}
+
+	for _, tt := range tests {
+		matcher, err := NewPatternMatcher([]string{tt.pattern}, WithGitStrict())
+		if err != nil {
+			t.Fatalf("Failed to create matcher for %q: %v", tt.pattern, err)
+		}
+		result, err := matcher.Matches(tt.file)
+		if err != nil {
+			t.Errorf("Error matching file %q: %v", tt.file, err)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("Pattern %q, file %q: expected %v, got %v", tt.pattern, tt.file, tt.expected, result)
+		}
+	}
+
+	// With backslash escapes, a class may contain an escaped "]"
+	matcher, err := NewPatternMatcher([]string{`v[\]x]`}, WithBackslashEscapes())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	for file, expected := range map[string]bool{"v]": true, "vx": true, `v\`: false} {
+		if result, err := matcher.Matches(file); err != nil || result != expected {
+			t.Errorf("Matches(%q) = %v, %v, want %v", file, result, err, expected)
+		}
+	}
+}
+
+func TestEscapedMarks(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		file     string
+		expected bool
+	}{
+		{`\#comment`, "#comment", true},
+		{`\#comment`, "docs/#comment", true},
+		{`\#comment`, "comment", false},
+		{`notes\#1.txt`, "notes#1.txt", true},
+		{`foo\!bar`, "foo!bar", true},
+		{`foo\!bar`, "foo/!bar", false},
+		{`/draft\!/`, "draft!/today.md", true},
+		{`*\!`, "wow!", true},
+	}
+
+	for _, opts := range [][]Option{nil, {WithBackslashEscapes()}} {
+		for _, tt := range tests {
+			matcher, err := NewPatternMatcher([]string{tt.pattern}, opts...)
+			if err != nil {
+				t.Fatalf("Failed to create matcher for %q: %v", tt.pattern, err)
+			}
+			result, err := matcher.Matches(tt.file)
+			if err != nil {
+				t.Errorf("Error matching file %q: %v", tt.file, err)
+				continue
+			}
+			if result != tt.expected {
+				t.Errorf("Pattern %q with %d options, file %q: expected %v, got %v", tt.pattern, len(opts), tt.file, tt.expected, result)
+			}
+		}
+	}
+
+	// A "#" that is not at the start of the line never begins a comment
+	matcher, err := NewPatternMatcher([]string{"#comment", "file#1"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if patterns := matcher.Patterns(); len(patterns) != 1 || patterns[0].Text() != "file#1" {
+		t.Errorf("Patterns() = %+v, want only file#1", patterns)
+	}
+}
+
 func TestEscapedNegationWithoutOtherPatterns(t *testing.T) {
 	// Test escaped negation pattern in isolation
 	patterns := []string{
@@ -1009,6 +2066,53 @@ func TestWindowsPaths(t *testing.T) {
 	}
 }
 
+func TestWindowsVolumePaths(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"build/", "/out.txt", "*.tmp"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	based, err := NewPatternMatcher([]string{"/build/", "/out.txt"}, WithBasePath(`C:\repo`))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		matcher  *PatternMatcher
+		file     string
+		expected bool
+	}{
+		{matcher, `C:\repo\build\out.txt`, true},
+		{matcher, `C:\repo\src\main.go`, false},
+		{matcher, `C:\out.txt`, false},
+		{matcher, `\\server\share\build\x`, true},
+		{matcher, `\\server\share\cache.tmp`, true},
+		{matcher, `\\?\C:\repo\build\x`, true},
+		{matcher, `\\?\UNC\server\share\a.tmp`, true},
+		{matcher, `src\..\build\x`, true},
+		{based, `C:\repo\build\out.txt`, true},
+		{based, `c:\repo\out.txt`, true},
+		{based, `\\?\C:\repo\out.txt`, true},
+		{based, `C:\repo\src\out.txt`, false},
+		{based, `build\x`, true},
+	}
+
+	for _, tt := range tests {
+		result, err := tt.matcher.Matches(tt.file)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", tt.file, err)
+		}
+		if result != tt.expected {
+			t.Errorf("File %q: expected %v, got %v", tt.file, tt.expected, result)
+		}
+	}
+
+	for _, outside := range []string{`D:\repo\out.txt`, `C:\other\out.txt`, `C:\repository\out.txt`} {
+		if _, err := based.Matches(outside); err == nil {
+			t.Errorf("Expected error for path outside base path: %s", outside)
+		}
+	}
+}
+
 func TestPatternOrderMatters(t *testing.T) {
 	// Test that pattern order affects the final result
 	patterns1 := []string{"*.txt", "!important.txt"}
@@ -1032,6 +2136,32 @@ func TestPatternOrderMatters(t *testing.T) {
 	}
 }
 
+func TestMatchesDoesNotAllocate(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{
+		"*.log", "/build/", "node_modules/", "**/*.test.js", "docs/*.md",
+		"!important.log", "src/**/gen", "cache",
+	}, WithStrictNegation())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	files := []string{
+		"app.log", "build/app.js", "web/node_modules/react/index.js",
+		"src/a/b/util.test.js", "site/docs/index.md", "important.log", "src/main.go",
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, file := range files {
+			if _, err := matcher.Matches(file); err != nil {
+				t.Fatalf("Error matching file %s: %v", file, err)
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Expected Matches not to allocate, got %v allocations per run", allocs)
+	}
+}
+
 func BenchmarkMatches(b *testing.B) {
 	patterns := []string{
 		"*.log", "*.tmp", "*.cache",
@@ -1062,3 +2192,24 @@ func BenchmarkMatches(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkMatchesSegments(b *testing.B) {
+	matcher, err := NewPatternMatcher([]string{"*.log", "build/", "**/*.test.js", "!important.log"})
+	if err != nil {
+		b.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	testPaths := [][]string{
+		{"app.log"},
+		{"src", "components", "component.js"},
+		{"src", "components", "component.test.js"},
+		{"build", "app.js"},
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, segs := range testPaths {
+			_, _ = matcher.MatchesSegments(segs, false)
+		}
+	}
+}
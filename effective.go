@@ -0,0 +1,101 @@
+package dotignore
+
+import "path/filepath"
+
+// EffectivePattern is a pattern that applies to the paths in a directory of
+// a repository, along with where it came from.
+type EffectivePattern struct {
+	Pattern
+
+	// Source is the path of the ignore file containing the pattern, in the
+	// form used by MatchResult.Source. It is empty for patterns that do not
+	// come from a file, such as npm's built-in rules.
+	Source string
+
+	// Dir is the directory the pattern is relative to, relative to the
+	// repository root, or "." for the root itself. Ancestors of the root
+	// loaded with IncludeAncestorIgnoreFiles are "..", "../.." and so on.
+	Dir string
+}
+
+// EffectivePatterns returns every pattern that applies to the paths in dir,
+// in the order they are evaluated: by precedence level, and within a level
+// from the ignore file at the root, or the outermost ancestor with
+// IncludeAncestorIgnoreFiles, down to the one in dir itself. Where two
+// patterns match a path, the later one decides. Ignore files in directories
+// beneath dir are not included.
+//
+// dir may be absolute or relative to the repository root. Built-in rules,
+// such as the exclusion of .git directories, are not patterns and are not
+// reported.
+func (rm *RepositoryMatcher) EffectivePatterns(dir string) ([]EffectivePattern, error) {
+	relDir, err := rm.relativePath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []EffectivePattern
+	patterns = appendRootPatterns(patterns, rm.underlay)
+	for level := 0; level < rm.levels; level++ {
+		for _, dir := range rm.applyingDirs(relDir) {
+			for _, file := range rm.matchers[dir] {
+				if file.level != level {
+					continue
+				}
+				fileDir, err := filepath.Rel(rm.rootDir, dir)
+				if err != nil {
+					continue
+				}
+				for _, pattern := range file.matcher.Patterns() {
+					patterns = append(patterns, EffectivePattern{
+						Pattern: pattern,
+						Source:  rm.sourcePath(file.path),
+						Dir:     filepath.ToSlash(fileDir),
+					})
+				}
+			}
+		}
+	}
+
+	// Override patterns are evaluated after every ignore file
+	patterns = appendRootPatterns(patterns, rm.overrides)
+	patterns = appendRootPatterns(patterns, rm.overlay)
+	return patterns, nil
+}
+
+// appendRootPatterns appends the patterns of matcher, which are relative to
+// the root directory and come from no file, to patterns. matcher may be nil.
+func appendRootPatterns(patterns []EffectivePattern, matcher *PatternMatcher) []EffectivePattern {
+	if matcher == nil {
+		return patterns
+	}
+	for _, pattern := range matcher.Patterns() {
+		patterns = append(patterns, EffectivePattern{Pattern: pattern, Dir: "."})
+	}
+	return patterns
+}
+
+// applyingDirs returns the absolute paths of the directories whose ignore
+// files apply to the paths in the slash-separated relDir, relative to the
+// root: the ancestors loaded with IncludeAncestorIgnoreFiles, the root, and
+// every directory down to relDir itself, outermost first. A nested
+// repository starts the list afresh with NestedRepositoryScope.
+func (rm *RepositoryMatcher) applyingDirs(relDir string) []string {
+	dirs := rm.baseDirs()
+	if relDir != "." {
+		for i := 0; i <= len(relDir); i++ {
+			if i == len(relDir) || relDir[i] == '/' {
+				dirs = append(dirs, filepath.Join(rm.rootDir, filepath.FromSlash(relDir[:i])))
+			}
+		}
+	}
+
+	if rm.config.NestedRepositories == NestedRepositoryScope {
+		for i := len(dirs) - 1; i > 0; i-- {
+			if rm.nestedRoots[dirs[i]] {
+				return dirs[i:]
+			}
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,102 @@
+package dotignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRepositoryMatcher_EffectivePatterns(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":         "*.log\n/build/\n",
+		"app/.gitignore":     "!debug.log\n",
+		"app/web/.gitignore": "dist/\n",
+		"app/web/index.html": "",
+		"lib/.gitignore":     "*.o\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository matcher: %v", err)
+	}
+
+	type entry struct {
+		Text, Source, Dir string
+		Line              int
+	}
+	tests := []struct {
+		dir      string
+		expected []entry
+	}{
+		{
+			dir: ".",
+			expected: []entry{
+				{"*.log", ".gitignore", ".", 1},
+				{"/build/", ".gitignore", ".", 2},
+			},
+		},
+		{
+			dir: "app/web",
+			expected: []entry{
+				{"*.log", ".gitignore", ".", 1},
+				{"/build/", ".gitignore", ".", 2},
+				{"!debug.log", "app/.gitignore", "app", 1},
+				{"dist/", "app/web/.gitignore", "app/web", 1},
+			},
+		},
+		{
+			dir: filepath.Join(tmpDir, "app"),
+			expected: []entry{
+				{"*.log", ".gitignore", ".", 1},
+				{"/build/", ".gitignore", ".", 2},
+				{"!debug.log", "app/.gitignore", "app", 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			patterns, err := matcher.EffectivePatterns(tt.dir)
+			if err != nil {
+				t.Fatalf("EffectivePatterns failed: %v", err)
+			}
+			got := make([]entry, len(patterns))
+			for i, pattern := range patterns {
+				got[i] = entry{pattern.Text(), pattern.Source, pattern.Dir, pattern.Line()}
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("EffectivePatterns(%q) = %+v, want %+v", tt.dir, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := matcher.EffectivePatterns(filepath.Dir(tmpDir)); err == nil {
+		t.Error("EffectivePatterns outside the repository succeeded")
+	}
+}
+
+func TestRepositoryMatcher_EffectivePatterns_Overrides(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		"package.json": "{}",
+		".npmignore":   "*.md\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewNpmMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("NewNpmMatcher failed: %v", err)
+	}
+	patterns, err := matcher.EffectivePatterns(".")
+	if err != nil {
+		t.Fatalf("EffectivePatterns failed: %v", err)
+	}
+	if len(patterns) < 2 || patterns[0].Text() != "*.md" || patterns[0].Source != ".npmignore" {
+		t.Fatalf("EffectivePatterns() = %+v, want .npmignore patterns first", patterns)
+	}
+	last := patterns[len(patterns)-1]
+	if last.Source != "" || last.Dir != "." {
+		t.Errorf("Override pattern %q has Source %q and Dir %q, want \"\" and \".\"", last.Text(), last.Source, last.Dir)
+	}
+}
@@ -0,0 +1,313 @@
+package dotignore
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// encodingVersion identifies the layout written by Encode. Decoding data
+// written with a different version fails rather than producing a matcher
+// that behaves differently.
+const encodingVersion = 2
+
+type encodedMatcher struct {
+	Version  int
+	Options  encodedOptions
+	Patterns []encodedPattern
+}
+
+type encodedOptions struct {
+	Syntax             Syntax
+	StrictNegation     bool
+	CaseInsensitive    bool
+	NoSubpathHeuristic bool
+	GitStrict          bool
+	ParentsDecided     bool
+	StrictDoubleStar   bool
+	StrictDirs         bool
+	BackslashEscapes   bool
+	BasePath           string
+	TrackHits          bool
+}
+
+type encodedPattern struct {
+	Text         string
+	Line         int
+	Pattern      string
+	Directory    bool
+	Negate       bool
+	Wildcard     bool
+	RootRelative bool
+	Inverted     bool
+}
+
+type encodedRepository struct {
+	Version     int
+	RootDir     string
+	Config      RepositoryConfig
+	Levels      int
+	NestedRoots map[string]bool
+	Ancestors   []string
+	IgnoreCase  bool
+	Excludes    string
+	DirModTimes map[string]time.Time
+	Subdirs     map[string][]string
+	Files       []encodedIgnoreFile
+	Overrides   *encodedMatcher
+	Underlay    *encodedMatcher
+	Overlay     *encodedMatcher
+}
+
+type encodedIgnoreFile struct {
+	Dir     string
+	Path    string
+	Level   int
+	ModTime time.Time
+	Size    int64
+	Matcher encodedMatcher
+}
+
+// Encode writes the parsed patterns and options of the matcher to w, so that
+// an equivalent matcher can be restored with DecodePatternMatcher without
+// reading and parsing the original ignore files. Hit counts are not encoded.
+func (p *PatternMatcher) Encode(w io.Writer) error {
+	if err := gob.NewEncoder(w).Encode(p.encode()); err != nil {
+		return fmt.Errorf("failed to encode matcher: %w", err)
+	}
+	return nil
+}
+
+// DecodePatternMatcher restores a matcher written by PatternMatcher.Encode.
+func DecodePatternMatcher(r io.Reader) (*PatternMatcher, error) {
+	if r == nil {
+		return nil, errors.New("reader cannot be nil")
+	}
+
+	var encoded encodedMatcher
+	if err := gob.NewDecoder(r).Decode(&encoded); err != nil {
+		return nil, fmt.Errorf("failed to decode matcher: %w", err)
+	}
+	return encoded.decode()
+}
+
+// Encode writes the repository matcher, including every loaded ignore file
+// and its modification time, the settings read from Git's configuration and
+// the directory state kept for Refresh, to w. The matcher can be restored
+// with DecodeRepositoryMatcher without walking the repository; calling
+// Reload or Refresh on the restored matcher then re-parses only the ignore
+// files that changed.
+func (rm *RepositoryMatcher) Encode(w io.Writer) error {
+	encoded := encodedRepository{
+		Version:     encodingVersion,
+		RootDir:     rm.rootDir,
+		Config:      rm.config,
+		Levels:      rm.levels,
+		NestedRoots: rm.nestedRoots,
+		Ancestors:   rm.ancestorDirs,
+		IgnoreCase:  rm.ignoreCase,
+		Excludes:    rm.excludesFile,
+		DirModTimes: rm.dirModTimes,
+		Subdirs:     rm.subdirs,
+	}
+	// Loggers, file systems and callbacks cannot be serialized
+	encoded.Config.Logger = nil
+	encoded.Config.FS = nil
+	encoded.Config.OnError = nil
+	encoded.Config.OnProgress = nil
+	for dir, files := range rm.matchers {
+		for _, file := range files {
+			encoded.Files = append(encoded.Files, encodedIgnoreFile{
+				Dir:     dir,
+				Path:    file.path,
+				Level:   file.level,
+				ModTime: file.modTime,
+				Size:    file.size,
+				Matcher: file.matcher.encode(),
+			})
+		}
+	}
+	encoded.Overrides = encodeOptional(rm.overrides)
+	encoded.Underlay = encodeOptional(rm.underlay)
+	encoded.Overlay = encodeOptional(rm.overlay)
+
+	if err := gob.NewEncoder(w).Encode(encoded); err != nil {
+		return fmt.Errorf("failed to encode repository matcher: %w", err)
+	}
+	return nil
+}
+
+// DecodeRepositoryMatcher restores a repository matcher written by
+// RepositoryMatcher.Encode. The repository itself is not accessed, so the
+// result reflects the ignore files at the time the matcher was encoded.
+func DecodeRepositoryMatcher(r io.Reader) (*RepositoryMatcher, error) {
+	if r == nil {
+		return nil, errors.New("reader cannot be nil")
+	}
+
+	var encoded encodedRepository
+	if err := gob.NewDecoder(r).Decode(&encoded); err != nil {
+		return nil, fmt.Errorf("failed to decode repository matcher: %w", err)
+	}
+	if encoded.Version != encodingVersion {
+		return nil, fmt.Errorf("unsupported encoding version %d", encoded.Version)
+	}
+
+	rm := &RepositoryMatcher{
+		rootDir:      encoded.RootDir,
+		config:       encoded.Config,
+		fsys:         osFileSystem{},
+		matchers:     make(map[string][]*ignoreFile),
+		levels:       encoded.Levels,
+		nestedRoots:  encoded.NestedRoots,
+		ancestorDirs: encoded.Ancestors,
+		ignoreCase:   encoded.IgnoreCase,
+		excludesFile: encoded.Excludes,
+		dirModTimes:  encoded.DirModTimes,
+		subdirs:      encoded.Subdirs,
+	}
+	if rm.nestedRoots == nil {
+		rm.nestedRoots = make(map[string]bool)
+	}
+	if rm.dirModTimes == nil {
+		rm.dirModTimes = make(map[string]time.Time)
+	}
+	if rm.subdirs == nil {
+		rm.subdirs = make(map[string][]string)
+	}
+
+	for _, file := range encoded.Files {
+		matcher, err := file.Matcher.decode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", file.Path, err)
+		}
+		rm.matchers[file.Dir] = append(rm.matchers[file.Dir], &ignoreFile{
+			path:    file.Path,
+			level:   file.Level,
+			modTime: file.ModTime,
+			size:    file.Size,
+			matcher: matcher,
+		})
+	}
+
+	var err error
+	if rm.overrides, err = encoded.Overrides.decodeOptional(); err != nil {
+		return nil, fmt.Errorf("failed to decode overrides: %w", err)
+	}
+	if rm.underlay, err = encoded.Underlay.decodeOptional(); err != nil {
+		return nil, fmt.Errorf("failed to decode lowest-precedence overlay: %w", err)
+	}
+	if rm.overlay, err = encoded.Overlay.decodeOptional(); err != nil {
+		return nil, fmt.Errorf("failed to decode overlay: %w", err)
+	}
+
+	return rm, nil
+}
+
+// encodeOptional encodes p, returning nil if p is nil.
+func encodeOptional(p *PatternMatcher) *encodedMatcher {
+	if p == nil {
+		return nil
+	}
+	encoded := p.encode()
+	return &encoded
+}
+
+// decodeOptional decodes e, returning nil if e is nil.
+func (e *encodedMatcher) decodeOptional() (*PatternMatcher, error) {
+	if e == nil {
+		return nil, nil
+	}
+	return e.decode()
+}
+
+func (p *PatternMatcher) encode() encodedMatcher {
+	rules := p.rules.Load()
+
+	encoded := encodedMatcher{
+		Version: encodingVersion,
+		Options: encodedOptions{
+			Syntax:             p.options.syntax,
+			StrictNegation:     p.options.strictNegation,
+			CaseInsensitive:    p.options.caseInsensitive,
+			NoSubpathHeuristic: p.options.noSubpathHeuristic,
+			GitStrict:          p.options.gitStrict,
+			ParentsDecided:     p.options.parentsDecided,
+			StrictDoubleStar:   p.options.strictDoubleStar,
+			StrictDirs:         p.options.strictDirs,
+			BackslashEscapes:   p.options.backslashEscapes,
+			BasePath:           p.options.basePath,
+			TrackHits:          p.options.trackHits,
+		},
+		Patterns: make([]encodedPattern, len(rules.patterns)),
+	}
+	for i, pattern := range rules.patterns {
+		encoded.Patterns[i] = encodedPattern{
+			Text:         pattern.text,
+			Line:         pattern.line,
+			Pattern:      pattern.pattern,
+			Directory:    pattern.isDirectory,
+			Negate:       pattern.negate,
+			Wildcard:     pattern.hasWildcard,
+			RootRelative: pattern.isRootRelative,
+			Inverted:     pattern.inverted,
+		}
+	}
+	return encoded
+}
+
+func (e encodedMatcher) decode() (*PatternMatcher, error) {
+	if e.Version != encodingVersion {
+		return nil, fmt.Errorf("unsupported encoding version %d", e.Version)
+	}
+
+	options := matcherOptions{
+		syntax:             e.Options.Syntax,
+		strictNegation:     e.Options.StrictNegation,
+		caseInsensitive:    e.Options.CaseInsensitive,
+		noSubpathHeuristic: e.Options.NoSubpathHeuristic,
+		gitStrict:          e.Options.GitStrict,
+		parentsDecided:     e.Options.ParentsDecided,
+		strictDoubleStar:   e.Options.StrictDoubleStar,
+		strictDirs:         e.Options.StrictDirs,
+		backslashEscapes:   e.Options.BackslashEscapes,
+		trackHits:          e.Options.TrackHits,
+	}
+	if e.Options.BasePath != "" {
+		options.setBasePath(e.Options.BasePath)
+	}
+	if options.syntax != SyntaxGit && options.syntax != SyntaxDocker &&
+		options.syntax != SyntaxHelm && options.syntax != SyntaxGcloud {
+		return nil, fmt.Errorf("unsupported syntax %v", options.syntax)
+	}
+
+	ignorePatterns := make([]ignorePattern, len(e.Patterns))
+	for i, pattern := range e.Patterns {
+		glob, regexPattern, err := compilePattern(pattern.Pattern, options.syntax)
+		if err != nil {
+			return nil, &ParseError{
+				Line:    pattern.Line,
+				Pattern: pattern.Text,
+				Reason:  fmt.Sprintf("malformed pattern: %v", err),
+				Err:     err,
+			}
+		}
+		ignorePatterns[i] = ignorePattern{
+			text:           pattern.Text,
+			line:           pattern.Line,
+			pattern:        pattern.Pattern,
+			glob:           glob,
+			regexPattern:   regexPattern,
+			isDirectory:    pattern.Directory,
+			negate:         pattern.Negate,
+			hasWildcard:    pattern.Wildcard,
+			isRootRelative: pattern.RootRelative,
+			inverted:       pattern.Inverted,
+		}
+	}
+	options.attachHitCounters(ignorePatterns)
+
+	return newPatternMatcher(ignorePatterns, options), nil
+}
@@ -0,0 +1,201 @@
+package dotignore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecodePatternMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		opts     []Option
+		files    []string
+	}{
+		{
+			name:     "Git syntax",
+			patterns: []string{"*.log", "!important.log", "/build/", "docs/*.md", "file[^0-9].txt"},
+			files:    []string{"app.log", "important.log", "build/out", "site/docs/a.md", "filex.txt", "file1.txt", "src/main.go"},
+		},
+		{
+			name:     "Options",
+			patterns: []string{"*.LOG", "docs/*.md", "build/", "!build/keep.txt"},
+			opts:     []Option{WithCaseInsensitive(), WithoutSubpathHeuristic(), WithStrictNegation()},
+			files:    []string{"APP.log", "site/docs/a.md", "docs/a.md", "build/keep.txt"},
+		},
+		{
+			name:     "Git-strict",
+			patterns: []string{"src/test", "docs/*.md"},
+			opts:     []Option{WithGitStrict()},
+			files:    []string{"src/test/a.go", "lib/src/test/a.go", "docs/a.md", "site/docs/a.md"},
+		},
+		{
+			name:     "Docker syntax",
+			patterns: []string{"**/*.go", "!cmd/**", "dist"},
+			opts:     []Option{WithSyntax(SyntaxDocker)},
+			files:    []string{"main.go", "pkg/a.go", "cmd/main.go", "dist/app.js", "README.md"},
+		},
+		{
+			name:     "Helm syntax",
+			patterns: []string{"*.tgz", "ci/", "!Chart.yaml"},
+			opts:     []Option{WithSyntax(SyntaxHelm)},
+			files:    []string{"Chart.yaml", "values.yaml", "dep.tgz", "ci/test.yaml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original, err := NewPatternMatcher(tt.patterns, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := original.Encode(&buf); err != nil {
+				t.Fatalf("Failed to encode matcher: %v", err)
+			}
+			decoded, err := DecodePatternMatcher(&buf)
+			if err != nil {
+				t.Fatalf("Failed to decode matcher: %v", err)
+			}
+
+			for _, file := range tt.files {
+				want, _ := original.Matches(file)
+				got, err := decoded.Matches(file)
+				if err != nil {
+					t.Fatalf("Error matching file %s: %v", file, err)
+				}
+				if got != want {
+					t.Errorf("File %q: original %v, decoded %v", file, want, got)
+				}
+			}
+
+			if len(decoded.Patterns()) != len(original.Patterns()) {
+				t.Fatalf("Expected %d patterns, got %d", len(original.Patterns()), len(decoded.Patterns()))
+			}
+			for i, pattern := range original.Patterns() {
+				if decoded.Patterns()[i] != pattern {
+					t.Errorf("Pattern %d: expected %+v, got %+v", i, pattern, decoded.Patterns()[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodePatternMatcherErrors(t *testing.T) {
+	if _, err := DecodePatternMatcher(nil); err == nil {
+		t.Error("Expected error for nil reader")
+	}
+	if _, err := DecodePatternMatcher(strings.NewReader("not a matcher")); err == nil {
+		t.Error("Expected error for invalid input")
+	}
+}
+
+func TestEncodeDecodeRepositoryMatcher(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":     "*.log\n",
+		"app/.gitignore": "dist/\n!keep.log\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	original, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := original.Encode(&buf); err != nil {
+		t.Fatalf("Failed to encode matcher: %v", err)
+	}
+	decoded, err := DecodeRepositoryMatcher(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode matcher: %v", err)
+	}
+
+	files := []string{"debug.log", "app/keep.log", "app/dist/bundle.js", "app/main.go"}
+	for _, file := range files {
+		want, _ := original.Matches(file)
+		got, err := decoded.Matches(file)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", file, err)
+		}
+		if got != want {
+			t.Errorf("File %q: original %v, decoded %v", file, want, got)
+		}
+	}
+	if decoded.IgnoreFileCount() != original.IgnoreFileCount() {
+		t.Errorf("Expected %d ignore files, got %d", original.IgnoreFileCount(), decoded.IgnoreFileCount())
+	}
+
+	// The directory state kept for Refresh survives the round trip
+	if !reflect.DeepEqual(decoded.subdirs, original.subdirs) || len(decoded.dirModTimes) != len(original.dirModTimes) {
+		t.Errorf("Expected directory state %v, %v, got %v, %v", original.subdirs, original.dirModTimes, decoded.subdirs, decoded.dirModTimes)
+	}
+	for dir, modTime := range original.dirModTimes {
+		if !decoded.dirModTimes[dir].Equal(modTime) {
+			t.Errorf("Directory %s: expected modification time %v, got %v", dir, modTime, decoded.dirModTimes[dir])
+		}
+	}
+
+	// Reloading the decoded matcher picks up changes made after encoding
+	future := time.Now().Add(time.Hour)
+	path := filepath.Join(tmpDir, "app", ".gitignore")
+	if err := os.WriteFile(path, []byte("dist/\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("Failed to change times: %v", err)
+	}
+	if err := decoded.Reload(); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+	if result, _ := decoded.Matches("app/keep.log"); !result {
+		t.Error("Expected app/keep.log to be ignored after reload")
+	}
+
+}
+
+func TestEncodeDecodeRepositoryMatcherGitConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+
+	tmpDir := createTestRepo(t, map[string]string{
+		".git/config": "[core]\n\tignorecase = true\n",
+		".gitignore":  "*.LOG\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	config := DefaultRepositoryConfig()
+	config.ReadGitConfig = true
+	original, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := original.Encode(&buf); err != nil {
+		t.Fatalf("Failed to encode matcher: %v", err)
+	}
+	decoded, err := DecodeRepositoryMatcher(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode matcher: %v", err)
+	}
+
+	// Overlays of the decoded matcher follow core.ignoreCase
+	view, err := decoded.WithOverlay([]string{"*.TMP"}, OverlayHighest)
+	if err != nil {
+		t.Fatalf("WithOverlay() failed: %v", err)
+	}
+	for _, file := range []string{"debug.log", "cache.tmp"} {
+		if ignored, err := view.Matches(file); err != nil || !ignored {
+			t.Errorf("Matches(%q) = %v, %v, want true", file, ignored, err)
+		}
+	}
+}
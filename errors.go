@@ -0,0 +1,98 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrInvalidPattern matches every *ParseError, so errors.Is can tell an
+	// invalid pattern apart from a failure to read the patterns.
+	ErrInvalidPattern = errors.New("invalid pattern")
+
+	// ErrInvalidNegation is reported for a line consisting of a single "!".
+	ErrInvalidNegation = errors.New("single '!' is not allowed")
+
+	// ErrEmptyPattern is reported for a pattern that is empty once its
+	// leading and trailing slashes are removed, such as "/".
+	ErrEmptyPattern = errors.New("pattern cannot be empty")
+
+	// ErrPatternTooLong is reported for a pattern longer than the limit set
+	// with WithMaxPatternLength or RepositoryConfig.MaxPatternLength.
+	ErrPatternTooLong = errors.New("pattern too long")
+
+	// ErrTooManyPatterns is returned when a matcher would hold more patterns
+	// than the limit set with WithMaxPatterns or RepositoryConfig.MaxPatterns.
+	ErrTooManyPatterns = errors.New("too many patterns")
+
+	// ErrTooManyIgnoreFiles is returned when a RepositoryMatcher finds more
+	// ignore files than RepositoryConfig.MaxIgnoreFiles allows.
+	ErrTooManyIgnoreFiles = errors.New("too many ignore files")
+
+	// ErrNotInRepository is returned by NewRepositoryMatcherFromPath when
+	// the path is not inside a Git work tree.
+	ErrNotInRepository = errors.New("not in a git repository")
+)
+
+// ParseError describes a line that cannot be parsed as a pattern. Matcher
+// constructors and methods that parse patterns return it wrapped, so use
+// errors.As to retrieve it.
+type ParseError struct {
+	// File is the ignore file containing the pattern, or "" if the patterns
+	// were not read from a file.
+	File string
+	// Line is the 1-based line number of the pattern.
+	Line int
+	// Column is the 1-based byte offset within the line at which the problem
+	// starts, or 0 if it is not known.
+	Column int
+	// Pattern is the line as written, without surrounding whitespace.
+	Pattern string
+	// Reason is a human-readable description of the problem.
+	Reason string
+	// Err is the underlying error: ErrInvalidNegation, ErrEmptyPattern, or
+	// the error reported for malformed glob syntax.
+	Err error
+}
+
+// Error returns the location of the pattern followed by the reason.
+func (e *ParseError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("invalid pattern at %s:%d: %s", e.File, e.Line, e.Reason)
+	}
+	return fmt.Sprintf("invalid pattern at line %d: %s", e.Line, e.Reason)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrInvalidPattern.
+func (e *ParseError) Is(target error) bool {
+	return target == ErrInvalidPattern
+}
+
+// newParseError returns a ParseError for the raw line found at the given line
+// number, pointing at the first character of the pattern.
+func newParseError(raw string, line int, err error) *ParseError {
+	text := strings.TrimLeftFunc(raw, unicode.IsSpace)
+	return &ParseError{
+		Line:    line,
+		Column:  len(raw) - len(text) + 1,
+		Pattern: strings.TrimSpace(text),
+		Reason:  err.Error(),
+		Err:     err,
+	}
+}
+
+// setParseErrorFile records file as the source of the ParseError within err,
+// if there is one that does not name a file yet.
+func setParseErrorFile(err error, file string) {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) && parseErr.File == "" {
+		parseErr.File = file
+	}
+}
@@ -0,0 +1,111 @@
+package dotignore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name       string
+		patterns   []string
+		syntax     Syntax
+		wantLine   int
+		wantColumn int
+		wantText   string
+		wantErr    error // nil if only ErrInvalidPattern applies
+	}{
+		{
+			name:       "Single exclamation",
+			patterns:   []string{"*.log", "!"},
+			wantLine:   2,
+			wantColumn: 1,
+			wantText:   "!",
+			wantErr:    ErrInvalidNegation,
+		},
+		{
+			name:       "Only a slash",
+			patterns:   []string{"", "# comment", "  /"},
+			wantLine:   3,
+			wantColumn: 3,
+			wantText:   "/",
+			wantErr:    ErrEmptyPattern,
+		},
+		{
+			name:       "Invalid class range",
+			patterns:   []string{"\t[z-a].txt "},
+			wantLine:   1,
+			wantColumn: 2,
+			wantText:   "[z-a].txt",
+		},
+		{
+			name:       "Docker single exclamation",
+			patterns:   []string{"build", " ! "},
+			syntax:     SyntaxDocker,
+			wantLine:   2,
+			wantColumn: 2,
+			wantText:   "!",
+			wantErr:    ErrInvalidNegation,
+		},
+		{
+			name:       "Docker unterminated class",
+			patterns:   []string{"a["},
+			syntax:     SyntaxDocker,
+			wantLine:   1,
+			wantColumn: 1,
+			wantText:   "a[",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPatternMatcher(tt.patterns, WithSyntax(tt.syntax))
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("NewPatternMatcher() error = %v, want a *ParseError", err)
+			}
+			if parseErr.Line != tt.wantLine || parseErr.Column != tt.wantColumn || parseErr.Pattern != tt.wantText {
+				t.Errorf("ParseError at line %d, column %d, pattern %q; want line %d, column %d, pattern %q",
+					parseErr.Line, parseErr.Column, parseErr.Pattern, tt.wantLine, tt.wantColumn, tt.wantText)
+			}
+			if parseErr.Reason == "" {
+				t.Error("ParseError has an empty Reason")
+			}
+			if !errors.Is(err, ErrInvalidPattern) {
+				t.Errorf("errors.Is(%v, ErrInvalidPattern) = false", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseErrorFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(path, []byte("*.log\n!\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewPatternMatcherFromFile(path)
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("NewPatternMatcherFromFile() error = %v, want a *ParseError", err)
+	}
+	if parseErr.File != path || parseErr.Line != 2 {
+		t.Errorf("ParseError at %s:%d, want %s:2", parseErr.File, parseErr.Line, path)
+	}
+	want := "invalid pattern at " + path + ":2: single '!' is not allowed"
+	if parseErr.Error() != want {
+		t.Errorf("Error() = %q, want %q", parseErr.Error(), want)
+	}
+
+	// Failing to read the file is not a parse error
+	_, err = NewPatternMatcherFromFile(filepath.Join(t.TempDir(), "missing"))
+	if errors.Is(err, ErrInvalidPattern) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("NewPatternMatcherFromFile() error = %v, want fs.ErrNotExist only", err)
+	}
+}
@@ -36,6 +36,23 @@ func ExampleNewPatternMatcher() {
 	// important.log matches: false
 }
 
+var buildOutputs = dotignore.MustNewPatternMatcher([]string{"/bin/", "*.o", "*.test"})
+
+func ExampleMustNewPatternMatcher() {
+	for _, file := range []string{"main.go", "bin/tool", "internal/cache.o", "dotignore.test"} {
+		ignored, err := buildOutputs.Matches(file)
+		if err != nil {
+			log.Fatalf("Error matching file: %v", err)
+		}
+		fmt.Printf("%s ignored: %v\n", file, ignored)
+	}
+	// Output:
+	// main.go ignored: false
+	// bin/tool ignored: true
+	// internal/cache.o ignored: true
+	// dotignore.test ignored: true
+}
+
 func ExamplePatternMatcher_Matches() {
 	patterns := []string{"*.txt", "reports/"}
 	matcher, err := dotignore.NewPatternMatcher(patterns)
@@ -234,3 +251,29 @@ func ExamplePatternMatcher_Matches_negation() {
 	// build/docs/api.md      matches: false
 	// build/dist/bundle.js   matches: true
 }
+
+func ExamplePatternMatcher_MatchWithDetail() {
+	// Layer a parent and a child ignore file: the child overrides the parent
+	// only where one of its patterns decides
+	parent := dotignore.MustNewPatternMatcher([]string{"*.log"})
+	child := dotignore.MustNewPatternMatcher([]string{"!audit.log", "tmp/"})
+
+	for _, file := range []string{"debug.log", "audit.log", "tmp/cache", "main.go"} {
+		ignored := false
+		for _, matcher := range []*dotignore.PatternMatcher{parent, child} {
+			result, decided, err := matcher.MatchWithDetail(file)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if decided {
+				ignored = result
+			}
+		}
+		fmt.Printf("%-10s ignored: %v\n", file, ignored)
+	}
+	// Output:
+	// debug.log  ignored: true
+	// audit.log  ignored: false
+	// tmp/cache  ignored: true
+	// main.go    ignored: false
+}
@@ -0,0 +1,45 @@
+package dotignore
+
+// Filter returns the paths that are not ignored, in their original order.
+// Large batches are matched concurrently, as with MatchesParallel.
+func (p *PatternMatcher) Filter(paths []string) ([]string, error) {
+	kept, _, err := partitionPaths(paths, p.Matches)
+	return kept, err
+}
+
+// Partition splits paths into those that are kept and those that are
+// ignored, preserving their order. Large batches are matched concurrently,
+// as with MatchesParallel.
+func (p *PatternMatcher) Partition(paths []string) (kept, ignored []string, err error) {
+	return partitionPaths(paths, p.Matches)
+}
+
+// Filter returns the paths that are not ignored, in their original order.
+// See PatternMatcher.Filter.
+func (rm *RepositoryMatcher) Filter(paths []string) ([]string, error) {
+	kept, _, err := partitionPaths(paths, rm.Matches)
+	return kept, err
+}
+
+// Partition splits paths into those that are kept and those that are
+// ignored, preserving their order. See PatternMatcher.Partition.
+func (rm *RepositoryMatcher) Partition(paths []string) (kept, ignored []string, err error) {
+	return partitionPaths(paths, rm.Matches)
+}
+
+// partitionPaths matches paths in a batch and splits them by the result.
+func partitionPaths(paths []string, match func(string) (bool, error)) (kept, ignored []string, err error) {
+	results, err := matchParallel(paths, 0, match)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, path := range paths {
+		if results[i] {
+			ignored = append(ignored, path)
+		} else {
+			kept = append(kept, path)
+		}
+	}
+	return kept, ignored, nil
+}
@@ -0,0 +1,94 @@
+package dotignore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFilterAndPartition(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log", "!important.log", "/build/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	paths := []string{"main.go", "debug.log", "build/out.bin", "important.log", "src/build/x.go", "src/app.log"}
+	wantKept := []string{"main.go", "important.log", "src/build/x.go"}
+	wantIgnored := []string{"debug.log", "build/out.bin", "src/app.log"}
+
+	kept, err := matcher.Filter(paths)
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+	if !reflect.DeepEqual(kept, wantKept) {
+		t.Errorf("Filter() = %q, want %q", kept, wantKept)
+	}
+
+	kept, ignored, err := matcher.Partition(paths)
+	if err != nil {
+		t.Fatalf("Partition failed: %v", err)
+	}
+	if !reflect.DeepEqual(kept, wantKept) || !reflect.DeepEqual(ignored, wantIgnored) {
+		t.Errorf("Partition() = %q, %q, want %q, %q", kept, ignored, wantKept, wantIgnored)
+	}
+
+	// Large batches keep their order
+	var many []string
+	for i := 0; i < 500; i++ {
+		many = append(many, fmt.Sprintf("pkg%d/main.go", i), fmt.Sprintf("pkg%d/run.log", i))
+	}
+	kept, ignored, err = matcher.Partition(many)
+	if err != nil {
+		t.Fatalf("Partition failed: %v", err)
+	}
+	for i := range kept {
+		if kept[i] != many[2*i] || ignored[i] != many[2*i+1] {
+			t.Fatalf("Partition() out of order at %d: %q, %q", i, kept[i], ignored[i])
+		}
+	}
+
+	if kept, err := matcher.Filter(nil); err != nil || len(kept) != 0 {
+		t.Errorf("Filter(nil) = %q, %v, want no paths", kept, err)
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log"}, WithBasePath("/srv/app"))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if _, err := matcher.Filter([]string{"/srv/app/a.log", "/etc/passwd"}); err == nil {
+		t.Error("Filter succeeded for a path outside the base path")
+	}
+}
+
+func TestRepositoryMatcher_FilterAndPartition(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":     "*.log\n",
+		"app/.gitignore": "!keep.log\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	paths := []string{
+		filepath.Join(tmpDir, "debug.log"),
+		filepath.Join(tmpDir, "app", "keep.log"),
+		filepath.Join(tmpDir, "app", "main.go"),
+	}
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository matcher: %v", err)
+	}
+
+	kept, ignored, err := matcher.Partition(paths)
+	if err != nil {
+		t.Fatalf("Partition failed: %v", err)
+	}
+	if !reflect.DeepEqual(kept, paths[1:]) || !reflect.DeepEqual(ignored, paths[:1]) {
+		t.Errorf("Partition() = %q, %q", kept, ignored)
+	}
+	if filtered, err := matcher.Filter(paths); err != nil || !reflect.DeepEqual(filtered, paths[1:]) {
+		t.Errorf("Filter() = %q, %v, want %q", filtered, err, paths[1:])
+	}
+}
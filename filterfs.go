@@ -0,0 +1,234 @@
+package dotignore
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// FilterFS returns a file system that serves the files of fsys that matcher
+// does not ignore. Ignored files, and everything beneath an ignored
+// directory, do not exist in it: opening them fails with fs.ErrNotExist and
+// directory listings leave them out. This keeps files such as ".env" or
+// "*.key" from being served even when they are present in fsys.
+//
+// Paths are passed to matcher as they are named in fsys, slash-separated and
+// relative to its root, with a trailing slash for the directories that are
+// known to be directories: those listed by ReadDir, and the parents of a
+// path. As with WriteTar, a negation pattern cannot bring
+// back a file beneath an ignored directory. Errors from matcher are returned
+// by the operation that caused them.
+func FilterFS(fsys fs.FS, matcher Matcher) fs.FS {
+	return filterFS{fsys: fsys, matcher: matcher}
+}
+
+// FilterHTTPFileSystem is like FilterFS for an http.FileSystem, such as an
+// http.Dir, so that an http.FileServer answers requests for ignored files
+// with 404 Not Found:
+//
+//	http.Handle("/", http.FileServer(dotignore.FilterHTTPFileSystem(http.Dir("./public"), matcher)))
+func FilterHTTPFileSystem(fsys http.FileSystem, matcher Matcher) http.FileSystem {
+	return filterHTTPFileSystem{fsys: fsys, matcher: matcher}
+}
+
+// hiddenPath reports whether the slash-separated name, relative to the root
+// of a filtered file system, is ignored by matcher or lies beneath an
+// ignored directory.
+func hiddenPath(matcher Matcher, name string) (bool, error) {
+	if matcher == nil {
+		return false, errors.New("matcher cannot be nil")
+	}
+	if name == "." {
+		return false, nil
+	}
+	for i := 1; i <= len(name); i++ {
+		if i < len(name) && name[i] != '/' {
+			continue
+		}
+		// Keep the slash after a parent as a hint that it is a directory
+		end := i
+		if i < len(name) {
+			end++
+		}
+		ignored, err := matcher.Matches(name[:end])
+		if err != nil || ignored {
+			return ignored, err
+		}
+	}
+	return false, nil
+}
+
+// filterFS implements FilterFS.
+type filterFS struct {
+	fsys    fs.FS
+	matcher Matcher
+}
+
+// check returns the error for op on name if name is invalid or hidden.
+func (f filterFS) check(op, name string) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	hidden, err := hiddenPath(f.matcher, name)
+	if err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if hidden {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return nil
+}
+
+func (f filterFS) Open(name string) (fs.File, error) {
+	if err := f.check("open", name); err != nil {
+		return nil, err
+	}
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only directories are wrapped, so that files keep methods such as Seek
+	dir, ok := file.(fs.ReadDirFile)
+	if !ok {
+		return file, nil
+	}
+	info, err := file.Stat()
+	if err != nil || !info.IsDir() {
+		return file, nil
+	}
+	return &filterDir{ReadDirFile: dir, matcher: f.matcher, name: name}, nil
+}
+
+func (f filterFS) Stat(name string) (fs.FileInfo, error) {
+	if err := f.check("stat", name); err != nil {
+		return nil, err
+	}
+	return fs.Stat(f.fsys, name)
+}
+
+func (f filterFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := f.check("readdir", name); err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		ignored, err := f.matcher.Matches(entryPath(name, entry.Name(), entry.IsDir()))
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+		}
+		if !ignored {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+// filterDir is an open directory of a filterFS.
+type filterDir struct {
+	fs.ReadDirFile
+	matcher Matcher
+	name    string
+}
+
+func (d *filterDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	var kept []fs.DirEntry
+	for {
+		entries, err := d.ReadDirFile.ReadDir(n)
+		for _, entry := range entries {
+			ignored, matchErr := d.matcher.Matches(entryPath(d.name, entry.Name(), entry.IsDir()))
+			if matchErr != nil {
+				return kept, &fs.PathError{Op: "readdir", Path: d.name, Err: matchErr}
+			}
+			if !ignored {
+				kept = append(kept, entry)
+			}
+		}
+		// With n > 0, read on until something is kept
+		if n <= 0 || len(kept) > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+// filterHTTPFileSystem implements FilterHTTPFileSystem.
+type filterHTTPFileSystem struct {
+	fsys    http.FileSystem
+	matcher Matcher
+}
+
+func (f filterHTTPFileSystem) Open(name string) (http.File, error) {
+	relPath := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if relPath == "" {
+		relPath = "."
+	}
+	hidden, err := hiddenPath(f.matcher, relPath)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if hidden {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil || !info.IsDir() {
+		return file, nil
+	}
+	return &filterHTTPDir{File: file, matcher: f.matcher, name: relPath}, nil
+}
+
+// filterHTTPDir is an open directory of a filterHTTPFileSystem.
+type filterHTTPDir struct {
+	http.File
+	matcher Matcher
+	name    string
+}
+
+func (d *filterHTTPDir) Readdir(count int) ([]fs.FileInfo, error) {
+	var kept []fs.FileInfo
+	for {
+		infos, err := d.File.Readdir(count)
+		for _, info := range infos {
+			ignored, matchErr := d.matcher.Matches(entryPath(d.name, info.Name(), info.IsDir()))
+			if matchErr != nil {
+				return kept, &fs.PathError{Op: "readdir", Path: d.name, Err: matchErr}
+			}
+			if !ignored {
+				kept = append(kept, info)
+			}
+		}
+		// With count > 0, read on until something is kept
+		if count <= 0 || len(kept) > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+// entryPath returns the path of the entry name of the slash-separated dir
+// to pass to a matcher, ending in "/" if the entry is a directory.
+func entryPath(dir, name string, isDir bool) string {
+	if isDir {
+		return childPath(dir, name) + "/"
+	}
+	return childPath(dir, name)
+}
+
+// childPath returns the path of the entry name of the slash-separated dir,
+// which is "." for the root.
+func childPath(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return dir + "/" + name
+}
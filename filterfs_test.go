@@ -0,0 +1,145 @@
+package dotignore
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newSecretsFS() fstest.MapFS {
+	return fstest.MapFS{
+		".env":                &fstest.MapFile{Data: []byte("TOKEN=secret")},
+		"index.html":          &fstest.MapFile{Data: []byte("<h1>hello</h1>")},
+		"certs/server.key":    &fstest.MapFile{Data: []byte("key")},
+		"certs/server.pem":    &fstest.MapFile{Data: []byte("pem")},
+		"private/notes.txt":   &fstest.MapFile{Data: []byte("notes")},
+		"private/public.txt":  &fstest.MapFile{Data: []byte("public")},
+		"static/app.js":       &fstest.MapFile{Data: []byte("app")},
+		"static/debug.log":    &fstest.MapFile{Data: []byte("log")},
+		"static/keep.log":     &fstest.MapFile{Data: []byte("log")},
+		"static/img/logo.png": &fstest.MapFile{Data: []byte("png")},
+	}
+}
+
+func TestFilterFS(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{".env", "*.key", "private/", "!private/public.txt", "*.log", "!keep.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	fsys := FilterFS(newSecretsFS(), matcher)
+
+	// The negation cannot bring back a file beneath an ignored directory
+	visible := []string{"certs/server.pem", "index.html", "static/app.js", "static/img/logo.png", "static/keep.log"}
+	if err := fstest.TestFS(fsys, visible...); err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WalkDir() failed: %v", err)
+	}
+	if !reflect.DeepEqual(files, visible) {
+		t.Errorf("WalkDir() found %v, want %v", files, visible)
+	}
+
+	for _, name := range []string{".env", "certs/server.key", "private", "private/public.txt", "static/debug.log"} {
+		if _, err := fs.ReadFile(fsys, name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("ReadFile(%q) error = %v, want fs.ErrNotExist", name, err)
+		}
+		if _, err := fs.Stat(fsys, name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Stat(%q) error = %v, want fs.ErrNotExist", name, err)
+		}
+	}
+
+	// Reading one entry at a time skips the ignored ones
+	dir, err := fsys.Open("static")
+	if err != nil {
+		t.Fatalf("Open(static) failed: %v", err)
+	}
+	defer dir.Close()
+	var names []string
+	for {
+		entries, err := dir.(fs.ReadDirFile).ReadDir(1)
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadDir(1) failed: %v", err)
+		}
+	}
+	if expected := []string{"app.js", "img", "keep.log"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("ReadDir(1) returned %v, want %v", names, expected)
+	}
+
+	if _, err := FilterFS(newSecretsFS(), nil).Open("index.html"); err == nil {
+		t.Error("Open() with a nil matcher should fail")
+	}
+
+	// Directories are passed with a trailing slash, which strict directory
+	// patterns need to match them
+	strict, err := NewPatternMatcher([]string{"img/", "server.pem/"}, WithStrictDirectoryPatterns())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	fsys = FilterFS(newSecretsFS(), strict)
+	entries, err := fs.ReadDir(fsys, "static")
+	if err != nil || len(entries) != 3 {
+		t.Errorf("ReadDir(static) = %v, %v, want the entries without img", entries, err)
+	}
+	if _, err := fs.ReadFile(fsys, "static/img/logo.png"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadFile(static/img/logo.png) error = %v, want fs.ErrNotExist", err)
+	}
+	if _, err := fs.ReadFile(fsys, "certs/server.pem"); err != nil {
+		t.Errorf("ReadFile(certs/server.pem) failed: %v", err)
+	}
+}
+
+func TestFilterHTTPFileSystem(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{".env", "*.key", "private/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	server := http.FileServer(FilterHTTPFileSystem(http.FS(newSecretsFS()), matcher))
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/", http.StatusOK},
+		{"/static/app.js", http.StatusOK},
+		{"/.env", http.StatusNotFound},
+		{"/certs/server.key", http.StatusNotFound},
+		{"/private/notes.txt", http.StatusNotFound},
+		{"/private/", http.StatusNotFound},
+		{"/static/../.env", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if recorder.Code != tt.status {
+			t.Errorf("GET %s = %d, want %d", tt.path, recorder.Code, tt.status)
+		}
+	}
+
+	// Directory listings leave ignored entries out
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/certs/", nil))
+	if body := recorder.Body.String(); !strings.Contains(body, "server.pem") || strings.Contains(body, "server.key") {
+		t.Errorf("listing of /certs/ = %q, want server.pem only", body)
+	}
+}
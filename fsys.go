@@ -0,0 +1,120 @@
+package dotignore
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileSystem gives a RepositoryMatcher access to the files of a repository.
+// Names are operating system paths, as used by the os package.
+type fileSystem interface {
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	WalkDir(root string, fn fs.WalkDirFunc) error
+
+	// loadMatcher reads the ignore file called name.
+	loadMatcher(name string, opts []Option) (*PatternMatcher, error)
+}
+
+// newFileSystem returns the file system described by config for a
+// repository rooted at the absolute path root.
+func newFileSystem(config *RepositoryConfig, root string) fileSystem {
+	if config.FS == nil {
+		return osFileSystem{}
+	}
+	return ioFileSystem{fsys: config.FS, root: root}
+}
+
+// osFileSystem is the file system of the operating system.
+type osFileSystem struct{}
+
+func (osFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFileSystem) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (osFileSystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFileSystem) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(root, fn)
+}
+
+func (osFileSystem) loadMatcher(name string, opts []Option) (*PatternMatcher, error) {
+	return NewPatternMatcherFromFile(name, opts...)
+}
+
+// ioFileSystem serves the files of an fs.FS as if it were mounted at root.
+// Paths outside root do not exist. fs.FS has no notion of symbolic links, so
+// Lstat is the same as Stat.
+type ioFileSystem struct {
+	fsys fs.FS
+	root string
+}
+
+// name converts the operating system path name into a name in fsys.
+func (f ioFileSystem) name(op, name string) (string, error) {
+	rel, err := filepath.Rel(f.root, name)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func (f ioFileSystem) Stat(name string) (fs.FileInfo, error) {
+	fsName, err := f.name("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(f.fsys, fsName)
+}
+
+func (f ioFileSystem) Lstat(name string) (fs.FileInfo, error) {
+	return f.Stat(name)
+}
+
+func (f ioFileSystem) ReadFile(name string) ([]byte, error) {
+	fsName, err := f.name("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(f.fsys, fsName)
+}
+
+func (f ioFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	fsName, err := f.name("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(f.fsys, fsName)
+}
+
+func (f ioFileSystem) WalkDir(root string, fn fs.WalkDirFunc) error {
+	fsRoot, err := f.name("lstat", root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+	return fs.WalkDir(f.fsys, fsRoot, func(path string, d fs.DirEntry, err error) error {
+		return fn(filepath.Join(f.root, filepath.FromSlash(path)), d, err)
+	})
+}
+
+func (f ioFileSystem) loadMatcher(name string, opts []Option) (*PatternMatcher, error) {
+	fsName, err := f.name("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return NewPatternMatcherFromFS(f.fsys, fsName, opts...)
+}
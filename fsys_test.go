@@ -0,0 +1,105 @@
+package dotignore
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRepositoryMatcherWithFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":         {Data: []byte("*.log\nbuild/\n")},
+		".git/config":        {Data: []byte("[core]\n\tignoreCase = true\n")},
+		"src/.gitignore":     {Data: []byte("!keep.log\n")},
+		"src/keep.log":       {},
+		"src/app.log":        {},
+		"src/main.go":        {},
+		"build":              {Data: []byte("a file, not a directory")},
+		"web/build/out.js":   {},
+		"web/README.md":      {},
+		"web/vendor/Lib.LOG": {},
+	}
+
+	// The root directory does not exist on disk
+	root := filepath.Join(t.TempDir(), "missing")
+	config := DefaultRepositoryConfig()
+	config.FS = fsys
+	config.CheckFileTypes = true
+	config.ReadGitConfig = true
+
+	matcher, err := NewRepositoryMatcherWithConfig(root, config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	expectedFiles := []string{".gitignore", "src/.gitignore"}
+	if files := slashPaths(matcher.IgnoreFilePaths()); !reflect.DeepEqual(files, expectedFiles) {
+		t.Errorf("IgnoreFilePaths() = %v, want %v", files, expectedFiles)
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"src/app.log", true},
+		{"src/keep.log", false},
+		{filepath.Join(root, "src", "app.log"), true},
+		{"src/main.go", false},
+		{"build", false}, // a file, so build/ does not match it
+		{"web/build/out.js", true},
+		{"web/vendor/Lib.LOG", true}, // core.ignoreCase from .git/config
+	}
+	for _, tt := range tests {
+		result, err := matcher.Matches(tt.path)
+		if err != nil {
+			t.Fatalf("Matches(%q) failed: %v", tt.path, err)
+		}
+		if result != tt.expected {
+			t.Errorf("Matches(%q) = %v, want %v", tt.path, result, tt.expected)
+		}
+	}
+
+	ignored, err := matcher.ListIgnoredFiles(context.Background())
+	if err != nil {
+		t.Fatalf("ListIgnoredFiles() failed: %v", err)
+	}
+	expected := []string{"src/app.log", "web/build/out.js", "web/vendor/Lib.LOG"}
+	if !reflect.DeepEqual(slashPaths(ignored), expected) {
+		t.Errorf("ListIgnoredFiles() = %v, want %v", ignored, expected)
+	}
+
+	// Reload picks up changes made to the file system
+	fsys["web/.gitignore"] = &fstest.MapFile{Data: []byte("*.md\n")}
+	if err := matcher.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if result, _ := matcher.Matches("web/README.md"); !result {
+		t.Error("Matches(web/README.md) = false after Reload, want true")
+	}
+}
+
+func TestRepositoryMatcherWithFSErrors(t *testing.T) {
+	config := DefaultRepositoryConfig()
+	config.FS = fstest.MapFS{"file": {}}
+
+	if _, err := NewRepositoryMatcherWithConfig(t.TempDir(), config); err != nil {
+		t.Errorf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	// The root of the file system must be a directory
+	config.FS = fstest.MapFS{".": {Data: []byte("not a directory")}}
+	if _, err := NewRepositoryMatcherWithConfig(t.TempDir(), config); err == nil {
+		t.Error("NewRepositoryMatcherWithConfig() with a file as the root should fail")
+	}
+}
+
+// slashPaths converts paths to slash-separated form.
+func slashPaths(paths []string) []string {
+	converted := make([]string, len(paths))
+	for i, path := range paths {
+		converted[i] = filepath.ToSlash(path)
+	}
+	return converted
+}
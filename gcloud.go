@@ -0,0 +1,136 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// gcloudIncludeDirective starts a .gcloudignore line that includes the
+// patterns of another file, as in "#!include:.gitignore".
+const gcloudIncludeDirective = "#!include:"
+
+// includeOpener opens the file named by an include directive and returns it
+// along with the path to report for it.
+type includeOpener func(name string) (io.ReadCloser, string, error)
+
+// withIncludeDir resolves include directives relative to the directory dir.
+func withIncludeDir(dir string) Option {
+	return func(o *matcherOptions) {
+		o.openInclude = func(name string) (io.ReadCloser, string, error) {
+			filePath := filepath.FromSlash(name)
+			if !filepath.IsAbs(filePath) {
+				filePath = filepath.Join(dir, filePath)
+			}
+			file, err := os.Open(filePath)
+			return file, filePath, err
+		}
+	}
+}
+
+// withIncludeFS resolves include directives relative to the directory dir
+// of fsys.
+func withIncludeFS(fsys fs.FS, dir string) Option {
+	return func(o *matcherOptions) {
+		o.openInclude = func(name string) (io.ReadCloser, string, error) {
+			name = path.Join(dir, name)
+			file, err := fsys.Open(name)
+			return file, name, err
+		}
+	}
+}
+
+// buildGcloudPatterns parses patterns the way gcloud reads a .gcloudignore
+// file: as gitignore patterns, with "#!include:" lines replaced by the
+// patterns of the files they name.
+func buildGcloudPatterns(patterns []string, options matcherOptions) ([]ignorePattern, error) {
+	return appendGcloudPatterns(nil, patterns, options, "", nil)
+}
+
+// appendGcloudPatterns appends the patterns parsed from lines, read from a
+// file in the slash-separated directory dir relative to where include
+// directives are resolved ("" for the top-level file). including lists the
+// files whose include directives led to lines, to detect cycles.
+func appendGcloudPatterns(ignorePatterns []ignorePattern, lines []string, options matcherOptions, dir string, including []string) ([]ignorePattern, error) {
+	for i, raw := range lines {
+		if strings.HasPrefix(raw, gcloudIncludeDirective) {
+			name := strings.TrimSpace(raw[len(gcloudIncludeDirective):])
+			included, err := includeGcloudFile(name, options, dir, including)
+			if err != nil {
+				if _, ok := err.(*ParseError); !ok {
+					err = newParseError(raw, i+1, err)
+				}
+				if options.skipInvalid(err) {
+					continue
+				}
+				return nil, err
+			}
+			ignorePatterns = append(ignorePatterns, included...)
+			continue
+		}
+
+		pattern, ok, err := parseIgnorePattern(raw, i+1, options)
+		if err != nil {
+			if options.skipInvalid(err) {
+				continue
+			}
+			return nil, err
+		}
+		if ok {
+			ignorePatterns = append(ignorePatterns, pattern)
+		}
+	}
+	return ignorePatterns, nil
+}
+
+// includeGcloudFile parses the patterns of the file named by an include
+// directive of a file in dir. As in gcloud, a relative name is relative to
+// the directory of the including file.
+func includeGcloudFile(name string, options matcherOptions, dir string, including []string) ([]ignorePattern, error) {
+	if name == "" {
+		return nil, errors.New("include directive without a file name")
+	}
+	resolved := name
+	if dir != "" && !path.IsAbs(name) && !filepath.IsAbs(filepath.FromSlash(name)) {
+		resolved = path.Join(dir, name)
+	}
+
+	open := options.openInclude
+	if open == nil {
+		open = func(name string) (io.ReadCloser, string, error) {
+			file, err := os.Open(filepath.FromSlash(name))
+			return file, name, err
+		}
+	}
+	file, filePath, err := open(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to include %q: %w", name, err)
+	}
+	defer file.Close()
+
+	for _, parent := range including {
+		if parent == filePath {
+			return nil, fmt.Errorf("include cycle through %q", filePath)
+		}
+	}
+
+	lines, err := options.readLines(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to include %q: %w", name, err)
+	}
+	lines, err = options.checkPatternLengths(lines)
+	var patterns []ignorePattern
+	if err == nil {
+		patterns, err = appendGcloudPatterns(nil, lines, options, path.Dir(resolved), append(including, filePath))
+	}
+	if err != nil {
+		setParseErrorFile(err, filePath)
+		return nil, err
+	}
+	return patterns, nil
+}
@@ -0,0 +1,97 @@
+package dotignore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGcloudSyntax(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".gcloudignore":  "# deploy rules\n.gcloudignore\n#!include:.gitignore\n!keep.log\n",
+		".gitignore":     "*.log\n#!include:config/ignore\n",
+		"config/ignore":  "/secrets/\n#!include:x/more\n",
+		"config/x/more":  "*.bak\n",
+		"cycle/a":        "a.txt\n#!include:b\n",
+		"cycle/b":        "#!include:a\n",
+		"broken/ignore":  "#!include:bad\n",
+		"broken/bad":     "ok\n!\n",
+		"missing/ignore": "#!include:nothing-here\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matcher, err := NewPatternMatcherFromFile(filepath.Join(dir, ".gcloudignore"), WithSyntax(SyntaxGcloud))
+	if err != nil {
+		t.Fatalf("NewPatternMatcherFromFile failed: %v", err)
+	}
+	tests := map[string]bool{
+		".gcloudignore":    true,
+		"app.log":          true,
+		"logs/keep.log":    false,
+		"secrets/key.pem":  true,
+		"app/secrets/x":    false,
+		"old.bak":          true, // included relative to config/ignore
+		"main.py":          false,
+		"requirements.txt": false,
+	}
+	for path, expected := range tests {
+		if got, err := matcher.Matches(path); err != nil || got != expected {
+			t.Errorf("Matches(%q) = %v, %v, want %v", path, got, err, expected)
+		}
+	}
+	if got := matcher.Patterns()[2]; got.Text() != "/secrets/" || got.Line() != 1 {
+		t.Errorf("Included pattern is %q at line %d, want /secrets/ at line 1", got.Text(), got.Line())
+	}
+
+	// Without SyntaxGcloud the directive is a comment
+	plain, err := NewPatternMatcherFromFile(filepath.Join(dir, ".gcloudignore"))
+	if err != nil {
+		t.Fatalf("NewPatternMatcherFromFile failed: %v", err)
+	}
+	if ignored, _ := plain.Matches("app.log"); ignored {
+		t.Error("Include directive was followed without SyntaxGcloud")
+	}
+
+	var parseErr *ParseError
+	_, err = NewPatternMatcherFromFile(filepath.Join(dir, "cycle", "a"), WithSyntax(SyntaxGcloud))
+	if !errors.As(err, &parseErr) {
+		t.Errorf("Expected a ParseError for an include cycle, got %v", err)
+	}
+	_, err = NewPatternMatcherFromFile(filepath.Join(dir, "broken", "ignore"), WithSyntax(SyntaxGcloud))
+	if !errors.As(err, &parseErr) || parseErr.File != filepath.Join(dir, "broken", "bad") || parseErr.Line != 2 {
+		t.Errorf("Expected a ParseError at line 2 of the included file, got %v", err)
+	}
+	_, err = NewPatternMatcherFromFile(filepath.Join(dir, "missing", "ignore"), WithSyntax(SyntaxGcloud))
+	if !errors.As(err, &parseErr) || parseErr.Line != 1 || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a ParseError wrapping fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestGcloudSyntaxFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app/.gcloudignore": {Data: []byte("#!include:.gitignore\nnode_modules/\n")},
+		"app/.gitignore":    {Data: []byte("*.pyc\n")},
+	}
+
+	matcher, err := NewPatternMatcherFromFS(fsys, "app/.gcloudignore", WithSyntax(SyntaxGcloud))
+	if err != nil {
+		t.Fatalf("NewPatternMatcherFromFS failed: %v", err)
+	}
+	for path, expected := range map[string]bool{"main.pyc": true, "node_modules/x.js": true, "main.py": false} {
+		if got, err := matcher.Matches(path); err != nil || got != expected {
+			t.Errorf("Matches(%q) = %v, %v, want %v", path, got, err, expected)
+		}
+	}
+}
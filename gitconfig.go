@@ -0,0 +1,213 @@
+package dotignore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeglyph/go-dotignore/v2/internal"
+)
+
+// readGitConfig reads the user's global Git configuration and that of the
+// repository containing the root directory, in that order so that the
+// repository's settings win, and applies core.ignoreCase, unless
+// CaseSensitive is set, and core.excludesFile. Files that cannot be read or
+// parsed are skipped.
+func (rm *RepositoryMatcher) readGitConfig() {
+	config := internal.GitConfig{}
+	home, _ := os.UserHomeDir()
+	for _, path := range globalGitConfigPaths(home) {
+		rm.readGitConfigFile(config, path)
+	}
+	if gitDir, ok := findGitDir(rm.fsys, rm.rootDir); ok {
+		rm.readGitConfigFile(config, filepath.Join(gitCommonDir(rm.fsys, gitDir), "config"))
+	}
+
+	ignoreCase, _ := config.Bool("core.ignorecase")
+	rm.ignoreCase = ignoreCase && !rm.config.CaseSensitive
+
+	excludesFile, set := config["core.excludesfile"]
+	if !set {
+		// Git falls back to $XDG_CONFIG_HOME/git/ignore
+		if dir := xdgConfigHome(home); dir != "" {
+			excludesFile = filepath.Join(dir, "git", "ignore")
+		}
+	}
+	rm.excludesFile = expandHome(excludesFile, home)
+}
+
+// readGitConfigFile adds the variables of the configuration file at path to
+// config, if it exists.
+func (rm *RepositoryMatcher) readGitConfigFile(config internal.GitConfig, path string) {
+	content, err := rm.fsys.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			rm.log("skipped unreadable git config", "path", path, "error", err)
+		}
+		return
+	}
+
+	if err := config.Read(bytes.NewReader(content)); err != nil {
+		rm.log("skipped unparseable git config", "path", path, "error", err)
+		return
+	}
+	rm.log("read git config", "path", path)
+}
+
+// globalGitConfigPaths returns the global configuration files Git reads, in
+// increasing order of precedence.
+func globalGitConfigPaths(home string) []string {
+	if path := os.Getenv("GIT_CONFIG_GLOBAL"); path != "" {
+		return []string{expandHome(path, home)}
+	}
+
+	var paths []string
+	if dir := xdgConfigHome(home); dir != "" {
+		paths = append(paths, filepath.Join(dir, "git", "config"))
+	}
+	if home != "" {
+		paths = append(paths, filepath.Join(home, ".gitconfig"))
+	}
+	return paths
+}
+
+// xdgConfigHome returns $XDG_CONFIG_HOME, or ~/.config if it is not set.
+func xdgConfigHome(home string) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config")
+}
+
+// expandHome replaces a leading "~/" in path with the home directory.
+func expandHome(path, home string) string {
+	if home != "" && (path == "~" || strings.HasPrefix(path, "~/")) {
+		return filepath.Join(home, path[1:])
+	}
+	return path
+}
+
+// findGitDir returns the Git directory of the repository containing dir,
+// looking for a .git directory, or a .git file pointing to one, in dir and
+// its parents.
+func findGitDir(fsys fileSystem, dir string) (string, bool) {
+	for {
+		path := filepath.Join(dir, gitDirName)
+		if info, err := fsys.Stat(path); err == nil {
+			if info.IsDir() {
+				return path, true
+			}
+			// Worktrees and submodules use a file containing "gitdir: <path>"
+			content, err := fsys.ReadFile(path)
+			if err != nil {
+				return "", false
+			}
+			line := strings.TrimSpace(string(content))
+			if !strings.HasPrefix(line, "gitdir:") {
+				return "", false
+			}
+			gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+			if !filepath.IsAbs(gitDir) {
+				gitDir = filepath.Join(dir, gitDir)
+			}
+			return filepath.Clean(gitDir), true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// findWorkTree returns the absolute path of the top of the Git work tree
+// containing path. As in Git, GIT_WORK_TREE names the work tree directly,
+// and with only GIT_DIR set it is core.worktree of that repository, or else
+// the current directory.
+func findWorkTree(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve absolute path for %q: %w", path, err)
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to access %q: %w", path, err)
+	}
+	dir := absPath
+	if !info.IsDir() {
+		dir = filepath.Dir(absPath)
+	}
+
+	var workTree string
+	if env := os.Getenv("GIT_WORK_TREE"); env != "" {
+		workTree = env
+	} else if gitDir := os.Getenv("GIT_DIR"); gitDir != "" {
+		if workTree, err = configuredWorkTree(gitDir); err != nil {
+			return "", err
+		}
+	} else {
+		for {
+			if _, err := os.Lstat(filepath.Join(dir, gitDirName)); err == nil {
+				return dir, nil
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return "", fmt.Errorf("%w: %q", ErrNotInRepository, path)
+			}
+			dir = parent
+		}
+	}
+
+	if workTree, err = filepath.Abs(workTree); err != nil {
+		return "", fmt.Errorf("failed to resolve work tree %q: %w", workTree, err)
+	}
+	if rel, err := filepath.Rel(workTree, dir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q is outside the work tree %q", ErrNotInRepository, path, workTree)
+	}
+	return workTree, nil
+}
+
+// configuredWorkTree returns the work tree of the repository whose Git
+// directory is gitDir, for GIT_DIR without GIT_WORK_TREE: core.worktree,
+// which is relative to gitDir, or else the current directory.
+func configuredWorkTree(gitDir string) (string, error) {
+	gitDir, err := filepath.Abs(gitDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve absolute path for %q: %w", gitDir, err)
+	}
+
+	config := internal.GitConfig{}
+	if content, err := os.ReadFile(filepath.Join(gitDir, "config")); err == nil {
+		// An unparseable configuration has no usable core.worktree
+		_ = config.Read(bytes.NewReader(content))
+	}
+	if workTree := config["core.worktree"]; workTree != "" {
+		if !filepath.IsAbs(workTree) {
+			workTree = filepath.Join(gitDir, workTree)
+		}
+		return filepath.Clean(workTree), nil
+	}
+	return os.Getwd()
+}
+
+// gitCommonDir returns the directory holding the configuration shared by
+// the worktrees of the repository whose Git directory is gitDir.
+func gitCommonDir(fsys fileSystem, gitDir string) string {
+	content, err := fsys.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	commonDir := strings.TrimSpace(string(content))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+	return filepath.Clean(commonDir)
+}
@@ -0,0 +1,219 @@
+package dotignore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepositoryMatcher_ReadGitConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+	excludesFile := filepath.Join(home, "global-ignore")
+	if err := os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[core]\n\texcludesFile = ~/global-ignore\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(excludesFile, []byte("*.bak\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir := createTestRepo(t, map[string]string{
+		".git/config": "[core]\n\tignorecase = true\n",
+		".gitignore":  "*.LOG\n!keep.bak\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	tests := []struct {
+		path          string
+		expected      bool
+		withoutConfig bool
+	}{
+		{"debug.log", true, false},
+		{"debug.LOG", true, true},
+		{"old.bak", true, false},
+		{"keep.bak", false, false},
+		{"main.go", false, false},
+	}
+
+	config := DefaultRepositoryConfig()
+	config.ReadGitConfig = true
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("Failed to create repository matcher: %v", err)
+	}
+	plain, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository matcher: %v", err)
+	}
+
+	for _, tt := range tests {
+		if got, err := matcher.Matches(tt.path); err != nil || got != tt.expected {
+			t.Errorf("Matches(%q) = %v, %v, want %v", tt.path, got, err, tt.expected)
+		}
+		if got, err := plain.Matches(tt.path); err != nil || got != tt.withoutConfig {
+			t.Errorf("Without ReadGitConfig, Matches(%q) = %v, %v, want %v", tt.path, got, err, tt.withoutConfig)
+		}
+	}
+
+	result, err := matcher.MatchDetail("old.bak")
+	if err != nil {
+		t.Fatalf("MatchDetail failed: %v", err)
+	}
+	if result.Source != filepath.ToSlash(excludesFile) || result.Line != 1 {
+		t.Errorf("MatchDetail(old.bak) = %+v, want line 1 of %s", result, excludesFile)
+	}
+
+	// Reload picks up configuration changes
+	if err := os.WriteFile(filepath.Join(tmpDir, ".git", "config"), []byte("[core]\n\tignorecase = false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := matcher.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if ignored, _ := matcher.Matches("debug.log"); ignored {
+		t.Error("debug.log is still ignored after core.ignoreCase was turned off")
+	}
+}
+
+func TestRepositoryMatcher_ReadGitConfig_Defaults(t *testing.T) {
+	home := t.TempDir()
+	xdg := filepath.Join(home, "xdg")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+	if err := os.MkdirAll(filepath.Join(xdg, "git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(xdg, "git", "config"), []byte("[core]\n\tignoreCase\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(xdg, "git", "ignore"), []byte(".DS_Store\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The matcher's root is a worktree whose .git file points elsewhere
+	tmpDir := createTestRepo(t, map[string]string{
+		"main/.git/config":                 "[core]\n\tignorecase = false\n",
+		"main/.git/worktrees/wt/commondir": "../..\n",
+		"wt/.git":                          "gitdir: ../main/.git/worktrees/wt\n",
+		"wt/src/.DS_Store":                 "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	config := DefaultRepositoryConfig()
+	config.ReadGitConfig = true
+	matcher, err := NewRepositoryMatcherWithConfig(filepath.Join(tmpDir, "wt", "src"), config)
+	if err != nil {
+		t.Fatalf("Failed to create repository matcher: %v", err)
+	}
+	for path, expected := range map[string]bool{".DS_Store": true, "a/.DS_Store": true, ".ds_store": false} {
+		if got, err := matcher.Matches(path); err != nil || got != expected {
+			t.Errorf("Matches(%q) = %v, %v, want %v", path, got, err, expected)
+		}
+	}
+}
+
+func TestRepositoryMatcher_ReadGitConfig_WorkTreeRelative(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+	t.Setenv("GIT_DIR", "")
+	t.Setenv("GIT_WORK_TREE", "")
+	if err := os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[core]\n\texcludesFile = ~/global-ignore\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "global-ignore"), []byte("/sub/gen/\n/top.txt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir := createTestRepo(t, map[string]string{
+		".git/HEAD":      "ref: refs/heads/main\n",
+		"sub/gen/a.go":   "",
+		"sub/top.txt":    "",
+		"sub/src/gen.go": "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	// Anchored patterns of the excludes file are relative to the top of
+	// the work tree, not to the matcher's root
+	config := DefaultRepositoryConfig()
+	config.ReadGitConfig = true
+	matcher, err := NewRepositoryMatcherWithConfig(filepath.Join(tmpDir, "sub"), config)
+	if err != nil {
+		t.Fatalf("Failed to create repository matcher: %v", err)
+	}
+	for path, expected := range map[string]bool{"gen/a.go": true, "top.txt": false, "src/gen.go": false} {
+		if got, err := matcher.Matches(path); err != nil || got != expected {
+			t.Errorf("Matches(%q) = %v, %v, want %v", path, got, err, expected)
+		}
+	}
+}
+
+func TestNewRepositoryMatcherFromPath(t *testing.T) {
+	t.Setenv("GIT_DIR", "")
+	t.Setenv("GIT_WORK_TREE", "")
+
+	tmpDir := createTestRepo(t, map[string]string{
+		".git/HEAD":             "ref: refs/heads/main\n",
+		".gitignore":            "*.log\n",
+		"src/app/main.go":       "",
+		"src/app/.gitignore":    "*.tmp\n",
+		"other/work/README.md":  "",
+		"other/git/config":      "[core]\n\tworktree = ../work\n",
+		"other/plain/README.md": "",
+	})
+	defer os.RemoveAll(tmpDir)
+	tmpDir, err := filepath.EvalSymlinks(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The root is found from a file or directory anywhere in the work tree
+	for _, path := range []string{tmpDir, filepath.Join(tmpDir, "src", "app"), filepath.Join(tmpDir, "src", "app", "main.go")} {
+		matcher, err := NewRepositoryMatcherFromPath(path)
+		if err != nil {
+			t.Fatalf("NewRepositoryMatcherFromPath(%q) failed: %v", path, err)
+		}
+		if matcher.RootDir() != tmpDir {
+			t.Errorf("NewRepositoryMatcherFromPath(%q) is rooted at %q, want %q", path, matcher.RootDir(), tmpDir)
+		}
+		if got, _ := matcher.Matches("src/app/debug.log"); !got {
+			t.Errorf("Matches(src/app/debug.log) = false for %q, want true", path)
+		}
+	}
+
+	// GIT_WORK_TREE names the work tree directly
+	workTree := filepath.Join(tmpDir, "other", "plain")
+	t.Setenv("GIT_WORK_TREE", workTree)
+	if matcher, err := NewRepositoryMatcherFromPath(filepath.Join(workTree, "README.md")); err != nil || matcher.RootDir() != workTree {
+		t.Errorf("with GIT_WORK_TREE, got %v, %v, want a matcher rooted at %q", matcher, err, workTree)
+	}
+	if _, err := NewRepositoryMatcherFromPath(filepath.Join(tmpDir, "src")); !errors.Is(err, ErrNotInRepository) {
+		t.Errorf("path outside GIT_WORK_TREE: got %v, want ErrNotInRepository", err)
+	}
+
+	// GIT_DIR alone uses core.worktree, relative to the Git directory
+	t.Setenv("GIT_WORK_TREE", "")
+	t.Setenv("GIT_DIR", filepath.Join(tmpDir, "other", "git"))
+	workTree = filepath.Join(tmpDir, "other", "work")
+	if matcher, err := NewRepositoryMatcherFromPath(workTree); err != nil || matcher.RootDir() != workTree {
+		t.Errorf("with GIT_DIR, got %v, %v, want a matcher rooted at %q", matcher, err, workTree)
+	}
+
+	// Paths outside any work tree fail
+	t.Setenv("GIT_DIR", "")
+	outside := t.TempDir()
+	if _, err := findWorkTree(outside); err == nil {
+		t.Skipf("%s is inside a Git work tree", outside)
+	}
+	if _, err := NewRepositoryMatcherFromPath(outside); !errors.Is(err, ErrNotInRepository) {
+		t.Errorf("NewRepositoryMatcherFromPath() outside a repository: got %v, want ErrNotInRepository", err)
+	}
+	if _, err := NewRepositoryMatcherFromPath(filepath.Join(outside, "missing")); err == nil {
+		t.Error("NewRepositoryMatcherFromPath() should fail for a missing path")
+	}
+}
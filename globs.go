@@ -0,0 +1,105 @@
+package dotignore
+
+import "strings"
+
+// ToGlobs converts the patterns into "**"-style globs, such as those
+// accepted by github.com/bmatcuk/doublestar, that match the same
+// slash-separated paths relative to the root as Matches does.
+//
+// The globs are returned in evaluation order. A glob starting with "!"
+// re-includes the paths it matches, so a path is ignored when the last glob
+// that matches it does not start with "!". A pattern may produce more than
+// one glob, for example "*.log" becomes "**/*.log" and "**/*.log/**" so that
+// the contents of a matching directory are covered as well.
+//
+// Case-insensitive matchers produce lowercase globs, which the caller must
+// match against lowercased paths. For SyntaxDocker, patterns containing "**"
+// also match the contents of deeper directories that Matches only checks up
+// to the pattern's own number of components. SyntaxHelm patterns starting
+// with "!" ignore every path they do not match, which globs cannot express,
+// and are left out.
+func (p *PatternMatcher) ToGlobs() []string {
+	rules := p.rules.Load()
+
+	var globs []string
+	for _, pattern := range rules.patterns {
+		globs = p.appendGlobs(globs, pattern)
+	}
+	return globs
+}
+
+// appendGlobs appends the globs equivalent to pattern, following the same
+// cases as matchPattern.
+func (p *PatternMatcher) appendGlobs(globs []string, pattern ignorePattern) []string {
+	if pattern.inverted {
+		return globs
+	}
+	glob := escapeGlob(pattern.pattern)
+	literal := !strings.ContainsAny(pattern.pattern, "*?[")
+
+	// floating patterns match at any depth, and contents patterns match
+	// everything below a matching path as well
+	var floating, contents bool
+	switch {
+	case p.options.syntax == SyntaxHelm:
+		// Helm does not descend into ignored directories
+		floating, contents = !pattern.isRootRelative, true
+	case p.options.syntax == SyntaxDocker:
+		// Docker only checks the parent with as many components as the
+		// pattern, so with "**" this also covers some deeper paths that
+		// Matches does not
+		contents = true
+	case pattern.isRootRelative:
+		contents = literal
+	case !strings.Contains(pattern.pattern, "/"):
+		floating, contents = true, true
+	case pattern.hasWildcard:
+		floating = !p.options.noSubpathHeuristic
+	default:
+		floating, contents = literal, literal
+	}
+
+	if floating {
+		for strings.HasPrefix(glob, "**/") {
+			glob = glob[len("**/"):]
+		}
+		if glob != "**" {
+			glob = "**/" + glob
+		}
+	}
+
+	// A trailing "/**" matches the directory itself in doublestar, but
+	// only its contents in ignore patterns
+	if strings.HasSuffix(glob, "/**") {
+		glob = glob[:len(glob)-len("**")] + "*/**"
+	}
+
+	prefix := ""
+	if pattern.negate {
+		prefix = "!"
+	} else if strings.HasPrefix(glob, "!") {
+		prefix = `\`
+	}
+
+	globs = append(globs, prefix+glob)
+	if contents && !strings.HasSuffix(glob, "**") {
+		globs = append(globs, prefix+glob+"/**")
+	}
+	return globs
+}
+
+// escapeGlob escapes the characters that have a special meaning in
+// doublestar globs but are literal in ignore patterns.
+func escapeGlob(pattern string) string {
+	if !strings.ContainsAny(pattern, "{}") {
+		return pattern
+	}
+	var b strings.Builder
+	for _, r := range pattern {
+		if r == '{' || r == '}' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package dotignore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToGlobs(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		opts     []Option
+		expected []string
+	}{
+		{
+			name:     "Simple patterns",
+			patterns: []string{"*.log", "!important.log", "build/"},
+			expected: []string{"**/*.log", "**/*.log/**", "!**/important.log", "!**/important.log/**", "**/build", "**/build/**"},
+		},
+		{
+			name:     "Root-relative patterns",
+			patterns: []string{"/dist", "/build*"},
+			expected: []string{"dist", "dist/**", "build*"},
+		},
+		{
+			name:     "Patterns with a slash",
+			patterns: []string{"docs/api", "docs/*.md"},
+			expected: []string{"**/docs/api", "**/docs/api/**", "**/docs/*.md"},
+		},
+		{
+			name:     "Without subpath heuristic",
+			patterns: []string{"docs/*.md"},
+			opts:     []Option{WithoutSubpathHeuristic()},
+			expected: []string{"docs/*.md"},
+		},
+		{
+			name:     "Double star",
+			patterns: []string{"**/temp", "logs/**", "a/**/b"},
+			expected: []string{"**/temp", "**/logs/*/**", "**/a/**/b"},
+		},
+		{
+			name:     "Escaping",
+			patterns: []string{"/!bang", "{a,b}"},
+			expected: []string{`\!bang`, `\!bang/**`, `**/\{a,b\}`, `**/\{a,b\}/**`},
+		},
+		{
+			name:     "Docker syntax",
+			patterns: []string{"*.go", "!cmd"},
+			opts:     []Option{WithSyntax(SyntaxDocker)},
+			expected: []string{"*.go", "*.go/**", "!cmd", "!cmd/**"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := NewPatternMatcher(tt.patterns, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+			if globs := matcher.ToGlobs(); !reflect.DeepEqual(globs, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, globs)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package dotignore
+
+import "strings"
+
+// GoGitMatcher has the method set of the Matcher interface in go-git's
+// plumbing/format/gitignore package, which reports whether a path, split
+// into its components relative to the root of the work tree, is ignored.
+//
+// Interfaces are satisfied implicitly, so go-git's matchers can be passed
+// where a GoGitMatcher is expected, and the value returned by
+// NewGoGitMatcher can be passed to go-git, without this package depending on
+// go-git.
+type GoGitMatcher interface {
+	Match(path []string, isDir bool) bool
+}
+
+// pathMatcher is implemented by matchers that can make use of whether a path
+// is a directory.
+type pathMatcher interface {
+	MatchesPath(path string, isDir bool) (bool, error)
+}
+
+var (
+	_ pathMatcher = (*PatternMatcher)(nil)
+	_ pathMatcher = (*RepositoryMatcher)(nil)
+	_ pathMatcher = goGitMatcher{}
+)
+
+// NewGoGitMatcher adapts m to go-git's gitignore.Matcher interface, so code
+// written against go-git can use any Matcher of this package:
+//
+//	var matcher gitignore.Matcher = dotignore.NewGoGitMatcher(repo)
+//	ignored := matcher.Match([]string{"src", "app.log"}, false)
+//
+// The components of each path are joined with "/" and passed to m, along
+// with whether the path is a directory if m has a MatchesPath method, as
+// PatternMatcher and RepositoryMatcher do. go-git's interface cannot report
+// errors, so a path m fails to match is reported as not ignored.
+func NewGoGitMatcher(m Matcher) GoGitMatcher {
+	return goGitAdapter{matcher: m}
+}
+
+// goGitAdapter implements GoGitMatcher for a Matcher.
+type goGitAdapter struct {
+	matcher Matcher
+}
+
+// Match reports whether the path with the given components is ignored.
+func (a goGitAdapter) Match(path []string, isDir bool) bool {
+	file := strings.Join(path, "/")
+	var ignored bool
+	var err error
+	if m, ok := a.matcher.(pathMatcher); ok {
+		ignored, err = m.MatchesPath(file, isDir)
+	} else {
+		ignored, err = a.matcher.Matches(file)
+	}
+	return err == nil && ignored
+}
+
+// FromGoGitMatcher adapts one of go-git's gitignore matchers, such as the
+// result of gitignore.NewMatcher, to the Matcher interface, so it can be
+// used with the helpers of this package or compared with its matchers.
+//
+// Paths are relative to the root of the work tree and split at slashes or
+// backslashes. Matches treats a path ending in a slash as a directory and
+// any other as a file; the MatchesPath method of the returned matcher takes
+// the directory flag explicitly.
+func FromGoGitMatcher(m GoGitMatcher) Matcher {
+	return goGitMatcher{matcher: m}
+}
+
+// goGitMatcher implements Matcher for a GoGitMatcher.
+type goGitMatcher struct {
+	matcher GoGitMatcher
+}
+
+// Matches reports whether path is ignored, taking a trailing slash to mark a
+// directory.
+func (g goGitMatcher) Matches(path string) (bool, error) {
+	return g.MatchesPath(path, hasTrailingSlash(path))
+}
+
+// MatchesPath reports whether path is ignored, given whether it is a
+// directory.
+func (g goGitMatcher) MatchesPath(path string, isDir bool) (bool, error) {
+	_, file := splitPath(path)
+	file = strings.TrimPrefix(file, "/")
+	if file == "" || file == "." {
+		return false, nil
+	}
+	return g.matcher.Match(strings.Split(file, "/"), isDir), nil
+}
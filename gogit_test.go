@@ -0,0 +1,96 @@
+package dotignore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// goGitStub stands in for a go-git matcher: it ignores directories named
+// build and files with a .log extension.
+type goGitStub struct {
+	calls [][]string
+}
+
+func (s *goGitStub) Match(path []string, isDir bool) bool {
+	s.calls = append(s.calls, path)
+	name := path[len(path)-1]
+	return (isDir && name == "build") || (!isDir && strings.HasSuffix(name, ".log"))
+}
+
+func TestNewGoGitMatcher(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"build/", "*.log", "!keep.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	adapter := NewGoGitMatcher(matcher)
+
+	tests := []struct {
+		path     []string
+		isDir    bool
+		expected bool
+	}{
+		{[]string{"src", "app.log"}, false, true},
+		{[]string{"src", "keep.log"}, false, false},
+		{[]string{"build"}, true, true},
+		{[]string{"build"}, false, false}, // a file named build
+		{[]string{"web", "build", "out.js"}, false, true},
+		{[]string{"main.go"}, false, false},
+	}
+	for _, tt := range tests {
+		if result := adapter.Match(tt.path, tt.isDir); result != tt.expected {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, result, tt.expected)
+		}
+	}
+
+	// Matchers without MatchesPath get the joined path; errors are not ignored
+	failing := NewGoGitMatcher(MatcherFunc(func(path string) (bool, error) {
+		return true, errors.New("broken")
+	}))
+	if failing.Match([]string{"a"}, false) {
+		t.Error("Match() = true for a matcher that fails, want false")
+	}
+	var seen string
+	plain := NewGoGitMatcher(MatcherFunc(func(path string) (bool, error) {
+		seen = path
+		return true, nil
+	}))
+	if !plain.Match([]string{"a", "b.txt"}, false) || seen != "a/b.txt" {
+		t.Errorf("Match() passed %q to the matcher, want a/b.txt", seen)
+	}
+}
+
+func TestFromGoGitMatcher(t *testing.T) {
+	stub := &goGitStub{}
+	matcher := FromGoGitMatcher(stub)
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"src/app.log", true},
+		{`src\app.log`, true},
+		{"/src/app.log", true},
+		{"build/", true},
+		{"build", false}, // treated as a file
+		{"main.go", false},
+		{"", false},
+		{".", false},
+	}
+	for _, tt := range tests {
+		result, err := matcher.Matches(tt.path)
+		if err != nil {
+			t.Fatalf("Matches(%q) failed: %v", tt.path, err)
+		}
+		if result != tt.expected {
+			t.Errorf("Matches(%q) = %v, want %v", tt.path, result, tt.expected)
+		}
+	}
+
+	if result, _ := matcher.(pathMatcher).MatchesPath("web/build", true); !result {
+		t.Error("MatchesPath(web/build, true) = false, want true")
+	}
+	if last := stub.calls[len(stub.calls)-1]; len(last) != 2 || last[0] != "web" || last[1] != "build" {
+		t.Errorf("Match() received %q, want [web build]", last)
+	}
+}
@@ -0,0 +1,273 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/codeglyph/go-dotignore/v2/internal"
+)
+
+// HgIgnoreMatcher matches paths against the patterns of a Mercurial
+// .hgignore file.
+//
+// Each line is a pattern in the current syntax, which starts as regexp and
+// is changed by "syntax: glob" or "syntax: regexp" lines. A line can also
+// select its own syntax with a "glob:", "rootglob:" or "re:" prefix.
+// Glob patterns match at any directory level unless they are rootglobs, and
+// regular expressions are matched anywhere in the path unless they start
+// with "^". A pattern that matches a directory ignores everything beneath
+// it. Mercurial has no negation.
+//
+// Regular expressions use Go's RE2 syntax, which lacks the lookaround
+// assertions and backreferences of Python's. "include:" and "subinclude:"
+// lines are not supported.
+type HgIgnoreMatcher struct {
+	patterns []hgPattern
+}
+
+// hgPattern is a compiled .hgignore pattern.
+type hgPattern struct {
+	text   string
+	syntax string // "glob", "rootglob" or "regexp"
+	re     *regexp.Regexp
+}
+
+// hgSyntaxes maps the names accepted in "syntax:" lines and line prefixes
+// to the syntax they select.
+var hgSyntaxes = map[string]string{
+	"re":       "regexp",
+	"regexp":   "regexp",
+	"relre":    "regexp",
+	"glob":     "glob",
+	"relglob":  "glob",
+	"rootglob": "rootglob",
+}
+
+// hgPrefixes lists the line prefixes that select a syntax for one pattern.
+var hgPrefixes = []string{"relre:", "re:", "regexp:", "relglob:", "glob:", "rootglob:"}
+
+// NewHgIgnoreMatcher creates an HgIgnoreMatcher from the lines of an
+// .hgignore file.
+func NewHgIgnoreMatcher(lines []string) (*HgIgnoreMatcher, error) {
+	m := &HgIgnoreMatcher{}
+	syntax := "regexp"
+	for i, raw := range lines {
+		line := stripHgComment(raw)
+		line = strings.TrimRight(line, " \t\r\n\f\v")
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "syntax:") {
+			name := strings.TrimSpace(line[len("syntax:"):])
+			selected, ok := hgSyntaxes[name]
+			if !ok {
+				return nil, newParseError(raw, i+1, fmt.Errorf("unknown syntax %q", name))
+			}
+			syntax = selected
+			continue
+		}
+
+		lineSyntax, pattern := syntax, line
+		for _, prefix := range hgPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				lineSyntax, pattern = hgSyntaxes[prefix[:len(prefix)-1]], line[len(prefix):]
+				break
+			}
+		}
+		if strings.HasPrefix(line, "include:") || strings.HasPrefix(line, "subinclude:") {
+			return nil, newParseError(raw, i+1, errors.New("include directives are not supported"))
+		}
+
+		re, err := regexp.Compile(hgRegexp(lineSyntax, pattern))
+		if err != nil {
+			return nil, newParseError(raw, i+1, fmt.Errorf("malformed pattern: %w", err))
+		}
+		m.patterns = append(m.patterns, hgPattern{text: line, syntax: lineSyntax, re: re})
+	}
+	return m, nil
+}
+
+// NewHgIgnoreMatcherFromReader creates an HgIgnoreMatcher from the contents
+// of an .hgignore file.
+func NewHgIgnoreMatcherFromReader(reader io.Reader) (*HgIgnoreMatcher, error) {
+	if reader == nil {
+		return nil, errors.New("reader cannot be nil")
+	}
+
+	lines, err := internal.ReadLines(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patterns: %w", err)
+	}
+	return NewHgIgnoreMatcher(lines)
+}
+
+// NewHgIgnoreMatcherFromFile creates an HgIgnoreMatcher from the .hgignore
+// file at filePath.
+func NewHgIgnoreMatcherFromFile(filePath string) (*HgIgnoreMatcher, error) {
+	if filePath == "" {
+		return nil, errors.New("file path cannot be empty")
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %q: %w", filePath, err)
+	}
+	defer file.Close()
+
+	lines, err := internal.ReadLines(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse patterns from file %q: %w", filePath, err)
+	}
+	m, err := NewHgIgnoreMatcher(lines)
+	if err != nil {
+		setParseErrorFile(err, filePath)
+		return nil, err
+	}
+	return m, nil
+}
+
+// Matches reports whether path, relative to the repository root, is ignored
+// by any pattern, either directly or because one of its parent directories
+// is.
+func (m *HgIgnoreMatcher) Matches(path string) (bool, error) {
+	_, file := splitPath(path)
+	file = strings.TrimPrefix(file, "/")
+	if file == "" || file == "." {
+		return false, nil
+	}
+
+	for _, pattern := range m.patterns {
+		if pattern.matches(file) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Patterns returns the text of every pattern in order, without comments.
+func (m *HgIgnoreMatcher) Patterns() []string {
+	patterns := make([]string, len(m.patterns))
+	for i, pattern := range m.patterns {
+		patterns[i] = pattern.text
+	}
+	return patterns
+}
+
+// matches reports whether the pattern matches file or one of its parent
+// directories.
+func (p hgPattern) matches(file string) bool {
+	if p.re.MatchString(file) {
+		return true
+	}
+	// Globs already match the paths beneath a matching directory
+	if p.syntax != "regexp" {
+		return false
+	}
+	for i := 0; i < len(file); i++ {
+		if file[i] == '/' && p.re.MatchString(file[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripHgComment removes a comment started by an unescaped "#" and turns
+// "\#" into "#".
+func stripHgComment(line string) string {
+	if !strings.Contains(line, "#") {
+		return line
+	}
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '#':
+			b.WriteByte('#')
+			i++
+		case line[i] == '#':
+			return b.String()
+		default:
+			b.WriteByte(line[i])
+		}
+	}
+	return b.String()
+}
+
+// hgRegexp returns the regular expression Mercurial uses for a pattern of
+// the given syntax.
+func hgRegexp(syntax, pattern string) string {
+	switch syntax {
+	case "glob":
+		return `^(?:|.*/)` + hgGlobRegexp(pattern) + `(?:/|$)`
+	case "rootglob":
+		return `^` + hgGlobRegexp(pattern) + `(?:/|$)`
+	default:
+		return pattern
+	}
+}
+
+// hgGlobRegexp translates a Mercurial glob into a regular expression: "*"
+// matches within a path component, "**" across components, "?" any
+// character, "[...]" a character class and "{a,b}" either alternative.
+func hgGlobRegexp(glob string) string {
+	var b strings.Builder
+	group := 0
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString(`(?:.*/)?`)
+				} else {
+					b.WriteString(`.*`)
+				}
+			} else {
+				b.WriteString(`[^/]*`)
+			}
+		case c == '?':
+			b.WriteString(`.`)
+		case c == '[':
+			j := i + 1
+			if j < len(glob) && (glob[j] == '!' || glob[j] == ']') {
+				j++
+			}
+			for j < len(glob) && glob[j] != ']' {
+				j++
+			}
+			if j >= len(glob) {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := strings.ReplaceAll(glob[i+1:j], `\`, `\\`)
+			i = j
+			switch class[0] {
+			case '!':
+				class = "^" + class[1:]
+			case '^':
+				class = `\` + class
+			}
+			b.WriteString("[" + class + "]")
+		case c == '{':
+			group++
+			b.WriteString(`(?:`)
+		case c == '}' && group > 0:
+			group--
+			b.WriteString(`)`)
+		case c == ',' && group > 0:
+			b.WriteString(`|`)
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package dotignore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHgIgnoreMatcher(t *testing.T) {
+	input := `# default syntax is regexp
+\.orig$
+^build/
+syntax: glob
+*.pyc
+docs/_build
+rootglob:dist
+re:\.tmp\d+$
+src/**/*.{o,a}
+issue\#1.txt  # escaped hash
+`
+	matcher, err := NewHgIgnoreMatcherFromReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("NewHgIgnoreMatcherFromReader failed: %v", err)
+	}
+
+	wantPatterns := []string{`\.orig$`, `^build/`, "*.pyc", "docs/_build", "rootglob:dist", `re:\.tmp\d+$`, "src/**/*.{o,a}", "issue#1.txt"}
+	if got := matcher.Patterns(); !reflect.DeepEqual(got, wantPatterns) {
+		t.Errorf("Patterns() = %q, want %q", got, wantPatterns)
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"main.go.orig", true},
+		{"a/b/main.go.orig", true},
+		{"main.orig.go", false},
+		{"build/out", true},
+		{"src/build/out", false},
+		{"x.pyc", true},
+		{"pkg/x.pyc", true},
+		{"pkg/x.pyc/inner", true},
+		{"x.pyc.bak", false},
+		{"docs/_build/index.html", true},
+		{"sub/docs/_build", true},
+		{"dist/app", true},
+		{"pkg/dist/app", false},
+		{"cache.tmp42", true},
+		{"cache.tmp", false},
+		{"src/lib.o", true},
+		{"src/a/b/lib.a", true},
+		{"src/lib.so", false},
+		{"issue#1.txt", true},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got, err := matcher.Matches(tt.path); err != nil || got != tt.expected {
+			t.Errorf("Matches(%q) = %v, %v, want %v", tt.path, got, err, tt.expected)
+		}
+	}
+}
+
+func TestHgIgnoreMatcherErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		line  int
+	}{
+		{"unknown syntax", []string{`\.o$`, "syntax: regex"}, 2},
+		{"invalid regexp", []string{"(unclosed"}, 1},
+		{"unsupported regexp", []string{`(?=lookahead)`}, 1},
+		{"unbalanced brace", []string{"syntax: glob", "*.{o,a"}, 2},
+		{"include", []string{"include:other.hgignore"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewHgIgnoreMatcher(tt.lines)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || parseErr.Line != tt.line {
+				t.Errorf("Expected a ParseError at line %d, got %v", tt.line, err)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), ".hgignore")
+	if err := os.WriteFile(path, []byte("syntax: glob\n*.{o\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var parseErr *ParseError
+	if _, err := NewHgIgnoreMatcherFromFile(path); !errors.As(err, &parseErr) || parseErr.File != path {
+		t.Errorf("Expected a ParseError naming %s, got %v", path, err)
+	}
+	if _, err := NewHgIgnoreMatcherFromFile(""); err == nil {
+		t.Error("Expected error for empty file path")
+	}
+	if _, err := NewHgIgnoreMatcherFromReader(nil); err == nil {
+		t.Error("Expected error for nil reader")
+	}
+}
@@ -0,0 +1,81 @@
+package dotignore
+
+import (
+	"path/filepath"
+	"sync/atomic"
+)
+
+// attachHitCounters gives each pattern its own zeroed hit counter if hit
+// tracking is enabled, and removes any counters otherwise.
+func (o matcherOptions) attachHitCounters(patterns []ignorePattern) {
+	for i := range patterns {
+		if o.trackHits {
+			patterns[i].hits = new(atomic.Uint64)
+		} else {
+			patterns[i].hits = nil
+		}
+	}
+}
+
+// HitCounts returns how many matched paths each pattern has matched so far,
+// in the same order as Patterns. A pattern counts as matched even if a later
+// pattern overrides its decision. It returns nil unless the matcher was
+// created with WithHitTracking.
+func (p *PatternMatcher) HitCounts() []uint64 {
+	if !p.options.trackHits {
+		return nil
+	}
+
+	rules := p.rules.Load()
+
+	counts := make([]uint64, len(rules.patterns))
+	for i, pattern := range rules.patterns {
+		counts[i] = pattern.hits.Load()
+	}
+	return counts
+}
+
+// UnusedPatterns returns the patterns that have not matched any path so far,
+// in evaluation order. It returns nil unless the matcher was created with
+// WithHitTracking.
+func (p *PatternMatcher) UnusedPatterns() []Pattern {
+	if !p.options.trackHits {
+		return nil
+	}
+
+	rules := p.rules.Load()
+
+	var unused []Pattern
+	for _, pattern := range rules.patterns {
+		if pattern.hits.Load() == 0 {
+			unused = append(unused, pattern.export(p.options.syntax))
+		}
+	}
+	return unused
+}
+
+// UnusedPatterns returns the patterns that have not matched any path passed
+// to Matches so far, keyed by the path of their ignore file relative to the
+// repository root (as reported by IgnoreFilePaths). Files whose patterns have
+// all matched are omitted. It returns nil unless RepositoryConfig.TrackPatternHits
+// is set.
+//
+// Patterns only count as used when a queried path matches them, so walk the
+// whole tree before pruning patterns reported here.
+func (rm *RepositoryMatcher) UnusedPatterns() map[string][]Pattern {
+	if !rm.config.TrackPatternHits {
+		return nil
+	}
+
+	unused := make(map[string][]Pattern)
+	for _, files := range rm.matchers {
+		for _, file := range files {
+			patterns := file.matcher.UnusedPatterns()
+			if len(patterns) == 0 {
+				continue
+			}
+			unused[filepath.FromSlash(rm.sourcePath(file.path))] = patterns
+		}
+	}
+	return unused
+}
@@ -0,0 +1,158 @@
+package dotignore
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestHitTracking(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log", "!debug.log", "*.bak", "vendor/"}, WithHitTracking())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	for _, file := range []string{"app.log", "debug.log", "src/main.go", "vendor/lib/a.go"} {
+		if _, err := matcher.Matches(file); err != nil {
+			t.Fatalf("Error matching file %s: %v", file, err)
+		}
+	}
+
+	expectedCounts := []uint64{2, 1, 0, 1}
+	counts := matcher.HitCounts()
+	if len(counts) != len(expectedCounts) {
+		t.Fatalf("Expected %d hit counts, got %d", len(expectedCounts), len(counts))
+	}
+	for i, want := range expectedCounts {
+		if counts[i] != want {
+			t.Errorf("Pattern %d: expected %d hits, got %d", i, want, counts[i])
+		}
+	}
+
+	unused := matcher.UnusedPatterns()
+	if len(unused) != 1 || unused[0].Text() != "*.bak" || unused[0].Line() != 3 {
+		t.Errorf("Expected only *.bak at line 3 to be unused, got %+v", unused)
+	}
+
+	// Clones keep their own counters
+	clone := matcher.Clone()
+	if _, err := clone.Matches("old.bak"); err != nil {
+		t.Fatalf("Error matching file: %v", err)
+	}
+	if len(clone.UnusedPatterns()) != 0 {
+		t.Errorf("Expected no unused patterns in clone, got %+v", clone.UnusedPatterns())
+	}
+	if len(matcher.UnusedPatterns()) != 1 {
+		t.Error("Matching with a clone changed the hit counts of the original")
+	}
+}
+
+func TestHitTrackingStrictNegation(t *testing.T) {
+	patterns := []string{"tmp", "!tmp", "*.go", "build/"}
+	expectedCounts := []uint64{1, 1, 2, 1}
+
+	// Parent directories looked up for strict negation do not count
+	matcher, err := NewPatternMatcher(patterns, WithStrictNegation(), WithHitTracking())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	for _, file := range []string{"tmp/main.go", "build/gen.go"} {
+		if _, err := matcher.Matches(file); err != nil {
+			t.Fatalf("Error matching file %s: %v", file, err)
+		}
+	}
+	if counts := matcher.HitCounts(); !reflect.DeepEqual(counts, expectedCounts) {
+		t.Errorf("Expected hit counts %v, got %v", expectedCounts, counts)
+	}
+
+	matcher, err = NewPatternMatcher(patterns, WithStrictNegation(), WithHitTracking())
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	top, err := matcher.DirState("")
+	if err != nil {
+		t.Fatalf("DirState failed: %v", err)
+	}
+	for _, dir := range []string{"tmp", "build"} {
+		state, err := top.Child(dir)
+		if err != nil {
+			t.Fatalf("Child(%q) failed: %v", dir, err)
+		}
+		name := "main.go"
+		if dir == "build" {
+			name = "gen.go"
+		}
+		if _, err := state.Matches(name, false); err != nil {
+			t.Fatalf("Error matching %s in %s: %v", name, dir, err)
+		}
+	}
+	if counts := matcher.HitCounts(); !reflect.DeepEqual(counts, expectedCounts) {
+		t.Errorf("Expected DirState hit counts %v, got %v", expectedCounts, counts)
+	}
+}
+
+func TestHitTrackingDisabled(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if _, err := matcher.Matches("app.log"); err != nil {
+		t.Fatalf("Error matching file: %v", err)
+	}
+	if matcher.HitCounts() != nil {
+		t.Error("Expected nil hit counts without WithHitTracking")
+	}
+	if matcher.UnusedPatterns() != nil {
+		t.Error("Expected nil unused patterns without WithHitTracking")
+	}
+}
+
+func TestRepositoryMatcher_UnusedPatterns(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":          "*.log\n*.tmp\n",
+		"app/.gitignore":      "dist/\n!keep.log\n",
+		"lib/.gitignore":      "build/\n",
+		"app/keep.log":        "",
+		"app/dist/bundle.js":  "",
+		"lib/build/output.so": "",
+		"main.go":             "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, &RepositoryConfig{TrackPatternHits: true})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	for _, file := range []string{"app/keep.log", "app/dist/bundle.js", "lib/build/output.so", "main.go"} {
+		if _, err := matcher.Matches(file); err != nil {
+			t.Fatalf("Error matching file %s: %v", file, err)
+		}
+	}
+
+	unused := matcher.UnusedPatterns()
+	if len(unused) != 1 {
+		t.Fatalf("Expected unused patterns in 1 file, got %v", unused)
+	}
+	patterns := unused[".gitignore"]
+	if len(patterns) != 1 || patterns[0].Text() != "*.tmp" {
+		t.Errorf("Expected *.tmp to be unused in .gitignore, got %+v", patterns)
+	}
+
+	plain, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if plain.UnusedPatterns() != nil {
+		t.Error("Expected nil unused patterns without TrackPatternHits")
+	}
+
+	// Directories looked up by discovery do not count as matched
+	skipping, err := NewRepositoryMatcherWithConfig(tmpDir, &RepositoryConfig{TrackPatternHits: true, SkipIgnoredDirectories: true})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if unused := skipping.UnusedPatterns(); len(unused[".gitignore"]) != 2 || len(unused["app/.gitignore"]) != 2 {
+		t.Errorf("Expected every pattern to be unused before matching, got %v", unused)
+	}
+}
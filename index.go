@@ -0,0 +1,293 @@
+package dotignore
+
+import "strings"
+
+// maxIndexDepth limits how many leading path segments of a pattern are
+// indexed. Patterns with longer literal prefixes are stored at this depth.
+const maxIndexDepth = 8
+
+// maxCandidateLists limits how many pattern lists a candidateIterator
+// merges: those found along the trie, those found for the basenames of the
+// path and the unindexed patterns.
+const maxCandidateLists = 2*maxIndexDepth + 2
+
+// patternIndex is a trie of the literal leading path segments of anchored
+// patterns. A pattern anchored to the root can only match paths that start
+// with its literal segments, so matching only needs to evaluate the patterns
+// stored along the queried path, plus the patterns that cannot be indexed.
+//
+// Patterns without a slash are indexed apart by their basename: a literal
+// name such as "node_modules", or a literal suffix or prefix with a single
+// "*", such as "*.log" or "tmp*". Such a pattern can only match a path with
+// a component that has the same name, suffix or prefix.
+type patternIndex struct {
+	root      trieNode
+	basenames basenameIndex
+	unindexed []int // indices of patterns that must always be evaluated
+}
+
+// basenameIndex maps the literal parts of patterns without a slash to the
+// indices of the patterns, in ascending order.
+type basenameIndex struct {
+	names    map[string][]int
+	suffixes map[string][]int // "*" followed by the key
+	prefixes map[string][]int // the key followed by "*"
+	lengths  []int            // distinct lengths of the suffix and prefix keys
+	all      []int            // every indexed pattern, for paths with many components
+}
+
+type trieNode struct {
+	children map[string]*trieNode
+	patterns []int // indices of patterns whose literal prefix ends here, ascending
+}
+
+// buildPatternIndex indexes patterns written in the given syntax. It
+// returns nil if no pattern can be indexed, in which case every pattern must
+// be evaluated.
+func buildPatternIndex(patterns []ignorePattern, syntax Syntax) *patternIndex {
+	index := &patternIndex{}
+	indexed := 0
+
+	for i, pattern := range patterns {
+		// Docker and Helm match patterns without a slash against the
+		// leading components only, or invert them
+		if syntax != SyntaxDocker && syntax != SyntaxHelm && index.basenames.add(pattern, i) {
+			indexed++
+			continue
+		}
+
+		node := &index.root
+		// Inverted patterns match the paths their literal prefix rules out
+		if pattern.isRootRelative && !pattern.inverted {
+			for depth, segment := range literalSegments(pattern.pattern) {
+				if depth == maxIndexDepth {
+					break
+				}
+				child := node.children[segment]
+				if child == nil {
+					if node.children == nil {
+						node.children = make(map[string]*trieNode)
+					}
+					child = &trieNode{}
+					node.children[segment] = child
+				}
+				node = child
+			}
+		}
+
+		if node == &index.root {
+			index.unindexed = append(index.unindexed, i)
+			continue
+		}
+		node.patterns = append(node.patterns, i)
+		indexed++
+	}
+
+	if indexed == 0 {
+		return nil
+	}
+	return index
+}
+
+// literalSegments returns the leading path segments of pattern that contain
+// no wildcards, character classes or escapes.
+func literalSegments(pattern string) []string {
+	var segments []string
+	for _, segment := range strings.Split(pattern, "/") {
+		if segment == "" || strings.ContainsAny(segment, `*?[\`) {
+			break
+		}
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+// add indexes the pattern at index i by its basename, reporting false if it
+// cannot be indexed that way.
+func (b *basenameIndex) add(pattern ignorePattern, i int) bool {
+	if pattern.isRootRelative || pattern.inverted || pattern.glob == nil ||
+		strings.ContainsAny(pattern.pattern, `/?[\`) {
+		return false
+	}
+
+	var m *map[string][]int
+	key := pattern.pattern
+	switch strings.Count(key, "*") {
+	case 0:
+		m = &b.names
+	case 1:
+		if strings.HasPrefix(key, "*") {
+			m, key = &b.suffixes, key[1:]
+		} else if strings.HasSuffix(key, "*") {
+			m, key = &b.prefixes, key[:len(key)-1]
+		}
+	}
+	if m == nil || key == "" {
+		return false
+	}
+
+	if *m == nil {
+		*m = make(map[string][]int)
+	}
+	(*m)[key] = append((*m)[key], i)
+	if m != &b.names && !containsInt(b.lengths, len(key)) {
+		b.lengths = append(b.lengths, len(key))
+	}
+	b.all = append(b.all, i)
+	return true
+}
+
+// candidates adds to it the patterns that may match a path with the given
+// component, reporting false if it has no room left for them.
+func (b *basenameIndex) candidates(it *candidateIterator, component string) bool {
+	if !it.add(b.names[component]) {
+		return false
+	}
+	for _, n := range b.lengths {
+		if n > len(component) {
+			continue
+		}
+		if !it.add(b.suffixes[component[len(component)-n:]]) || !it.add(b.prefixes[component[:n]]) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsInt reports whether list contains v.
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// candidates returns an iterator over the indices of the patterns that may
+// match file, in ascending order.
+func (idx *patternIndex) candidates(file string) candidateIterator {
+	var it candidateIterator
+	it.add(idx.unindexed)
+
+	node := &idx.root
+	for rest, depth := file, 0; depth < maxIndexDepth && node.children != nil; depth++ {
+		end := strings.IndexByte(rest, '/')
+		segment := rest
+		if end >= 0 {
+			segment = rest[:end]
+		}
+
+		child := node.children[segment]
+		if child == nil {
+			break
+		}
+		it.add(child.patterns)
+		node = child
+
+		if end < 0 {
+			break
+		}
+		rest = rest[end+1:]
+	}
+
+	if len(idx.basenames.all) > 0 {
+		// Fall back to every indexed basename if the lists do not fit
+		n := it.n
+		for rest := file; ; {
+			end := strings.IndexByte(rest, '/')
+			component := rest
+			if end >= 0 {
+				component = rest[:end]
+			}
+			if !idx.basenames.candidates(&it, component) {
+				it.n = n
+				it.add(idx.basenames.all)
+				break
+			}
+			if end < 0 {
+				break
+			}
+			rest = rest[end+1:]
+		}
+	}
+
+	return it
+}
+
+// candidateIterator merges the sorted pattern lists collected from the
+// index, skipping indices found in more than one of them. It is a value type
+// with fixed capacity so that iterating does not allocate.
+type candidateIterator struct {
+	lists    [maxCandidateLists][]int
+	n        int
+	returned int // index returned last, plus one; 0 before the first
+}
+
+// add adds list to the lists to merge, reporting false if there is no room
+// for it.
+func (it *candidateIterator) add(list []int) bool {
+	if len(list) == 0 {
+		return true
+	}
+	if it.n == len(it.lists) {
+		return false
+	}
+	it.lists[it.n] = list
+	it.n++
+	return true
+}
+
+// next returns the smallest remaining index, or false when none are left.
+func (it *candidateIterator) next() (int, bool) {
+	for {
+		best := -1
+		for i := 0; i < it.n; i++ {
+			if len(it.lists[i]) > 0 && (best < 0 || it.lists[i][0] < it.lists[best][0]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return 0, false
+		}
+
+		index := it.lists[best][0]
+		it.lists[best] = it.lists[best][1:]
+		if it.take(index) {
+			return index, true
+		}
+	}
+}
+
+// prev returns the largest remaining index, or false when none are left. An
+// iterator is consumed either with next or with prev, not both.
+func (it *candidateIterator) prev() (int, bool) {
+	for {
+		best := -1
+		for i := 0; i < it.n; i++ {
+			if n := len(it.lists[i]); n > 0 && (best < 0 || it.lists[i][n-1] > it.lists[best][len(it.lists[best])-1]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return 0, false
+		}
+
+		last := len(it.lists[best]) - 1
+		index := it.lists[best][last]
+		it.lists[best] = it.lists[best][:last]
+		if it.take(index) {
+			return index, true
+		}
+	}
+}
+
+// take records index as returned, reporting false if it just was, from
+// another list. The lists are merged in order, so duplicates are adjacent.
+func (it *candidateIterator) take(index int) bool {
+	if it.returned == index+1 {
+		return false
+	}
+	it.returned = index + 1
+	return true
+}
@@ -0,0 +1,218 @@
+package dotignore
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPatternIndexMatchesFullScan(t *testing.T) {
+	patterns := []string{
+		"/vendor/",
+		"!/vendor/keep/",
+		"/third_party/lib/*.go",
+		"/third_party/lib/gen/",
+		"!/third_party/lib/gen/keep.go",
+		"/build",
+		"/*.tmp",
+		"/docs/**/draft.md",
+		"*.log",
+		"!/logs/important.log",
+		"/a/b/c/d/e/f/g/h/i/j",
+		"node_modules/",
+	}
+	paths := []string{
+		"vendor", "vendor/lib.go", "vendor/keep/lib.go", "src/vendor/lib.go",
+		"third_party/lib/a.go", "third_party/lib/sub/a.go", "third_party/lib/gen/x.go", "third_party/lib/gen/keep.go",
+		"build", "build/out", "buildx", "src/build", "a.tmp", "src/a.tmp", "docs/draft.md", "docs/x/y/draft.md",
+		"logs/important.log", "logs/other.log", "a/b/c/d/e/f/g/h/i/j", "a/b/c/d/e/f/g/h/i/k", "web/node_modules/x.js",
+	}
+
+	matcher, err := NewPatternMatcher(patterns)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	rules := matcher.rules.Load()
+	if rules.index == nil {
+		t.Fatal("Expected anchored patterns to be indexed")
+	}
+
+	for _, path := range paths {
+		indexed, indexedAny, err := matcher.MatchWithDetail(path)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", path, err)
+		}
+
+		matcher.rules.Store(&ruleSet{patterns: rules.patterns})
+		scanned, scannedAny, err := matcher.MatchWithDetail(path)
+		matcher.rules.Store(rules)
+		if err != nil {
+			t.Fatalf("Error matching file %s: %v", path, err)
+		}
+
+		if indexed != scanned || indexedAny != scannedAny {
+			t.Errorf("File %q: indexed (%v, %v), full scan (%v, %v)", path, indexed, indexedAny, scanned, scannedAny)
+		}
+	}
+}
+
+func TestBasenameIndexMatchesFullScan(t *testing.T) {
+	patterns := []string{
+		"*.log", "!keep.log", "node_modules/", "tmp*", "*.tmp", "!tmp.keep", "build",
+		"*_test.go", "*.LOG", "cache/", "!*.md", "*.o", "x*", "a?c", "[ab].txt", "*.*.bak",
+	}
+	paths := []string{
+		"app.log", "keep.log", "logs/keep.log", "web/node_modules/x.js", "node_modules", "tmpfile",
+		"src/tmp/a.go", "a.tmp", "tmp.keep", "build", "build/out", "src/build.go", "pkg/a_test.go",
+		"App.LOG", "cache/x.md", "docs/cache", "main.o", "xa/xb/xc", "abc", "a.txt", "c.txt",
+		"a.b.bak", "a/b/c/d/e/f/g/h/i/j/k/l/m/n/o/p/q/r/s/t/x.log", "main.go",
+		"xa/xb/xc/xd/xe/xf/xg/xh/xi/xj/xk/xl/xm/xn/xo/xp/xq/xr/xs/keep.log",
+	}
+
+	for _, opts := range [][]Option{nil, {WithCaseInsensitive()}, {WithGitStrict()}, {WithSyntax(SyntaxGcloud)}} {
+		matcher, err := NewPatternMatcher(patterns, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+		rules := matcher.rules.Load()
+		if rules.index == nil || len(rules.index.basenames.all) == 0 {
+			t.Fatal("Expected patterns without a slash to be indexed")
+		}
+
+		for _, path := range paths {
+			for _, isDir := range []bool{false, true} {
+				indexed, err := matcher.MatchesPath(path, isDir)
+				if err != nil {
+					t.Fatalf("Error matching file %s: %v", path, err)
+				}
+
+				matcher.rules.Store(&ruleSet{patterns: rules.patterns})
+				scanned, err := matcher.MatchesPath(path, isDir)
+				matcher.rules.Store(rules)
+				if err != nil {
+					t.Fatalf("Error matching file %s: %v", path, err)
+				}
+
+				if indexed != scanned {
+					t.Errorf("File %q (dir %v): indexed %v, full scan %v", path, isDir, indexed, scanned)
+				}
+			}
+		}
+	}
+
+	// Docker and Helm patterns without a slash are not indexed by basename
+	for _, syntax := range []Syntax{SyntaxDocker, SyntaxHelm} {
+		matcher, err := NewPatternMatcher([]string{"*.log"}, WithSyntax(syntax))
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+		if matcher.rules.Load().index != nil {
+			t.Errorf("%v patterns were indexed", syntax)
+		}
+	}
+}
+
+func TestDecideBackwards(t *testing.T) {
+	patterns := []string{
+		"*.log", "!keep.log", "/build/", "!/build/keep/", "node_modules/", "!node_modules/x/",
+		"docs/**/*.md", "!README.md", "/*.tmp", "tmp*", "*.log",
+	}
+	paths := []string{
+		"app.log", "keep.log", "build/a", "build/keep/a", "web/node_modules/x/y", "web/node_modules/z",
+		"docs/a/b.md", "docs/README.md", "a.tmp", "src/a.tmp", "tmpdir/x", "main.go",
+	}
+
+	for _, index := range []bool{true, false} {
+		matcher, err := NewPatternMatcher(patterns)
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+		rules := matcher.rules.Load()
+		if !index {
+			rules = &ruleSet{patterns: rules.patterns}
+		}
+
+		for _, path := range paths {
+			backwards, err := matcher.decide(rules, path, kindUnknown, true)
+			if err != nil {
+				t.Fatalf("decide(%q) failed: %v", path, err)
+			}
+
+			// Hit tracking applies every pattern from the first
+			matcher.options.trackHits = true
+			forwards, err := matcher.decide(rules, path, kindUnknown, true)
+			matcher.options.trackHits = false
+			if err != nil {
+				t.Fatalf("decide(%q) failed: %v", path, err)
+			}
+
+			if backwards != forwards {
+				t.Errorf("decide(%q) = %d backwards, %d forwards (index %v)", path, backwards, forwards, index)
+			}
+		}
+	}
+}
+
+func TestPatternIndexUpdatedOnMutation(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"**/*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if matcher.rules.Load().index != nil {
+		t.Error("Expected no index without indexable patterns")
+	}
+
+	if err := matcher.AddPatterns([]string{"/build/"}); err != nil {
+		t.Fatalf("Failed to add patterns: %v", err)
+	}
+	if result, _ := matcher.Matches("build/out.bin"); !result {
+		t.Error("Expected build/out.bin to match after AddPatterns")
+	}
+
+	matcher.RemovePatterns([]string{"/build/"})
+	if result, _ := matcher.Matches("build/out.bin"); result {
+		t.Error("Expected build/out.bin not to match after RemovePatterns")
+	}
+	if matcher.rules.Load().index != nil {
+		t.Error("Expected index to be dropped after removing the anchored pattern")
+	}
+}
+
+func BenchmarkMatchesAnchored(b *testing.B) {
+	var patterns []string
+	for i := 0; i < 200; i++ {
+		patterns = append(patterns, fmt.Sprintf("/third_party/pkg%d/", i))
+	}
+	patterns = append(patterns, "*.log")
+
+	matcher, err := NewPatternMatcher(patterns)
+	if err != nil {
+		b.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := matcher.Matches("src/components/app.js"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMatchesBasenames(b *testing.B) {
+	var patterns []string
+	for i := 0; i < 200; i++ {
+		patterns = append(patterns, fmt.Sprintf("*.ext%d", i))
+	}
+	patterns = append(patterns, "node_modules/", "!keep.ext7")
+
+	matcher, err := NewPatternMatcher(patterns)
+	if err != nil {
+		b.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := matcher.Matches("src/components/app.ext7"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
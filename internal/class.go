@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Class is a parsed bracket expression ("[...]") of a gitignore pattern. It
+// follows Git's wildmatch: a leading "!" or "^" negates the class, a "]"
+// right after the opening bracket (and any negation) is a member, a "-"
+// at either end is literal, a backslash escapes the next character, and
+// "[:alpha:]" and the other POSIX classes name sets of ASCII characters.
+// Like "?", a class never matches '/'.
+type Class struct {
+	negated bool
+	ranges  []runeRange // members, without '/'
+}
+
+// posixClasses holds the ASCII members of the POSIX character classes.
+var posixClasses = map[string][]runeRange{
+	"alnum":  {{'0', '9'}, {'A', 'Z'}, {'a', 'z'}},
+	"alpha":  {{'A', 'Z'}, {'a', 'z'}},
+	"blank":  {{'\t', '\t'}, {' ', ' '}},
+	"cntrl":  {{0, 0x1f}, {0x7f, 0x7f}},
+	"digit":  {{'0', '9'}},
+	"graph":  {{'!', '~'}},
+	"lower":  {{'a', 'z'}},
+	"print":  {{' ', '~'}},
+	"punct":  {{'!', '/'}, {':', '@'}, {'[', '`'}, {'{', '~'}},
+	"space":  {{'\t', '\r'}, {' ', ' '}},
+	"upper":  {{'A', 'Z'}},
+	"xdigit": {{'0', '9'}, {'A', 'F'}, {'a', 'f'}},
+}
+
+// ParseClass parses the bracket expression at the start of pattern, which
+// must begin with "[", and returns it along with the length of its text. The
+// length is 0 if the expression is not terminated, in which case the "[" is
+// a literal character. A range whose end comes before its start, such as
+// "[z-a]", and an unknown POSIX class, such as "[[:spaci:]]", are errors.
+func ParseClass(pattern string) (Class, int, error) {
+	var class Class
+	i := 1
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		class.negated = true
+		i++
+	}
+
+	prev := rune(-1) // the last single member, which may start a range
+	for first := true; ; first = false {
+		if i >= len(pattern) {
+			return Class{}, 0, nil
+		}
+		if pattern[i] == ']' && !first {
+			break
+		}
+
+		switch {
+		case pattern[i] == '\\':
+			r, size, ok := escapedRune(pattern, i)
+			if !ok {
+				return Class{}, 0, nil
+			}
+			class.add(r, r)
+			prev = r
+			i += size
+		case pattern[i] == '-' && prev >= 0 && i+1 < len(pattern) && pattern[i+1] != ']':
+			i++
+			var hi rune
+			var size int
+			if pattern[i] == '\\' {
+				var ok bool
+				if hi, size, ok = escapedRune(pattern, i); !ok {
+					return Class{}, 0, nil
+				}
+			} else {
+				hi, size = utf8.DecodeRuneInString(pattern[i:])
+			}
+			if hi < prev {
+				return Class{}, 0, fmt.Errorf("invalid character class range %q", string(prev)+"-"+string(hi))
+			}
+			class.addRange(prev, hi)
+			prev = -1
+			i += size
+		case strings.HasPrefix(pattern[i:], "[:"):
+			end := strings.IndexByte(pattern[i+2:], ']')
+			if end < 0 {
+				return Class{}, 0, nil
+			}
+			name := pattern[i+2 : i+2+end]
+			if !strings.HasSuffix(name, ":") {
+				// Not a POSIX class; the "[" is a member
+				class.add('[', '[')
+				prev = '['
+				i++
+				continue
+			}
+			ranges, ok := posixClasses[strings.TrimSuffix(name, ":")]
+			if !ok {
+				return Class{}, 0, fmt.Errorf("unknown character class %q", "[:"+name+"]")
+			}
+			for _, rr := range ranges {
+				class.add(rr.lo, rr.hi)
+			}
+			prev = -1
+			i += 2 + end + 1
+		default:
+			r, size := utf8.DecodeRuneInString(pattern[i:])
+			class.add(r, r)
+			prev = r
+			i += size
+		}
+	}
+
+	return class, i + 1, nil
+}
+
+// escapedRune decodes the character escaped by the backslash at pattern[i]
+// and returns it with the length of the escape sequence. It reports false if
+// the backslash ends the pattern.
+func escapedRune(pattern string, i int) (rune, int, bool) {
+	if i+1 >= len(pattern) {
+		return 0, 0, false
+	}
+	r, size := utf8.DecodeRuneInString(pattern[i+1:])
+	return r, 1 + size, true
+}
+
+// addRange adds the range of members starting with the single member added
+// last.
+func (c *Class) addRange(lo, hi rune) {
+	if n := len(c.ranges); n > 0 && c.ranges[n-1] == (runeRange{lo, lo}) {
+		c.ranges = c.ranges[:n-1]
+	}
+	c.add(lo, hi)
+}
+
+// add adds the members lo to hi, leaving out '/'.
+func (c *Class) add(lo, hi rune) {
+	if lo <= '/' && '/' <= hi {
+		if lo < '/' {
+			c.ranges = append(c.ranges, runeRange{lo, '/' - 1})
+		}
+		if hi > '/' {
+			c.ranges = append(c.ranges, runeRange{'/' + 1, hi})
+		}
+		return
+	}
+	c.ranges = append(c.ranges, runeRange{lo, hi})
+}
+
+// Match reports whether the class matches r.
+func (c Class) Match(r rune) bool {
+	if r == '/' {
+		return false
+	}
+	for _, rr := range c.ranges {
+		if r >= rr.lo && r <= rr.hi {
+			return !c.negated
+		}
+	}
+	return c.negated
+}
+
+// writeRegex writes a regular expression matching the same characters as
+// the class.
+func (c Class) writeRegex(sb *strings.Builder) {
+	if !c.negated && len(c.ranges) == 0 {
+		// An empty class matches nothing
+		sb.WriteString(`[^\x00-\x{10FFFF}]`)
+		return
+	}
+
+	sb.WriteByte('[')
+	if c.negated {
+		sb.WriteString("^/")
+	}
+	for _, rr := range c.ranges {
+		writeClassRune(sb, rr.lo)
+		if rr.hi != rr.lo {
+			sb.WriteByte('-')
+			writeClassRune(sb, rr.hi)
+		}
+	}
+	sb.WriteByte(']')
+}
+
+// writeClassRune writes r as a member of a regular expression class.
+func writeClassRune(sb *strings.Builder, r rune) {
+	switch {
+	case strings.ContainsRune(`\]-^[`, r):
+		sb.WriteByte('\\')
+		sb.WriteRune(r)
+	case unicode.IsPrint(r) && r != utf8.RuneError:
+		sb.WriteRune(r)
+	default:
+		fmt.Fprintf(sb, `\x{%x}`, r)
+	}
+}
@@ -0,0 +1,60 @@
+package internal
+
+import "testing"
+
+func TestParseClass(t *testing.T) {
+	tests := []struct {
+		pattern string
+		size    int
+		match   string
+		noMatch string
+	}{
+		{"[]]", 3, "]", "a["},
+		{"[]a]", 4, "]a", "b["},
+		{"[a-]", 4, "a-", "b]"},
+		{"[-a]", 4, "-a", "b]"},
+		{"[!]]", 4, "a[", "]"},
+		{"[^]-a]", 6, "b[", "]^_`a"},
+		{"[a-c-e]", 7, "abce-", "d"},
+		{`[\]]`, 4, "]", `\`},
+		{`[\!a]`, 5, "!a", `\b`},
+		{`[\1-\3]`, 7, "123", `\4`},
+		{"[[-\\]]", 6, "[\\]", "^"},
+		{"[a/b]", 5, "ab", "/"},
+		{"[!a]", 4, "b]", "a/"},
+		{"[.-0]", 5, ".0", "/"},
+		{"[[:digit:][:upper:]]", 20, "0A", "a:"},
+		{"[[:]", 4, "[:", "]"},
+		{"[ä-ö]x", 7, "äö", "a"},
+	}
+
+	for _, tt := range tests {
+		class, size, err := ParseClass(tt.pattern)
+		if err != nil || size != tt.size {
+			t.Errorf("ParseClass(%q) = size %d, %v; want %d", tt.pattern, size, err, tt.size)
+			continue
+		}
+		for _, r := range tt.match {
+			if !class.Match(r) {
+				t.Errorf("ParseClass(%q) does not match %q", tt.pattern, r)
+			}
+		}
+		for _, r := range tt.noMatch {
+			if class.Match(r) {
+				t.Errorf("ParseClass(%q) matches %q", tt.pattern, r)
+			}
+		}
+	}
+
+	for _, pattern := range []string{"[", "[]", "[!]", "[abc", `[a\]`, `[a\`, "[[:alpha:]"} {
+		if _, size, err := ParseClass(pattern); size != 0 || err != nil {
+			t.Errorf("ParseClass(%q) = size %d, %v; want an unterminated class", pattern, size, err)
+		}
+	}
+
+	for _, pattern := range []string{"[z-a]", "[[:spaci:]]", "[a[:digit:]b-a]"} {
+		if _, _, err := ParseClass(pattern); err == nil {
+			t.Errorf("ParseClass(%q) succeeded, want an error", pattern)
+		}
+	}
+}
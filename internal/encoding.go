@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// decodeReader returns a reader of the UTF-8 text of reader. Text starting
+// with a UTF-16 byte order mark is decoded from UTF-16 in that byte order,
+// without the mark; any other text is returned as is.
+func decodeReader(reader io.Reader) io.Reader {
+	buffered := bufio.NewReader(reader)
+	prefix, _ := buffered.Peek(2)
+
+	var order binary.ByteOrder
+	switch string(prefix) {
+	case string(utf16LEBOM):
+		order = binary.LittleEndian
+	case string(utf16BEBOM):
+		order = binary.BigEndian
+	default:
+		return buffered
+	}
+	_, _ = buffered.Discard(len(prefix))
+	return &utf16Reader{r: buffered, order: order, held: -1}
+}
+
+// utf16Reader decodes UTF-16 text into UTF-8. Unpaired surrogates and a
+// trailing odd byte are decoded as utf8.RuneError.
+type utf16Reader struct {
+	r       *bufio.Reader
+	order   binary.ByteOrder
+	held    int32  // code unit read ahead of a surrogate, or -1
+	pending []byte // encoded bytes not yet returned
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(u.pending) == 0 {
+			r, err := u.readRune()
+			if err != nil {
+				if n > 0 {
+					return n, nil
+				}
+				return 0, err
+			}
+			u.pending = utf8.AppendRune(u.pending[:0], r)
+		}
+		copied := copy(p[n:], u.pending)
+		u.pending = u.pending[copied:]
+		n += copied
+	}
+	return n, nil
+}
+
+// readRune decodes the next character, combining surrogate pairs.
+func (u *utf16Reader) readRune() (rune, error) {
+	first, err := u.readUnit()
+	if err != nil || !utf16.IsSurrogate(first) {
+		return first, err
+	}
+
+	second, err := u.readUnit()
+	if err != nil {
+		return utf8.RuneError, nil
+	}
+	if r := utf16.DecodeRune(first, second); r != utf8.RuneError {
+		return r, nil
+	}
+	// An unpaired surrogate; the second unit starts the next character
+	u.held = int32(second)
+	return utf8.RuneError, nil
+}
+
+// readUnit reads the next UTF-16 code unit.
+func (u *utf16Reader) readUnit() (rune, error) {
+	if u.held >= 0 {
+		unit := rune(u.held)
+		u.held = -1
+		return unit, nil
+	}
+
+	var b [2]byte
+	if _, err := io.ReadFull(u.r, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return utf8.RuneError, nil
+		}
+		return 0, err
+	}
+	return rune(u.order.Uint16(b[:])), nil
+}
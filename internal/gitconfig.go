@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GitConfig holds variables read from Git configuration files, keyed by
+// their lower-case "section.name", or "section.subsection.name" with the
+// subsection kept as written.
+type GitConfig map[string]string
+
+// Read parses a Git configuration file in the format described in
+// git-config(1) and adds its variables to c, replacing earlier values of the
+// same variables. Include directives are not followed.
+func (c GitConfig) Read(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	section := ""
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+
+		// A trailing backslash continues the value on the next line
+		for strings.HasSuffix(line, `\`) && !strings.HasSuffix(line, `\\`) && scanner.Scan() {
+			lineNum++
+			line = line[:len(line)-1] + scanner.Text()
+		}
+
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			name, rest, err := parseGitConfigSection(line)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			section = name
+			line = strings.TrimSpace(rest)
+			if line == "" || line[0] == '#' || line[0] == ';' {
+				continue
+			}
+		}
+		if section == "" {
+			return fmt.Errorf("line %d: variable outside of a section", lineNum)
+		}
+
+		name, value := line, "true" // a name without a value means true
+		if i := strings.IndexByte(line, '='); i >= 0 {
+			name = strings.TrimSpace(line[:i])
+			var err error
+			if value, err = parseGitConfigValue(line[i+1:]); err != nil {
+				return fmt.Errorf("line %d: %w", lineNum, err)
+			}
+		} else if i := strings.IndexAny(line, "#;"); i >= 0 {
+			name = strings.TrimSpace(line[:i])
+		}
+		if name == "" {
+			return fmt.Errorf("line %d: missing variable name", lineNum)
+		}
+		c[section+"."+strings.ToLower(name)] = value
+	}
+	return scanner.Err()
+}
+
+// parseGitConfigSection parses a section header such as [core],
+// [remote "origin"] or the deprecated [branch.main], and returns the
+// section name and whatever follows the closing bracket.
+func parseGitConfigSection(line string) (name, rest string, err error) {
+	end := strings.IndexByte(line, ']')
+	if quote := strings.IndexByte(line, '"'); quote >= 0 && quote < end {
+		// The subsection may contain "]" when quoted
+		closing := quote + 1
+		for closing < len(line) && line[closing] != '"' {
+			if line[closing] == '\\' {
+				closing++
+			}
+			closing++
+		}
+		end = -1
+		if closing < len(line) {
+			if i := strings.IndexByte(line[closing:], ']'); i >= 0 {
+				end = closing + i
+			}
+		}
+		if end < 0 {
+			return "", "", fmt.Errorf("malformed section header %q", line)
+		}
+		section := strings.ToLower(strings.TrimSpace(line[1:quote]))
+		subsection := strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(line[quote+1 : closing])
+		return section + "." + subsection, line[end+1:], nil
+	}
+	if end < 0 {
+		return "", "", fmt.Errorf("malformed section header %q", line)
+	}
+
+	// In [section.subsection] only the section name is case-insensitive
+	header := strings.TrimSpace(line[1:end])
+	if dot := strings.IndexByte(header, '.'); dot >= 0 {
+		return strings.ToLower(header[:dot]) + header[dot:], line[end+1:], nil
+	}
+	return strings.ToLower(header), line[end+1:], nil
+}
+
+// parseGitConfigValue unquotes a variable value and strips a trailing
+// comment.
+func parseGitConfigValue(raw string) (string, error) {
+	var b strings.Builder
+	quoted := false
+	pending := "" // whitespace kept only if more of the value follows
+	raw = strings.TrimSpace(raw)
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+		switch {
+		case ch == '"':
+			b.WriteString(pending)
+			pending = ""
+			quoted = !quoted
+		case ch == '\\':
+			if i+1 == len(raw) {
+				return "", fmt.Errorf("unterminated escape in %q", raw)
+			}
+			i++
+			b.WriteString(pending)
+			pending = ""
+			switch raw[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'b':
+				b.WriteByte('\b')
+			case '"', '\\':
+				b.WriteByte(raw[i])
+			default:
+				return "", fmt.Errorf("invalid escape %q in %q", raw[i-1:i+1], raw)
+			}
+		case !quoted && (ch == '#' || ch == ';'):
+			return b.String(), nil
+		case !quoted && (ch == ' ' || ch == '\t'):
+			pending += string(ch)
+		default:
+			b.WriteString(pending)
+			pending = ""
+			b.WriteByte(ch)
+		}
+	}
+	if quoted {
+		return "", fmt.Errorf("unterminated quote in %q", raw)
+	}
+	return b.String(), nil
+}
+
+// Bool returns the boolean value of the variable key and whether it is set
+// to a valid boolean.
+func (c GitConfig) Bool(key string) (value, ok bool) {
+	switch strings.ToLower(c[key]) {
+	case "true", "yes", "on", "1":
+		return true, true
+	case "false", "no", "off", "0", "":
+		_, set := c[key]
+		return false, set
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGitConfigRead(t *testing.T) {
+	input := `# global settings
+[core]
+	ignoreCase = true
+	excludesFile = "~/.config/git/my ignore"  ; trailing comment
+	bare
+[Remote "Origin"]
+	url = https://example.com/repo.git # comment
+[branch.Main] merge = refs/heads/main
+[alias]
+	lg = log \
+--oneline
+	say = "tab\there \"quoted\""
+`
+	config := GitConfig{}
+	if err := config.Read(strings.NewReader(input)); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	expected := GitConfig{
+		"core.ignorecase":   "true",
+		"core.excludesfile": "~/.config/git/my ignore",
+		"core.bare":         "true",
+		"remote.Origin.url": "https://example.com/repo.git",
+		"branch.Main.merge": "refs/heads/main",
+		"alias.lg":          "log --oneline",
+		"alias.say":         "tab\there \"quoted\"",
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("Read() = %q, want %q", config, expected)
+	}
+
+	// Later files override earlier ones
+	if err := config.Read(strings.NewReader("[core]\nignorecase = off\n")); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if value, ok := config.Bool("core.ignorecase"); value || !ok {
+		t.Errorf("Bool(core.ignorecase) = %v, %v, want false, true", value, ok)
+	}
+	if value, ok := config.Bool("core.bare"); !value || !ok {
+		t.Errorf("Bool(core.bare) = %v, %v, want true, true", value, ok)
+	}
+	if _, ok := config.Bool("core.missing"); ok {
+		t.Error("Bool reported an unset variable as set")
+	}
+	if _, ok := config.Bool("alias.lg"); ok {
+		t.Error("Bool reported a non-boolean value as valid")
+	}
+}
+
+func TestGitConfigReadErrors(t *testing.T) {
+	inputs := []string{
+		"name = value\n",
+		"[core\nname = value\n",
+		"[core]\nname = \"unterminated\n",
+		"[core]\nname = bad\\escape\n",
+		"[core]\n= value\n",
+	}
+	for _, input := range inputs {
+		if err := (GitConfig{}).Read(strings.NewReader(input)); err == nil {
+			t.Errorf("Read(%q) succeeded", input)
+		}
+	}
+}
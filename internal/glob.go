@@ -0,0 +1,272 @@
+package internal
+
+import (
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Glob is a compiled ignore pattern that matches paths without using the
+// regexp package. It accepts exactly the same paths as the regular expression
+// produced for the pattern by BuildRegex (or BuildDockerRegex), but is several
+// times faster for the simple patterns that make up most ignore files.
+type Glob struct {
+	kind    globKind
+	literal string
+	tokens  []globToken
+}
+
+type globKind uint8
+
+const (
+	globExact  globKind = iota // the whole pattern is literal
+	globPrefix                 // literal followed by a single *
+	globSuffix                 // a single * followed by a literal
+	globNFA                    // anything else
+)
+
+type globOp uint8
+
+const (
+	opLiteral  globOp = iota // one specific rune
+	opAnyRune                // ?: any rune except '/'
+	opStar                   // *: any run of runes except '/'
+	opAnything               // **: any run of runes except '\n'
+	opClass                  // [...]: any rune in a set
+	opOptional               // consumes nothing; the next skip tokens are optional
+)
+
+type globToken struct {
+	op    globOp
+	r     rune
+	class Class
+	skip  int // number of optional tokens following an opOptional
+}
+
+type runeRange struct {
+	lo, hi rune
+}
+
+// maxGlobTokens bounds the number of tokens so that the set of active states
+// fits into a single uint64 bitmask.
+const maxGlobTokens = 63
+
+// Size returns the approximate number of bytes of memory held by the glob.
+func (g *Glob) Size() int {
+	size := int(unsafe.Sizeof(*g)) + len(g.literal) + len(g.tokens)*int(unsafe.Sizeof(globToken{}))
+	for _, token := range g.tokens {
+		size += len(token.class.ranges) * int(unsafe.Sizeof(runeRange{}))
+	}
+	return size
+}
+
+// CompileGlob compiles a gitignore pattern into a Glob. It reports false if
+// the pattern cannot be matched by a Glob, such as one with too many
+// wildcards or a malformed class, in which case BuildRegex must be used
+// instead.
+func CompileGlob(pattern string) (*Glob, bool) {
+	return compileGlob(pattern, false)
+}
+
+// CompileDockerGlob compiles a .dockerignore pattern into a Glob with the
+// semantics of BuildDockerRegex. It reports false if BuildDockerRegex must be
+// used instead.
+func CompileDockerGlob(pattern string) (*Glob, bool) {
+	return compileGlob(pattern, true)
+}
+
+func compileGlob(pattern string, docker bool) (*Glob, bool) {
+	// The regexp package rejects invalid UTF-8, and a literal U+FFFD would
+	// match invalid bytes in paths, which byte comparison cannot reproduce
+	if pattern == "" || !utf8.ValidString(pattern) || strings.ContainsRune(pattern, utf8.RuneError) {
+		return nil, false
+	}
+
+	var tokens []globToken
+	for i := 0; i < len(pattern); {
+		switch char := pattern[i]; char {
+		case '*':
+			tokens, i = appendWildcard(tokens, pattern, i, docker)
+		case '?':
+			tokens = append(tokens, globToken{op: opAnyRune})
+			i++
+		case '[':
+			if docker {
+				return nil, false
+			}
+			class, size, err := ParseClass(pattern[i:])
+			if err != nil {
+				return nil, false
+			}
+			if size == 0 {
+				// An unterminated class is a literal '['
+				tokens = append(tokens, globToken{op: opLiteral, r: '['})
+				i++
+				continue
+			}
+			tokens = append(tokens, globToken{op: opClass, class: class})
+			i += size
+		case '\\':
+			if i+1 == len(pattern) {
+				tokens = append(tokens, globToken{op: opLiteral, r: '\\'})
+				i++
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(pattern[i+1:])
+			tokens = append(tokens, globToken{op: opLiteral, r: r})
+			i += 1 + size
+		default:
+			r, size := utf8.DecodeRuneInString(pattern[i:])
+			tokens = append(tokens, globToken{op: opLiteral, r: r})
+			i += size
+		}
+	}
+
+	if len(tokens) > maxGlobTokens {
+		return nil, false
+	}
+	return newGlob(tokens), true
+}
+
+// appendWildcard appends the token for the * or ** at position i and returns
+// the position after it, following the translation of BuildRegex or
+// BuildDockerRegex.
+func appendWildcard(tokens []globToken, pattern string, i int, docker bool) ([]globToken, int) {
+	if i+1 >= len(pattern) || pattern[i+1] != '*' {
+		return append(tokens, globToken{op: opStar}), i + 1
+	}
+	i += 2 // consume "**"
+
+	hasSlash := i < len(pattern) && pattern[i] == '/'
+	if hasSlash {
+		i++
+	}
+	if !docker {
+		if hasSlash {
+			return appendDirs(tokens), i
+		}
+		return append(tokens, globToken{op: opAnything}), i
+	}
+
+	// Docker matches any number of directories unless "**" ends the pattern
+	if i == len(pattern) {
+		return append(tokens, globToken{op: opAnything}), i
+	}
+	return appendDirs(tokens), i
+}
+
+// appendDirs appends tokens matching "(.*/)?", i.e. either nothing or any run
+// of runes except '\n' that ends in '/'.
+func appendDirs(tokens []globToken) []globToken {
+	return append(tokens,
+		globToken{op: opOptional, skip: 2},
+		globToken{op: opAnything},
+		globToken{op: opLiteral, r: '/'},
+	)
+}
+
+// newGlob selects the fastest matching strategy for tokens.
+func newGlob(tokens []globToken) *Glob {
+	stars := 0
+	var literal strings.Builder
+	for _, token := range tokens {
+		switch token.op {
+		case opLiteral:
+			literal.WriteRune(token.r)
+		case opStar:
+			stars++
+		default:
+			return &Glob{kind: globNFA, tokens: tokens}
+		}
+	}
+
+	switch {
+	case stars == 0:
+		return &Glob{kind: globExact, literal: literal.String()}
+	case stars == 1 && tokens[len(tokens)-1].op == opStar:
+		return &Glob{kind: globPrefix, literal: literal.String()}
+	case stars == 1 && tokens[0].op == opStar:
+		return &Glob{kind: globSuffix, literal: literal.String()}
+	default:
+		return &Glob{kind: globNFA, tokens: tokens}
+	}
+}
+
+// Match reports whether the glob matches the whole of s.
+func (g *Glob) Match(s string) bool {
+	switch g.kind {
+	case globExact:
+		return s == g.literal
+	case globPrefix:
+		return strings.HasPrefix(s, g.literal) && strings.IndexByte(s[len(g.literal):], '/') < 0
+	case globSuffix:
+		return strings.HasSuffix(s, g.literal) && strings.IndexByte(s[:len(s)-len(g.literal)], '/') < 0
+	default:
+		return g.matchNFA(s)
+	}
+}
+
+// matchNFA simulates the pattern as a nondeterministic automaton whose states
+// are token positions, so matching never backtracks.
+func (g *Glob) matchNFA(s string) bool {
+	final := uint64(1) << len(g.tokens)
+	states := g.closure(1)
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+
+		var next uint64
+		for j, token := range g.tokens {
+			if states&(1<<j) == 0 {
+				continue
+			}
+			switch token.op {
+			case opLiteral:
+				if r == token.r {
+					next |= 1 << (j + 1)
+				}
+			case opAnyRune:
+				if r != '/' {
+					next |= 1 << (j + 1)
+				}
+			case opStar:
+				if r != '/' {
+					next |= 1 << j
+				}
+			case opAnything:
+				if r != '\n' {
+					next |= 1 << j
+				}
+			case opClass:
+				if token.class.Match(r) {
+					next |= 1 << (j + 1)
+				}
+			}
+		}
+
+		states = g.closure(next)
+		if states == 0 {
+			return false
+		}
+	}
+
+	return states&final != 0
+}
+
+// closure adds the states reachable without consuming input, i.e. by
+// skipping wildcards that may match the empty string.
+func (g *Glob) closure(states uint64) uint64 {
+	for j, token := range g.tokens {
+		if states&(1<<j) == 0 {
+			continue
+		}
+		switch token.op {
+		case opStar, opAnything:
+			states |= 1 << (j + 1)
+		case opOptional:
+			states |= 1<<(j+1) | 1<<(j+1+token.skip)
+		}
+	}
+	return states
+}
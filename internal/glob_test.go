@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var globTestPatterns = []string{
+	"foo", "*.txt", "file?.txt", "*", "**", "**/test", "**/dir/", "a/**", "a/**/b", "src/**/test/*.js",
+	"a\\*b", "file\\", "a\\?", "file[0-9].txt", "[abc]", "[a-]x", "[-a]x", "file[incomplete", "*.{log,tmp}",
+	"file$(test).txt", "a*b*c", "*/foo", "foo/*", "***", "a**b", "**.log", "ü*", "[ä-ö]", "x?y*z",
+	"foo/**/", "**/**", "a/*/b", "[.]hidden", "*[0-9]*",
+	"[]]", "[!a-c]x", "[^a]", "a[/]b", "[a\\]]", "[\\!]*", "[[:digit:]x]", "[[:bogus:]]", "[z-a]", "[[:]",
+}
+
+var globTestPaths = []string{
+	"", "foo", "foo/", "foo/bar", "a.txt", "dir/a.txt", "file1.txt", "file12.txt", "file/.txt", "test", "dir/test",
+	"a/b/c/test", "testing", "dir/", "path/dir/", "dir/file", "a", "a/", "a/b", "a/x/b", "a/x/y/b", "ab", "a*b",
+	"aXb", "file\\", "a?", "ax", "file5.txt", "b", "-x", "ax", "x", "file[incomplete", "x.{log,tmp}", "x.log",
+	"file$(test).txt", "abc", "a/b/c", "aXbYc", "x/foo", "x/y/foo", "foo/x", "foo/x/y", "a\nb", "a/\n/b",
+	"ü", "üx", "é", "xyz", "xqyz", "x/y/z", "src/test/app.js", "src/a/b/test/app.js", ".hidden", "a1b",
+	"dir/sub.log", "\xff", "a\xffb", "foo/\xff/bar", "]", "-", "dx", "!x", "a]", "5x", "[", ":",
+}
+
+func TestGlobMatchesRegex(t *testing.T) {
+	builders := []struct {
+		name    string
+		compile func(string) (*Glob, bool)
+		regex   func(string) (*regexp.Regexp, error)
+	}{
+		{"gitignore", CompileGlob, BuildRegex},
+		{"dockerignore", CompileDockerGlob, BuildDockerRegex},
+	}
+
+	for _, builder := range builders {
+		for _, pattern := range globTestPatterns {
+			regex, err := builder.regex(pattern)
+			glob, ok := builder.compile(pattern)
+			if err != nil {
+				continue
+			}
+			if !ok {
+				t.Logf("%s: pattern %q falls back to regex", builder.name, pattern)
+				continue
+			}
+			for _, path := range globTestPaths {
+				if got, want := glob.Match(path), regex.MatchString(path); got != want {
+					t.Errorf("%s: pattern %q against %q: glob %v, regex %v", builder.name, pattern, path, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestCompileGlobFallback(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		fallback bool
+	}{
+		{"*.go", false},
+		{"[a-z]*", false},
+		{"[^a]", false},
+		{"[a\\]]", false},
+		{"[]", false},
+		{"[z-a]", true},
+		{"[[:alpha:]]", false},
+		{"[[:bogus:]]", true},
+		{strings.Repeat("a?", 32), true},
+		{"a\xffb", true},
+		{"", true},
+	}
+
+	for _, test := range tests {
+		if _, ok := CompileGlob(test.pattern); ok == test.fallback {
+			t.Errorf("CompileGlob(%q): expected fallback %v, got %v", test.pattern, test.fallback, !ok)
+		}
+	}
+}
+
+func BenchmarkGlobMatch(b *testing.B) {
+	glob, ok := CompileGlob("**/*.js")
+	if !ok {
+		b.Fatal("CompileGlob failed")
+	}
+
+	testPaths := []string{
+		"app.js",
+		"src/app.js",
+		"src/components/Header.js",
+		"build/static/js/main.js",
+		"node_modules/react/index.js",
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range testPaths {
+			glob.Match(path)
+		}
+	}
+}
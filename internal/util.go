@@ -3,37 +3,95 @@ package internal
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"regexp"
 	"strings"
 )
 
+// DefaultMaxLineLength is the longest line ReadLines accepts, in bytes.
+const DefaultMaxLineLength = 16 << 20
+
 // ReadLines reads lines from an io.Reader and strips UTF-8 BOM characters.
+// Text starting with a UTF-16LE or UTF-16BE BOM is decoded to UTF-8 first.
+// Lines may end in "\n", "\r\n" or a lone "\r", and the terminators are not
+// part of the lines. Lines longer than DefaultMaxLineLength are rejected.
 func ReadLines(reader io.Reader) ([]string, error) {
+	return ReadLinesLimit(reader, DefaultMaxLineLength)
+}
+
+// ReadLinesLimit is like ReadLines but rejects lines longer than
+// maxLineLength bytes of UTF-8, not counting the line terminator. The error wraps
+// bufio.ErrTooLong.
+func ReadLinesLimit(reader io.Reader, maxLineLength int) ([]string, error) {
 	if reader == nil {
 		return nil, fmt.Errorf("reader cannot be nil")
 	}
 
-	scanner := bufio.NewScanner(reader)
-	var lines []string
-	utf8BOM := []byte{0xEF, 0xBB, 0xBF}
+	// Leave room for a BOM and a "\r\n" terminator around the longest
+	// accepted line, without overflowing for a huge limit
+	overhead := len(utf8BOM) + 2
+	bufferSize := math.MaxInt
+	if maxLineLength < bufferSize-overhead {
+		bufferSize = maxLineLength + overhead
+	}
+	initialSize := bufio.MaxScanTokenSize
+	if bufferSize < initialSize {
+		initialSize = bufferSize
+	}
+	scanner := bufio.NewScanner(decodeReader(reader))
+	scanner.Buffer(make([]byte, 0, initialSize), bufferSize)
+	scanner.Split(scanLines)
 
-	for lineNumber := 0; scanner.Scan(); lineNumber++ {
+	var lines []string
+	lineNumber := 0
+	for ; scanner.Scan(); lineNumber++ {
 		line := scanner.Bytes()
 		if lineNumber == 0 {
 			line = bytes.TrimPrefix(line, utf8BOM)
 		}
+		if len(line) > maxLineLength {
+			return nil, fmt.Errorf("line %d is longer than %d bytes: %w", lineNumber+1, maxLineLength, bufio.ErrTooLong)
+		}
 		lines = append(lines, string(line))
 	}
 
 	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("line %d is longer than %d bytes: %w", lineNumber+1, maxLineLength, err)
+		}
 		return nil, fmt.Errorf("error reading lines: %w", err)
 	}
 
 	return lines, nil
 }
 
+// scanLines is a bufio.SplitFunc like bufio.ScanLines that also ends lines
+// at a lone "\r", as written by classic Mac OS editors.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		switch {
+		case data[i] == '\n':
+			return i + 1, data[:i], nil
+		case i+1 < len(data) && data[i+1] == '\n':
+			return i + 2, data[:i], nil
+		case i+1 < len(data) || atEOF:
+			return i + 1, data[:i], nil
+		}
+		// Wait to see whether a "\n" follows the "\r"
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
 // BuildRegex converts a gitignore-style pattern to a regular expression.
 // It properly handles wildcards, escaping, and gitignore-specific rules.
 func BuildRegex(pattern string) (*regexp.Regexp, error) {
@@ -51,7 +109,67 @@ func BuildRegex(pattern string) (*regexp.Regexp, error) {
 		case '?':
 			sb.WriteString("[^/]")
 		case '[':
-			i = writeCharClass(pattern, i, &sb)
+			class, size, err := ParseClass(pattern[i:])
+			if err != nil {
+				return nil, err
+			}
+			if size == 0 {
+				// An unterminated class is a literal '['
+				sb.WriteString("\\[")
+				continue
+			}
+			class.writeRegex(&sb)
+			i += size - 1
+		case '.', '+', '^', '$', '(', ')', '{', '}', '|':
+			sb.WriteByte('\\')
+			sb.WriteByte(char)
+		case '\\':
+			i = writeEscaped(pattern, i, &sb)
+		default:
+			sb.WriteByte(char)
+		}
+	}
+
+	sb.WriteString("$")
+
+	regex, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile regex %q: %w", sb.String(), err)
+	}
+	return regex, nil
+}
+
+// BuildDockerRegex converts a .dockerignore pattern to a regular expression
+// using the same translation as Docker's pattern matcher. Unlike gitignore,
+// "**" followed by more pattern text matches any number of directories,
+// including none, and a trailing "**" matches everything below a directory.
+func BuildDockerRegex(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern cannot be empty")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch char := pattern[i]; char {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++ // consume second '*'
+				// Docker treats "**/" as "**", so the slash is consumed
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+				}
+				if i+1 == len(pattern) {
+					sb.WriteString(".*")
+				} else {
+					sb.WriteString("(.*/)?")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
 		case '.', '+', '^', '$', '(', ')', '{', '}', '|':
 			sb.WriteByte('\\')
 			sb.WriteByte(char)
@@ -87,20 +205,6 @@ func writeWildcard(pattern string, i int, sb *strings.Builder) int {
 	return i
 }
 
-// writeCharClass writes a character class [...] and returns the new index.
-func writeCharClass(pattern string, i int, sb *strings.Builder) int {
-	j := i + 1
-	for j < len(pattern) && pattern[j] != ']' {
-		j++
-	}
-	if j < len(pattern) {
-		sb.WriteString(pattern[i : j+1])
-		return j
-	}
-	sb.WriteString("\\[")
-	return i
-}
-
 // writeEscaped writes an escaped character and returns the new index.
 func writeEscaped(pattern string, i int, sb *strings.Builder) int {
 	if i+1 < len(pattern) {
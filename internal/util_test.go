@@ -1,9 +1,16 @@
 package internal
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"unicode/utf16"
 )
 
 func TestReadLines(t *testing.T) {
@@ -52,7 +59,37 @@ func TestReadLines(t *testing.T) {
 		{
 			name:       "Mixed line endings",
 			input:      "line1\nline2\rline3\r\nline4",
-			expected:   []string{"line1", "line2\rline3", "line4"},
+			expected:   []string{"line1", "line2", "line3", "line4"},
+			shouldFail: false,
+		},
+		{
+			name:       "UTF-16LE with BOM",
+			input:      utf16Text(binary.LittleEndian, "*.log\r\nbuild/\r\n"),
+			expected:   []string{"*.log", "build/"},
+			shouldFail: false,
+		},
+		{
+			name:       "UTF-16BE with BOM",
+			input:      utf16Text(binary.BigEndian, "caf\u00e9/\n\U0001F600.txt"),
+			expected:   []string{"caf\u00e9/", "\U0001F600.txt"},
+			shouldFail: false,
+		},
+		{
+			name:       "UTF-16 with unpaired surrogate and odd length",
+			input:      utf16Text(binary.LittleEndian, "a\n") + "\x00\xD8b\x00\n\x00c",
+			expected:   []string{"a", "\uFFFDb", "\uFFFD"},
+			shouldFail: false,
+		},
+		{
+			name:       "CRLF line endings",
+			input:      "line1\r\n\r\nline2\r\n",
+			expected:   []string{"line1", "", "line2"},
+			shouldFail: false,
+		},
+		{
+			name:       "Lone CR line endings",
+			input:      "line1\rline2\r\rline3\r",
+			expected:   []string{"line1", "line2", "", "line3"},
 			shouldFail: false,
 		},
 	}
@@ -86,6 +123,25 @@ func TestReadLines(t *testing.T) {
 			}
 		})
 	}
+
+	// A "\r\n" split across reads is still a single terminator
+	lines, err := ReadLines(iotest.OneByteReader(strings.NewReader("line1\r\nline2\rline3")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"line1", "line2", "line3"}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected %q, got %q", expected, lines)
+	}
+}
+
+// utf16Text encodes s as UTF-16 in the given byte order, with a BOM.
+func utf16Text(order binary.ByteOrder, s string) string {
+	units := append([]uint16{0xFEFF}, utf16.Encode([]rune(s))...)
+	encoded := make([]byte, 2*len(units))
+	for i, unit := range units {
+		order.PutUint16(encoded[2*i:], unit)
+	}
+	return string(encoded)
 }
 
 func TestReadLinesNilReader(t *testing.T) {
@@ -100,6 +156,49 @@ func TestReadLinesNilReader(t *testing.T) {
 	}
 }
 
+func TestReadLinesLimit(t *testing.T) {
+	bom := string([]byte{0xEF, 0xBB, 0xBF})
+	tests := []struct {
+		name       string
+		input      string
+		limit      int
+		shouldFail bool
+	}{
+		{name: "Line at the limit", input: "short\n" + strings.Repeat("a", 10) + "\n", limit: 10},
+		{name: "Line at the limit with CRLF and BOM", input: bom + strings.Repeat("a", 10) + "\r\nb\r\n", limit: 10},
+		{name: "Line at the limit with lone CR", input: strings.Repeat("a", 10) + "\rb\r", limit: 10},
+		{name: "Last line at the limit", input: "short\n" + strings.Repeat("a", 10), limit: 10},
+		{name: "Line over the limit", input: "short\n" + strings.Repeat("a", 11) + "\n", limit: 10, shouldFail: true},
+		{name: "Line far over the limit", input: strings.Repeat("a", 1000), limit: 10, shouldFail: true},
+		{name: "Largest limit", input: "short\n" + strings.Repeat("a", 10), limit: math.MaxInt},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadLinesLimit(strings.NewReader(test.input), test.limit)
+			if test.shouldFail {
+				if !errors.Is(err, bufio.ErrTooLong) {
+					t.Errorf("Expected an error wrapping bufio.ErrTooLong, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	// The default limit handles lines much longer than bufio.Scanner's
+	long := strings.Repeat("x", 4<<20)
+	lines, err := ReadLines(strings.NewReader("*.log\n" + long + "\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(lines) != 2 || lines[1] != long {
+		t.Errorf("Expected the long line to be read intact")
+	}
+}
+
 func TestBuildRegex(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -301,6 +400,54 @@ func TestBuildRegexEdgeCases(t *testing.T) {
 	}
 }
 
+func TestBuildDockerRegex(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		shouldPass []string
+		shouldFail []string
+	}{
+		{
+			pattern:    "**/*.go",
+			shouldPass: []string{"main.go", "cmd/main.go", "a/b/c/main.go"},
+			shouldFail: []string{"main.go.bak", "cmd/main.gox"},
+		},
+		{
+			pattern:    "a/**/b",
+			shouldPass: []string{"a/b", "a/x/b", "a/x/y/b"},
+			shouldFail: []string{"a/xb", "b"},
+		},
+		{
+			pattern:    "dir/**",
+			shouldPass: []string{"dir/file", "dir/a/b/c"},
+			shouldFail: []string{"other/file"},
+		},
+		{
+			pattern:    "*.md",
+			shouldPass: []string{"README.md"},
+			shouldFail: []string{"docs/README.md", "READMEmd"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			regex, err := BuildDockerRegex(test.pattern)
+			if err != nil {
+				t.Fatalf("Failed to build regex for pattern %q: %v", test.pattern, err)
+			}
+			for _, input := range test.shouldPass {
+				if !regex.MatchString(input) {
+					t.Errorf("Pattern %q should match input %q, but it did not", test.pattern, input)
+				}
+			}
+			for _, input := range test.shouldFail {
+				if regex.MatchString(input) {
+					t.Errorf("Pattern %q should not match input %q, but it did", test.pattern, input)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkBuildRegex(b *testing.B) {
 	patterns := []string{
 		"*.txt",
@@ -0,0 +1,86 @@
+//go:build go1.23
+
+package dotignore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// errStopIteration stops a walk when the consumer of an iterator breaks out
+// of its loop.
+var errStopIteration = errors.New("iteration stopped")
+
+// Files returns an iterator over the files beneath root that are not
+// ignored, relative to root, in lexical order. Paths are matched relative to
+// root, and directories that CanSkipDir reports as ignored in full are not
+// read:
+//
+//	for path, err := range matcher.Files("./project") {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Println(path)
+//	}
+//
+// The walk is lazy: it advances as the loop does and stops when the loop
+// does. An error ends the iteration after being yielded with an empty path.
+func (p *PatternMatcher) Files(root string) iter.Seq2[string, error] {
+	return p.files(root, false)
+}
+
+// IgnoredFiles returns an iterator over the files beneath root that are
+// ignored, relative to root, in lexical order, as described for Files. The
+// files of a directory that CanSkipDir reports as ignored in full are listed
+// without matching each of them.
+func (p *PatternMatcher) IgnoredFiles(root string) iter.Seq2[string, error] {
+	return p.files(root, true)
+}
+
+// files implements Files and IgnoredFiles.
+func (p *PatternMatcher) files(root string, ignored bool) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		err := p.walkFiles(context.Background(), root, ignored, func(path string) error {
+			if !yield(path, nil) {
+				return errStopIteration
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopIteration) {
+			yield("", fmt.Errorf("failed to walk %q: %w", root, err))
+		}
+	}
+}
+
+// Files returns an iterator over the files of the repository that are not
+// ignored, relative to the root directory, in lexical order: the paths of
+// ListTrackedCandidates, produced lazily as the loop advances. An error,
+// including ctx.Err() once ctx is done, ends the iteration after being
+// yielded with an empty path.
+func (rm *RepositoryMatcher) Files(ctx context.Context) iter.Seq2[string, error] {
+	return rm.files(ctx, false)
+}
+
+// IgnoredFiles returns an iterator over the ignored files of the
+// repository, relative to the root directory, in lexical order: the paths of
+// ListIgnoredFiles, produced lazily as described for Files.
+func (rm *RepositoryMatcher) IgnoredFiles(ctx context.Context) iter.Seq2[string, error] {
+	return rm.files(ctx, true)
+}
+
+// files implements Files and IgnoredFiles.
+func (rm *RepositoryMatcher) files(ctx context.Context, ignored bool) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		err := rm.walkFiles(ctx, ignored, func(path string) error {
+			if !yield(path, nil) {
+				return errStopIteration
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopIteration) {
+			yield("", fmt.Errorf("failed to list files: %w", err))
+		}
+	}
+}
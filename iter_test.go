@@ -0,0 +1,126 @@
+//go:build go1.23
+
+package dotignore
+
+import (
+	"context"
+	"iter"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPatternMatcherFiles(t *testing.T) {
+	root := createTestRepo(t, map[string]string{
+		"main.go":          "",
+		"app.log":          "",
+		"build/out.bin":    "",
+		"build/sub/x.bin":  "",
+		"docs/guide.md":    "",
+		"docs/draft.md":    "",
+		"logs/keep.log":    "",
+		"logs/today.log":   "",
+		"vendor/lib/a.go":  "",
+		"vendor/lib/b.txt": "",
+	})
+	defer os.RemoveAll(root)
+
+	matcher, err := NewPatternMatcher([]string{"*.log", "!keep.log", "build/", "draft.md", "vendor/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	collect := func(seq iter.Seq2[string, error]) []string {
+		var paths []string
+		for path, err := range seq {
+			if err != nil {
+				t.Fatalf("iteration failed: %v", err)
+			}
+			paths = append(paths, filepath.ToSlash(path))
+		}
+		return paths
+	}
+
+	files := collect(matcher.Files(root))
+	expected := []string{"docs/guide.md", "logs/keep.log", "main.go"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Files() = %v, want %v", files, expected)
+	}
+
+	ignored := collect(matcher.IgnoredFiles(root))
+	expected = []string{"app.log", "build/out.bin", "build/sub/x.bin", "docs/draft.md", "logs/today.log", "vendor/lib/a.go", "vendor/lib/b.txt"}
+	if !reflect.DeepEqual(ignored, expected) {
+		t.Errorf("IgnoredFiles() = %v, want %v", ignored, expected)
+	}
+
+	// Breaking out of the loop stops the walk
+	count := 0
+	for range matcher.IgnoredFiles(root) {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	if count != 2 {
+		t.Errorf("loop ran %d times, want 2", count)
+	}
+
+	// Errors are yielded once and end the iteration
+	var errs int
+	for path, err := range matcher.Files(filepath.Join(root, "missing")) {
+		if err == nil || path != "" {
+			t.Errorf("Files() of a missing directory yielded %q, %v", path, err)
+		}
+		errs++
+	}
+	if errs != 1 {
+		t.Errorf("Files() of a missing directory yielded %d errors, want 1", errs)
+	}
+}
+
+func TestRepositoryMatcherFiles(t *testing.T) {
+	root := createTestRepo(t, map[string]string{
+		".gitignore":     "*.log\n",
+		"src/.gitignore": "!keep.log\n",
+		"src/app.log":    "",
+		"src/keep.log":   "",
+		"src/main.go":    "",
+	})
+	defer os.RemoveAll(root)
+
+	matcher, err := NewRepositoryMatcher(root)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcher() failed: %v", err)
+	}
+
+	var files, ignored []string
+	for path, err := range matcher.Files(context.Background()) {
+		if err != nil {
+			t.Fatalf("Files() failed: %v", err)
+		}
+		files = append(files, filepath.ToSlash(path))
+	}
+	for path, err := range matcher.IgnoredFiles(context.Background()) {
+		if err != nil {
+			t.Fatalf("IgnoredFiles() failed: %v", err)
+		}
+		ignored = append(ignored, filepath.ToSlash(path))
+	}
+
+	if expected := []string{".gitignore", "src/.gitignore", "src/keep.log", "src/main.go"}; !reflect.DeepEqual(files, expected) {
+		t.Errorf("Files() = %v, want %v", files, expected)
+	}
+	if expected := []string{"src/app.log"}; !reflect.DeepEqual(ignored, expected) {
+		t.Errorf("IgnoredFiles() = %v, want %v", ignored, expected)
+	}
+
+	// A cancelled context ends the iteration with its error
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, err := range matcher.Files(ctx) {
+		if err == nil {
+			t.Error("Files() with a cancelled context yielded a path")
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// IssueKind classifies a problem found in a list of patterns.
+type IssueKind int
+
+const (
+	// IssueInvalid marks a line that cannot be parsed as a pattern.
+	IssueInvalid IssueKind = iota + 1
+
+	// IssueDuplicate marks a pattern identical to an earlier one, with no
+	// pattern in between that could change the outcome.
+	IssueDuplicate
+
+	// IssueShadowed marks a pattern that never changes the outcome because
+	// every path it matches, including the paths beneath a directory it
+	// matches, is already ignored by earlier patterns.
+	IssueShadowed
+
+	// IssueNeverMatches marks a pattern that cannot match any path, such as
+	// one containing an empty path component ("a//b").
+	IssueNeverMatches
+
+	// IssueIneffectiveNegation marks a negation that cannot re-include
+	// anything because a parent directory is excluded by an earlier pattern.
+	// Git does not descend into excluded directories.
+	IssueIneffectiveNegation
+)
+
+// String returns a short, hyphenated name for the issue kind.
+func (k IssueKind) String() string {
+	switch k {
+	case IssueInvalid:
+		return "invalid"
+	case IssueDuplicate:
+		return "duplicate"
+	case IssueShadowed:
+		return "shadowed"
+	case IssueNeverMatches:
+		return "never-matches"
+	case IssueIneffectiveNegation:
+		return "ineffective-negation"
+	default:
+		return fmt.Sprintf("IssueKind(%d)", int(k))
+	}
+}
+
+// Issue describes a problem with a single pattern line.
+type Issue struct {
+	// Line is the 1-based line number of the pattern.
+	Line int
+	// Pattern is the line as written, without surrounding whitespace.
+	Pattern string
+	// Kind classifies the issue.
+	Kind IssueKind
+	// Message is a human-readable description of the issue.
+	Message string
+	// RelatedLine is the line of the earlier pattern that causes the issue,
+	// or 0 if there is none.
+	RelatedLine int
+}
+
+// Lint checks gitignore patterns for lines that are invalid or have no effect:
+// duplicates, patterns shadowed by earlier ones, patterns that can never
+// match, and negations made useless by an excluded parent directory.
+//
+// The checks are conservative: a pattern is only reported if it is certain
+// to have no effect within the list. A negation that re-includes nothing is
+// not reported, because it may be overriding an ignore file in a parent
+// directory.
+func Lint(patterns []string) []Issue {
+	var issues []Issue
+	var parsed []ignorePattern
+
+	for i, line := range patterns {
+		pattern, ok, err := parseIgnorePattern(line, i+1, matcherOptions{})
+		if err != nil {
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				parseErr = &ParseError{Line: i + 1, Pattern: strings.TrimSpace(line), Reason: err.Error(), Err: err}
+			}
+			issues = append(issues, invalidIssue(parseErr))
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if issue, found := lintPattern(parsed, pattern); found {
+			issues = append(issues, issue)
+		}
+		parsed = append(parsed, pattern)
+	}
+
+	return issues
+}
+
+// Minimize returns patterns without the lines that cannot change whether
+// any path is ignored: blank lines, comments, and the duplicate, shadowed and
+// never-matching patterns reported by Lint. The remaining lines are returned
+// as written and in their original order, so the result ignores exactly the
+// same paths as the input.
+//
+// Negations are always kept, even if Lint reports them as ineffective,
+// because PatternMatcher applies them unless WithStrictNegation is set and
+// they may be overriding an ignore file in a parent directory. Lines that
+// are not valid patterns are kept too.
+func Minimize(patterns []string) []string {
+	var minimized []string
+	var kept []ignorePattern
+
+	for i, line := range patterns {
+		pattern, ok, err := parseIgnorePattern(line, i+1, matcherOptions{})
+		if err != nil {
+			minimized = append(minimized, line)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if issue, found := lintPattern(kept, pattern); found && issue.Kind != IssueIneffectiveNegation {
+			continue
+		}
+		minimized = append(minimized, line)
+		kept = append(kept, pattern)
+	}
+
+	return minimized
+}
+
+// lintPattern checks pattern against the patterns preceding it.
+func lintPattern(prior []ignorePattern, pattern ignorePattern) (Issue, bool) {
+	issue := Issue{Line: pattern.line, Pattern: pattern.text}
+
+	if strings.Contains(pattern.pattern, "//") {
+		issue.Kind = IssueNeverMatches
+		issue.Message = "pattern contains an empty path component and can never match"
+		return issue, true
+	}
+
+	if line, found := findDuplicate(prior, pattern); found {
+		issue.Kind = IssueDuplicate
+		issue.Message = fmt.Sprintf("duplicate of line %d", line)
+		issue.RelatedLine = line
+		return issue, true
+	}
+
+	if pattern.negate {
+		if dir, line, found := findExcludedParent(prior, pattern); found {
+			issue.Kind = IssueIneffectiveNegation
+			issue.Message = fmt.Sprintf("cannot re-include paths inside %q, which is excluded by line %d", dir, line)
+			issue.RelatedLine = line
+			return issue, true
+		}
+		return issue, false
+	}
+
+	if line, found := findShadowingPattern(prior, pattern); found {
+		issue.Kind = IssueShadowed
+		issue.Message = fmt.Sprintf("has no effect: every path it matches is already ignored by line %d", line)
+		issue.RelatedLine = line
+		return issue, true
+	}
+
+	return issue, false
+}
+
+// findDuplicate returns the line of the last earlier pattern equivalent to
+// pattern, provided no pattern of the opposite kind lies between them.
+func findDuplicate(prior []ignorePattern, pattern ignorePattern) (int, bool) {
+	for i := len(prior) - 1; i >= 0; i-- {
+		candidate := prior[i]
+		if candidate.pattern == pattern.pattern && candidate.negate == pattern.negate &&
+			candidate.isDirectory == pattern.isDirectory && candidate.isRootRelative == pattern.isRootRelative {
+			return candidate.line, true
+		}
+		if candidate.negate != pattern.negate {
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// findExcludedParent reports the first literal parent directory of a
+// negation pattern that is excluded by the earlier patterns, together with
+// the line of the pattern excluding it.
+func findExcludedParent(prior []ignorePattern, pattern ignorePattern) (string, int, bool) {
+	components := strings.Split(pattern.pattern, "/")
+	for i := 1; i < len(components); i++ {
+		if strings.ContainsAny(components[i-1], "*?[") {
+			return "", 0, false
+		}
+		dir := strings.Join(components[:i], "/")
+		if match, found := lastMatchingPattern(prior, dir); found && !match.negate {
+			return dir, match.line, true
+		}
+	}
+	return "", 0, false
+}
+
+// findShadowingPattern returns the line of an earlier pattern that already
+// ignores every path matched by pattern, including the paths beneath the
+// directories it matches.
+func findShadowingPattern(prior []ignorePattern, pattern ignorePattern) (int, bool) {
+	// An earlier negation could re-include some of the paths pattern
+	// matches, which pattern would then exclude again
+	for _, candidate := range prior {
+		if candidate.negate && (candidate.hasWildcard || strings.Contains(candidate.pattern, pattern.pattern)) {
+			return 0, false
+		}
+	}
+
+	if strings.ContainsAny(pattern.pattern, "*?[") {
+		return findCoveringWildcard(prior, pattern)
+	}
+
+	// A literal pattern matches the path it names, at any depth unless it is
+	// anchored, even with a slash in the middle; check a path at the root
+	// and one below it
+	paths := []string{pattern.pattern}
+	if !pattern.isRootRelative {
+		paths = append(paths, "dir/"+pattern.pattern)
+	}
+
+	line := 0
+	for _, path := range paths {
+		match, found := lastMatchingPattern(prior, path)
+		if !found || match.negate || (match.isDirectory && !pattern.isDirectory) {
+			return 0, false
+		}
+		// pattern also matches everything beneath path, which "b/*" does
+		// not for "b/build", for instance
+		if !ignoresBeneath(prior, path) {
+			return 0, false
+		}
+		if line == 0 {
+			line = match.line
+		}
+	}
+	return line, true
+}
+
+// ignoresBeneath reports whether the patterns ignore every path beneath dir,
+// regardless of its name, as subtreeIgnored does for a PatternMatcher.
+func ignoresBeneath(patterns []ignorePattern, dir string) bool {
+	covered := false
+	for _, pattern := range patterns {
+		switch {
+		case coversDescendants(dir, pattern, matcherOptions{}):
+			covered = !pattern.negate
+		case pattern.negate && mayMatchBeneath(dir, pattern, SyntaxGit):
+			covered = false
+		}
+	}
+	return covered
+}
+
+// findCoveringWildcard returns the line of an earlier floating pattern of the
+// form "*suffix", such as "*.log", whose suffix every path matched by the
+// wildcard pattern ends with. Such a pattern matches any component ending
+// with the suffix, so it also covers the paths beneath those matched by the
+// wildcard pattern. Only patterns after the last negation count.
+func findCoveringWildcard(prior []ignorePattern, pattern ignorePattern) (int, bool) {
+	base := pattern.pattern[strings.LastIndex(pattern.pattern, "/")+1:]
+
+	for i := len(prior) - 1; i >= 0; i-- {
+		candidate := prior[i]
+		if candidate.negate {
+			// The negation may re-include paths that pattern matches
+			return 0, false
+		}
+		if candidate.isDirectory || candidate.isRootRelative {
+			continue
+		}
+		if !strings.HasPrefix(candidate.pattern, "*") || strings.Contains(candidate.pattern, "/") {
+			continue
+		}
+		suffix := strings.TrimLeft(candidate.pattern, "*")
+		if strings.ContainsAny(suffix, "*?[") {
+			continue
+		}
+		if suffix == "" || (strings.HasSuffix(base, suffix) && !strings.ContainsAny(base[len(base)-len(suffix):], "*?[")) {
+			return candidate.line, true
+		}
+	}
+	return 0, false
+}
+
+// lastMatchingPattern returns the last pattern in patterns that matches file,
+// which is the pattern that decides whether file is ignored.
+func lastMatchingPattern(patterns []ignorePattern, file string) (ignorePattern, bool) {
+	matcher := &PatternMatcher{}
+	for i := len(patterns) - 1; i >= 0; i-- {
+		if isMatch, err := matcher.matchPattern(file, kindUnknown, patterns[i]); err == nil && isMatch {
+			return patterns[i], true
+		}
+	}
+	return ignorePattern{}, false
+}
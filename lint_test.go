@@ -0,0 +1,192 @@
+package dotignore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	type issue struct {
+		line        int
+		kind        IssueKind
+		relatedLine int
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		expected []issue
+	}{
+		{
+			name:     "Clean file",
+			patterns: []string{"# deps", "node_modules/", "*.log", "!important.log", "/build/"},
+		},
+		{
+			name:     "Duplicate",
+			patterns: []string{"*.log", "dist/", "*.log"},
+			expected: []issue{{3, IssueDuplicate, 1}},
+		},
+		{
+			name:     "Repeated pattern after negation is significant",
+			patterns: []string{"*.log", "!debug.log", "*.log"},
+		},
+		{
+			name:     "Literal shadowed by wildcard",
+			patterns: []string{"*.log", "debug.log"},
+			expected: []issue{{2, IssueShadowed, 1}},
+		},
+		{
+			name:     "Wildcard shadowed by wildcard",
+			patterns: []string{"*.log", "logs/app-*.log"},
+			expected: []issue{{2, IssueShadowed, 1}},
+		},
+		{
+			name:     "Directory shadowed by same name",
+			patterns: []string{"cache", "cache/"},
+			expected: []issue{{2, IssueShadowed, 1}},
+		},
+		{
+			name:     "Directory pattern does not shadow files",
+			patterns: []string{"cache/", "cache"},
+		},
+		{
+			name:     "Directory contents do not shadow the directory",
+			patterns: []string{"b/*", "b/build"},
+		},
+		{
+			name:     "Pattern not covering beneath a directory does not shadow",
+			patterns: []string{"/f*", "/foo/", "**/a.log", "a.log/"},
+		},
+		{
+			name:     "Negation beneath a directory keeps pattern significant",
+			patterns: []string{"b", "!x", "b/build"},
+		},
+		{
+			name:     "Anchored pattern does not shadow floating pattern",
+			patterns: []string{"/debug.log", "debug.log"},
+		},
+		{
+			name:     "Negation in between keeps pattern significant",
+			patterns: []string{"*.log", "!logs/*.log", "logs/debug.log"},
+		},
+		{
+			name:     "Never matches",
+			patterns: []string{"a//b"},
+			expected: []issue{{1, IssueNeverMatches, 0}},
+		},
+		{
+			name:     "Negation inside excluded directory",
+			patterns: []string{"build/", "!build/keep.txt"},
+			expected: []issue{{2, IssueIneffectiveNegation, 1}},
+		},
+		{
+			name:     "Negation inside directory contents is effective",
+			patterns: []string{"build/*", "!build/keep.txt"},
+		},
+		{
+			name:     "Negation after re-including directory is effective",
+			patterns: []string{"build/", "!build/", "!build/keep.txt"},
+		},
+		{
+			name:     "Invalid pattern",
+			patterns: []string{"*.log", "!", "/"},
+			expected: []issue{{2, IssueInvalid, 0}, {3, IssueInvalid, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := Lint(tt.patterns)
+			if len(issues) != len(tt.expected) {
+				t.Fatalf("Expected %d issues, got %d: %+v", len(tt.expected), len(issues), issues)
+			}
+			for i, want := range tt.expected {
+				got := issues[i]
+				if got.Line != want.line || got.Kind != want.kind || got.RelatedLine != want.relatedLine {
+					t.Errorf("Issue %d: expected line %d %v (related %d), got line %d %v (related %d): %s",
+						i, want.line, want.kind, want.relatedLine, got.Line, got.Kind, got.RelatedLine, got.Message)
+				}
+				if got.Pattern != tt.patterns[got.Line-1] {
+					t.Errorf("Issue %d: expected pattern %q, got %q", i, tt.patterns[got.Line-1], got.Pattern)
+				}
+				if got.Message == "" {
+					t.Errorf("Issue %d: empty message", i)
+				}
+			}
+		})
+	}
+}
+
+func TestMinimize(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{
+			name:     "Nothing redundant",
+			patterns: []string{"node_modules/", "*.log", "!important.log"},
+			want:     []string{"node_modules/", "*.log", "!important.log"},
+		},
+		{
+			name:     "Duplicates, comments and blank lines",
+			patterns: []string{"# logs", "*.log", "", "dist/", "*.log", "  dist/  "},
+			want:     []string{"*.log", "dist/"},
+		},
+		{
+			name:     "Shadowed patterns",
+			patterns: []string{"*.log", "debug.log", "logs/app-*.log", "cache", "cache/"},
+			want:     []string{"*.log", "cache"},
+		},
+		{
+			name:     "Patterns covering more beneath a directory are kept",
+			patterns: []string{"b/*", "b/build", "/f*", "/foo/", "**/a.log", "a.log/"},
+			want:     []string{"b/*", "b/build", "/f*", "/foo/", "**/a.log", "a.log/"},
+		},
+		{
+			name:     "Pattern with a slash matches below the root",
+			patterns: []string{"/foo", "foo/a.log"},
+			want:     []string{"/foo", "foo/a.log"},
+		},
+		{
+			name:     "Negation keeps later patterns significant",
+			patterns: []string{"*.log", "!debug.log", "*.log"},
+			want:     []string{"*.log", "!debug.log", "*.log"},
+		},
+		{
+			name:     "Ineffective negation and invalid lines are kept",
+			patterns: []string{"build/", "!build/keep.txt", "!", "a//b"},
+			want:     []string{"build/", "!build/keep.txt", "!"},
+		},
+	}
+
+	paths := []string{
+		"app.log", "debug.log", "logs/app-1.log", "logs/debug.log", "cache", "cache/x", "dist/app.js",
+		"node_modules/x.js", "important.log", "build/keep.txt", "build/out.bin", "a/b", "main.go",
+		"b/build", "b/build/x", "foo", "foo/a.log", "a.log/x", "sub/foo/a.log",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Minimize(tt.patterns)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Minimize() = %q, want %q", got, tt.want)
+			}
+
+			original, err := NewPatternMatcher(tt.patterns, WithSkipInvalidPatterns(nil))
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+			minimized, err := NewPatternMatcher(got, WithSkipInvalidPatterns(nil))
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+			for _, path := range paths {
+				want, _ := original.Matches(path)
+				if got, _ := minimized.Matches(path); got != want {
+					t.Errorf("Matches(%q) = %v after minimizing, want %v", path, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package dotignore
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// ListIgnoredFiles walks the repository and returns the path of every
+// ignored file, relative to the root directory, in lexical order. These are
+// the files "git clean -X" would remove. Directories are not listed
+// themselves, and the contents of .git directories are left out unless
+// RepositoryConfig.IncludeGitDir is set.
+//
+// Directories that CanSkipDir reports as ignored in full are listed without
+// matching each file inside them. The walk stops with ctx.Err() once ctx is
+// done.
+func (rm *RepositoryMatcher) ListIgnoredFiles(ctx context.Context) ([]string, error) {
+	var paths []string
+	err := rm.WalkIgnoredFiles(ctx, func(path string) error {
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// WalkIgnoredFiles is like ListIgnoredFiles but calls fn with each path as
+// soon as it is found instead of collecting them. An error returned by fn
+// stops the walk and is returned.
+func (rm *RepositoryMatcher) WalkIgnoredFiles(ctx context.Context, fn func(path string) error) error {
+	if err := rm.walkFiles(ctx, true, fn); err != nil {
+		return fmt.Errorf("failed to list ignored files: %w", err)
+	}
+	return nil
+}
+
+// ListTrackedCandidates walks the repository and returns the path of every
+// file that is not ignored, relative to the root directory, in lexical
+// order. These are the files Git would offer to track. The contents of .git
+// directories are left out unless RepositoryConfig.IncludeGitDir is set.
+//
+// Directories that CanSkipDir reports as ignored in full are not read. The
+// walk stops with ctx.Err() once ctx is done.
+func (rm *RepositoryMatcher) ListTrackedCandidates(ctx context.Context) ([]string, error) {
+	var paths []string
+	err := rm.WalkTrackedCandidates(ctx, func(path string) error {
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// WalkTrackedCandidates is like ListTrackedCandidates but calls fn with each
+// path as soon as it is found instead of collecting them. An error returned
+// by fn stops the walk and is returned.
+func (rm *RepositoryMatcher) WalkTrackedCandidates(ctx context.Context, fn func(path string) error) error {
+	if err := rm.walkFiles(ctx, false, fn); err != nil {
+		return fmt.Errorf("failed to list files: %w", err)
+	}
+	return nil
+}
+
+// walkFiles calls fn for every file in the repository that is ignored, or
+// for every file that is not if ignored is false.
+func (rm *RepositoryMatcher) walkFiles(ctx context.Context, ignored bool, fn func(path string) error) error {
+	var progress Progress
+	return rm.fsys.WalkDir(rm.rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rm.visited(&progress, d)
+		if path == rm.rootDir {
+			return nil
+		}
+
+		if d.IsDir() {
+			if d.Name() == ".git" && !rm.config.IncludeGitDir {
+				return filepath.SkipDir
+			}
+			skip, err := rm.CanSkipDir(path)
+			if err != nil || !skip {
+				return err
+			}
+			// Everything beneath the directory is ignored
+			if ignored {
+				if err := rm.walkAllFiles(ctx, path, &progress, fn); err != nil {
+					return err
+				}
+			}
+			return filepath.SkipDir
+		}
+
+		isIgnored, err := rm.Matches(path)
+		if err != nil || isIgnored != ignored {
+			return err
+		}
+		return rm.reportFile(path, fn)
+	})
+}
+
+// walkAllFiles calls fn for every file beneath dir without matching them.
+// Entries other than dir itself are added to progress.
+func (rm *RepositoryMatcher) walkAllFiles(ctx context.Context, dir string, progress *Progress, fn func(path string) error) error {
+	return rm.fsys.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path != dir {
+			rm.visited(progress, d)
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" && !rm.config.IncludeGitDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return rm.reportFile(path, fn)
+	})
+}
+
+// visited adds the entry d to progress and reports the new totals.
+func (rm *RepositoryMatcher) visited(progress *Progress, d fs.DirEntry) {
+	if d.IsDir() {
+		progress.Dirs++
+	} else {
+		progress.Files++
+	}
+	rm.reportProgress(*progress)
+}
+
+// reportFile calls fn with path relative to the root directory.
+func (rm *RepositoryMatcher) reportFile(path string, fn func(path string) error) error {
+	relPath, err := filepath.Rel(rm.rootDir, path)
+	if err != nil {
+		return err
+	}
+	return fn(relPath)
+}
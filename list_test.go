@@ -0,0 +1,139 @@
+package dotignore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRepositoryMatcher_ListIgnoredFiles(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":            "*.log\n/build/\nnode_modules/\n!keep.log\n",
+		".git/config":           "",
+		"build/out.bin":         "",
+		"build/sub/deep.o":      "",
+		"app/.gitignore":        "!debug.log\ndist/\n",
+		"app/debug.log":         "",
+		"app/error.log":         "",
+		"app/dist/bundle.js":    "",
+		"app/main.go":           "",
+		"logs/keep.log":         "",
+		"logs/run.log":          "",
+		"web/node_modules/a.js": "",
+		"README.md":             "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository matcher: %v", err)
+	}
+
+	paths, err := matcher.ListIgnoredFiles(context.Background())
+	if err != nil {
+		t.Fatalf("ListIgnoredFiles failed: %v", err)
+	}
+	expected := []string{
+		"app/dist/bundle.js",
+		"app/error.log",
+		"build/out.bin",
+		"build/sub/deep.o",
+		"logs/run.log",
+		"web/node_modules/a.js",
+	}
+	for i := range expected {
+		expected[i] = filepath.FromSlash(expected[i])
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("ListIgnoredFiles() = %q, want %q", paths, expected)
+	}
+}
+
+func TestRepositoryMatcher_ListIgnoredFiles_Errors(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore": "*.log\n",
+		"a.log":      "",
+		"b.log":      "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository matcher: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := matcher.ListIgnoredFiles(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListIgnoredFiles() error = %v, want context.Canceled", err)
+	}
+
+	errStop := errors.New("stop")
+	var seen []string
+	err = matcher.WalkIgnoredFiles(context.Background(), func(path string) error {
+		seen = append(seen, path)
+		return errStop
+	})
+	if !errors.Is(err, errStop) || len(seen) != 1 {
+		t.Errorf("WalkIgnoredFiles() = %v after %q, want errStop after one path", err, seen)
+	}
+}
+
+func TestRepositoryMatcher_ListTrackedCandidates(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":         "*.log\n/build/\n!keep.log\n",
+		".git/config":        "",
+		"build/out.bin":      "",
+		"app/.gitignore":     "!debug.log\ndist/\n",
+		"app/debug.log":      "",
+		"app/error.log":      "",
+		"app/dist/bundle.js": "",
+		"app/main.go":        "",
+		"logs/keep.log":      "",
+		"logs/run.log":       "",
+		"README.md":          "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository matcher: %v", err)
+	}
+
+	paths, err := matcher.ListTrackedCandidates(context.Background())
+	if err != nil {
+		t.Fatalf("ListTrackedCandidates failed: %v", err)
+	}
+	expected := []string{
+		".gitignore",
+		"README.md",
+		"app/.gitignore",
+		"app/debug.log",
+		"app/main.go",
+		"logs/keep.log",
+	}
+	for i := range expected {
+		expected[i] = filepath.FromSlash(expected[i])
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("ListTrackedCandidates() = %q, want %q", paths, expected)
+	}
+
+	// Kept and ignored files together make up the whole repository
+	ignored, err := matcher.ListIgnoredFiles(context.Background())
+	if err != nil {
+		t.Fatalf("ListIgnoredFiles failed: %v", err)
+	}
+	if len(paths)+len(ignored) != 10 {
+		t.Errorf("Expected 10 files outside .git, got %q and %q", paths, ignored)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := matcher.ListTrackedCandidates(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListTrackedCandidates() error = %v, want context.Canceled", err)
+	}
+}
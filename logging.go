@@ -0,0 +1,54 @@
+package dotignore
+
+// Logger receives debug events from matchers, such as the ignore files a
+// RepositoryMatcher loads or skips and the pattern that decided each match.
+// Arguments after msg are alternating keys and values, so a *slog.Logger
+// can be used directly:
+//
+//	matcher, err := dotignore.NewPatternMatcher(patterns,
+//	    dotignore.WithLogger(slog.Default()))
+//
+// Logging every match is expensive; attach a logger only while debugging.
+type Logger interface {
+	Debug(msg string, args ...any)
+}
+
+// WithLogger records the decision of every call to Matches on logger.
+// Loggers are not preserved by Encode.
+func WithLogger(logger Logger) Option {
+	return func(o *matcherOptions) {
+		o.logger = logger
+	}
+}
+
+// logDecision records that the pattern at index i, or none if i is
+// negative, decided whether file is ignored.
+func (p *PatternMatcher) logDecision(rules *ruleSet, file string, i int) {
+	if i < 0 {
+		p.options.logger.Debug("no pattern matched", "path", file, "ignored", false)
+		return
+	}
+	pattern := rules.patterns[i]
+	p.options.logger.Debug("pattern matched", "path", file, "ignored", !pattern.negate,
+		"pattern", pattern.text, "line", pattern.line)
+}
+
+// logDecision records the result of matching path.
+func (rm *RepositoryMatcher) logDecision(path string, result MatchResult) {
+	switch {
+	case result.Matched:
+		rm.config.Logger.Debug("pattern matched", "path", path, "ignored", result.Ignored,
+			"source", result.Source, "line", result.Line, "pattern", result.Pattern)
+	case result.Ignored:
+		rm.config.Logger.Debug("built-in rule matched", "path", path, "ignored", true)
+	default:
+		rm.config.Logger.Debug("no pattern matched", "path", path, "ignored", false)
+	}
+}
+
+// log records a discovery event if a logger is configured.
+func (rm *RepositoryMatcher) log(msg string, args ...any) {
+	if rm.config.Logger != nil {
+		rm.config.Logger.Debug(msg, args...)
+	}
+}
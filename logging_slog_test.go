@@ -0,0 +1,28 @@
+//go:build go1.21
+
+package dotignore
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWithLogger_Slog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	matcher, err := NewPatternMatcher([]string{"/build/"}, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if _, err := matcher.Matches("build/out.bin"); err != nil {
+		t.Fatalf("Matches failed: %v", err)
+	}
+
+	want := `msg="pattern matched" path=build/out.bin ignored=true pattern=/build/ line=1`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("Log output %q does not contain %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,114 @@
+package dotignore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingLogger collects debug events as "msg key=value ..." lines.
+type recordingLogger struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *recordingLogger) Debug(msg string, args ...any) {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, b.String())
+}
+
+func (l *recordingLogger) contains(t *testing.T, want string) {
+	t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, event := range l.events {
+		if strings.Contains(event, want) {
+			return
+		}
+	}
+	t.Errorf("No event contains %q, got:\n%s", want, strings.Join(l.events, "\n"))
+}
+
+func TestWithLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	matcher, err := NewPatternMatcher([]string{"*.log", "!keep.log"}, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	for _, file := range []string{"debug.log", "keep.log", "main.go"} {
+		if _, err := matcher.Matches(file); err != nil {
+			t.Fatalf("Matches(%q) failed: %v", file, err)
+		}
+	}
+
+	logger.contains(t, "pattern matched path=debug.log ignored=true pattern=*.log line=1")
+	logger.contains(t, "pattern matched path=keep.log ignored=false pattern=!keep.log line=2")
+	logger.contains(t, "no pattern matched path=main.go ignored=false")
+}
+
+func TestRepositoryMatcher_Logger(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":        "*.log\n",
+		"app/.gitignore":    "!\n",
+		"vendor/.git/HEAD":  "",
+		"vendor/.gitignore": "*.tmp\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	logger := &recordingLogger{}
+	config := DefaultRepositoryConfig()
+	config.NestedRepositories = NestedRepositoryScope
+	config.Logger = logger
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("Failed to create repository matcher: %v", err)
+	}
+
+	logger.contains(t, "loaded ignore file path="+filepath.Join(tmpDir, ".gitignore")+" patterns=1")
+	logger.contains(t, "skipped unparseable ignore file path="+filepath.Join(tmpDir, "app", ".gitignore"))
+	logger.contains(t, "found nested repository path="+filepath.Join(tmpDir, "vendor"))
+
+	if _, err := matcher.Matches("app/debug.log"); err != nil {
+		t.Fatalf("Matches failed: %v", err)
+	}
+	logger.contains(t, "pattern matched path=app/debug.log ignored=true source=.gitignore line=1 pattern=*.log")
+
+	if err := matcher.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	logger.contains(t, "reused unchanged ignore file path="+filepath.Join(tmpDir, ".gitignore"))
+
+	// The logger is dropped when encoding
+	var buf strings.Builder
+	if err := matcher.Encode(&buf); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	decoded, err := DecodeRepositoryMatcher(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("DecodeRepositoryMatcher failed: %v", err)
+	}
+	logger.mu.Lock()
+	logged := len(logger.events)
+	logger.mu.Unlock()
+	if _, err := decoded.Matches("app/debug.log"); err != nil {
+		t.Fatalf("Matches failed: %v", err)
+	}
+	if err := decoded.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.events) != logged {
+		t.Errorf("Decoded matcher logged %q", logger.events[logged:])
+	}
+}
@@ -0,0 +1,113 @@
+package dotignore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ManifestEntry describes one file of a Manifest.
+type ManifestEntry struct {
+	// Path is the slash-separated path of the file relative to the root.
+	Path string
+	// Size is the size of the file in bytes.
+	Size int64
+	// SHA256 is the lowercase hexadecimal SHA-256 digest of the contents.
+	SHA256 string
+}
+
+// Manifest lists the files of a tree with their sizes and digests, sorted
+// by path, as returned by BuildManifest.
+type Manifest []ManifestEntry
+
+// BuildManifest hashes every regular file below root that matcher does not
+// ignore, following the same rules as WriteTar, and returns them in lexical
+// order of their paths. A symbolic link to a regular file is listed with the
+// contents of its target, as sha256sum would read it; other links, and
+// sockets, devices and other special files, are left out.
+//
+// The manifest depends only on the paths and contents of the kept files, not
+// on modification times or modes, so its Digest can serve as a cache key for
+// a build whose inputs are the files the ignore rules keep.
+func BuildManifest(root string, matcher Matcher) (Manifest, error) {
+	var manifest Manifest
+	err := walkKept(root, matcher, func(path, name string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+		}
+
+		entry, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		entry.Path = name
+		manifest = append(manifest, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build manifest: %w", err)
+	}
+
+	// Walk order puts "a/b" before "a-b", which sorts first as a string
+	sort.Slice(manifest, func(i, j int) bool {
+		return manifest[i].Path < manifest[j].Path
+	})
+	return manifest, nil
+}
+
+// hashFile returns the size and digest of the file at path.
+func hashFile(path string) (ManifestEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	return ManifestEntry{Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// WriteTo writes the manifest to w in the format of sha256sum, one
+// "<digest>  <path>" line per file, so that it can be checked with
+// "sha256sum -c" from the root. As with sha256sum, a path containing a
+// newline or a backslash is escaped and its line starts with a backslash.
+func (m Manifest) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for _, entry := range m {
+		prefix, path := "", entry.Path
+		if strings.ContainsAny(path, "\n\\") {
+			prefix = `\`
+			path = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(path)
+		}
+		written, err := fmt.Fprintf(w, "%s%s  %s\n", prefix, entry.SHA256, path)
+		n += int64(written)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Digest returns the lowercase hexadecimal SHA-256 digest of the manifest
+// as written by WriteTo. It changes whenever a kept file is added, removed,
+// renamed or modified.
+func (m Manifest) Digest() string {
+	h := sha256.New()
+	m.WriteTo(h)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,101 @@
+package dotignore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuildManifest(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":     "*.log\nbuild/\n",
+		"main.go":        "package main\n",
+		"debug.log":      "debug",
+		"build/out.bin":  "binary",
+		"src/empty.txt":  "",
+		"src/lib/lib.go": "package lib\n",
+		"src-gen.txt":    "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	manifest, err := BuildManifest(tmpDir, matcher)
+	if err != nil {
+		t.Fatalf("BuildManifest() failed: %v", err)
+	}
+
+	const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	expected := []struct {
+		path string
+		size int64
+	}{
+		{".gitignore", 13},
+		{"main.go", 13},
+		{"src-gen.txt", 0}, // sorted by path, not in walk order
+		{"src/empty.txt", 0},
+		{"src/lib/lib.go", 12},
+	}
+	if len(manifest) != len(expected) {
+		t.Fatalf("BuildManifest() = %v, want %d entries", manifest, len(expected))
+	}
+	for i, entry := range manifest {
+		if entry.Path != expected[i].path || entry.Size != expected[i].size {
+			t.Errorf("entry %d = %s (%d bytes), want %s (%d bytes)", i, entry.Path, entry.Size, expected[i].path, expected[i].size)
+		}
+	}
+	if got := manifest[2].SHA256; got != emptySHA256 {
+		t.Errorf("SHA256 of an empty file = %s, want %s", got, emptySHA256)
+	}
+
+	// The digest only changes with the kept files
+	digest := manifest.Digest()
+	if err := os.WriteFile(filepath.Join(tmpDir, "trace.log"), []byte("trace"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	again, err := BuildManifest(tmpDir, matcher)
+	if err != nil {
+		t.Fatalf("BuildManifest() failed: %v", err)
+	}
+	if !reflect.DeepEqual(again, manifest) || again.Digest() != digest {
+		t.Error("Adding an ignored file changed the manifest")
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	changed, err := BuildManifest(tmpDir, matcher)
+	if err != nil {
+		t.Fatalf("BuildManifest() failed: %v", err)
+	}
+	if changed.Digest() == digest {
+		t.Error("Modifying a kept file did not change the digest")
+	}
+}
+
+func TestManifestWriteTo(t *testing.T) {
+	manifest := Manifest{
+		{Path: "empty.txt", SHA256: "e3b0"},
+		{Path: "a\\b\nc.txt", SHA256: "ab12"},
+	}
+	var buf bytes.Buffer
+	n, err := manifest.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() failed: %v", err)
+	}
+	expected := "e3b0  empty.txt\n\\ab12  a\\\\b\\nc.txt\n"
+	if buf.String() != expected || n != int64(len(expected)) {
+		t.Errorf("WriteTo() = %q, %d, want %q", buf.String(), n, expected)
+	}
+}
+
+func TestBuildManifestErrors(t *testing.T) {
+	if _, err := BuildManifest(t.TempDir(), nil); err == nil {
+		t.Error("Expected error for nil matcher")
+	}
+}
@@ -0,0 +1,59 @@
+package dotignore
+
+// Matcher reports whether a path is ignored. It is implemented by
+// PatternMatcher, RepositoryMatcher, HgIgnoreMatcher and MatcherChain, and
+// accepted by helpers such as WriteTar, so custom implementations can be used
+// with them.
+type Matcher interface {
+	Matches(path string) (bool, error)
+}
+
+var (
+	_ Matcher = (*PatternMatcher)(nil)
+	_ Matcher = (*RepositoryMatcher)(nil)
+	_ Matcher = (*HgIgnoreMatcher)(nil)
+	_ Matcher = (*MatcherChain)(nil)
+	_ Matcher = MatcherFunc(nil)
+)
+
+// MatcherFunc adapts an ordinary function to the Matcher interface.
+type MatcherFunc func(path string) (bool, error)
+
+// Matches returns f(path).
+func (f MatcherFunc) Matches(path string) (bool, error) {
+	return f(path)
+}
+
+// MatcherChain combines several matchers: a path is ignored if any of them
+// ignores it. The matchers are consulted in order and the first one that
+// ignores the path ends the search.
+//
+// Unlike the patterns within a single matcher, a later matcher in the chain
+// cannot re-include a path that an earlier one ignores.
+type MatcherChain struct {
+	matchers []Matcher
+}
+
+// NewMatcherChain returns a MatcherChain consulting matchers in order. Nil
+// matchers are skipped.
+func NewMatcherChain(matchers ...Matcher) *MatcherChain {
+	chain := &MatcherChain{}
+	for _, matcher := range matchers {
+		if matcher != nil {
+			chain.matchers = append(chain.matchers, matcher)
+		}
+	}
+	return chain
+}
+
+// Matches reports whether any matcher in the chain ignores path. An error
+// from a matcher is returned immediately.
+func (c *MatcherChain) Matches(path string) (bool, error) {
+	for _, matcher := range c.matchers {
+		ignored, err := matcher.Matches(path)
+		if err != nil || ignored {
+			return ignored, err
+		}
+	}
+	return false, nil
+}
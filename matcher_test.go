@@ -0,0 +1,64 @@
+package dotignore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMatcherChain(t *testing.T) {
+	gitignore, err := NewPatternMatcher([]string{"*.log", "!keep.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	dockerignore, err := NewPatternMatcher([]string{"docs"}, WithSyntax(SyntaxDocker))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	hidden := MatcherFunc(func(path string) (bool, error) {
+		return strings.HasPrefix(path, "."), nil
+	})
+
+	chain := NewMatcherChain(gitignore, nil, dockerignore, hidden)
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"main.go", false},
+		{"debug.log", true},
+		{"keep.log", false},
+		{"docs/index.md", true},
+		{".env", true},
+		{"src/docs/index.md", false},
+	}
+	for _, tt := range tests {
+		result, err := chain.Matches(tt.path)
+		if err != nil {
+			t.Fatalf("Matches(%q) failed: %v", tt.path, err)
+		}
+		if result != tt.expected {
+			t.Errorf("Matches(%q) = %v, want %v", tt.path, result, tt.expected)
+		}
+	}
+
+	if result, err := NewMatcherChain().Matches("anything"); err != nil || result {
+		t.Errorf("Empty chain: Matches() = %v, %v, want false", result, err)
+	}
+}
+
+func TestMatcherChainError(t *testing.T) {
+	errBroken := errors.New("broken")
+	calls := 0
+	chain := NewMatcherChain(
+		MatcherFunc(func(string) (bool, error) { return false, errBroken }),
+		MatcherFunc(func(string) (bool, error) { calls++; return true, nil }),
+	)
+
+	if _, err := chain.Matches("file"); !errors.Is(err, errBroken) {
+		t.Errorf("Matches() error = %v, want %v", err, errBroken)
+	}
+	if calls != 0 {
+		t.Error("Matches() consulted a matcher after an error")
+	}
+}
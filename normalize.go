@@ -0,0 +1,51 @@
+package dotignore
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalize returns the canonical form of a gitignore pattern, so that
+// patterns written differently but interpreted the same way compare equal.
+// It trims leading whitespace and trailing spaces other than escaped ones,
+// collapses repeated "**" components, and keeps everything else as
+// written. A leading "./" is kept because Git never matches it, and a
+// leading "**/" because PatternMatcher does not let "**/foo" match the
+// contents of a directory foo as it lets "foo" do.
+//
+// Blank lines and comments normalize to "". Invalid patterns are reported
+// with the same *ParseError as NewPatternMatcher.
+func Normalize(pattern string) (string, error) {
+	if _, ok, err := parseIgnorePattern(pattern, 1, matcherOptions{}); err != nil || !ok {
+		return "", err
+	}
+	text := trimTrailingSpaces(strings.TrimLeftFunc(pattern, unicode.IsSpace))
+
+	negation := ""
+	if strings.HasPrefix(text, "!") {
+		negation, text = "!", text[1:]
+	}
+
+	anchor := ""
+	if strings.HasPrefix(text, "/") {
+		anchor, text = "/", text[1:]
+	}
+
+	suffix := ""
+	if strings.HasSuffix(text, "/") && !strings.HasSuffix(text, `\/`) {
+		suffix, text = "/", text[:len(text)-1]
+	}
+	if text == "" {
+		return "", newParseError(pattern, 1, ErrEmptyPattern)
+	}
+
+	var components []string
+	for _, component := range strings.Split(text, "/") {
+		if component == "**" && len(components) > 0 && components[len(components)-1] == "**" {
+			continue
+		}
+		components = append(components, component)
+	}
+
+	return negation + anchor + strings.Join(components, "/") + suffix, nil
+}
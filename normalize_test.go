@@ -0,0 +1,98 @@
+package dotignore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"*.log", "*.log"},
+		{"  *.log  ", "*.log"},
+		{`foo\ `, `foo\ `},
+		{`foo\  `, `foo\ `},
+		{"# comment", ""},
+		{"   ", ""},
+		{"./build", "./build"},
+		{"././build/", "././build/"},
+		{"!./build", "!./build"},
+		{"./", "./"},
+		{"a/**/**/b", "a/**/b"},
+		{"**/**/*.log", "**/*.log"},
+		{"logs/**/**", "logs/**"},
+		{"**/foo", "**/foo"},
+		{"/**/foo/", "/**/foo/"},
+		{"**/foo/bar", "**/foo/bar"},
+		{"/**/foo/bar", "/**/foo/bar"},
+		{"!**/debug.log", "!**/debug.log"},
+		{`\!important`, `\!important`},
+		{"dist//", "dist//"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := Normalize(tt.pattern)
+			if err != nil {
+				t.Fatalf("Normalize(%q) failed: %v", tt.pattern, err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePreservesMeaning(t *testing.T) {
+	patterns := []string{"./build", "a/**/**/b", "**/**/*.log", "logs/**/**", "**/foo", "/**/foo/", "!**/debug.log", "dist//"}
+	paths := []string{
+		"build", "build/x", "a", "a/b", "a/x/b", "a/x/y/b", "a/b/c", "x.log", "a/x.log", "x.log/y",
+		"logs/a", "logs/a/b", "foo", "foo/x", "a/foo", "a/foo/x", "debug.log", "a/debug.log", "dist", "dist/x",
+	}
+
+	for _, pattern := range patterns {
+		normalized, err := Normalize(pattern)
+		if err != nil {
+			t.Fatalf("Normalize(%q) failed: %v", pattern, err)
+		}
+		// A negation is checked against everything being ignored
+		original, err := NewPatternMatcher([]string{"*", pattern})
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+		rewritten, err := NewPatternMatcher([]string{"*", normalized})
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+		if pattern[0] != '!' {
+			original, _ = NewPatternMatcher([]string{pattern})
+			rewritten, _ = NewPatternMatcher([]string{normalized})
+		}
+		for _, path := range paths {
+			want, _ := original.Matches(path)
+			if got, _ := rewritten.Matches(path); got != want {
+				t.Errorf("Path %q: %q gives %v, normalized %q gives %v", path, pattern, want, normalized, got)
+			}
+		}
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    error
+	}{
+		{"!", ErrInvalidNegation},
+		{"/", ErrEmptyPattern},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if _, err := Normalize(tt.pattern); !errors.Is(err, tt.want) {
+				t.Errorf("Normalize(%q) error = %v, want %v", tt.pattern, err, tt.want)
+			}
+		})
+	}
+}
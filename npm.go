@@ -0,0 +1,72 @@
+package dotignore
+
+import "fmt"
+
+// npmDefaultRules lists the paths npm always leaves out of a package and the
+// files it always includes, in evaluation order. They are applied after every
+// .npmignore/.gitignore file, so they cannot be overridden.
+var npmDefaultRules = []string{
+	// Always excluded
+	".npmignore",
+	".gitignore",
+	".git/",
+	".svn/",
+	".hg/",
+	"CVS/",
+	"node_modules/",
+	"/.lock-wscript",
+	"/.wafpickle-*",
+	"/build/config.gypi",
+	"npm-debug.log",
+	".npmrc",
+	".*.swp",
+	".DS_Store",
+	"._*",
+	"*.orig",
+	"/package-lock.json",
+	"/yarn.lock",
+	"/pnpm-lock.yaml",
+	"/archived-packages/",
+
+	// Always included
+	"!/package.json",
+	"!/[Rr][Ee][Aa][Dd][Mm][Ee]*",
+	"!/[Cc][Oo][Pp][Yy][Ii][Nn][Gg]*",
+	"!/[Ll][Ii][Cc][Ee][Nn][CcSs][Ee]*",
+}
+
+// NpmRepositoryConfig returns a RepositoryConfig that discovers ignore files the
+// way npm does when packing a package: each directory uses its .npmignore file if
+// present and falls back to its .gitignore file otherwise.
+func NpmRepositoryConfig() *RepositoryConfig {
+	config := DefaultRepositoryConfig()
+	config.IgnoreFileName = ".npmignore"
+	config.FallbackIgnoreFileName = ".gitignore"
+	return config
+}
+
+// NewNpmMatcher creates a RepositoryMatcher that reports which files npm would
+// leave out when publishing the package rooted at packageDir.
+//
+// In addition to the .npmignore/.gitignore files discovered with
+// NpmRepositoryConfig, npm's implicit rules are applied on top of them:
+//   - package.json, README*, LICENSE*/LICENCE* and COPYING* at the package root
+//     are always included
+//   - version control directories, node_modules, lock files, .npmrc,
+//     npm-debug.log and editor artifacts are always excluded
+//
+// The "files" field of package.json is not consulted.
+func NewNpmMatcher(packageDir string) (*RepositoryMatcher, error) {
+	rm, err := NewRepositoryMatcherWithConfig(packageDir, NpmRepositoryConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := NewPatternMatcher(npmDefaultRules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build npm default rules: %w", err)
+	}
+	rm.overrides = overrides
+
+	return rm, nil
+}
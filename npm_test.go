@@ -0,0 +1,91 @@
+package dotignore
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNewNpmMatcher(t *testing.T) {
+	tests := []struct {
+		name      string
+		structure map[string]string
+		cases     map[string]bool
+	}{
+		{
+			name: "npmignore takes precedence over gitignore",
+			structure: map[string]string{
+				".npmignore": "test/\n",
+				".gitignore": "dist/\n",
+			},
+			cases: map[string]bool{
+				"test/index.test.js": true,
+				"dist/index.js":      false,
+				"index.js":           false,
+			},
+		},
+		{
+			name: "gitignore is used when npmignore is absent",
+			structure: map[string]string{
+				".gitignore":     "dist/\n",
+				"lib/.npmignore": "*.map\n",
+			},
+			cases: map[string]bool{
+				"dist/index.js":    true,
+				"lib/index.js.map": true,
+				"lib/index.js":     false,
+			},
+		},
+		{
+			name: "implicit excludes cannot be negated",
+			structure: map[string]string{
+				".npmignore": "!node_modules/\n!.npmrc\n",
+			},
+			cases: map[string]bool{
+				"node_modules/dep/index.js": true,
+				".npmrc":                    true,
+				".git/config":               true,
+				"package-lock.json":         true,
+				".gitignore":                true,
+				"lib/.DS_Store":             true,
+			},
+		},
+		{
+			name: "implicit includes cannot be ignored",
+			structure: map[string]string{
+				".npmignore": "*\n",
+			},
+			cases: map[string]bool{
+				"package.json":   false,
+				"README.md":      false,
+				"readme":         false,
+				"LICENSE":        false,
+				"LICENCE.txt":    false,
+				"docs/README.md": true,
+				"index.js":       true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := createTestRepo(t, tt.structure)
+			defer os.RemoveAll(tmpDir)
+
+			matcher, err := NewNpmMatcher(tmpDir)
+			if err != nil {
+				t.Fatalf("NewNpmMatcher() failed: %v", err)
+			}
+
+			for path, want := range tt.cases {
+				got, err := matcher.Matches(path)
+				if err != nil {
+					t.Errorf("Matches(%q) error: %v", path, err)
+					continue
+				}
+				if got != want {
+					t.Errorf("Matches(%q) = %v, want %v", path, got, want)
+				}
+			}
+		})
+	}
+}
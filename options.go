@@ -0,0 +1,308 @@
+package dotignore
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeglyph/go-dotignore/v2/internal"
+)
+
+// Option configures optional behavior of a PatternMatcher.
+// Options are passed to NewPatternMatcher and the other PatternMatcher constructors.
+type Option func(*matcherOptions)
+
+// matcherOptions holds the settings applied by Option values.
+type matcherOptions struct {
+	syntax             Syntax
+	strictNegation     bool
+	caseInsensitive    bool
+	noSubpathHeuristic bool
+	gitStrict          bool // anchor patterns containing a slash
+	parentsDecided     bool // the caller applies excluded parents, as with strictNegation
+	strictDoubleStar   bool // "**" crosses directories only as a whole component
+	strictDirs         bool // a path is a directory only if it ends with a slash
+	backslashEscapes   bool // a backslash in a pattern escapes the next character
+	basePath           string
+	baseVolume         string // Windows volume of basePath, if any
+	baseDir            string // basePath as a clean slash-separated path
+	trackHits          bool
+	maxLineLength      int         // 0 means DefaultMaxLineLength
+	maxPatterns        int         // 0 means no limit
+	maxPatternLength   int         // 0 means no limit
+	lenient            bool        // skip lines that cannot be parsed
+	onInvalid          func(Issue) // reports skipped lines; may be nil
+	logger             Logger      // nil unless decisions are logged
+	trace              func(TraceEvent)
+	openInclude        includeOpener // resolves SyntaxGcloud includes; nil for the current directory
+}
+
+// WithStrictNegation enables Git's rule that a file cannot be re-included by a
+// negation pattern if one of its parent directories is excluded.
+//
+// For example, with the patterns "build/" and "!build/keep.txt", Git still
+// ignores build/keep.txt because the build directory itself is excluded and
+// Git never looks inside it. By default PatternMatcher evaluates every path
+// independently and would report build/keep.txt as not ignored.
+func WithStrictNegation() Option {
+	return func(o *matcherOptions) {
+		o.strictNegation = true
+	}
+}
+
+// WithCaseInsensitive matches patterns against paths without regard to letter
+// case, as Git does when core.ignoreCase is set (the default on Windows and macOS).
+func WithCaseInsensitive() Option {
+	return func(o *matcherOptions) {
+		o.caseInsensitive = true
+	}
+}
+
+// WithBasePath sets the directory that patterns are relative to. Absolute
+// paths passed to Matches are made relative to basePath before matching, and
+// paths outside basePath are reported as errors. Relative paths are assumed to
+// be relative to basePath already.
+//
+// Both basePath and the paths passed to Matches may be Windows paths with a
+// drive letter, a UNC share or a "\\?\" long-path prefix; the volumes are
+// compared without regard to case.
+func WithBasePath(basePath string) Option {
+	return func(o *matcherOptions) {
+		o.setBasePath(basePath)
+	}
+}
+
+// setBasePath records basePath along with the parts of it that paths are
+// compared against.
+func (o *matcherOptions) setBasePath(basePath string) {
+	o.basePath = filepath.Clean(basePath)
+	o.baseVolume, o.baseDir = splitPath(o.basePath)
+}
+
+// WithoutSubpathHeuristic disables the non-standard fallback that tries
+// wildcard patterns against every trailing sub-path of the queried path.
+//
+// By default a wildcard pattern such as "docs/*.md" also matches
+// "site/docs/index.md", because the pattern matches the sub-path
+// "docs/index.md". Git anchors such patterns to the directory of the ignore
+// file, so with this option "docs/*.md" only matches "docs/index.md".
+func WithoutSubpathHeuristic() Option {
+	return func(o *matcherOptions) {
+		o.noSubpathHeuristic = true
+	}
+}
+
+// WithStrictDoubleStar treats "**" as Git does: it matches any number of
+// directories only as a whole path component, that is as a leading "**/", a
+// trailing "/**" or a "/**/" in the middle. Anywhere else it matches like
+// "*" and does not cross a slash, so "a**b" matches "axb" but not "a/b".
+//
+// By default a "**" in any position matches across directories.
+func WithStrictDoubleStar() Option {
+	return func(o *matcherOptions) {
+		o.strictDoubleStar = true
+	}
+}
+
+// WithStrictDirectoryPatterns makes directory patterns such as "build/"
+// match only directories and the paths beneath them, as Git does. Since a
+// path alone does not say whether it names a directory, Matches treats paths
+// ending in a slash, such as "build/", as directories and all others as
+// files; use MatchesPath to say which a path is instead.
+//
+// By default a directory pattern also matches a file of the same name, and
+// only paths ending in a slash are known to be directories.
+func WithStrictDirectoryPatterns() Option {
+	return func(o *matcherOptions) {
+		o.strictDirs = true
+	}
+}
+
+// WithBackslashEscapes treats a backslash in a Git pattern as an escape
+// character, as Git does, so that "foo\[bar\].txt" matches the file
+// foo[bar].txt and "\*" matches only a file named "*". WithGitStrict
+// implies it.
+//
+// By default every backslash in a pattern is converted to a slash, so that
+// patterns written with Windows separators such as "build\out" still match.
+// A backslash in a path passed to Matches is then a separator too; with
+// WithBackslashEscapes it is one only on Windows, and elsewhere it is an
+// ordinary character of a name, as it is to Git.
+func WithBackslashEscapes() Option {
+	return func(o *matcherOptions) {
+		o.backslashEscapes = true
+	}
+}
+
+// backslashSeparates reports whether a backslash in a path passed to Matches
+// is a separator. With backslash escapes, Git patterns can name files that
+// contain a backslash, which only Windows forbids.
+func (o matcherOptions) backslashSeparates() bool {
+	return !o.backslashEscapes || os.PathSeparator == '\\'
+}
+
+// kindOf returns what is known about the type of the path file passed to
+// Matches.
+func (o matcherOptions) kindOf(file string) pathKind {
+	switch {
+	case strings.HasSuffix(file, "/"), o.backslashSeparates() && strings.HasSuffix(file, `\`):
+		return kindDir
+	case !o.strictDirs:
+		return kindUnknown
+	default:
+		return kindFile
+	}
+}
+
+// WithGitStrict matches patterns exactly as Git does where PatternMatcher
+// otherwise departs from the gitignore specification. A pattern with a slash
+// at its beginning or in its middle, such as "src/test", is anchored to the
+// directory of the ignore file, so it matches "src/test" and the paths
+// beneath it but not "foo/src/test"; any other pattern is matched against
+// the last component of a path. It also implies WithoutSubpathHeuristic,
+// WithStrictDoubleStar and WithBackslashEscapes.
+//
+// A pattern that matches a directory, with or without wildcards, matches
+// everything beneath it, so "/f*" matches foo/a.log. A negation only
+// re-includes the paths it matches itself: after "*.log" and "!b/",
+// b/a.log is still ignored, as Git re-includes the directory b but not the
+// files in it. Git also never re-includes a path beneath an excluded
+// directory, and lets a directory re-included by a later negation, as with
+// "b/" and "!*/", pass nothing of the exclusion on to its contents; add
+// WithStrictNegation for both.
+//
+// Patterns of the other syntaxes follow their own rules and are unaffected.
+// By default, patterns with a slash also match at any directory boundary.
+// Git-strict matching is expected to become the default in a future major
+// version.
+func WithGitStrict() Option {
+	return func(o *matcherOptions) {
+		o.gitStrict = true
+		o.noSubpathHeuristic = true
+		o.strictDoubleStar = true
+		o.backslashEscapes = true
+	}
+}
+
+// strictGit reports whether patterns are matched as Git matches them, which
+// WithGitStrict selects for the syntaxes following the gitignore rules.
+func (o matcherOptions) strictGit() bool {
+	return o.gitStrict && o.syntax != SyntaxDocker && o.syntax != SyntaxHelm
+}
+
+// matchesBeneath reports whether a Git-strict pattern matching a directory
+// matches the paths beneath it too. With strict negation the state of the
+// directory decides for them instead, as a later negation may re-include it.
+func (o matcherOptions) matchesBeneath() bool {
+	return !o.strictNegation && !o.parentsDecided
+}
+
+// withParentsDecided is used by RepositoryMatcher, which applies strict
+// negation across its ignore files, for the matchers of those files.
+func withParentsDecided() Option {
+	return func(o *matcherOptions) {
+		o.parentsDecided = true
+	}
+}
+
+// WithHitTracking counts how many paths each pattern matches, so patterns that
+// never match anything can be found with UnusedPatterns. Counting adds a small
+// cost to every match and is disabled by default.
+func WithHitTracking() Option {
+	return func(o *matcherOptions) {
+		o.trackHits = true
+	}
+}
+
+// DefaultMaxLineLength is the longest line, in bytes, that
+// NewPatternMatcherFromReader and NewPatternMatcherFromFile accept unless
+// WithMaxLineLength says otherwise.
+const DefaultMaxLineLength = internal.DefaultMaxLineLength
+
+// WithMaxLineLength sets the longest line, in bytes, that
+// NewPatternMatcherFromReader and NewPatternMatcherFromFile accept. Longer
+// lines make them fail with an error wrapping bufio.ErrTooLong. A value of
+// zero or less selects DefaultMaxLineLength.
+func WithMaxLineLength(n int) Option {
+	return func(o *matcherOptions) {
+		o.maxLineLength = n
+	}
+}
+
+// WithMaxPatterns limits the number of patterns a matcher may hold to n.
+// Constructors, SetPatterns, AddPatterns and Merge fail with an error
+// wrapping ErrTooManyPatterns rather than exceed it. A value of zero or less
+// means no limit.
+//
+// Together with WithMaxLineLength and WithMaxPatternLength, it bounds the
+// memory used by patterns from untrusted sources.
+func WithMaxPatterns(n int) Option {
+	return func(o *matcherOptions) {
+		o.maxPatterns = n
+	}
+}
+
+// WithMaxPatternLength rejects patterns longer than n bytes, not counting
+// surrounding whitespace, with a *ParseError wrapping ErrPatternTooLong.
+// Comments are not limited. A value of zero or less means no limit.
+func WithMaxPatternLength(n int) Option {
+	return func(o *matcherOptions) {
+		o.maxPatternLength = n
+	}
+}
+
+// checkPatternLengths returns a *ParseError for the first of lines holding
+// a pattern longer than the limit of the options. If invalid lines are
+// skipped, it instead returns a copy of lines with the long ones blanked.
+func (o matcherOptions) checkPatternLengths(lines []string) ([]string, error) {
+	if o.maxPatternLength <= 0 {
+		return lines, nil
+	}
+	checked := lines
+	for i, raw := range lines {
+		text := strings.TrimSpace(raw)
+		if len(text) <= o.maxPatternLength || strings.HasPrefix(text, "#") {
+			continue
+		}
+		err := newParseError(raw, i+1, fmt.Errorf("%w: %d bytes exceed the limit of %d", ErrPatternTooLong, len(text), o.maxPatternLength))
+		if !o.skipInvalid(err) {
+			return nil, err
+		}
+		if &checked[0] == &lines[0] {
+			checked = append([]string(nil), lines...)
+		}
+		checked[i] = ""
+	}
+	return checked, nil
+}
+
+// checkPatternCount returns an error if n patterns exceed the limit of the
+// options.
+func (o matcherOptions) checkPatternCount(n int) error {
+	if o.maxPatterns > 0 && n > o.maxPatterns {
+		return fmt.Errorf("%w: %d patterns exceed the limit of %d", ErrTooManyPatterns, n, o.maxPatterns)
+	}
+	return nil
+}
+
+// readLines reads the lines of an ignore file, enforcing the line length
+// limit of the options.
+func (o matcherOptions) readLines(reader io.Reader) ([]string, error) {
+	if o.maxLineLength <= 0 {
+		return internal.ReadLines(reader)
+	}
+	return internal.ReadLinesLimit(reader, o.maxLineLength)
+}
+
+// buildOptions applies opts in order and returns the resulting settings.
+func buildOptions(opts []Option) matcherOptions {
+	var o matcherOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
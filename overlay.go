@@ -0,0 +1,77 @@
+package dotignore
+
+import "fmt"
+
+// OverlayPrecedence selects where the patterns of an overlay are evaluated
+// relative to the ignore files of a repository.
+type OverlayPrecedence int
+
+const (
+	// OverlayHighest evaluates the overlay after every ignore file and
+	// RepositoryConfig.ExtraPatterns, so its patterns decide wherever they
+	// match, like the patterns passed to "git clean -e".
+	OverlayHighest OverlayPrecedence = iota
+
+	// OverlayLowest evaluates the overlay before every ignore file, like
+	// core.excludesFile, so that the ignore files can override it.
+	OverlayLowest
+)
+
+// String returns the name of the precedence.
+func (p OverlayPrecedence) String() string {
+	switch p {
+	case OverlayHighest:
+		return "highest"
+	case OverlayLowest:
+		return "lowest"
+	default:
+		return fmt.Sprintf("OverlayPrecedence(%d)", int(p))
+	}
+}
+
+// WithOverlay returns a view of the repository matcher that also applies
+// patterns, relative to the root directory, at the given precedence. The
+// view shares the loaded ignore files with rm and costs no more than
+// parsing patterns, so a long-lived server can create one per request for
+// excludes given with it. Neither rm nor the files on disk are changed.
+//
+// The overlay patterns are parsed with the options of ExtraPatterns. A view
+// can be given further overlays; at the same precedence, a later overlay
+// takes precedence over an earlier one. Decisions made by overlay patterns
+// are reported by MatchDetail with an empty Source.
+//
+// The view sees the ignore files rm had when it was created: Reload and
+// Refresh on rm do not affect it, and must not be called concurrently with
+// WithOverlay. With SkipIgnoredDirectories, this includes the directories
+// that discovery skipped without the overlay, so an overlay negation such as
+// "!vendor/" re-includes vendor without applying the ignore files in it.
+// Call Reload on the view to discover them with the overlay applied.
+func (rm *RepositoryMatcher) WithOverlay(patterns []string, precedence OverlayPrecedence) (*RepositoryMatcher, error) {
+	if precedence != OverlayHighest && precedence != OverlayLowest {
+		return nil, fmt.Errorf("invalid overlay precedence %v", precedence)
+	}
+	overlay, err := NewPatternMatcher(patterns, rm.patternOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid overlay patterns: %w", err)
+	}
+
+	existing := rm.overlay
+	if precedence == OverlayLowest {
+		existing = rm.underlay
+	}
+	if existing != nil {
+		combined := existing.Clone()
+		if err := combined.Merge(overlay); err != nil {
+			return nil, fmt.Errorf("invalid overlay patterns: %w", err)
+		}
+		overlay = combined
+	}
+
+	view := *rm
+	if precedence == OverlayLowest {
+		view.underlay = overlay
+	} else {
+		view.overlay = overlay
+	}
+	return &view, nil
+}
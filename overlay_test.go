@@ -0,0 +1,198 @@
+package dotignore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepositoryMatcher_WithOverlay(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":     "*.log\n",
+		"app/.gitignore": "!debug.log\n",
+		"app/main.go":    "",
+		"app/debug.log":  "",
+		"tmp/data.bin":   "",
+		"trace.log":      "",
+		"notes.txt":      "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	highest, err := matcher.WithOverlay([]string{"tmp/", "app/debug.log", "!trace.log"}, OverlayHighest)
+	if err != nil {
+		t.Fatalf("WithOverlay() failed: %v", err)
+	}
+	lowest, err := matcher.WithOverlay([]string{"tmp/", "app/debug.log", "!trace.log"}, OverlayLowest)
+	if err != nil {
+		t.Fatalf("WithOverlay() failed: %v", err)
+	}
+	stacked, err := highest.WithOverlay([]string{"!tmp/"}, OverlayHighest)
+	if err != nil {
+		t.Fatalf("WithOverlay() failed: %v", err)
+	}
+
+	tests := []struct {
+		path                               string
+		original, highest, lowest, stacked bool
+	}{
+		{"tmp/data.bin", false, true, true, false},
+		{"app/debug.log", false, true, false, true},
+		{"trace.log", true, false, true, false},
+		{"notes.txt", false, false, false, false},
+		{"app/main.go", false, false, false, false},
+	}
+
+	for _, tt := range tests {
+		for _, view := range []struct {
+			name     string
+			matcher  *RepositoryMatcher
+			expected bool
+		}{
+			{"original", matcher, tt.original},
+			{"highest", highest, tt.highest},
+			{"lowest", lowest, tt.lowest},
+			{"stacked", stacked, tt.stacked},
+		} {
+			ignored, err := view.matcher.Matches(tt.path)
+			if err != nil {
+				t.Fatalf("Matches(%q) failed: %v", tt.path, err)
+			}
+			if ignored != view.expected {
+				t.Errorf("%s: Matches(%q) = %v, want %v", view.name, tt.path, ignored, view.expected)
+			}
+		}
+	}
+
+	pruned, err := matcher.WithOverlay([]string{"tmp/"}, OverlayHighest)
+	if err != nil {
+		t.Fatalf("WithOverlay() failed: %v", err)
+	}
+	if skip, err := pruned.CanSkipDir("tmp"); err != nil || !skip {
+		t.Errorf("CanSkipDir(tmp) = %v, %v, want true", skip, err)
+	}
+	if skip, err := matcher.CanSkipDir("tmp"); err != nil || skip {
+		t.Errorf("original CanSkipDir(tmp) = %v, %v, want false", skip, err)
+	}
+
+	result, err := highest.MatchDetail("app/debug.log")
+	if err != nil || !result.Ignored || result.Source != "" || result.Pattern != "app/debug.log" {
+		t.Errorf("MatchDetail(app/debug.log) = %+v, %v", result, err)
+	}
+
+	patterns, err := lowest.EffectivePatterns("app")
+	if err != nil {
+		t.Fatalf("EffectivePatterns() failed: %v", err)
+	}
+	if len(patterns) != 5 || patterns[0].Text() != "tmp/" || patterns[4].Text() != "!debug.log" {
+		t.Errorf("EffectivePatterns() = %v", patterns)
+	}
+
+	var buf bytes.Buffer
+	if err := highest.Encode(&buf); err != nil {
+		t.Fatalf("Encode() failed: %v", err)
+	}
+	decoded, err := DecodeRepositoryMatcher(&buf)
+	if err != nil {
+		t.Fatalf("DecodeRepositoryMatcher() failed: %v", err)
+	}
+	if ignored, _ := decoded.Matches("tmp/data.bin"); !ignored {
+		t.Error("Decoded view lost its overlay")
+	}
+
+	if _, err := matcher.WithOverlay([]string{"!"}, OverlayHighest); err == nil {
+		t.Error("Expected error for an invalid pattern")
+	}
+	if _, err := matcher.WithOverlay(nil, OverlayPrecedence(5)); err == nil {
+		t.Error("Expected error for an invalid precedence")
+	}
+}
+
+func TestRepositoryMatcher_WithOverlaySkipIgnoredDirectories(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":        "vendor/\n",
+		"vendor/.gitignore": "*.tmp\n",
+		"vendor/lib.go":     "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	config := DefaultRepositoryConfig()
+	config.SkipIgnoredDirectories = true
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	view, err := matcher.WithOverlay([]string{"!vendor/"}, OverlayHighest)
+	if err != nil {
+		t.Fatalf("WithOverlay() failed: %v", err)
+	}
+
+	// The skipped directory's ignore file is only loaded once the view is
+	// reloaded
+	if files := view.IgnoreFilePaths(); len(files) != 1 {
+		t.Errorf("IgnoreFilePaths() = %v before Reload, want only .gitignore", files)
+	}
+	if err := view.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if files := slashPaths(view.IgnoreFilePaths()); len(files) != 2 || files[1] != "vendor/.gitignore" {
+		t.Errorf("IgnoreFilePaths() = %v after Reload, want vendor/.gitignore as well", files)
+	}
+	if ignored, _ := view.Matches("vendor/lib.go"); ignored {
+		t.Error("Matches(vendor/lib.go) = true, want false")
+	}
+	if ignored, _ := matcher.Matches("vendor/lib.go"); !ignored || len(matcher.IgnoreFilePaths()) != 1 {
+		t.Error("Reloading the view changed the original matcher")
+	}
+}
+
+func TestRepositoryMatcher_WithOverlayReloadIgnoreCase(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+
+	tmpDir := createTestRepo(t, map[string]string{
+		".git/config": "[core]\n\tignorecase = false\n",
+		"debug.log":   "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	config := DefaultRepositoryConfig()
+	config.ReadGitConfig = true
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	view, err := matcher.WithOverlay([]string{"*.LOG"}, OverlayHighest)
+	if err != nil {
+		t.Fatalf("WithOverlay() failed: %v", err)
+	}
+	if ignored, _ := view.Matches("debug.log"); ignored {
+		t.Error("Matches(debug.log) = true before core.ignoreCase was set, want false")
+	}
+
+	// A reload picking up core.ignoreCase applies it to the overlay, and to
+	// overlays added afterwards
+	if err := os.WriteFile(filepath.Join(tmpDir, ".git", "config"), []byte("[core]\n\tignorecase = true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := view.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if ignored, _ := view.Matches("debug.log"); !ignored {
+		t.Error("Matches(debug.log) = false after core.ignoreCase was set, want true")
+	}
+	lowest, err := view.WithOverlay([]string{"*.TMP"}, OverlayLowest)
+	if err != nil {
+		t.Fatalf("WithOverlay() failed: %v", err)
+	}
+	if ignored, _ := lowest.Matches("cache.tmp"); !ignored {
+		t.Error("Matches(cache.tmp) = false for an overlay added after Reload, want true")
+	}
+}
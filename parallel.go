@@ -0,0 +1,85 @@
+package dotignore
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelChunkSize is the number of paths a worker claims at a time, which
+// keeps contention on the shared counter low for large batches.
+const parallelChunkSize = 64
+
+// MatchesParallel matches paths concurrently using up to workers goroutines
+// and returns the results in the same order as paths. If workers is zero or
+// negative, runtime.GOMAXPROCS(0) workers are used.
+//
+// If matching any path fails, the remaining paths are skipped and one of the
+// errors is returned.
+func (p *PatternMatcher) MatchesParallel(paths []string, workers int) ([]bool, error) {
+	return matchParallel(paths, workers, p.Matches)
+}
+
+// MatchesParallel matches paths concurrently using up to workers goroutines
+// and returns the results in the same order as paths. If workers is zero or
+// negative, runtime.GOMAXPROCS(0) workers are used.
+//
+// If matching any path fails, the remaining paths are skipped and one of the
+// errors is returned. MatchesParallel must not be called concurrently with
+// Reload.
+func (rm *RepositoryMatcher) MatchesParallel(paths []string, workers int) ([]bool, error) {
+	return matchParallel(paths, workers, rm.Matches)
+}
+
+// matchParallel fans paths out to workers that claim chunks of the input
+// until it is exhausted or an error occurs.
+func matchParallel(paths []string, workers int, match func(string) (bool, error)) ([]bool, error) {
+	results := make([]bool, len(paths))
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if chunks := (len(paths) + parallelChunkSize - 1) / parallelChunkSize; workers > chunks {
+		workers = chunks
+	}
+
+	var (
+		next     atomic.Int64
+		failed   atomic.Bool
+		errOnce  sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				start := int(next.Add(parallelChunkSize)) - parallelChunkSize
+				if start >= len(paths) {
+					return
+				}
+				end := start + parallelChunkSize
+				if end > len(paths) {
+					end = len(paths)
+				}
+
+				for i := start; i < end; i++ {
+					matched, err := match(paths[i])
+					if err != nil {
+						errOnce.Do(func() { firstErr = err })
+						failed.Store(true)
+						return
+					}
+					results[i] = matched
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
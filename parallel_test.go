@@ -0,0 +1,78 @@
+package dotignore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchesParallel(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log", "!important.log", "/build/", "node_modules/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	var paths []string
+	for i := 0; i < 1000; i++ {
+		paths = append(paths,
+			fmt.Sprintf("src/pkg%d/app.log", i),
+			fmt.Sprintf("src/pkg%d/important.log", i),
+			fmt.Sprintf("build/out%d.bin", i),
+			fmt.Sprintf("web/node_modules/mod%d/index.js", i),
+			fmt.Sprintf("src/pkg%d/main.go", i),
+		)
+	}
+
+	for _, workers := range []int{0, 1, 3, 16} {
+		results, err := matcher.MatchesParallel(paths, workers)
+		if err != nil {
+			t.Fatalf("Workers %d: unexpected error: %v", workers, err)
+		}
+		if len(results) != len(paths) {
+			t.Fatalf("Workers %d: expected %d results, got %d", workers, len(paths), len(results))
+		}
+		for i, path := range paths {
+			expected, _ := matcher.Matches(path)
+			if results[i] != expected {
+				t.Errorf("Workers %d, file %q: expected %v, got %v", workers, path, expected, results[i])
+			}
+		}
+	}
+
+	results, err := matcher.MatchesParallel(nil, 4)
+	if err != nil || len(results) != 0 {
+		t.Errorf("Expected empty results for no paths, got %v, %v", results, err)
+	}
+}
+
+func TestRepositoryMatcher_MatchesParallel(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":     "*.log\n",
+		"app/.gitignore": "dist/\n!keep.log\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	paths := []string{"debug.log", "app/keep.log", "app/dist/bundle.js", "app/main.go"}
+	expected := []bool{true, false, true, false}
+
+	results, err := matcher.MatchesParallel(paths, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i, path := range paths {
+		if results[i] != expected[i] {
+			t.Errorf("File %q: expected %v, got %v", path, expected[i], results[i])
+		}
+	}
+
+	outside := filepath.Join(filepath.Dir(tmpDir), "elsewhere.log")
+	if _, err := matcher.MatchesParallel([]string{"a.log", outside}, 2); err == nil {
+		t.Error("Expected error for path outside the repository")
+	}
+}
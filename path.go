@@ -0,0 +1,131 @@
+package dotignore
+
+import (
+	"os"
+	"path"
+	"strings"
+)
+
+// splitPath converts file into a clean slash-separated path and splits off
+// its Windows volume: a drive letter ("C:"), a UNC share
+// ("\\server\share") or either of those behind a "\\?\" or "\\.\" device
+// prefix. The remainder of an absolute path starts with "/", just like a
+// Unix absolute path.
+//
+// UNC and device prefixes are only recognized in their backslash form, or
+// on Windows, since "//" is an ordinary path on other systems.
+func splitPath(file string) (volume, rest string) {
+	unc := strings.HasPrefix(file, `\\`) || (os.PathSeparator == '\\' && strings.HasPrefix(file, "//"))
+	if strings.IndexByte(file, '\\') >= 0 {
+		file = strings.ReplaceAll(file, `\`, "/")
+	}
+
+	if unc {
+		rest := file[2:]
+		if len(rest) >= 2 && (rest[0] == '?' || rest[0] == '.') && rest[1] == '/' {
+			// Device path: \\?\C:\dir, \\?\UNC\server\share\dir
+			rest = rest[2:]
+			switch {
+			case len(rest) >= 4 && strings.EqualFold(rest[:4], "UNC/"):
+				rest = rest[4:]
+				n := prefixLength(rest, 2)
+				return file[:len(file)-len(rest)+n], path.Clean("/" + rest[n:])
+			case isDriveLetter(rest):
+				file = rest
+			default:
+				n := prefixLength(rest, 1)
+				return file[:len(file)-len(rest)+n], path.Clean("/" + rest[n:])
+			}
+		} else {
+			n := prefixLength(rest, 2)
+			return file[:2+n], path.Clean("/" + rest[n:])
+		}
+	}
+
+	if isDriveLetter(file) {
+		volume, file = file[:2], file[2:]
+		if file == "" {
+			return volume, ""
+		}
+	}
+	return volume, path.Clean(file)
+}
+
+// splitLiteralPath is like splitPath for a path whose backslashes are
+// ordinary characters of its names rather than separators. Only a drive
+// letter written with a forward slash, such as "C:/dir", is split off.
+func splitLiteralPath(file string) (volume, rest string) {
+	if isDriveLetter(file) {
+		volume, file = file[:2], file[2:]
+		if file == "" {
+			return volume, ""
+		}
+	}
+	return volume, path.Clean(file)
+}
+
+// isDriveLetter reports whether file starts with a drive letter volume such
+// as "C:" that is followed by a separator or nothing at all.
+func isDriveLetter(file string) bool {
+	if len(file) < 2 || file[1] != ':' || (len(file) > 2 && file[2] != '/') {
+		return false
+	}
+	c := file[0]
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// hasTrailingSlash reports whether file ends in a slash or backslash, which
+// marks it as a directory.
+func hasTrailingSlash(file string) bool {
+	return strings.HasSuffix(file, "/") || strings.HasSuffix(file, `\`)
+}
+
+// prefixLength returns the length of the first n slash-separated components
+// of file, not including the separator that follows them.
+func prefixLength(file string, n int) int {
+	end := 0
+	for ; n > 0; n-- {
+		i := strings.IndexByte(file[end:], '/')
+		if i < 0 {
+			return len(file)
+		}
+		end += i
+		if n > 1 {
+			end++
+		}
+	}
+	return end
+}
+
+// relativeToBase makes the absolute slash-separated file with the given
+// volume relative to the base path of the options. It reports false if file
+// lies outside the base path.
+func (o matcherOptions) relativeToBase(volume, file string) (string, bool) {
+	if volume != "" && o.baseVolume != "" && !strings.EqualFold(volume, o.baseVolume) {
+		return "", false
+	}
+
+	base := o.baseDir
+	if base == "/" {
+		return file[1:], true
+	}
+	if len(file) < len(base) || !sameName(file[:len(base)], base) {
+		return "", false
+	}
+	if len(file) == len(base) {
+		return ".", true
+	}
+	if file[len(base)] != '/' {
+		return "", false
+	}
+	return file[len(base)+1:], true
+}
+
+// sameName compares path names the way the file system does: without
+// regard to case on Windows, and exactly elsewhere.
+func sameName(a, b string) bool {
+	if os.PathSeparator == '\\' {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
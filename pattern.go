@@ -0,0 +1,104 @@
+package dotignore
+
+import "strings"
+
+// Pattern describes a single parsed ignore pattern. Comments and blank lines
+// are not patterns and are never reported.
+type Pattern struct {
+	text         string
+	glob         string
+	syntax       Syntax
+	line         int
+	negate       bool
+	dirOnly      bool
+	rootRelative bool
+}
+
+// Text returns the pattern as written in the source, without surrounding
+// whitespace. Negation and escape characters are preserved, so "!keep.txt"
+// is reported as "!keep.txt".
+func (p Pattern) Text() string {
+	return p.text
+}
+
+// Line returns the 1-based line number of the pattern within the input it was
+// parsed from. For patterns added with AddPatterns the line is relative to the
+// slice passed to that call.
+func (p Pattern) Line() int {
+	return p.line
+}
+
+// Negate reports whether the pattern re-includes paths (starts with "!").
+func (p Pattern) Negate() bool {
+	return p.negate
+}
+
+// DirOnly reports whether the pattern only matches directories (ends with "/").
+func (p Pattern) DirOnly() bool {
+	return p.dirOnly
+}
+
+// RootRelative reports whether the pattern is anchored to the root of the
+// matcher rather than matching at any depth.
+func (p Pattern) RootRelative() bool {
+	return p.rootRelative
+}
+
+// Glob returns the glob the pattern matches paths with, after the negation
+// prefix, the leading and trailing slashes and escapes of the source text
+// have been resolved. For "!/build/" it returns "build".
+func (p Pattern) Glob() string {
+	return p.glob
+}
+
+// String returns the pattern text.
+func (p Pattern) String() string {
+	return p.text
+}
+
+// Patterns returns the parsed patterns in evaluation order.
+func (p *PatternMatcher) Patterns() []Pattern {
+	rules := p.rules.Load()
+
+	patterns := make([]Pattern, len(rules.patterns))
+	for i, pattern := range rules.patterns {
+		patterns[i] = pattern.export(p.options.syntax)
+	}
+	return patterns
+}
+
+// Source returns the text of every pattern in evaluation order, as reported
+// by Pattern.Text. Comments and blank lines are left out.
+func (p *PatternMatcher) Source() []string {
+	rules := p.rules.Load()
+
+	source := make([]string, len(rules.patterns))
+	for i, pattern := range rules.patterns {
+		source[i] = pattern.text
+	}
+	return source
+}
+
+// String renders the patterns as the contents of an ignore file, one pattern
+// per line. Parsing the result with the same options yields an equivalent
+// matcher.
+func (p *PatternMatcher) String() string {
+	source := p.Source()
+	if len(source) == 0 {
+		return ""
+	}
+	return strings.Join(source, "\n") + "\n"
+}
+
+// export converts the internal representation into a public Pattern.
+func (ip ignorePattern) export(syntax Syntax) Pattern {
+	return Pattern{
+		text:         ip.text,
+		glob:         ip.pattern,
+		syntax:       syntax,
+		line:         ip.line,
+		negate:       ip.negate,
+		dirOnly:      ip.isDirectory,
+		rootRelative: ip.isRootRelative,
+	}
+}
@@ -0,0 +1,165 @@
+package dotignore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPatterns(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{
+		"# build outputs",
+		"/build/",
+		"",
+		"*.log",
+		"!important.log",
+		"\\!literal",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	expected := []Pattern{
+		{text: "/build/", line: 2, dirOnly: true, rootRelative: true},
+		{text: "*.log", line: 4},
+		{text: "!important.log", line: 5, negate: true},
+		{text: "\\!literal", line: 6},
+	}
+
+	patterns := matcher.Patterns()
+	if len(patterns) != len(expected) {
+		t.Fatalf("Expected %d patterns, got %d", len(expected), len(patterns))
+	}
+
+	for i, want := range expected {
+		got := patterns[i]
+		if got.Text() != want.text || got.Line() != want.line || got.Negate() != want.negate ||
+			got.DirOnly() != want.dirOnly || got.RootRelative() != want.rootRelative {
+			t.Errorf("Pattern %d: expected %+v, got %+v", i, want, got)
+		}
+		if got.String() != want.text {
+			t.Errorf("Pattern %d: String() = %q, want %q", i, got.String(), want.text)
+		}
+	}
+
+	// The returned slice is a copy
+	patterns[0] = Pattern{}
+	if matcher.Patterns()[0].Text() != "/build/" {
+		t.Error("Modifying the result of Patterns() changed the matcher")
+	}
+}
+
+func TestSourceAndString(t *testing.T) {
+	lines := []string{
+		"# build outputs",
+		"  /build/  ",
+		"",
+		"*.log",
+		"!important.log",
+		"trailing\\ ",
+	}
+	matcher, err := NewPatternMatcher(lines)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	want := []string{"/build/", "*.log", "!important.log", "trailing\\ "}
+	if got := matcher.Source(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Source() = %q, want %q", got, want)
+	}
+	if got, want := matcher.String(), "/build/\n*.log\n!important.log\ntrailing\\ \n"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	// The rendered text parses back into the same patterns
+	reparsed, err := NewPatternMatcherFromReader(strings.NewReader(matcher.String()))
+	if err != nil {
+		t.Fatalf("Failed to parse String(): %v", err)
+	}
+	for _, file := range []string{"build/out", "debug.log", "important.log", "trailing ", "trailing"} {
+		want, err := matcher.Matches(file)
+		if err != nil {
+			t.Fatalf("Matches(%q) failed: %v", file, err)
+		}
+		got, err := reparsed.Matches(file)
+		if err != nil {
+			t.Fatalf("Matches(%q) failed: %v", file, err)
+		}
+		if got != want {
+			t.Errorf("Reparsed matcher: Matches(%q) = %v, want %v", file, got, want)
+		}
+	}
+
+	empty, err := NewPatternMatcher([]string{"# only a comment"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if got := empty.String(); got != "" {
+		t.Errorf("String() = %q, want empty", got)
+	}
+}
+
+func TestPatternTokens(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		opts     []Option
+		glob     string
+		regexp   string
+		expected []Token
+	}{
+		{
+			pattern:  "*.log",
+			glob:     "*.log",
+			regexp:   `^[^/]*\.log$`,
+			expected: []Token{{Kind: TokenStar}, {Kind: TokenLiteral, Value: ".log"}},
+		},
+		{
+			pattern: "!/src/**/gen?/",
+			glob:    "src/**/gen?",
+			regexp:  `^src/(.*?/)?gen[^/]$`,
+			expected: []Token{
+				{Kind: TokenLiteral, Value: "src/"}, {Kind: TokenAnyDirs},
+				{Kind: TokenLiteral, Value: "gen"}, {Kind: TokenAnyChar},
+			},
+		},
+		{
+			pattern:  "logs/**",
+			glob:     "logs/**",
+			regexp:   `^logs/.*$`,
+			expected: []Token{{Kind: TokenLiteral, Value: "logs/"}, {Kind: TokenAnything}},
+		},
+		{
+			pattern:  "file[0-9].txt",
+			glob:     "file[0-9].txt",
+			regexp:   `^file[0-9]\.txt$`,
+			expected: []Token{{Kind: TokenLiteral, Value: "file"}, {Kind: TokenClass, Value: "0-9"}, {Kind: TokenLiteral, Value: ".txt"}},
+		},
+		{
+			pattern:  "a**b",
+			opts:     []Option{WithSyntax(SyntaxDocker)},
+			glob:     "a**b",
+			regexp:   `^a(.*/)?b$`,
+			expected: []Token{{Kind: TokenLiteral, Value: "a"}, {Kind: TokenAnyDirs}, {Kind: TokenLiteral, Value: "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			matcher, err := NewPatternMatcher([]string{tt.pattern}, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+			pattern := matcher.Patterns()[0]
+
+			if pattern.Glob() != tt.glob {
+				t.Errorf("Glob() = %q, want %q", pattern.Glob(), tt.glob)
+			}
+			if pattern.Regexp() != tt.regexp {
+				t.Errorf("Regexp() = %q, want %q", pattern.Regexp(), tt.regexp)
+			}
+			if tokens := pattern.Tokens(); !reflect.DeepEqual(tokens, tt.expected) {
+				t.Errorf("Tokens() = %+v, want %+v", tokens, tt.expected)
+			}
+		})
+	}
+}
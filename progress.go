@@ -0,0 +1,24 @@
+package dotignore
+
+// Progress holds the running totals of a discovery or walk, reported to
+// RepositoryConfig.OnProgress.
+type Progress struct {
+	// Dirs is the number of directories visited so far.
+	Dirs int64
+	// Files is the number of files visited so far by a walk. Discovery
+	// looks up ignore files by name and does not visit other files.
+	Files int64
+	// IgnoreFiles is the number of ignore files loaded so far by discovery,
+	// including unchanged files reused by Reload and Refresh.
+	IgnoreFiles int64
+	// BytesRead is the size of the ignore files read and parsed so far by
+	// discovery. Reused files are not read again and do not count.
+	BytesRead int64
+}
+
+// reportProgress passes progress to OnProgress, if set.
+func (rm *RepositoryMatcher) reportProgress(progress Progress) {
+	if rm.config.OnProgress != nil {
+		rm.config.OnProgress(progress)
+	}
+}
@@ -0,0 +1,65 @@
+package dotignore
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestRepositoryConfig_OnProgress(t *testing.T) {
+	root := createTestRepo(t, map[string]string{
+		".gitignore":       "*.log\nbuild/\n",
+		"src/.gitignore":   "*.tmp\n",
+		"src/main.go":      "",
+		"src/debug.log":    "",
+		"build/a/out.bin":  "",
+		"docs/README.md":   "",
+		"docs/guide/1.txt": "",
+	})
+	defer os.RemoveAll(root)
+
+	var reports []Progress
+	config := DefaultRepositoryConfig()
+	config.OnProgress = func(p Progress) {
+		reports = append(reports, p)
+	}
+
+	matcher, err := NewRepositoryMatcherWithConfig(root, config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	// Discovery reports once per directory: ., build, build/a, docs, docs/guide, src
+	expected := Progress{Dirs: 6, IgnoreFiles: 2, BytesRead: int64(len("*.log\nbuild/\n") + len("*.tmp\n"))}
+	if len(reports) != 6 || reports[len(reports)-1] != expected {
+		t.Fatalf("discovery reported %v, want 6 reports ending with %+v", reports, expected)
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i].Dirs <= reports[i-1].Dirs {
+			t.Errorf("report %d = %+v does not advance on %+v", i, reports[i], reports[i-1])
+		}
+	}
+
+	// Reload counts reused files but reads nothing
+	reports = nil
+	if err := matcher.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if last := reports[len(reports)-1]; last.IgnoreFiles != 2 || last.BytesRead != 0 {
+		t.Errorf("Reload() reported %+v, want 2 ignore files and no bytes read", last)
+	}
+
+	// Walks report every directory and file they visit, including those
+	// inside directories listed without matching
+	reports = nil
+	if _, err := matcher.ListIgnoredFiles(context.Background()); err != nil {
+		t.Fatalf("ListIgnoredFiles() failed: %v", err)
+	}
+	expected = Progress{Dirs: 6, Files: 7}
+	if last := reports[len(reports)-1]; last != expected {
+		t.Errorf("ListIgnoredFiles() reported %+v last, want %+v", last, expected)
+	}
+	if len(reports) != 13 {
+		t.Errorf("ListIgnoredFiles() reported %d times, want 13", len(reports))
+	}
+}
@@ -0,0 +1,231 @@
+package dotignore
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// CanSkipDir reports whether dir is ignored and so is every path beneath
+// it, so that a directory walk can skip it without reading its contents.
+// It returns false whenever a negation pattern could re-include something
+// beneath dir, even if no such path exists.
+//
+// With WithStrictNegation every ignored directory can be skipped. Otherwise
+// a negation pattern that is not anchored to the root, such as "!*.md",
+// keeps every directory from being skipped once it follows the pattern that
+// excludes the directory. Without any negation pattern, the first pattern
+// that covers everything beneath dir settles it.
+func (p *PatternMatcher) CanSkipDir(dir string) (bool, error) {
+	dir, ok, err := p.normalizePath(dir)
+	if !ok || err != nil {
+		return false, err
+	}
+
+	rules := p.rules.Load()
+
+	i, err := p.resolve(rules, dir, kindDir, true)
+	if err != nil || i < 0 || rules.patterns[i].negate {
+		return false, err
+	}
+	if p.options.strictNegation {
+		return true, nil
+	}
+	return p.subtreeIgnored(false, dir), nil
+}
+
+// CanSkipDir reports whether the directory at path is ignored and so is
+// every path beneath it, taking the ignore files of the repository into
+// account, including those inside the directory. See
+// PatternMatcher.CanSkipDir.
+func (rm *RepositoryMatcher) CanSkipDir(path string) (bool, error) {
+	result, err := rm.MatchDetail(path)
+	if err != nil || !result.Ignored {
+		return false, err
+	}
+	// Built-in exclusions and strict negation cover whole directories
+	if !result.Matched || rm.config.StrictNegation {
+		return true, nil
+	}
+
+	relPath, err := rm.relativePath(path)
+	if err != nil {
+		return false, err
+	}
+	absDir := filepath.Join(rm.rootDir, filepath.FromSlash(relPath))
+
+	// Ignore files from the root down to the directory apply to every path
+	// beneath it, and so may those inside it
+	dirsToCheck := rm.baseDirs()
+	for i := 0; i < len(relPath); i++ {
+		if relPath[i] == '/' {
+			dirsToCheck = append(dirsToCheck, filepath.Join(rm.rootDir, filepath.FromSlash(relPath[:i])))
+		}
+	}
+	dirsToCheck = append(dirsToCheck, absDir)
+
+	if rm.config.NestedRepositories == NestedRepositoryScope {
+		for i := len(dirsToCheck) - 1; i > 0; i-- {
+			if rm.nestedRoots[dirsToCheck[i]] {
+				dirsToCheck = dirsToCheck[i:]
+				break
+			}
+		}
+		// Ancestor rules stop applying inside a nested repository
+		for root := range rm.nestedRoots {
+			if strings.HasPrefix(root, absDir+string(filepath.Separator)) {
+				return false, nil
+			}
+		}
+	}
+
+	var inside []*ignoreFile
+	for dir, files := range rm.matchers {
+		if strings.HasPrefix(dir, absDir+string(filepath.Separator)) {
+			inside = append(inside, files...)
+		}
+	}
+
+	covered := false
+	if rm.underlay != nil {
+		covered = rm.underlay.subtreeIgnored(covered, relPath)
+	}
+	for level := 0; level < rm.levels; level++ {
+		for _, dir := range dirsToCheck {
+			for _, file := range rm.matchers[dir] {
+				if file.level != level {
+					continue
+				}
+				relDir, err := filepath.Rel(dir, absDir)
+				if err != nil {
+					return false, err
+				}
+				if relDir == "." {
+					relDir = ""
+				}
+				covered = file.matcher.subtreeIgnored(covered, filepath.ToSlash(relDir))
+			}
+		}
+		for _, file := range inside {
+			if file.level == level {
+				covered = file.matcher.subtreeIgnored(covered, "")
+			}
+		}
+	}
+	if rm.overrides != nil {
+		covered = rm.overrides.subtreeIgnored(covered, relPath)
+	}
+	if rm.overlay != nil {
+		covered = rm.overlay.subtreeIgnored(covered, relPath)
+	}
+	return covered, nil
+}
+
+// subtreeIgnored applies the patterns in order to the paths beneath the
+// slash-separated dir, relative to the matcher ("" for paths beneath the
+// matcher's own directory), and reports whether all of them are ignored.
+// covered is the state left by the patterns evaluated before this matcher.
+func (p *PatternMatcher) subtreeIgnored(covered bool, dir string) bool {
+	if dir != "" {
+		normalized, ok, err := p.normalizePath(dir)
+		if !ok || err != nil {
+			return false
+		}
+		dir = normalized
+	}
+
+	rules := p.rules.Load()
+
+	// Without negations nothing can uncover the paths once they are covered
+	if covered && rules.negationFree {
+		return true
+	}
+
+	for _, pattern := range rules.patterns {
+		switch {
+		case coversDescendants(dir, pattern, p.options):
+			if rules.negationFree {
+				return true
+			}
+			covered = !pattern.negate
+		case pattern.negate && mayMatchBeneath(dir, pattern, p.options.syntax):
+			covered = false
+		}
+	}
+	return covered
+}
+
+// coversDescendants reports whether pattern matches every path beneath dir,
+// regardless of its name, following the rules of matchPattern.
+func coversDescendants(dir string, pattern ignorePattern, options matcherOptions) bool {
+	if dir == "" {
+		return false
+	}
+
+	syntax := options.syntax
+	if options.strictGit() {
+		// Only an excluding pattern matching dir or one of its parents
+		// matches what is beneath, and with strict negation not even that:
+		// the state of the parent decides, as a later negation may
+		// re-include it
+		if pattern.negate || !options.matchesBeneath() {
+			return false
+		}
+		for i := 1; i <= len(dir); i++ {
+			if (i == len(dir) || dir[i] == '/') && matchGitPath(dir[:i], kindDir, pattern) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if syntax == SyntaxHelm {
+		// Helm does not descend into a directory it ignores
+		return matchHelmPattern(dir, kindDir, pattern)
+	}
+	if syntax == SyntaxDocker {
+		// Docker matches the parent with as many components as the pattern
+		components := strings.Count(pattern.pattern, "/") + 1
+		for i := 0; i <= len(dir); i++ {
+			if i < len(dir) && dir[i] != '/' {
+				continue
+			}
+			if components--; components == 0 {
+				return pattern.matchString(dir[:i])
+			}
+		}
+		return false
+	}
+
+	literal := pattern.literal()
+	hasLiteralPrefix := dir == literal || strings.HasPrefix(dir, literal+"/")
+	switch {
+	case pattern.isRootRelative:
+		return hasLiteralPrefix
+	case pattern.isDirectory && hasLiteralPrefix:
+		return true
+	case strings.Contains(literal, "/"):
+		return hasLiteralPrefix || strings.HasSuffix(dir, "/"+literal) || strings.Contains(dir, "/"+literal+"/")
+	default:
+		return matchSimplePattern(dir, pattern)
+	}
+}
+
+// mayMatchBeneath reports whether pattern could match some path beneath
+// dir. Only patterns anchored to the root can be ruled out, when one of
+// their leading literal segments differs from dir.
+func mayMatchBeneath(dir string, pattern ignorePattern, syntax Syntax) bool {
+	if dir == "" || !(pattern.isRootRelative || syntax == SyntaxDocker) {
+		return true
+	}
+
+	dirSegments := strings.Split(dir, "/")
+	for i, segment := range literalSegments(pattern.pattern) {
+		if i == len(dirSegments) {
+			break
+		}
+		if segment != dirSegments[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package dotignore
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCanSkipDir(t *testing.T) {
+	descendants := []string{"a", "b.md", "keep.txt", "x/y/z.go", "docs/keep.md", "sub/keep.txt"}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		opts     []Option
+		dir      string
+		expected bool
+	}{
+		{"Directory pattern", []string{"node_modules/"}, nil, "web/node_modules", true},
+		{"Not ignored", []string{"node_modules/"}, nil, "web/src", false},
+		{"Floating negation", []string{"node_modules/", "!*.md"}, nil, "web/node_modules", false},
+		{"Negation before exclusion", []string{"!*.md", "node_modules/"}, nil, "web/node_modules", true},
+		{"Anchored negation elsewhere", []string{"/build/", "!/docs/keep.md"}, nil, "build", true},
+		{"Anchored negation beneath", []string{"/build/", "!/build/keep.txt"}, nil, "build", false},
+		{"Anchored negation with wildcard", []string{"/build/", "!/*/keep.txt"}, nil, "build", false},
+		{"Strict negation", []string{"/build/", "!/build/keep.txt"}, []Option{WithStrictNegation()}, "build", true},
+		{"Component pattern", []string{"*.cache"}, nil, "a/b.cache", true},
+		{"Wildcard children only", []string{"build/*"}, nil, "build/sub", false},
+		{"Root-relative wildcard", []string{"/build*"}, nil, "build1", false},
+		{"Slash pattern", []string{"out/gen"}, nil, "pkg/out/gen/v1", true},
+		{"Re-excluded after negation", []string{"vendor/", "!*.go", "/vendor/"}, nil, "vendor", true},
+		{"Docker syntax", []string{"dist", "!src/keep"}, []Option{WithSyntax(SyntaxDocker)}, "dist", true},
+		{"Docker negation beneath", []string{"dist", "!dist/keep"}, []Option{WithSyntax(SyntaxDocker)}, "dist", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := NewPatternMatcher(tt.patterns, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+
+			result, err := matcher.CanSkipDir(tt.dir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("CanSkipDir(%q) = %v, want %v", tt.dir, result, tt.expected)
+			}
+
+			// A skippable directory must not hide any path that is kept
+			if result {
+				for _, descendant := range descendants {
+					path := tt.dir + "/" + descendant
+					if ignored, _ := matcher.Matches(path); !ignored {
+						t.Errorf("CanSkipDir(%q) is true but %q is not ignored", tt.dir, path)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRepositoryMatcher_CanSkipDir(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":                  "node_modules/\nbuild/\n",
+		"app/.gitignore":              "!build/keep.txt\n",
+		"app/build/out.bin":           "",
+		"web/node_modules/a/index.js": "",
+		"lib/build/out.bin":           "",
+		"lib/build/.gitignore":        "!*.txt\n",
+		"src/main.go":                 "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		dir      string
+		expected bool
+	}{
+		{"web/node_modules", true},
+		{"src", false},
+		{"app/build", false}, // re-included by app/.gitignore
+		{"lib/build", false}, // ignore file inside the directory
+		{".git", true},
+	}
+
+	for _, tt := range tests {
+		result, err := matcher.CanSkipDir(tt.dir)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tt.dir, err)
+		}
+		if result != tt.expected {
+			t.Errorf("CanSkipDir(%q) = %v, want %v", tt.dir, result, tt.expected)
+		}
+	}
+
+	strict, err := NewRepositoryMatcherWithConfig(tmpDir, &RepositoryConfig{IgnoreFileName: ".gitignore", StrictNegation: true})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if result, _ := strict.CanSkipDir("app/build"); !result {
+		t.Error("Expected app/build to be skippable with strict negation")
+	}
+}
+
+func TestNegationFree(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"build/", "*.log", "/dist", "node_modules"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if !matcher.rules.Load().negationFree {
+		t.Fatal("Expected patterns without negations to be negation-free")
+	}
+	if !matcher.Clone().rules.Load().negationFree {
+		t.Error("Expected the clone to be negation-free")
+	}
+
+	// A covered directory stays covered by later matchers without negations
+	if !matcher.subtreeIgnored(true, "src") {
+		t.Error("subtreeIgnored() uncovered a covered directory")
+	}
+	for _, dir := range []string{"build", "web/node_modules/pkg", "dist/a"} {
+		if skip, err := matcher.CanSkipDir(dir); !skip || err != nil {
+			t.Errorf("CanSkipDir(%q) = %v, %v, want true", dir, skip, err)
+		}
+	}
+
+	// Entries beneath an ignored directory are decided without patterns
+	state, err := matcher.DirState("build/sub")
+	if err != nil {
+		t.Fatalf("DirState() failed: %v", err)
+	}
+	if state.residual != nil {
+		t.Errorf("DirState(build/sub) residual = %v, want none", state.residual)
+	}
+	if ignored, err := state.Matches("main.go", false); !ignored || err != nil {
+		t.Errorf("Matches(main.go) = %v, %v, want true", ignored, err)
+	}
+
+	if err := matcher.AddPatterns([]string{"!build/keep.txt"}); err != nil {
+		t.Fatalf("Failed to add patterns: %v", err)
+	}
+	if matcher.rules.Load().negationFree {
+		t.Error("Expected a negation to be detected")
+	}
+	if skip, _ := matcher.CanSkipDir("build"); skip {
+		t.Error("CanSkipDir(build) = true with a negation beneath it")
+	}
+	if matcher.subtreeIgnored(true, "build") {
+		t.Error("subtreeIgnored() kept build covered with a negation beneath it")
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
 // RepositoryMatcher provides hierarchical .gitignore pattern matching that mirrors
@@ -31,8 +33,43 @@ import (
 //	    log.Fatal(err)
 //	}
 type RepositoryMatcher struct {
-	rootDir  string
-	matchers map[string]*PatternMatcher // Map of directory path -> matcher
+	rootDir     string
+	config      RepositoryConfig
+	fsys        fileSystem               // where the repository's files are read from
+	matchers    map[string][]*ignoreFile // Map of directory path -> loaded ignore files
+	levels      int                      // Number of ignore file precedence levels
+	overrides   *PatternMatcher          // Root-relative patterns applied after all ignore files
+	underlay    *PatternMatcher          // Overlay patterns applied before all ignore files
+	overlay     *PatternMatcher          // Overlay patterns applied after the overrides
+	nestedRoots map[string]bool          // Directories containing a nested repository
+
+	// Ancestors of the root whose ignore files are loaded with
+	// IncludeAncestorIgnoreFiles, outermost first
+	ancestorDirs []string
+
+	// Directory state recorded by discovery for Refresh
+	dirModTimes map[string]time.Time // Modification times of the visited directories
+	subdirs     map[string][]string  // Directory path -> paths of its subdirectories
+
+	// Settings read from Git's configuration when ReadGitConfig is set
+	ignoreCase   bool
+	excludesFile string
+
+	// Totals checked against MaxIgnoreFiles and MaxPatterns during discovery
+	ignoreFileCount int
+	patternCount    int
+
+	// Totals reported to OnProgress during discovery
+	progress Progress
+}
+
+// ignoreFile is an ignore file loaded from the repository.
+type ignoreFile struct {
+	path    string // Absolute path to the file
+	level   int    // Precedence level; higher levels override lower ones
+	modTime time.Time
+	size    int64
+	matcher *PatternMatcher
 }
 
 // RepositoryConfig configures the behavior of RepositoryMatcher.
@@ -40,13 +77,176 @@ type RepositoryConfig struct {
 	// IgnoreFileName is the name of ignore files to process (default: ".gitignore")
 	IgnoreFileName string
 
+	// IgnoreFileNames lists several ignore file names to load from every
+	// directory, in increasing order of precedence (e.g. ".gitignore",
+	// ".ignore", ".rgignore"). When set, it replaces IgnoreFileName.
+	//
+	// Precedence follows ripgrep: a decision made by a higher-precedence file
+	// anywhere in the hierarchy overrides every lower-precedence file, and
+	// among files of the same name the deepest directory wins.
+	IgnoreFileNames []string
+
+	// FallbackIgnoreFileName is loaded in directories that do not contain
+	// any of the configured ignore files (default: none), at the lowest
+	// precedence level. npm uses this to fall back from .npmignore to .gitignore.
+	FallbackIgnoreFileName string
+
 	// MaxDepth limits how deep to search for ignore files (0 = unlimited)
 	MaxDepth int
 
 	// FollowSymlinks determines whether to follow symbolic links when discovering ignore files
 	FollowSymlinks bool
+
+	// IncludeGitDir disables the default handling of .git directories. By
+	// default, discovery never descends into a directory named .git and every
+	// path inside one is reported as ignored, as Git itself does.
+	IncludeGitDir bool
+
+	// NestedRepositories controls how subdirectories containing their own .git
+	// (nested repositories and submodules) are treated (default:
+	// NestedRepositoryInclude).
+	NestedRepositories NestedRepositoryMode
+
+	// StrictNegation applies Git's rule that a path cannot be re-included by a
+	// negation pattern when one of its parent directories is excluded, even if
+	// the negation lives in a deeper ignore file (see WithStrictNegation).
+	StrictNegation bool
+
+	// GitStrict anchors patterns containing a slash, such as "src/test", to
+	// the directory of their ignore file, as Git does, instead of also
+	// matching them beneath nested directories (see WithGitStrict).
+	GitStrict bool
+
+	// SkipIgnoredDirectories makes discovery skip directories that are
+	// excluded by the ignore files found above them, such as node_modules or
+	// build output, instead of looking for ignore files inside them. As in
+	// Git, ignore files in excluded directories are then not loaded, so
+	// their negations cannot re-include anything. With StrictNegation those
+	// files never change a decision, and setting this only saves the walk.
+	SkipIgnoredDirectories bool
+
+	// IncludeAncestorIgnoreFiles also loads the ignore files of the
+	// directories between the top of the Git work tree containing the root
+	// directory and the root itself, as Git applies them when the root is a
+	// subdirectory of a repository. Their patterns stay relative to their own
+	// directories, so "/build/" in the work tree's top-level .gitignore does
+	// not match build in the root directory. The work tree is found as by
+	// NewRepositoryMatcherFromPath; nothing is loaded if there is none.
+	IncludeAncestorIgnoreFiles bool
+
+	// CheckFileTypes makes matching look up each path in the file system
+	// to tell directories from other files. Directory patterns such as
+	// "build/" then only match directories and the paths beneath them, as in
+	// Git, instead of also matching files and symbolic links named build.
+	// Paths that do not exist are matched as if the option were off.
+	CheckFileTypes bool
+
+	// MatchThroughSymlinks treats symbolic links to directories as the
+	// directories they point to when CheckFileTypes is set, so directory
+	// patterns match the link and the paths beneath it are matched on their
+	// own. By default a symbolic link is a file, as it is to Git, and a path
+	// beneath one is ignored exactly when the link is.
+	MatchThroughSymlinks bool
+
+	// MaxLineLength is the longest line, in bytes, accepted in an ignore file
+	// (default: DefaultMaxLineLength). Files with longer lines are skipped,
+	// like any other file that cannot be parsed.
+	MaxLineLength int
+
+	// MaxPatternLength is the longest pattern, in bytes, accepted in an
+	// ignore file (default: no limit). Files with longer patterns are
+	// skipped, like any other file that cannot be parsed.
+	MaxPatternLength int
+
+	// MaxPatterns limits the total number of patterns loaded from all ignore
+	// files (default: no limit). Discovery fails with an error wrapping
+	// ErrTooManyPatterns when it is exceeded.
+	MaxPatterns int
+
+	// MaxIgnoreFiles limits the number of ignore files loaded (default: no
+	// limit). Discovery fails with an error wrapping ErrTooManyIgnoreFiles
+	// when more are found.
+	MaxIgnoreFiles int
+
+	// Logger receives debug events about discovered, reused and unparseable
+	// ignore files, nested repositories, and the decision for every matched
+	// path (default: none). It is not preserved by Encode.
+	Logger Logger
+
+	// ReadGitConfig applies settings from the Git configuration of the
+	// repository containing the root directory and from the user's global
+	// configuration (~/.gitconfig or $XDG_CONFIG_HOME/git/config), as Git
+	// does: core.ignoreCase makes patterns match without regard to case, and
+	// the patterns of core.excludesFile (default:
+	// $XDG_CONFIG_HOME/git/ignore) apply to the whole repository at a lower
+	// precedence than every ignore file. The configuration is read again by
+	// Reload.
+	ReadGitConfig bool
+
+	// CaseSensitive keeps patterns case-sensitive when ReadGitConfig finds
+	// core.ignoreCase set, for tools that only take core.excludesFile from
+	// the Git configuration.
+	CaseSensitive bool
+
+	// OnError, if set, is called when an ignore file cannot be read or
+	// parsed, or a directory cannot be read, during discovery. Such files
+	// and directories are skipped if it returns nil (the default without
+	// OnError); any other error aborts discovery and is returned from
+	// NewRepositoryMatcherWithConfig, Reload or Refresh. It is not preserved
+	// by Encode.
+	OnError func(path string, err error) error
+
+	// OnProgress, if set, is called with running totals as discovery and
+	// the walks of ListIgnoredFiles, ListTrackedCandidates and related
+	// methods advance: after each directory during discovery, and after
+	// each file or directory during a walk. It is called synchronously, so
+	// it should return quickly, and concurrent walks call it concurrently.
+	// It is not preserved by Encode.
+	OnProgress func(Progress)
+
+	// TrackPatternHits counts how many paths each pattern matches so that
+	// patterns which never match can be listed with UnusedPatterns.
+	TrackPatternHits bool
+
+	// FS, if set, is the file system the repository is read from instead of
+	// the operating system's. It holds the contents of the root directory,
+	// which is then only used to resolve absolute paths passed to Matches.
+	// An afero.Fs can be used through afero.NewIOFS. Files outside the root
+	// directory, such as the user's Git configuration with ReadGitConfig,
+	// cannot be read through it, and symbolic links are seen as their
+	// targets. It is not preserved by Encode.
+	FS fs.FS
+
+	// ExtraPatterns are gitignore patterns applied in addition to the
+	// ignore files, like the patterns passed to "git clean -e" or
+	// "git ls-files -x". They are relative to the root directory and take
+	// precedence over every ignore file, so "!keep.log" re-includes a file
+	// that any ignore file excludes. They are parsed with the same options
+	// and limits as the ignore files.
+	ExtraPatterns []string
 }
 
+// NestedRepositoryMode controls how RepositoryMatcher treats nested Git
+// repositories and submodules, i.e. subdirectories that contain a .git
+// directory or file of their own.
+type NestedRepositoryMode int
+
+const (
+	// NestedRepositoryInclude treats nested repositories like any other
+	// directory: their ignore files are loaded and ancestor rules apply.
+	NestedRepositoryInclude NestedRepositoryMode = iota
+
+	// NestedRepositorySkip leaves nested repositories out entirely. Discovery
+	// does not descend into them, and the nested repository directory and
+	// everything inside it are reported as ignored.
+	NestedRepositorySkip
+
+	// NestedRepositoryScope treats each nested repository as a separate scope,
+	// like Git does for submodules: its own ignore files are loaded, but ignore
+	// files from directories above it no longer apply inside it.
+	NestedRepositoryScope
+)
+
 // DefaultRepositoryConfig returns a RepositoryConfig with sensible defaults.
 func DefaultRepositoryConfig() *RepositoryConfig {
 	return &RepositoryConfig{
@@ -65,6 +265,27 @@ func NewRepositoryMatcher(rootDir string) (*RepositoryMatcher, error) {
 	return NewRepositoryMatcherWithConfig(rootDir, DefaultRepositoryConfig())
 }
 
+// NewRepositoryMatcherFromPath creates a RepositoryMatcher rooted at the
+// top of the Git work tree containing path, which may be any file or
+// directory inside it. The work tree is found as Git finds it: by looking
+// for a .git directory or file in the directory of path and its parents,
+// unless the GIT_WORK_TREE or GIT_DIR environment variable says otherwise.
+// ErrNotInRepository is returned if there is none.
+func NewRepositoryMatcherFromPath(path string) (*RepositoryMatcher, error) {
+	return NewRepositoryMatcherFromPathWithConfig(path, DefaultRepositoryConfig())
+}
+
+// NewRepositoryMatcherFromPathWithConfig is like NewRepositoryMatcherFromPath
+// with custom configuration. The work tree is always looked for on disk, even
+// if config.FS is set.
+func NewRepositoryMatcherFromPathWithConfig(path string, config *RepositoryConfig) (*RepositoryMatcher, error) {
+	root, err := findWorkTree(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewRepositoryMatcherWithConfig(root, config)
+}
+
 // NewRepositoryMatcherWithConfig creates a new RepositoryMatcher with custom configuration.
 func NewRepositoryMatcherWithConfig(rootDir string, config *RepositoryConfig) (*RepositoryMatcher, error) {
 	if rootDir == "" {
@@ -86,7 +307,8 @@ func NewRepositoryMatcherWithConfig(rootDir string, config *RepositoryConfig) (*
 	}
 
 	// Verify directory exists
-	info, err := os.Stat(absRoot)
+	fsys := newFileSystem(config, absRoot)
+	info, err := fsys.Stat(absRoot)
 	if err != nil {
 		return nil, fmt.Errorf("failed to access directory %q: %w", absRoot, err)
 	}
@@ -95,66 +317,535 @@ func NewRepositoryMatcherWithConfig(rootDir string, config *RepositoryConfig) (*
 	}
 
 	rm := &RepositoryMatcher{
-		rootDir:  absRoot,
-		matchers: make(map[string]*PatternMatcher),
+		rootDir:     absRoot,
+		config:      *config,
+		fsys:        fsys,
+		matchers:    make(map[string][]*ignoreFile),
+		nestedRoots: make(map[string]bool),
 	}
+	rm.config.IgnoreFileNames = append([]string(nil), config.IgnoreFileNames...)
+	rm.config.ExtraPatterns = append([]string(nil), config.ExtraPatterns...)
 
 	// Discover and load all .gitignore files
-	if err := rm.discoverIgnoreFiles(config); err != nil {
+	if err := rm.discoverIgnoreFiles(nil); err != nil {
 		return nil, fmt.Errorf("failed to discover ignore files: %w", err)
 	}
 
 	return rm, nil
 }
 
-// discoverIgnoreFiles walks the directory tree and loads all .gitignore files.
-func (rm *RepositoryMatcher) discoverIgnoreFiles(config *RepositoryConfig) error {
-	return filepath.WalkDir(rm.rootDir, func(path string, d fs.DirEntry, err error) error {
+// Reload re-discovers the ignore files under the repository root so that added,
+// removed and edited files take effect. Files whose modification time and size
+// are unchanged since they were last loaded are not parsed again.
+//
+// If discovery fails, the matcher keeps its previous state. Reload must not be
+// called concurrently with other methods of the RepositoryMatcher.
+func (rm *RepositoryMatcher) Reload() error {
+	return rm.rediscover(func(fresh *RepositoryMatcher, loaded map[string]*ignoreFile) error {
+		return fresh.discoverIgnoreFiles(loaded)
+	})
+}
+
+// Refresh is an incremental Reload for large trees. Instead of walking the
+// whole tree, it looks for ignore files only in the directories it visited
+// before, re-parsing those whose modification time or size changed, and reads
+// only directories whose modification time changed to find subdirectories
+// that were added or removed.
+//
+// Adding or removing an entry changes the modification time of its
+// directory on all common file systems, so Refresh finds the same ignore
+// files as Reload unless modification times are preserved or reset, as by
+// some archive tools. Refresh has the same error handling and concurrency
+// requirements as Reload.
+func (rm *RepositoryMatcher) Refresh() error {
+	return rm.rediscover(func(fresh *RepositoryMatcher, loaded map[string]*ignoreFile) error {
+		names, err := fresh.beginDiscovery(loaded)
+		if err != nil {
+			return err
+		}
+		info, err := fresh.fsys.Stat(fresh.rootDir)
 		if err != nil {
-			// If we can't read a directory, skip it but don't fail
+			return fmt.Errorf("failed to access directory %q: %w", fresh.rootDir, err)
+		}
+		return fresh.refreshDirs(fresh.rootDir, fs.FileInfoToDirEntry(info), names, rm, loaded)
+	})
+}
+
+// rediscover runs discover on a fresh copy of rm, passing it the ignore
+// files loaded so far for reuse, and adopts the result if it succeeds.
+func (rm *RepositoryMatcher) rediscover(discover func(fresh *RepositoryMatcher, loaded map[string]*ignoreFile) error) error {
+	info, err := rm.fsys.Stat(rm.rootDir)
+	if err != nil {
+		return fmt.Errorf("failed to access directory %q: %w", rm.rootDir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%q is not a directory", rm.rootDir)
+	}
+
+	// Index the currently loaded files so unchanged ones can be reused
+	loaded := make(map[string]*ignoreFile)
+	for _, files := range rm.matchers {
+		for _, file := range files {
+			loaded[file.path] = file
+		}
+	}
+
+	fresh := &RepositoryMatcher{
+		rootDir:     rm.rootDir,
+		config:      rm.config,
+		fsys:        rm.fsys,
+		matchers:    make(map[string][]*ignoreFile),
+		overrides:   rm.overrides,
+		underlay:    rm.underlay,
+		overlay:     rm.overlay,
+		nestedRoots: make(map[string]bool),
+	}
+	if err := discover(fresh, loaded); err != nil {
+		return fmt.Errorf("failed to discover ignore files: %w", err)
+	}
+
+	rm.matchers = fresh.matchers
+	rm.levels = fresh.levels
+	rm.ancestorDirs = fresh.ancestorDirs
+	rm.overrides = fresh.overrides
+	rm.underlay = fresh.underlay
+	rm.overlay = fresh.overlay
+	rm.nestedRoots = fresh.nestedRoots
+	rm.dirModTimes = fresh.dirModTimes
+	rm.subdirs = fresh.subdirs
+	rm.ignoreCase = fresh.ignoreCase
+	rm.excludesFile = fresh.excludesFile
+	return nil
+}
+
+// discoverIgnoreFiles walks the directory tree and loads the ignore files of each
+// directory. Files found in loaded with an unchanged modification time and size
+// are reused instead of being parsed again. With SkipIgnoredDirectories,
+// directories excluded by the ignore files loaded so far are not walked.
+func (rm *RepositoryMatcher) discoverIgnoreFiles(loaded map[string]*ignoreFile) error {
+	names, err := rm.beginDiscovery(loaded)
+	if err != nil {
+		return err
+	}
+
+	return rm.fsys.WalkDir(rm.rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// If we can't read a directory, skip it but don't fail,
+			// unless OnError asks to
 			if os.IsPermission(err) || os.IsNotExist(err) {
+				rm.log("skipped unreadable directory", "path", path, "error", err)
+				delete(rm.dirModTimes, path)
+				if err := rm.reportError(path, err); err != nil {
+					return err
+				}
 				return fs.SkipDir
 			}
 			return err
 		}
 
-		// Check depth limit
-		if config.MaxDepth > 0 {
-			relPath, err := filepath.Rel(rm.rootDir, path)
-			if err != nil {
-				return err
+		// Ignore files are looked up per directory; symlinked directories
+		// are never descended into by WalkDir
+		if !d.IsDir() {
+			return nil
+		}
+		if path != rm.rootDir {
+			parent := filepath.Dir(path)
+			rm.subdirs[parent] = append(rm.subdirs[parent], path)
+		}
+
+		return rm.visitDir(path, d, names, loaded)
+	})
+}
+
+// beginDiscovery prepares rm for discovery: it reads the Git configuration
+// and the global excludes file if ReadGitConfig is set, and compiles the
+// extra patterns. It returns the ignore file names to look for.
+func (rm *RepositoryMatcher) beginDiscovery(loaded map[string]*ignoreFile) ([]string, error) {
+	config := &rm.config
+	names := ignoreFileNames(config)
+	rm.levels = len(names)
+	rm.dirModTimes = make(map[string]time.Time)
+	rm.subdirs = make(map[string][]string)
+	rm.progress = Progress{}
+
+	// The global excludes file comes before every other ignore file. Like
+	// Git, its anchored patterns are relative to the top of the work tree,
+	// which is kept as an ancestor when the root lies below it
+	rm.ancestorDirs = nil
+	if config.ReadGitConfig {
+		rm.readGitConfig()
+		if rm.excludesFile != "" {
+			dir := rm.rootDir
+			if workTree, err := findWorkTree(rm.rootDir); err == nil && workTree != rm.rootDir {
+				dir = workTree
+				rm.ancestorDirs = []string{workTree}
 			}
-			depth := strings.Count(relPath, string(filepath.Separator))
-			if depth > config.MaxDepth {
-				return fs.SkipDir
+			if _, err := rm.loadIgnoreFileAt(dir, rm.excludesFile, 0, loaded); err != nil {
+				return nil, err
 			}
 		}
+	}
 
-		// Handle symlinks
-		if d.Type()&fs.ModeSymlink != 0 && !config.FollowSymlinks {
-			if d.IsDir() {
-				return fs.SkipDir
-			}
-			return nil
+	if config.IncludeAncestorIgnoreFiles {
+		if err := rm.loadAncestorIgnoreFiles(names, loaded); err != nil {
+			return nil, err
 		}
+	}
 
-		// Check if this is an ignore file
-		if !d.IsDir() && d.Name() == config.IgnoreFileName {
-			dir := filepath.Dir(path)
+	// Extra patterns and overlays can re-include directories, so they must
+	// be known before excluded directories are skipped
+	if err := rm.loadOverrides(); err != nil {
+		return nil, err
+	}
+	if err := rm.recompileOverlays(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
 
-			// Load the .gitignore file
-			matcher, err := NewPatternMatcherFromFile(path)
-			if err != nil {
-				// If we can't parse the file, skip it but log the error
-				// Don't fail the entire operation
-				return nil
+// loadAncestorIgnoreFiles loads the ignore files of the directories from the
+// top of the work tree containing the root down to the root's parent.
+func (rm *RepositoryMatcher) loadAncestorIgnoreFiles(names []string, loaded map[string]*ignoreFile) error {
+	workTree, err := findWorkTree(rm.rootDir)
+	if err != nil {
+		rm.log("found no work tree above the root", "path", rm.rootDir, "error", err)
+		return nil
+	}
+
+	if workTree == rm.rootDir {
+		return nil
+	}
+	// The work tree may already hold the global excludes file, which stays
+	// ahead of its ignore files
+	var dirs []string
+	for dir := filepath.Dir(rm.rootDir); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == workTree || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	reverseStrings(dirs)
+	rm.ancestorDirs = dirs
+
+	for _, dir := range dirs {
+		if err := rm.loadIgnoreFiles(dir, names, loaded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// baseDirs returns the directories whose ignore files apply to every path in
+// the repository, outermost first: the ancestors of the root loaded with
+// IncludeAncestorIgnoreFiles, and the root itself. The top of the work tree
+// also holds the global excludes file, ahead of its own ignore files, so
+// that every ignore file of the repository can override it.
+func (rm *RepositoryMatcher) baseDirs() []string {
+	dirs := make([]string, 0, len(rm.ancestorDirs)+1)
+	dirs = append(dirs, rm.ancestorDirs...)
+	return append(dirs, rm.rootDir)
+}
+
+// visitDir loads the ignore files of the directory at path, or returns
+// fs.SkipDir if discovery must not descend into it. The modification time
+// of a visited directory is recorded for Refresh.
+func (rm *RepositoryMatcher) visitDir(path string, d fs.DirEntry, names []string, loaded map[string]*ignoreFile) error {
+	config := &rm.config
+
+	// Git metadata never contains ignore files that apply to the work tree
+	if !config.IncludeGitDir && d.Name() == gitDirName && path != rm.rootDir {
+		return fs.SkipDir
+	}
+
+	if config.NestedRepositories != NestedRepositoryInclude && path != rm.rootDir && rm.isRepositoryRoot(path) {
+		rm.nestedRoots[path] = true
+		rm.log("found nested repository", "path", path)
+		if config.NestedRepositories == NestedRepositorySkip {
+			return fs.SkipDir
+		}
+	}
+
+	// Check depth limit
+	if config.MaxDepth > 0 {
+		relPath, err := filepath.Rel(rm.rootDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath != "." && strings.Count(relPath, string(filepath.Separator))+1 > config.MaxDepth {
+			return fs.SkipDir
+		}
+	}
+
+	// Ignore files of the parent directories are loaded before their
+	// subdirectories are visited, so they can decide for this one
+	if config.SkipIgnoredDirectories && path != rm.rootDir {
+		result, err := rm.matchDetail(path, kindDir, false)
+		if err != nil {
+			return err
+		}
+		if result.Ignored {
+			rm.log("skipped ignored directory", "path", path)
+			return fs.SkipDir
+		}
+	}
+
+	if info, err := d.Info(); err == nil {
+		rm.dirModTimes[path] = info.ModTime()
+	}
+	if err := rm.loadIgnoreFiles(path, names, loaded); err != nil {
+		return err
+	}
+	rm.progress.Dirs++
+	rm.reportProgress(rm.progress)
+	return nil
+}
+
+// refreshDirs implements the discovery of Refresh for the directory at path
+// and the directories beneath it. The subdirectories of a directory whose
+// modification time is unchanged since the previous discovery are taken from
+// previous instead of being read again.
+func (rm *RepositoryMatcher) refreshDirs(path string, d fs.DirEntry, names []string, previous *RepositoryMatcher, loaded map[string]*ignoreFile) error {
+	if err := rm.visitDir(path, d, names, loaded); err != nil {
+		if err == fs.SkipDir {
+			return nil
+		}
+		return err
+	}
+
+	var subdirs []fs.DirEntry
+	if modTime, known := previous.dirModTimes[path]; known && modTime.Equal(rm.dirModTimes[path]) {
+		for _, subdir := range previous.subdirs[path] {
+			info, err := rm.fsys.Lstat(subdir)
+			if err != nil || !info.IsDir() {
+				continue
 			}
+			subdirs = append(subdirs, fs.FileInfoToDirEntry(info))
+		}
+	} else {
+		entries, err := rm.fsys.ReadDir(path)
+		if err != nil {
+			if os.IsPermission(err) || os.IsNotExist(err) {
+				rm.log("skipped unreadable directory", "path", path, "error", err)
+				delete(rm.dirModTimes, path)
+				return rm.reportError(path, err)
+			}
+			return err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				subdirs = append(subdirs, entry)
+			}
+		}
+	}
 
-			rm.matchers[dir] = matcher
+	for _, subdir := range subdirs {
+		subPath := filepath.Join(path, subdir.Name())
+		rm.subdirs[path] = append(rm.subdirs[path], subPath)
+		if err := rm.refreshDirs(subPath, subdir, names, previous, loaded); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
+// loadOverrides compiles ExtraPatterns into the override matcher, keeping
+// an existing one compiled with the same case sensitivity.
+func (rm *RepositoryMatcher) loadOverrides() error {
+	if len(rm.config.ExtraPatterns) == 0 {
+		return nil
+	}
+	if rm.overrides != nil && rm.overrides.options.caseInsensitive == rm.ignoreCase {
 		return nil
+	}
+
+	overrides, err := NewPatternMatcher(rm.config.ExtraPatterns, rm.patternOptions()...)
+	if err != nil {
+		return fmt.Errorf("invalid extra patterns: %w", err)
+	}
+	rm.overrides = overrides
+	return nil
+}
+
+// recompileOverlays compiles the overlays of a view again if the case
+// sensitivity read from Git's configuration has changed since they were
+// compiled.
+func (rm *RepositoryMatcher) recompileOverlays() error {
+	for _, overlay := range []**PatternMatcher{&rm.underlay, &rm.overlay} {
+		if *overlay == nil || (*overlay).options.caseInsensitive == rm.ignoreCase {
+			continue
+		}
+		rules := (*overlay).rules.Load()
+		patterns := make([]string, len(rules.patterns))
+		for i, pattern := range rules.patterns {
+			patterns[i] = pattern.text
+		}
+		recompiled, err := NewPatternMatcher(patterns, rm.patternOptions()...)
+		if err != nil {
+			return fmt.Errorf("invalid overlay patterns: %w", err)
+		}
+		*overlay = recompiled
+	}
+	return nil
+}
+
+// patternOptions returns the options for the patterns of the repository,
+// both those read from ignore files and those relative to the root
+// directory, such as ExtraPatterns. StrictNegation is not among them, since
+// it applies across the ignore files in matchDetail; the matchers are only
+// told that the parent directories are decided there.
+func (rm *RepositoryMatcher) patternOptions() []Option {
+	opts := []Option{
+		WithMaxLineLength(rm.config.MaxLineLength),
+		WithMaxPatternLength(rm.config.MaxPatternLength),
+		WithMaxPatterns(rm.config.MaxPatterns),
+	}
+	if rm.config.TrackPatternHits {
+		opts = append(opts, WithHitTracking())
+	}
+	if rm.ignoreCase {
+		opts = append(opts, WithCaseInsensitive())
+	}
+	if rm.config.GitStrict {
+		opts = append(opts, WithGitStrict())
+	}
+	if rm.config.StrictNegation {
+		opts = append(opts, withParentsDecided())
+	}
+	return opts
+}
+
+// ignoreFileNames returns the ignore file names configured in config, in
+// increasing order of precedence.
+func ignoreFileNames(config *RepositoryConfig) []string {
+	if len(config.IgnoreFileNames) > 0 {
+		return config.IgnoreFileNames
+	}
+	return []string{config.IgnoreFileName}
+}
+
+// loadIgnoreFiles loads the ignore files named in names from dir. If none of
+// them exist, FallbackIgnoreFileName is loaded at the lowest precedence level.
+// An error is returned only if a resource limit is exceeded or OnError
+// returns one.
+func (rm *RepositoryMatcher) loadIgnoreFiles(dir string, names []string, loaded map[string]*ignoreFile) error {
+	found := false
+	for level, name := range names {
+		exists, err := rm.loadIgnoreFile(dir, name, level, loaded)
+		if err != nil {
+			return err
+		}
+		found = found || exists
+	}
+	if !found && rm.config.FallbackIgnoreFileName != "" {
+		if _, err := rm.loadIgnoreFile(dir, rm.config.FallbackIgnoreFileName, 0, loaded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadIgnoreFile loads the ignore file called name in dir, if present, and
+// reports whether it exists.
+func (rm *RepositoryMatcher) loadIgnoreFile(dir, name string, level int, loaded map[string]*ignoreFile) (bool, error) {
+	return rm.loadIgnoreFileAt(dir, filepath.Join(dir, name), level, loaded)
+}
+
+// loadIgnoreFileAt loads the ignore file at path, if present, as one of the
+// ignore files of dir, and reports whether it exists. A file that cannot be
+// parsed is skipped; an error is returned only if loading the file would
+// exceed MaxIgnoreFiles or MaxPatterns, or OnError returns one.
+func (rm *RepositoryMatcher) loadIgnoreFileAt(dir, path string, level int, loaded map[string]*ignoreFile) (bool, error) {
+	info, ok := rm.statIgnoreFile(path)
+	if !ok {
+		return false, nil
+	}
+
+	rm.ignoreFileCount++
+	if limit := rm.config.MaxIgnoreFiles; limit > 0 && rm.ignoreFileCount > limit {
+		return true, fmt.Errorf("%w: %q exceeds the limit of %d", ErrTooManyIgnoreFiles, path, limit)
+	}
+
+	// Reuse the previously parsed file if it has not changed
+	if previous, exists := loaded[path]; exists && previous.modTime.Equal(info.ModTime()) && previous.size == info.Size() &&
+		previous.matcher.options.caseInsensitive == rm.ignoreCase {
+		if err := rm.countPatterns(path, previous.matcher); err != nil {
+			return true, err
+		}
+		rm.log("reused unchanged ignore file", "path", path)
+		rm.progress.IgnoreFiles++
+		rm.matchers[dir] = append(rm.matchers[dir], &ignoreFile{
+			path:    path,
+			level:   level,
+			modTime: previous.modTime,
+			size:    previous.size,
+			matcher: previous.matcher,
+		})
+		return true, nil
+	}
+
+	// Load the ignore file
+	matcher, err := rm.fsys.loadMatcher(path, rm.patternOptions())
+	if errors.Is(err, ErrTooManyPatterns) {
+		return true, fmt.Errorf("failed to load %q: %w", path, err)
+	}
+	if err != nil {
+		// If we can't parse the file, skip it but don't fail
+		// the entire operation, unless OnError asks to
+		rm.log("skipped unparseable ignore file", "path", path, "error", err)
+		return true, rm.reportError(path, err)
+	}
+	if err := rm.countPatterns(path, matcher); err != nil {
+		return true, err
+	}
+	rm.log("loaded ignore file", "path", path, "patterns", len(matcher.rules.Load().patterns))
+	rm.progress.IgnoreFiles++
+	rm.progress.BytesRead += info.Size()
+
+	rm.matchers[dir] = append(rm.matchers[dir], &ignoreFile{
+		path:    path,
+		level:   level,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		matcher: matcher,
 	})
+	return true, nil
+}
+
+// reportError passes an error that discovery would otherwise skip over to
+// OnError, and returns the error to abort discovery with, if any.
+func (rm *RepositoryMatcher) reportError(path string, err error) error {
+	if rm.config.OnError == nil {
+		return nil
+	}
+	return rm.config.OnError(path, err)
+}
+
+// countPatterns adds the patterns of the ignore file at path to the total
+// and returns an error if the total exceeds MaxPatterns.
+func (rm *RepositoryMatcher) countPatterns(path string, matcher *PatternMatcher) error {
+	rm.patternCount += len(matcher.rules.Load().patterns)
+	if limit := rm.config.MaxPatterns; limit > 0 && rm.patternCount > limit {
+		return fmt.Errorf("%w: %q brings the total to %d, exceeding the limit of %d", ErrTooManyPatterns, path, rm.patternCount, limit)
+	}
+	return nil
+}
+
+// statIgnoreFile returns the file info for path if it names a regular file,
+// following a symbolic link only if FollowSymlinks is set.
+func (rm *RepositoryMatcher) statIgnoreFile(path string) (fs.FileInfo, bool) {
+	info, err := rm.fsys.Lstat(path)
+	if err != nil {
+		return nil, false
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if !rm.config.FollowSymlinks {
+			return nil, false
+		}
+		if info, err = rm.fsys.Stat(path); err != nil {
+			return nil, false
+		}
+	}
+	return info, info.Mode().IsRegular()
 }
 
 // Matches checks if the given file path should be ignored according to the
@@ -167,10 +858,192 @@ func (rm *RepositoryMatcher) discoverIgnoreFiles(config *RepositoryConfig) error
 //  3. Patterns are evaluated from root to the file's directory, with later patterns
 //     taking precedence
 func (rm *RepositoryMatcher) Matches(path string) (bool, error) {
+	result, err := rm.MatchDetail(path)
+	return result.Ignored, err
+}
+
+// MatchesPath reports whether path is ignored, like Matches, given whether
+// it is a directory. Directory patterns such as "build/" then match only
+// directories and the paths beneath them, as in Git, without the file
+// system lookup that CheckFileTypes performs.
+func (rm *RepositoryMatcher) MatchesPath(path string, isDir bool) (bool, error) {
+	kind := kindFile
+	if isDir {
+		kind = kindDir
+	}
+	result, err := rm.matchDetail(path, kind, true)
+	if err == nil && path != "" && rm.config.Logger != nil {
+		rm.logDecision(path, result)
+	}
+	return result.Ignored, err
+}
+
+// IsIgnored reports whether path is ignored, like MatchesPath, for callers
+// that have no use for an error. Paths that cannot be matched, such as
+// those outside the root directory, are reported as not ignored.
+func (rm *RepositoryMatcher) IsIgnored(path string, isDir bool) bool {
+	ignored, err := rm.MatchesPath(path, isDir)
+	return ignored && err == nil
+}
+
+// MustMatch is like MatchesPath but panics if path cannot be matched, such
+// as a path outside the root directory.
+func (rm *RepositoryMatcher) MustMatch(path string, isDir bool) bool {
+	ignored, err := rm.MatchesPath(path, isDir)
+	if err != nil {
+		panic("dotignore: " + err.Error())
+	}
+	return ignored
+}
+
+// MatchResult describes how a RepositoryMatcher decided whether a path is
+// ignored.
+type MatchResult struct {
+	// Ignored reports whether the path is ignored.
+	Ignored bool
+
+	// Matched reports whether a pattern decided the outcome. It is false if
+	// no pattern matched, or if the path was ignored by a built-in rule such
+	// as the exclusion of .git directories.
+	Matched bool
+
+	// Source is the path of the ignore file containing the deciding pattern,
+	// relative to the repository root, or absolute for a file outside it such
+	// as core.excludesFile. It is empty for patterns that do not come from a
+	// file, such as npm's built-in rules.
+	Source string
+
+	// Line is the 1-based line number of the deciding pattern in Source.
+	Line int
+
+	// Pattern is the deciding pattern as written, including any leading "!"
+	// or trailing "/".
+	Pattern string
+
+	// Overridden locates the pattern from an earlier ignore file whose
+	// decision was reversed, such as "*.log" in the root .gitignore when
+	// "!debug.log" in a deeper one re-includes debug.log. Only the deciding
+	// pattern of each file is considered.
+	Overridden PatternLocation
+
+	// OverriddenPattern is the overridden pattern as written. It is empty,
+	// like Overridden, if every ignore file that matched the path agrees.
+	OverriddenPattern string
+}
+
+// MatchDetail reports whether path is ignored, like Matches, along with the
+// ignore file, line and pattern that decided it. This is the information
+// shown by "git check-ignore -v". As with "git check-ignore", a path ending
+// in a slash is taken to be a directory.
+func (rm *RepositoryMatcher) MatchDetail(path string) (MatchResult, error) {
+	result, err := rm.matchDetail(path, kindUnknown, true)
+	if err == nil && path != "" && rm.config.Logger != nil {
+		rm.logDecision(path, result)
+	}
+	return result, err
+}
+
+// matchDetail implements MatchDetail without logging, for a path of the
+// given kind. The kind of a path of unknown kind is looked up if
+// CheckFileTypes is set. Unless observe is set, as for lookups made during
+// discovery, the query does not count toward hit tracking.
+func (rm *RepositoryMatcher) matchDetail(path string, kind pathKind, observe bool) (MatchResult, error) {
 	if path == "" {
-		return false, nil
+		return MatchResult{}, nil
 	}
 
+	relPath, err := rm.relativePath(path)
+	if err != nil {
+		return MatchResult{}, err
+	}
+
+	if !rm.config.IncludeGitDir && inGitDir(relPath) {
+		return MatchResult{Ignored: true}, nil
+	}
+
+	if rm.config.NestedRepositories == NestedRepositorySkip && rm.inNestedRepository(relPath) {
+		return MatchResult{Ignored: true}, nil
+	}
+
+	// A trailing slash marks a directory, as in "git check-ignore build/"
+	if kind == kindUnknown && hasTrailingSlash(path) {
+		kind = kindDir
+	}
+	if kind == kindUnknown && rm.config.CheckFileTypes {
+		relPath, kind = rm.fileKind(relPath)
+	}
+
+	if rm.config.StrictNegation {
+		// A path inside an excluded directory stays excluded regardless of
+		// any negation patterns, because Git never descends into it. The
+		// parent directories are not queried, so they do not count as hits.
+		for i := 1; i < len(relPath); i++ {
+			if relPath[i] != '/' {
+				continue
+			}
+			d, err := rm.matchRelative(relPath[:i], kindDir, false)
+			if err != nil {
+				return MatchResult{}, err
+			}
+			if !d.ignored() {
+				continue
+			}
+			if observe && rm.config.TrackPatternHits {
+				if _, err := rm.matchRelative(relPath, kind, true); err != nil {
+					return MatchResult{}, err
+				}
+			}
+			return rm.result(d), nil
+		}
+	}
+
+	d, err := rm.matchRelative(relPath, kind, observe)
+	if err != nil {
+		return MatchResult{}, err
+	}
+	return rm.result(d), nil
+}
+
+// fileKind looks up the type of the file at the slash-separated relPath for
+// CheckFileTypes. Unless MatchThroughSymlinks is set, a path beneath a
+// symbolic link is replaced by the link, which then decides for it. Paths
+// that cannot be examined are of unknown kind.
+func (rm *RepositoryMatcher) fileKind(relPath string) (string, pathKind) {
+	if relPath == "." {
+		return relPath, kindUnknown
+	}
+
+	stat := rm.fsys.Stat
+	if !rm.config.MatchThroughSymlinks {
+		stat = rm.fsys.Lstat
+		for i := 1; i < len(relPath); i++ {
+			if relPath[i] != '/' {
+				continue
+			}
+			info, err := rm.fsys.Lstat(filepath.Join(rm.rootDir, filepath.FromSlash(relPath[:i])))
+			if err != nil {
+				return relPath, kindUnknown
+			}
+			if info.Mode()&fs.ModeSymlink != 0 {
+				return relPath[:i], kindFile
+			}
+		}
+	}
+
+	info, err := stat(filepath.Join(rm.rootDir, filepath.FromSlash(relPath)))
+	switch {
+	case err != nil:
+		return relPath, kindUnknown
+	case info.IsDir():
+		return relPath, kindDir
+	default:
+		return relPath, kindFile
+	}
+}
+
+// relativePath converts path, either absolute or relative to the repository
+// root, into a slash-separated path relative to the root.
+func (rm *RepositoryMatcher) relativePath(path string) (string, error) {
 	// Convert to absolute path if needed
 	var absPath string
 	if filepath.IsAbs(path) {
@@ -181,23 +1054,87 @@ func (rm *RepositoryMatcher) Matches(path string) (bool, error) {
 
 	// Ensure the path is within the repository
 	if !strings.HasPrefix(absPath, rm.rootDir) {
-		return false, fmt.Errorf("path %q is outside repository root %q", path, rm.rootDir)
+		return "", fmt.Errorf("path %q is outside repository root %q", path, rm.rootDir)
 	}
 
 	// Get relative path from root
 	relPath, err := filepath.Rel(rm.rootDir, absPath)
 	if err != nil {
-		return false, fmt.Errorf("failed to compute relative path: %w", err)
+		return "", fmt.Errorf("failed to compute relative path: %w", err)
 	}
 
 	// Normalize to forward slashes for consistent matching
-	relPath = filepath.ToSlash(relPath)
+	return filepath.ToSlash(relPath), nil
+}
+
+// decision records the pattern that decided a match and the ignore file it
+// came from. A nil file means the pattern is an override.
+type decision struct {
+	matched    bool
+	file       *ignoreFile
+	pattern    ignorePattern
+	overridden *decision // earlier decision with the opposite outcome, if any
+}
+
+func (d decision) ignored() bool {
+	return d.matched && !d.pattern.negate
+}
+
+// result converts a decision into the public MatchResult.
+func (rm *RepositoryMatcher) result(d decision) MatchResult {
+	if !d.matched {
+		return MatchResult{}
+	}
+
+	result := MatchResult{
+		Ignored: !d.pattern.negate,
+		Matched: true,
+		Line:    d.pattern.line,
+		Pattern: d.pattern.text,
+	}
+	if d.file != nil {
+		result.Source = rm.sourcePath(d.file.path)
+	}
+	if d.overridden != nil {
+		overridden := rm.result(*d.overridden)
+		result.Overridden = PatternLocation{Source: overridden.Source, Line: overridden.Line}
+		result.OverriddenPattern = overridden.Pattern
+	}
+	return result
+}
+
+// sourcePath returns the slash-separated path of the ignore file at path
+// relative to the repository root, or its absolute path if it lies outside
+// the root, like core.excludesFile.
+func (rm *RepositoryMatcher) sourcePath(path string) string {
+	relPath, err := filepath.Rel(rm.rootDir, path)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(relPath)
+}
+
+// override returns the decision made by pattern from file, which takes
+// precedence over d, recording the decision it reverses.
+func (d decision) override(file *ignoreFile, pattern ignorePattern) decision {
+	next := decision{matched: true, file: file, pattern: pattern, overridden: d.overridden}
+	if d.matched && d.pattern.negate != pattern.negate {
+		reversed := d
+		reversed.overridden = nil
+		next.overridden = &reversed
+	}
+	return next
+}
+
+// matchRelative evaluates the hierarchical ignore rules for a slash-separated
+// path relative to the repository root.
+func (rm *RepositoryMatcher) matchRelative(relPath string, kind pathKind, observe bool) (decision, error) {
+	absPath := filepath.Join(rm.rootDir, filepath.FromSlash(relPath))
 
 	// Build list of directories from root to the file's directory
 	// We need to check .gitignore files in order from root to leaf
-	var dirsToCheck []string
+	dirsToCheck := rm.baseDirs()
 	currentDir := rm.rootDir
-	dirsToCheck = append(dirsToCheck, currentDir)
 
 	// Split the relative path and build up directory path
 	parts := strings.Split(relPath, "/")
@@ -206,45 +1143,126 @@ func (rm *RepositoryMatcher) Matches(path string) (bool, error) {
 		dirsToCheck = append(dirsToCheck, currentDir)
 	}
 
-	// Apply matchers in order from root to leaf
-	// Later matchers can override earlier ones via negation
-	matched := false
-
-	for _, dir := range dirsToCheck {
-		matcher, exists := rm.matchers[dir]
-		if !exists {
-			continue
+	// A nested repository starts a new scope in which ignore files from
+	// the directories above it no longer apply
+	if rm.config.NestedRepositories == NestedRepositoryScope {
+		for i := len(dirsToCheck) - 1; i > 0; i-- {
+			if rm.nestedRoots[dirsToCheck[i]] {
+				dirsToCheck = dirsToCheck[i:]
+				break
+			}
 		}
+	}
 
-		// Compute path relative to this matcher's directory
-		var matchPath string
-		if dir == rm.rootDir {
-			matchPath = relPath
-		} else {
-			relToDir, err := filepath.Rel(dir, absPath)
-			if err != nil {
-				continue
+	// Apply matchers in order of precedence level, and within a level from
+	// root to leaf. Later matchers can override earlier ones via negation
+	result, err := rm.applyRootPatterns(decision{}, rm.underlay, "lowest-precedence overlay", relPath, kind, observe)
+	if err != nil {
+		return decision{}, err
+	}
+
+	for level := 0; level < rm.levels; level++ {
+		for _, dir := range dirsToCheck {
+			for _, file := range rm.matchers[dir] {
+				if file.level != level {
+					continue
+				}
+
+				// Compute path relative to this matcher's directory
+				var matchPath string
+				if dir == rm.rootDir {
+					matchPath = relPath
+				} else {
+					relToDir, err := filepath.Rel(dir, absPath)
+					if err != nil {
+						continue
+					}
+					matchPath = filepath.ToSlash(relToDir)
+				}
+
+				// Check if this matcher has a pattern that applies
+				pattern, anyPatternMatched, err := file.matcher.matchDetail(matchPath, kind, observe)
+				if err != nil {
+					return decision{}, fmt.Errorf("error matching against %s: %w", file.path, err)
+				}
+
+				// Only update matched status if a pattern actually matched
+				// This allows deeper .gitignore files to override parent patterns
+				// through negation (e.g., parent has "*.log", child has "!debug.log")
+				// but doesn't override if the child .gitignore has no applicable patterns
+				if anyPatternMatched {
+					result = result.override(file, pattern)
+				}
 			}
-			matchPath = filepath.ToSlash(relToDir)
 		}
+	}
 
-		// Check if this matcher has a pattern that applies
-		// Use MatchesWithTracking to know if any pattern actually matched
-		isMatch, anyPatternMatched, err := matcher.MatchesWithTracking(matchPath)
-		if err != nil {
-			return false, fmt.Errorf("error matching against %s: %w", dir, err)
-		}
+	// Override patterns take precedence over every ignore file, and overlay
+	// patterns over those
+	if result, err = rm.applyRootPatterns(result, rm.overrides, "override", relPath, kind, observe); err != nil {
+		return decision{}, err
+	}
+	return rm.applyRootPatterns(result, rm.overlay, "overlay", relPath, kind, observe)
+}
+
+// applyRootPatterns applies matcher, holding patterns relative to the root
+// directory such as the override patterns, to the slash-separated relPath
+// after the decision d. A nil matcher leaves d unchanged. label names the
+// patterns in errors.
+func (rm *RepositoryMatcher) applyRootPatterns(d decision, matcher *PatternMatcher, label, relPath string, kind pathKind, observe bool) (decision, error) {
+	if matcher == nil {
+		return d, nil
+	}
+	pattern, anyPatternMatched, err := matcher.matchDetail(relPath, kind, observe)
+	if err != nil {
+		return decision{}, fmt.Errorf("error matching %s patterns: %w", label, err)
+	}
+	if anyPatternMatched {
+		d = d.override(nil, pattern)
+	}
+	return d, nil
+}
+
+// gitDirName is the name of the directory holding Git's repository metadata.
+const gitDirName = ".git"
 
-		// Only update matched status if a pattern actually matched
-		// This allows deeper .gitignore files to override parent patterns
-		// through negation (e.g., parent has "*.log", child has "!debug.log")
-		// but doesn't override if the child .gitignore has no applicable patterns
-		if anyPatternMatched {
-			matched = isMatch
+// inGitDir reports whether any component of the slash-separated relPath is a
+// .git directory.
+func inGitDir(relPath string) bool {
+	for relPath != "" {
+		component := relPath
+		if i := strings.IndexByte(relPath, '/'); i >= 0 {
+			component, relPath = relPath[:i], relPath[i+1:]
+		} else {
+			relPath = ""
+		}
+		if component == gitDirName {
+			return true
 		}
 	}
+	return false
+}
 
-	return matched, nil
+// isRepositoryRoot reports whether dir contains a .git directory or file.
+func (rm *RepositoryMatcher) isRepositoryRoot(dir string) bool {
+	_, err := rm.fsys.Lstat(filepath.Join(dir, gitDirName))
+	return err == nil
+}
+
+// inNestedRepository reports whether the slash-separated relPath is a nested
+// repository directory or lies inside one.
+func (rm *RepositoryMatcher) inNestedRepository(relPath string) bool {
+	if len(rm.nestedRoots) == 0 {
+		return false
+	}
+	for i := 0; i <= len(relPath); i++ {
+		if i == len(relPath) || relPath[i] == '/' {
+			if rm.nestedRoots[filepath.Join(rm.rootDir, filepath.FromSlash(relPath[:i]))] {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // RootDir returns the absolute path to the repository root directory.
@@ -254,23 +1272,86 @@ func (rm *RepositoryMatcher) RootDir() string {
 
 // IgnoreFileCount returns the number of .gitignore files discovered and loaded.
 func (rm *RepositoryMatcher) IgnoreFileCount() int {
-	return len(rm.matchers)
+	count := 0
+	for _, files := range rm.matchers {
+		count += len(files)
+	}
+	return count
 }
 
 // IgnoreFilePaths returns a list of all .gitignore file paths that were loaded,
-// relative to the repository root.
+// relative to the repository root. Files outside the root, such as
+// core.excludesFile, are listed by absolute path.
+//
+// The paths are in the order of WalkPatternMatchers: from the root to the
+// leaves, with the files of each directory in increasing order of precedence.
 func (rm *RepositoryMatcher) IgnoreFilePaths() []string {
 	var paths []string
-	for dir := range rm.matchers {
+	for _, dir := range rm.sortedDirs() {
+		for _, file := range rm.matchers[dir] {
+			paths = append(paths, filepath.FromSlash(rm.sourcePath(file.path)))
+		}
+	}
+	return paths
+}
+
+// WalkPatternMatchers calls fn for each loaded ignore file with the matcher
+// holding its patterns and the directory its patterns are relative to,
+// relative to the repository root ("." for the root itself, and ".." and so
+// on for the ancestors loaded with IncludeAncestorIgnoreFiles).
+// core.excludesFile belongs to the top of the work tree. Directories are visited in
+// lexical order, each before the directories beneath it, as discovery visits
+// them, and the files of a directory in increasing order of precedence.
+// Walking stops at the first error returned by fn, which WalkPatternMatchers
+// returns.
+//
+// The matchers are shared with rm and must not be modified.
+func (rm *RepositoryMatcher) WalkPatternMatchers(fn func(dir string, matcher *PatternMatcher) error) error {
+	for _, dir := range rm.sortedDirs() {
 		relDir, err := filepath.Rel(rm.rootDir, dir)
 		if err != nil {
+			return fmt.Errorf("failed to compute relative path: %w", err)
+		}
+		for _, file := range rm.matchers[dir] {
+			if err := fn(relDir, file.matcher); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortedDirs returns the directories with loaded ignore files in lexical
+// order, comparing paths component by component so that each directory
+// comes before the directories beneath it.
+func (rm *RepositoryMatcher) sortedDirs() []string {
+	dirs := make([]string, 0, len(rm.matchers))
+	for dir := range rm.matchers {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return comparePaths(dirs[i], dirs[j]) < 0
+	})
+	return dirs
+}
+
+// comparePaths compares two paths component by component, so that a
+// separator sorts before any other character.
+func comparePaths(a, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
 			continue
 		}
-		if relDir == "." {
-			paths = append(paths, ".gitignore")
-		} else {
-			paths = append(paths, filepath.Join(relDir, ".gitignore"))
+		switch {
+		case os.IsPathSeparator(a[i]):
+			return -1
+		case os.IsPathSeparator(b[i]):
+			return 1
+		case a[i] < b[i]:
+			return -1
+		default:
+			return 1
 		}
 	}
-	return paths
+	return len(a) - len(b)
 }
@@ -1,9 +1,14 @@
 package dotignore
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Helper function to create a test directory structure with .gitignore files
@@ -225,6 +230,53 @@ func TestRepositoryMatcher_Matches_Negation(t *testing.T) {
 	}
 }
 
+func TestRepositoryMatcher_StrictNegation(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":       "build/\n",
+		"build/.gitignore": "!keep.txt\n",
+		"docs/.gitignore":  "*.md\n!README.md\n",
+		"build/keep.txt":   "",
+		"docs/README.md":   "",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	config := DefaultRepositoryConfig()
+	config.StrictNegation = true
+
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		// build/ is excluded at the root, so the nested negation cannot apply
+		{"build/keep.txt", true},
+		{"build/output.bin", true},
+
+		// No parent directory is excluded, so negation still works
+		{"docs/README.md", false},
+		{"docs/guide.md", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := matcher.Matches(tt.path)
+			if err != nil {
+				t.Errorf("Matches(%q) error: %v", tt.path, err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestRepositoryMatcher_Matches_MonorepoScenario(t *testing.T) {
 	// Real-world monorepo structure from issue #4
 	structure := map[string]string{
@@ -429,9 +481,12 @@ func TestRepositoryMatcher_Matches_AbsolutePaths(t *testing.T) {
 
 func TestRepositoryMatcher_IgnoreFilePaths(t *testing.T) {
 	structure := map[string]string{
-		".gitignore": "*.log\n",
-		"frontend/.gitignore": "node_modules/\n",
-		"backend/.gitignore": "target/\n",
+		".gitignore":              "*.log\n",
+		"frontend/.gitignore":     "node_modules/\n",
+		"backend/.gitignore":      "target/\n",
+		"backend-old/.gitignore":  "*.bak\n",
+		"backend/api/.gitignore":  "*.pb.go\n",
+		"frontend/app/.gitignore": "dist/\n",
 	}
 
 	tmpDir := createTestRepo(t, structure)
@@ -442,22 +497,158 @@ func TestRepositoryMatcher_IgnoreFilePaths(t *testing.T) {
 		t.Fatalf("NewRepositoryMatcher() failed: %v", err)
 	}
 
-	paths := matcher.IgnoreFilePaths()
-	if len(paths) != 3 {
-		t.Errorf("IgnoreFilePaths() returned %d paths, want 3", len(paths))
+	// Paths are relative to the root and sorted from the root to the leaves
+	expected := []string{
+		".gitignore",
+		"backend/.gitignore",
+		"backend/api/.gitignore",
+		"backend-old/.gitignore",
+		"frontend/.gitignore",
+		"frontend/app/.gitignore",
 	}
+	for i := 0; i < 3; i++ {
+		if paths := slashPaths(matcher.IgnoreFilePaths()); !reflect.DeepEqual(paths, expected) {
+			t.Fatalf("IgnoreFilePaths() = %v, want %v", paths, expected)
+		}
+	}
+}
 
-	// Check that paths are relative to root
-	expectedPaths := map[string]bool{
-		".gitignore":          true,
-		"frontend/.gitignore": true,
-		"backend/.gitignore":  true,
+func TestRepositoryMatcher_WalkPatternMatchers(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":          "*.log\n",
+		".ignore":             "*.tmp\n",
+		"frontend/.gitignore": "node_modules/\n",
+		"backend/.ignore":     "target/\n",
 	}
 
-	for _, path := range paths {
-		if !expectedPaths[filepath.ToSlash(path)] {
-			t.Errorf("unexpected path in IgnoreFilePaths(): %s", path)
-		}
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	config := DefaultRepositoryConfig()
+	config.IgnoreFileNames = []string{".gitignore", ".ignore"}
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	var visited []string
+	err = matcher.WalkPatternMatchers(func(dir string, m *PatternMatcher) error {
+		visited = append(visited, filepath.ToSlash(dir)+": "+m.Patterns()[0].Text())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkPatternMatchers() failed: %v", err)
+	}
+	expected := []string{".: *.log", ".: *.tmp", "backend: target/", "frontend: node_modules/"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("WalkPatternMatchers() visited %v, want %v", visited, expected)
+	}
+
+	// Walking stops at the first error
+	errStop := errors.New("stop")
+	calls := 0
+	err = matcher.WalkPatternMatchers(func(dir string, m *PatternMatcher) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("WalkPatternMatchers() = %v after %d calls, want %v after 1", err, calls, errStop)
+	}
+}
+
+func TestRepositoryMatcher_GitStrict(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":        "src/test\n",
+		"web/.gitignore":    "assets/gen\n",
+		"src/test/a.go":     "",
+		"lib/src/test/a.go": "",
+		"web/assets/gen/x":  "",
+		"assets/gen/x":      "",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	config := DefaultRepositoryConfig()
+	config.GitStrict = true
+
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		// Patterns with a slash are anchored to their ignore file's directory
+		{"src/test/a.go", true},
+		{"lib/src/test/a.go", false},
+		{"web/assets/gen/x", true},
+		{"assets/gen/x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := matcher.Matches(tt.path)
+			if err != nil {
+				t.Errorf("Matches(%q) error: %v", tt.path, err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryMatcher_GitStrictDirectories(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":   "*.log\n!b/\n/f*\nc/\n!c/\n",
+		"b/a.log":      "",
+		"foo/x":        "",
+		"c/x":          "",
+		"d/.gitignore": "e/*\n",
+		"d/e/build/x":  "",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	config := DefaultRepositoryConfig()
+	config.CheckFileTypes = true
+	config.StrictNegation = true
+	config.GitStrict = true
+
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		// Re-including the directory b does not re-include its files
+		{"b/a.log", true},
+		// Wildcard patterns matching a directory cover what is beneath it
+		{"foo/x", true},
+		{"d/e/build/x", true},
+		// A later negation re-includes the directory c and so its contents
+		{"c/x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := matcher.Matches(tt.path)
+			if err != nil {
+				t.Errorf("Matches(%q) error: %v", tt.path, err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
 	}
 }
 
@@ -491,6 +682,288 @@ func TestRepositoryMatcherWithConfig_MaxDepth(t *testing.T) {
 	}
 }
 
+func TestRepositoryMatcherWithConfig_SkipIgnoredDirectories(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":                      "node_modules/\n/build/\nvendor/\n*.log\n",
+		"node_modules/pkg/.gitignore":     "*.js\n",
+		"web/node_modules/pkg/.gitignore": "*.js\n",
+		"build/.gitignore":                "!keep.log\n",
+		"src/.gitignore":                  "*.tmp\n",
+		"src/build/.gitignore":            "*.out\n", // /build/ is anchored to the root
+		"vendor/.gitignore":               "*.bak\n", // re-included by the extra patterns
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	config := DefaultRepositoryConfig()
+	config.SkipIgnoredDirectories = true
+	config.ExtraPatterns = []string{"!vendor/"}
+
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	files := slashPaths(matcher.IgnoreFilePaths())
+	expected := []string{".gitignore", "src/.gitignore", "src/build/.gitignore", "vendor/.gitignore"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("IgnoreFilePaths() = %v, want %v", files, expected)
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"node_modules/pkg/index.js", true},
+		{"build/keep.log", true}, // build/.gitignore was never loaded
+		{"src/build/a.out", true},
+		{"src/a.tmp", true},
+		{"web/app.log", true},
+	}
+	for _, tt := range tests {
+		result, err := matcher.Matches(tt.path)
+		if err != nil {
+			t.Fatalf("Matches(%q) failed: %v", tt.path, err)
+		}
+		if result != tt.expected {
+			t.Errorf("Matches(%q) = %v, want %v", tt.path, result, tt.expected)
+		}
+	}
+
+	// Without the option every directory is searched
+	matcher, err = NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcher() failed: %v", err)
+	}
+	if count := matcher.IgnoreFileCount(); count != len(structure) {
+		t.Errorf("IgnoreFileCount() = %d, want %d", count, len(structure))
+	}
+}
+
+func TestRepositoryMatcherWithConfig_Limits(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":     "*.log\n*.tmp\n",
+		"a/.gitignore":   "/" + strings.Repeat("x", 100) + "\n",
+		"a/b/.gitignore": "*.cache\n",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	tests := []struct {
+		name    string
+		config  RepositoryConfig
+		wantErr error
+		want    int // ignore files loaded
+	}{
+		{"no limits", RepositoryConfig{}, nil, 3},
+		{"enough patterns", RepositoryConfig{MaxPatterns: 4}, nil, 3},
+		{"too many patterns", RepositoryConfig{MaxPatterns: 3}, ErrTooManyPatterns, 0},
+		{"too many patterns in one file", RepositoryConfig{MaxPatterns: 1}, ErrTooManyPatterns, 0},
+		{"enough ignore files", RepositoryConfig{MaxIgnoreFiles: 3}, nil, 3},
+		{"too many ignore files", RepositoryConfig{MaxIgnoreFiles: 2}, ErrTooManyIgnoreFiles, 0},
+		{"long pattern skipped", RepositoryConfig{MaxPatternLength: 50}, nil, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			matcher, err := NewRepositoryMatcherWithConfig(tmpDir, &config)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("NewRepositoryMatcherWithConfig() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+			}
+			if count := matcher.IgnoreFileCount(); count != tt.want {
+				t.Errorf("got %d ignore files, want %d", count, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryMatcherWithConfig_IncludeAncestorIgnoreFiles(t *testing.T) {
+	t.Setenv("GIT_DIR", "")
+	t.Setenv("GIT_WORK_TREE", "")
+
+	structure := map[string]string{
+		".git/HEAD":               "ref: refs/heads/main\n",
+		".gitignore":              "*.log\n/build/\nservices/api/gen/\n",
+		"services/.gitignore":     "*.tmp\n",
+		"services/api/.gitignore": "!keep.log\n",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+	root := filepath.Join(tmpDir, "services", "api")
+
+	config := DefaultRepositoryConfig()
+	config.IncludeAncestorIgnoreFiles = true
+	matcher, err := NewRepositoryMatcherWithConfig(root, config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	expectedFiles := []string{
+		filepath.ToSlash(filepath.Join(tmpDir, ".gitignore")),
+		filepath.ToSlash(filepath.Join(tmpDir, "services", ".gitignore")),
+		".gitignore",
+	}
+	if files := slashPaths(matcher.IgnoreFilePaths()); !reflect.DeepEqual(files, expectedFiles) {
+		t.Errorf("IgnoreFilePaths() = %v, want %v", files, expectedFiles)
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"debug.log", true},
+		{"keep.log", false}, // the root's own file takes precedence
+		{"cache.tmp", true},
+		{"build/app", false}, // /build/ is anchored to the top of the work tree
+		{"gen/api.go", true},
+		{"main.go", false},
+	}
+	for _, tt := range tests {
+		result, err := matcher.Matches(tt.path)
+		if err != nil {
+			t.Fatalf("Matches(%q) failed: %v", tt.path, err)
+		}
+		if result != tt.expected {
+			t.Errorf("Matches(%q) = %v, want %v", tt.path, result, tt.expected)
+		}
+	}
+
+	patterns, err := matcher.EffectivePatterns(".")
+	if err != nil {
+		t.Fatalf("EffectivePatterns() failed: %v", err)
+	}
+	if len(patterns) != 5 || patterns[0].Dir != "../.." || patterns[3].Dir != ".." {
+		t.Errorf("EffectivePatterns() = %v, want the ancestors' patterns first", patterns)
+	}
+
+	// Encoding keeps the ancestors
+	var buf bytes.Buffer
+	if err := matcher.Encode(&buf); err != nil {
+		t.Fatalf("Encode() failed: %v", err)
+	}
+	decoded, err := DecodeRepositoryMatcher(&buf)
+	if err != nil {
+		t.Fatalf("DecodeRepositoryMatcher() failed: %v", err)
+	}
+	if result, _ := decoded.Matches("gen/api.go"); !result {
+		t.Error("Matches(gen/api.go) = false after decoding, want true")
+	}
+
+	// Without the option only the root's own file applies
+	matcher, err = NewRepositoryMatcher(root)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcher() failed: %v", err)
+	}
+	if result, _ := matcher.Matches("debug.log"); result {
+		t.Error("Matches(debug.log) = true without IncludeAncestorIgnoreFiles, want false")
+	}
+}
+
+func TestRepositoryMatcherWithConfig_IncludeAncestorIgnoreFilesExcludesFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+	t.Setenv("GIT_DIR", "")
+	t.Setenv("GIT_WORK_TREE", "")
+	if err := os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[core]\n\texcludesFile = ~/global-ignore\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "global-ignore"), []byte("*.tmp\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	structure := map[string]string{
+		".git/HEAD":  "ref: refs/heads/main\n",
+		".gitignore": "!keep.tmp\n",
+		"sub/a.go":   "",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	// The excludes file has the lowest precedence, below the ancestors
+	config := DefaultRepositoryConfig()
+	config.ReadGitConfig = true
+	config.IncludeAncestorIgnoreFiles = true
+	matcher, err := NewRepositoryMatcherWithConfig(filepath.Join(tmpDir, "sub"), config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	result, err := matcher.MatchDetail("keep.tmp")
+	if err != nil {
+		t.Fatalf("MatchDetail() failed: %v", err)
+	}
+	if result.Ignored || result.Source != filepath.ToSlash(filepath.Join(tmpDir, ".gitignore")) {
+		t.Errorf("MatchDetail(keep.tmp) = %+v, want re-included by the work tree's .gitignore", result)
+	}
+	if ignored, _ := matcher.Matches("cache.tmp"); !ignored {
+		t.Error("Matches(cache.tmp) = false, want true")
+	}
+}
+
+func TestRepositoryMatcherWithConfig_OnError(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":     "*.log\n",
+		"bad/.gitignore": "*.tmp\n!\n",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	// Errors are reported and the file is skipped
+	var reported []string
+	config := DefaultRepositoryConfig()
+	config.OnError = func(path string, err error) error {
+		if !errors.Is(err, ErrInvalidNegation) {
+			t.Errorf("OnError(%q) got %v, want an error wrapping ErrInvalidNegation", path, err)
+		}
+		reported = append(reported, path)
+		return nil
+	}
+
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+	want := []string{filepath.Join(tmpDir, "bad", ".gitignore")}
+	if !reflect.DeepEqual(reported, want) {
+		t.Errorf("OnError called for %v, want %v", reported, want)
+	}
+	if got, _ := matcher.Matches("bad/a.tmp"); got {
+		t.Error("Matches(bad/a.tmp) = true, want false for a skipped file")
+	}
+
+	// Returning an error aborts discovery
+	errAbort := errors.New("abort")
+	config.OnError = func(path string, err error) error {
+		return errAbort
+	}
+	if _, err := NewRepositoryMatcherWithConfig(tmpDir, config); !errors.Is(err, errAbort) {
+		t.Errorf("NewRepositoryMatcherWithConfig() error = %v, want %v", err, errAbort)
+	}
+
+	// Reload keeps the previous state when aborted
+	matcher.config.OnError = config.OnError
+	if err := matcher.Reload(); !errors.Is(err, errAbort) {
+		t.Errorf("Reload() error = %v, want %v", err, errAbort)
+	}
+	if got, _ := matcher.Matches("app.log"); !got {
+		t.Error("Matches(app.log) = false after a failed Reload, want true")
+	}
+}
+
 func TestRepositoryMatcherWithConfig_CustomIgnoreFileName(t *testing.T) {
 	structure := map[string]string{
 		".ignore": "*.log\n",
@@ -523,6 +996,695 @@ func TestRepositoryMatcherWithConfig_CustomIgnoreFileName(t *testing.T) {
 	}
 }
 
+func TestRepositoryMatcherWithConfig_FallbackIgnoreFileName(t *testing.T) {
+	structure := map[string]string{
+		".ignore":        "*.log\n",
+		".gitignore":     "*.tmp\n",
+		"src/.gitignore": "*.cache\n",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	config := &RepositoryConfig{
+		IgnoreFileName:         ".ignore",
+		FallbackIgnoreFileName: ".gitignore",
+	}
+
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	expectedPaths := map[string]bool{
+		".ignore":        true,
+		"src/.gitignore": true,
+	}
+	paths := matcher.IgnoreFilePaths()
+	if len(paths) != len(expectedPaths) {
+		t.Errorf("IgnoreFilePaths() = %v, want %d paths", paths, len(expectedPaths))
+	}
+	for _, path := range paths {
+		if !expectedPaths[filepath.ToSlash(path)] {
+			t.Errorf("unexpected path in IgnoreFilePaths(): %s", path)
+		}
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"app.log", true},
+		{"app.tmp", false}, // root .gitignore is shadowed by .ignore
+		{"src/data.cache", true},
+	}
+
+	for _, tt := range tests {
+		got, err := matcher.Matches(tt.path)
+		if err != nil {
+			t.Errorf("Matches(%q) error: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRepositoryMatcherWithConfig_IgnoreFileNames(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":     "*.log\n*.tmp\n",
+		".ignore":        "!debug.log\n",
+		"src/.gitignore": "debug.log\n",
+		"src/.fdignore":  "*.gen.go\n",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	config := &RepositoryConfig{
+		IgnoreFileNames: []string{".gitignore", ".ignore", ".fdignore"},
+	}
+
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	if count := matcher.IgnoreFileCount(); count != 4 {
+		t.Errorf("got %d ignore files, want 4", count)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"app.log", true},
+		{"debug.log", false},     // .ignore overrides .gitignore in the same directory
+		{"src/debug.log", false}, // .ignore outranks the deeper src/.gitignore
+		{"src/app.tmp", true},    // only .gitignore has an opinion
+		{"src/types.gen.go", true},
+		{"types.gen.go", false}, // src/.fdignore does not apply outside src
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := matcher.Matches(tt.path)
+			if err != nil {
+				t.Errorf("Matches(%q) error: %v", tt.path, err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryMatcher_GitDir(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":           "*.log\n",
+		".git/config":          "",
+		".git/info/.gitignore": "*.go\n",
+		"sub/.git":             "gitdir: ../.git/modules/sub\n",
+		"main.go":              "",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	t.Run("excluded by default", func(t *testing.T) {
+		matcher, err := NewRepositoryMatcher(tmpDir)
+		if err != nil {
+			t.Fatalf("NewRepositoryMatcher() failed: %v", err)
+		}
+
+		if count := matcher.IgnoreFileCount(); count != 1 {
+			t.Errorf("got %d ignore files, want 1 (.git must not be searched)", count)
+		}
+
+		tests := []struct {
+			path string
+			want bool
+		}{
+			{".git", true},
+			{".git/config", true},
+			{".git/objects/ab/cdef", true},
+			{"sub/.git", true},
+			{"main.go", false},
+			{".github/workflows/build.yml", false},
+		}
+
+		for _, tt := range tests {
+			got, err := matcher.Matches(tt.path)
+			if err != nil {
+				t.Errorf("Matches(%q) error: %v", tt.path, err)
+				continue
+			}
+			if got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("included on request", func(t *testing.T) {
+		config := DefaultRepositoryConfig()
+		config.IncludeGitDir = true
+
+		matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+		if err != nil {
+			t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+		}
+
+		if count := matcher.IgnoreFileCount(); count != 2 {
+			t.Errorf("got %d ignore files, want 2", count)
+		}
+
+		got, err := matcher.Matches(".git/config")
+		if err != nil {
+			t.Fatalf("Matches() error: %v", err)
+		}
+		if got {
+			t.Error("Matches(.git/config) = true, want false")
+		}
+	})
+}
+
+func TestRepositoryMatcher_NestedRepositories(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":             "*.log\n",
+		"lib/.git":               "gitdir: ../.git/modules/lib\n",
+		"lib/.gitignore":         "*.tmp\n",
+		"vendor/tool/.git/HEAD":  "ref: refs/heads/main\n",
+		"vendor/tool/.gitignore": "bin/\n",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	tests := []struct {
+		mode      NestedRepositoryMode
+		wantCount int
+		cases     map[string]bool
+	}{
+		{
+			mode:      NestedRepositoryInclude,
+			wantCount: 3,
+			cases: map[string]bool{
+				"lib/app.log":         true,
+				"lib/cache.tmp":       true,
+				"vendor/tool/bin/x":   true,
+				"vendor/tool/main.go": false,
+			},
+		},
+		{
+			mode:      NestedRepositorySkip,
+			wantCount: 1,
+			cases: map[string]bool{
+				"lib":                 true,
+				"lib/main.go":         true,
+				"vendor/tool/main.go": true,
+				"vendor/other.go":     false,
+				"app.log":             true,
+			},
+		},
+		{
+			mode:      NestedRepositoryScope,
+			wantCount: 3,
+			cases: map[string]bool{
+				"lib/app.log":         false, // root *.log does not cross into the submodule
+				"lib/cache.tmp":       true,
+				"vendor/tool/bin/x":   true,
+				"vendor/tool/app.log": false,
+				"vendor/app.log":      true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		config := DefaultRepositoryConfig()
+		config.NestedRepositories = tt.mode
+
+		matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+		if err != nil {
+			t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+		}
+
+		if count := matcher.IgnoreFileCount(); count != tt.wantCount {
+			t.Errorf("mode %d: got %d ignore files, want %d", tt.mode, count, tt.wantCount)
+		}
+
+		for path, want := range tt.cases {
+			got, err := matcher.Matches(path)
+			if err != nil {
+				t.Errorf("mode %d: Matches(%q) error: %v", tt.mode, path, err)
+				continue
+			}
+			if got != want {
+				t.Errorf("mode %d: Matches(%q) = %v, want %v", tt.mode, path, got, want)
+			}
+		}
+	}
+}
+
+func TestRepositoryMatcherWithConfig_ExtraPatterns(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":     "*.log\n",
+		"src/.gitignore": "!debug.log\n",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	config := &RepositoryConfig{
+		IgnoreFileName: ".gitignore",
+		ExtraPatterns:  []string{"*.tmp", "/dist/", "src/debug.log", "!keep.log"},
+	}
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcherWithConfig() failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"app.log", true},
+		{"a.tmp", true},
+		{"src/a.tmp", true},
+		{"dist/app.js", true},
+		{"src/dist/app.js", false},
+		{"src/debug.log", true},
+		{"keep.log", false},
+		{"main.go", false},
+	}
+	for _, tt := range tests {
+		if got, err := matcher.Matches(tt.path); err != nil || got != tt.want {
+			t.Errorf("Matches(%q) = %v, %v; want %v", tt.path, got, err, tt.want)
+		}
+	}
+
+	result, err := matcher.MatchDetail("src/debug.log")
+	if err != nil {
+		t.Fatalf("MatchDetail() failed: %v", err)
+	}
+	if result.Source != "" || result.Pattern != "src/debug.log" {
+		t.Errorf("MatchDetail(src/debug.log) = %+v, want the extra pattern without a source", result)
+	}
+
+	// Extra patterns survive Reload
+	if err := matcher.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if got, _ := matcher.Matches("a.tmp"); !got {
+		t.Error("Matches(a.tmp) = false after Reload, want true")
+	}
+
+	config.ExtraPatterns = []string{"!"}
+	if _, err := NewRepositoryMatcherWithConfig(tmpDir, config); err == nil {
+		t.Error("Expected an error for an invalid extra pattern")
+	}
+
+	// The limits for ignore files apply as well
+	config.ExtraPatterns = []string{strings.Repeat("a", 20)}
+	config.MaxPatternLength = 10
+	if _, err := NewRepositoryMatcherWithConfig(tmpDir, config); err == nil {
+		t.Error("Expected an error for an extra pattern over MaxPatternLength")
+	}
+}
+
+func TestRepositoryMatcher_Reload(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":          "*.log\n",
+		"frontend/.gitignore": "dist/\n",
+		"backend/.gitignore":  "target/\n",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcher() failed: %v", err)
+	}
+	rootMatcher := matcher.matchers[tmpDir][0].matcher
+
+	// Edit one file, remove another and add a new one
+	if err := os.WriteFile(filepath.Join(tmpDir, "frontend", ".gitignore"), []byte("build/\ncoverage/\n"), 0644); err != nil {
+		t.Fatalf("failed to edit .gitignore: %v", err)
+	}
+	if err := os.Remove(filepath.Join(tmpDir, "backend", ".gitignore")); err != nil {
+		t.Fatalf("failed to remove .gitignore: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "docs"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "docs", ".gitignore"), []byte("_build/\n"), 0644); err != nil {
+		t.Fatalf("failed to write .gitignore: %v", err)
+	}
+
+	if err := matcher.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+
+	if count := matcher.IgnoreFileCount(); count != 3 {
+		t.Errorf("got %d ignore files after Reload(), want 3", count)
+	}
+	if matcher.matchers[tmpDir][0].matcher != rootMatcher {
+		t.Error("unchanged root .gitignore was parsed again")
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"app.log", true},
+		{"frontend/dist/app.js", false},
+		{"frontend/build/app.js", true},
+		{"backend/target/app.jar", false},
+		{"docs/_build/index.html", true},
+	}
+
+	for _, tt := range tests {
+		got, err := matcher.Matches(tt.path)
+		if err != nil {
+			t.Errorf("Matches(%q) error: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	// Reload fails without losing state once the root disappears
+	os.RemoveAll(tmpDir)
+	if err := matcher.Reload(); err == nil {
+		t.Error("expected error reloading a removed repository")
+	}
+	if count := matcher.IgnoreFileCount(); count != 3 {
+		t.Errorf("got %d ignore files after failed Reload(), want 3", count)
+	}
+}
+
+func TestRepositoryMatcher_Refresh(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":          "*.log\n",
+		"frontend/.gitignore": "dist/\n",
+		"backend/.gitignore":  "target/\n",
+		"lib/util/util.go":    "",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	// Give every directory a known modification time in the past
+	past := time.Now().Add(-time.Hour)
+	for _, dir := range []string{"", "frontend", "backend", "lib", "lib/util"} {
+		if err := os.Chtimes(filepath.Join(tmpDir, dir), past, past); err != nil {
+			t.Fatalf("failed to set modification time: %v", err)
+		}
+	}
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcher() failed: %v", err)
+	}
+	rootMatcher := matcher.matchers[tmpDir][0].matcher
+
+	// Edit one file, remove another, and add a new directory with one
+	if err := os.WriteFile(filepath.Join(tmpDir, "frontend", ".gitignore"), []byte("build/\ncoverage/\n"), 0644); err != nil {
+		t.Fatalf("failed to edit .gitignore: %v", err)
+	}
+	if err := os.Remove(filepath.Join(tmpDir, "backend", ".gitignore")); err != nil {
+		t.Fatalf("failed to remove .gitignore: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "lib", "docs"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "lib", "docs", ".gitignore"), []byte("_build/\n"), 0644); err != nil {
+		t.Fatalf("failed to write .gitignore: %v", err)
+	}
+
+	// A directory added without changing its parent's modification time
+	// is only found by Reload
+	hidden := filepath.Join(tmpDir, "lib", "util", "gen")
+	if err := os.MkdirAll(hidden, 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(hidden, ".gitignore"), []byte("*.pb.go\n"), 0644); err != nil {
+		t.Fatalf("failed to write .gitignore: %v", err)
+	}
+	if err := os.Chtimes(filepath.Join(tmpDir, "lib", "util"), past, past); err != nil {
+		t.Fatalf("failed to set modification time: %v", err)
+	}
+
+	if err := matcher.Refresh(); err != nil {
+		t.Fatalf("Refresh() failed: %v", err)
+	}
+	if count := matcher.IgnoreFileCount(); count != 3 {
+		t.Errorf("got %d ignore files after Refresh(), want 3", count)
+	}
+	if matcher.matchers[tmpDir][0].matcher != rootMatcher {
+		t.Error("unchanged root .gitignore was parsed again")
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"app.log", true},
+		{"frontend/dist/app.js", false},
+		{"frontend/build/app.js", true},
+		{"backend/target/app.jar", false},
+		{"lib/docs/_build/index.html", true},
+		{"lib/util/gen/api.pb.go", false},
+	}
+
+	for _, tt := range tests {
+		got, err := matcher.Matches(tt.path)
+		if err != nil {
+			t.Errorf("Matches(%q) error: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if err := matcher.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if got, _ := matcher.Matches("lib/util/gen/api.pb.go"); !got {
+		t.Error("Matches(lib/util/gen/api.pb.go) = false after Reload, want true")
+	}
+
+	// Removed directories are dropped
+	if err := os.RemoveAll(filepath.Join(tmpDir, "lib")); err != nil {
+		t.Fatalf("failed to remove directory: %v", err)
+	}
+	if err := matcher.Refresh(); err != nil {
+		t.Fatalf("Refresh() failed: %v", err)
+	}
+	if count := matcher.IgnoreFileCount(); count != 2 {
+		t.Errorf("got %d ignore files after removing lib, want 2", count)
+	}
+}
+
+func TestRepositoryMatcher_MatchDetail(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":         "*.log\n/build/\n",
+		"app/.gitignore":     "# app rules\n!keep.log\n",
+		"app/old/.gitignore": "keep.log\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected MatchResult
+	}{
+		{"debug.log", MatchResult{Ignored: true, Matched: true, Source: ".gitignore", Line: 1, Pattern: "*.log"}},
+		{"build/out.bin", MatchResult{Ignored: true, Matched: true, Source: ".gitignore", Line: 2, Pattern: "/build/"}},
+		{"app/keep.log", MatchResult{Ignored: false, Matched: true, Source: "app/.gitignore", Line: 2, Pattern: "!keep.log",
+			Overridden: PatternLocation{Source: ".gitignore", Line: 1}, OverriddenPattern: "*.log"}},
+		{"app/old/keep.log", MatchResult{Ignored: true, Matched: true, Source: "app/old/.gitignore", Line: 1, Pattern: "keep.log",
+			Overridden: PatternLocation{Source: "app/.gitignore", Line: 2}, OverriddenPattern: "!keep.log"}},
+		{"app/main.go", MatchResult{}},
+		{".git/config", MatchResult{Ignored: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result, err := matcher.MatchDetail(tt.path)
+			if err != nil {
+				t.Fatalf("Error matching %s: %v", tt.path, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestRepositoryMatcher_CheckFileTypes(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":             "build/\nvendor/\n",
+		"build":                  "a file, not a directory",
+		"src/build/out.bin":      "",
+		"third_party/lib/lib.go": "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.Symlink("third_party", filepath.Join(tmpDir, "vendor")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	newMatcher := func(config *RepositoryConfig) *RepositoryMatcher {
+		config.IgnoreFileName = ".gitignore"
+		matcher, err := NewRepositoryMatcherWithConfig(tmpDir, config)
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+		return matcher
+	}
+
+	tests := []struct {
+		name     string
+		matcher  *RepositoryMatcher
+		expected map[string]bool
+	}{
+		{
+			name:    "Default",
+			matcher: newMatcher(&RepositoryConfig{}),
+			expected: map[string]bool{
+				"build":          true,
+				"src/build":      true,
+				"vendor":         true,
+				"vendor/lib.go":  true,
+				"missing/vendor": true,
+			},
+		},
+		{
+			name:    "Check file types",
+			matcher: newMatcher(&RepositoryConfig{CheckFileTypes: true}),
+			expected: map[string]bool{
+				"build":             false,
+				"build/":            true, // the trailing slash marks a directory
+				"src/build":         true,
+				"src/build/out.bin": true,
+				"vendor":            false,
+				"vendor/lib/lib.go": false,
+				"missing/vendor":    true,
+			},
+		},
+		{
+			name:    "Match through symlinks",
+			matcher: newMatcher(&RepositoryConfig{CheckFileTypes: true, MatchThroughSymlinks: true}),
+			expected: map[string]bool{
+				"build":             false,
+				"vendor":            true,
+				"vendor/lib/lib.go": true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for path, expected := range tt.expected {
+				result, err := tt.matcher.Matches(path)
+				if err != nil {
+					t.Fatalf("Error matching %s: %v", path, err)
+				}
+				if result != expected {
+					t.Errorf("Path %q: expected %v, got %v", path, expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestRepositoryMatcher_MatchesPath(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":     "build/\n*.log\n",
+		"src/.gitignore": "!keep.log\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		// No file named build exists; the caller says what it is
+		{"build", false, false},
+		{"build", true, true},
+		{"build/out.bin", false, true},
+		{"src/build", true, true},
+		{"app.log", false, true},
+		{"src/keep.log", false, false},
+	}
+
+	for _, tt := range tests {
+		result, err := matcher.MatchesPath(tt.path, tt.isDir)
+		if err != nil {
+			t.Fatalf("Error matching %s: %v", tt.path, err)
+		}
+		if result != tt.expected {
+			t.Errorf("MatchesPath(%q, %v) = %v, want %v", tt.path, tt.isDir, result, tt.expected)
+		}
+	}
+}
+
+func TestRepositoryMatcher_IsIgnored(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":     "build/\n*.log\n",
+		"src/.gitignore": "!keep.log\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	outside := filepath.Join(filepath.Dir(tmpDir), "elsewhere.log")
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"build", true, true},
+		{"build", false, false},
+		{"src/app.log", false, true},
+		{"src/keep.log", false, false},
+		{outside, false, false},
+	}
+	for _, tt := range tests {
+		if result := matcher.IsIgnored(tt.path, tt.isDir); result != tt.expected {
+			t.Errorf("IsIgnored(%q, %v) = %v, want %v", tt.path, tt.isDir, result, tt.expected)
+		}
+	}
+
+	if !matcher.MustMatch("src/app.log", false) {
+		t.Error("MustMatch(src/app.log) = false, want true")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustMatch() of a path outside the root did not panic")
+		}
+	}()
+	matcher.MustMatch(outside, false)
+}
+
 func TestRepositoryMatcher_Matches_WildcardPatterns(t *testing.T) {
 	structure := map[string]string{
 		".gitignore": "node_modules/\n**/*.test.js\n",
@@ -0,0 +1,20 @@
+package dotignore
+
+// RipgrepRepositoryConfig returns a RepositoryConfig that layers ignore files
+// the way ripgrep does. Each directory may contain .gitignore, .ignore and
+// .rgignore files, in increasing order of precedence: a decision made by an
+// .rgignore file anywhere in the hierarchy overrides every .ignore and
+// .gitignore file, and a decision made by an .ignore file overrides every
+// .gitignore file. Among files of the same name the deepest directory wins.
+//
+// Like ripgrep, the global excludes file named by core.excludesFile applies
+// below all of them, and core.ignoreCase is not applied: patterns stay
+// case-sensitive. ripgrep's other filters, such as skipping hidden and
+// binary files, are not ignore rules and are left to the caller.
+func RipgrepRepositoryConfig() *RepositoryConfig {
+	config := DefaultRepositoryConfig()
+	config.IgnoreFileNames = []string{".gitignore", ".ignore", ".rgignore"}
+	config.ReadGitConfig = true
+	config.CaseSensitive = true
+	return config
+}
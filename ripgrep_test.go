@@ -0,0 +1,49 @@
+package dotignore
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRipgrepRepositoryConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+
+	tmpDir := createTestRepo(t, map[string]string{
+		".git/config":    "[core]\n\tignorecase = true\n",
+		".gitignore":     "*.log\n/vendor/\n",
+		".ignore":        "!keep.log\ngenerated/\n",
+		".rgignore":      "!generated/\n",
+		"app/.gitignore": "keep.log\n!vendor.log\n",
+		"app/.ignore":    "*.tmp\n",
+		"app/.rgignore":  "!important.tmp\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcherWithConfig(tmpDir, RipgrepRepositoryConfig())
+	if err != nil {
+		t.Fatalf("Failed to create repository matcher: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"debug.log", true},
+		{"keep.log", false},
+		{"app/keep.log", false},   // root .ignore beats the deeper .gitignore
+		{"app/vendor.log", false}, // the deeper .gitignore beats the root one
+		{"vendor/lib.go", true},
+		{"generated/code.go", false}, // .rgignore beats .ignore
+		{"app/cache.tmp", true},
+		{"app/important.tmp", false},
+		{"cache.tmp", false},
+		{"debug.LOG", false}, // core.ignoreCase does not apply
+	}
+	for _, tt := range tests {
+		if got, err := matcher.Matches(tt.path); err != nil || got != tt.expected {
+			t.Errorf("Matches(%q) = %v, %v, want %v", tt.path, got, err, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package dotignore
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RsyncFilterRules translates the patterns of the matcher into rsync filter
+// rules, one rule per element, in the order rsync must evaluate them. Write
+// them to a file, one per line, and pass it to rsync with
+// --filter="merge FILE" or --exclude-from=FILE.
+//
+// rsync applies the first rule that matches while ignore files apply the
+// last, so the rules are emitted in reverse pattern order. Excluded patterns
+// become "- " rules and negations become "+ " rules; directory-only patterns
+// keep their trailing slash and anchored patterns get a leading slash.
+//
+// Some behavior cannot be expressed in rsync's rule syntax:
+//   - rsync does not descend into excluded directories, so a negation cannot
+//     re-include a file below an excluded directory, as with WithStrictNegation
+//   - case-insensitive matchers produce lowercase rules, which rsync still
+//     matches case-sensitively
+//   - SyntaxHelm patterns starting with "!" ignore every path they do not
+//     match and are left out
+func (p *PatternMatcher) RsyncFilterRules() []string {
+	rules := p.rules.Load()
+
+	var filterRules []string
+	for _, pattern := range rules.patterns {
+		filterRules = appendRsyncRules(filterRules, pattern, p.options.syntax, "")
+	}
+	reverseStrings(filterRules)
+	return filterRules
+}
+
+// RsyncFilterRules translates the rules of every loaded ignore file into a
+// single list of rsync filter rules, as described for
+// PatternMatcher.RsyncFilterRules. Patterns from ignore files in
+// subdirectories are anchored to their directory, and the built-in
+// exclusions of .git directories and skipped nested repositories are
+// included.
+//
+// NestedRepositoryScope is not reproduced: rules from parent directories
+// still apply inside nested repositories. Of the ignore files above the
+// root, such as those of ancestors loaded with IncludeAncestorIgnoreFiles,
+// only the patterns matching a name at any depth, like "*.log", are kept:
+// patterns anchored to a directory above the root cannot be expressed
+// relative to it and are left out.
+func (rm *RepositoryMatcher) RsyncFilterRules() []string {
+	var rules []string
+	rm.exportPatterns(func(matcher *PatternMatcher, pattern ignorePattern, dir, _ string) {
+		rules = appendRsyncRules(rules, pattern, matcher.options.syntax, dir)
+	})
+
+	// Built-in exclusions take precedence over every pattern
+	nested := rm.skippedNestedDirs()
+	for i := len(nested) - 1; i >= 0; i-- {
+		rules = append(rules, "- /"+nested[i]+"/")
+	}
+	if !rm.config.IncludeGitDir {
+		rules = append(rules, "- "+gitDirName)
+	}
+
+	reverseStrings(rules)
+	return rules
+}
+
+// exportPatterns calls fn with the patterns of every loaded ignore file, the
+// override patterns and the overlays, in evaluation order, along with the matcher
+// holding them, the slash-separated directory of their ignore file relative
+// to the root ("" for the root) and the ignore file in the form used by
+// MatchResult.Source ("" for overrides and overlays). The ignore files of
+// directories above the root are exported as if they were at the root, with
+// only their floating patterns that name a single path component.
+func (rm *RepositoryMatcher) exportPatterns(fn func(matcher *PatternMatcher, pattern ignorePattern, dir, source string)) {
+	// Deeper directories override shallower ones within a precedence level
+	ancestors := make(map[string]bool, len(rm.ancestorDirs))
+	for _, dir := range rm.ancestorDirs {
+		ancestors[dir] = true
+	}
+	dirs := make([]string, 0, len(rm.matchers))
+	for dir := range rm.matchers {
+		if !ancestors[dir] {
+			dirs = append(dirs, dir)
+		}
+	}
+	relDirs := make(map[string]string, len(dirs))
+	for _, dir := range dirs {
+		relDir, err := filepath.Rel(rm.rootDir, dir)
+		if err != nil || relDir == "." {
+			relDir = ""
+		}
+		relDirs[dir] = filepath.ToSlash(relDir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		di, dj := rsyncDepth(relDirs[dirs[i]]), rsyncDepth(relDirs[dirs[j]])
+		if di != dj {
+			return di < dj
+		}
+		return relDirs[dirs[i]] < relDirs[dirs[j]]
+	})
+
+	exportRoot := func(matcher *PatternMatcher) {
+		if matcher == nil {
+			return
+		}
+		for _, pattern := range matcher.rules.Load().patterns {
+			fn(matcher, pattern, "", "")
+		}
+	}
+
+	exportRoot(rm.underlay)
+	for level := 0; level < rm.levels; level++ {
+		// Ancestors come first, outermost first, as in matchRelative
+		for _, dir := range rm.ancestorDirs {
+			for _, file := range rm.matchers[dir] {
+				if file.level != level {
+					continue
+				}
+				for _, pattern := range file.matcher.rules.Load().patterns {
+					if isFloatingName(pattern, file.matcher.options.syntax) {
+						fn(file.matcher, pattern, "", rm.sourcePath(file.path))
+					}
+				}
+			}
+		}
+		for _, dir := range dirs {
+			for _, file := range rm.matchers[dir] {
+				if file.level != level {
+					continue
+				}
+				for _, pattern := range file.matcher.rules.Load().patterns {
+					fn(file.matcher, pattern, relDirs[dir], rm.sourcePath(file.path))
+				}
+			}
+		}
+	}
+	exportRoot(rm.overrides)
+	exportRoot(rm.overlay)
+}
+
+// isFloatingName reports whether pattern matches a single path component at
+// any depth, so that it means the same relative to any directory beneath the
+// one of its ignore file.
+func isFloatingName(pattern ignorePattern, syntax Syntax) bool {
+	return syntax != SyntaxDocker && !pattern.inverted && !pattern.isRootRelative && !strings.Contains(pattern.pattern, "/")
+}
+
+// skippedNestedDirs returns the slash-separated paths, relative to the root,
+// of the nested repositories skipped by NestedRepositorySkip, sorted.
+func (rm *RepositoryMatcher) skippedNestedDirs() []string {
+	if rm.config.NestedRepositories != NestedRepositorySkip {
+		return nil
+	}
+	var dirs []string
+	for dir := range rm.nestedRoots {
+		if relDir, err := filepath.Rel(rm.rootDir, dir); err == nil {
+			dirs = append(dirs, filepath.ToSlash(relDir))
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// appendRsyncRules appends the rsync rules equivalent to pattern, read from
+// an ignore file in the slash-separated directory dir ("" for the root).
+func appendRsyncRules(rules []string, pattern ignorePattern, syntax Syntax, dir string) []string {
+	if pattern.inverted {
+		return rules
+	}
+	prefix := "- "
+	if pattern.negate {
+		prefix = "+ "
+	}
+
+	glob := pattern.pattern
+	anchored := pattern.isRootRelative || syntax == SyntaxDocker
+	if !anchored {
+		// A floating pattern matches at any depth in rsync already, which
+		// makes a leading "**/" redundant
+		for strings.HasPrefix(glob, "**/") {
+			glob = glob[len("**/"):]
+		}
+	}
+
+	switch {
+	case anchored:
+		glob = "/" + joinRsyncPath(dir, glob)
+	case dir != "":
+		// Floating patterns of nested ignore files match at any depth below
+		// their directory
+		glob = "/" + dir + "/**/" + glob
+	}
+
+	suffix := ""
+	if pattern.isDirectory {
+		suffix = "/"
+	}
+	for _, expanded := range expandDoubleStarDirs(glob) {
+		rules = append(rules, prefix+expanded+suffix)
+	}
+	return rules
+}
+
+// expandDoubleStarDirs returns the variants of glob needed for rsync to
+// match "/**/" against zero directories, which it does not do on its own.
+func expandDoubleStarDirs(glob string) []string {
+	i := strings.Index(glob, "/**/")
+	if i < 0 {
+		return []string{glob}
+	}
+
+	var variants []string
+	for _, rest := range expandDoubleStarDirs(glob[i+len("/**/"):]) {
+		variants = append(variants, glob[:i]+"/**/"+rest, glob[:i]+"/"+rest)
+	}
+	return variants
+}
+
+// rsyncDepth returns the number of components in the slash-separated dir.
+func rsyncDepth(dir string) int {
+	if dir == "" {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+// joinRsyncPath joins a slash-separated directory and glob.
+func joinRsyncPath(dir, glob string) string {
+	if dir == "" {
+		return glob
+	}
+	return dir + "/" + glob
+}
+
+// reverseStrings reverses s in place.
+func reverseStrings(s []string) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
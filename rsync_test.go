@@ -0,0 +1,116 @@
+package dotignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRsyncFilterRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		opts     []Option
+		expected []string
+	}{
+		{
+			name:     "Reversed order",
+			patterns: []string{"*.log", "!important.log", "build/", "/dist"},
+			expected: []string{"- /dist", "- build/", "+ important.log", "- *.log"},
+		},
+		{
+			name:     "Double star",
+			patterns: []string{"**/temp", "src/**/gen/", "logs/**"},
+			expected: []string{"- logs/**", "- src/gen/", "- src/**/gen/", "- temp"},
+		},
+		{
+			name:     "Docker syntax",
+			patterns: []string{"**/*.go", "!cmd"},
+			opts:     []Option{WithSyntax(SyntaxDocker)},
+			expected: []string{"+ /cmd", "- /*.go", "- /**/*.go"},
+		},
+		{
+			name:     "No patterns",
+			patterns: []string{"# comment", ""},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := NewPatternMatcher(tt.patterns, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+			if rules := matcher.RsyncFilterRules(); !reflect.DeepEqual(rules, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, rules)
+			}
+		})
+	}
+}
+
+func TestRepositoryMatcher_RsyncFilterRules(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":           "*.log\n/build/\n",
+		"app/.gitignore":       "!keep.log\n/dist\n",
+		"app/web/.gitignore":   "cache/\n",
+		"docs/.gitignore":      "*.tmp\n",
+		"app/web/index.html":   "",
+		"docs/guide/readme.md": "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	expected := []string{
+		"- .git",
+		"- /app/web/cache/",
+		"- /app/web/**/cache/",
+		"- /docs/*.tmp",
+		"- /docs/**/*.tmp",
+		"- /app/dist",
+		"+ /app/keep.log",
+		"+ /app/**/keep.log",
+		"- /build/",
+		"- *.log",
+	}
+	if rules := matcher.RsyncFilterRules(); !reflect.DeepEqual(rules, expected) {
+		t.Errorf("Expected %q, got %q", expected, rules)
+	}
+}
+
+func TestRepositoryMatcher_RsyncFilterRulesAncestors(t *testing.T) {
+	t.Setenv("GIT_DIR", "")
+	t.Setenv("GIT_WORK_TREE", "")
+
+	tmpDir := createTestRepo(t, map[string]string{
+		".git/HEAD":               "ref: refs/heads/main\n",
+		".gitignore":              "*.log\n/build/\nservices/api/gen/\n!keep.tmp\n",
+		"services/.gitignore":     "*.tmp\n",
+		"services/api/.gitignore": "/dist\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	config := DefaultRepositoryConfig()
+	config.IncludeAncestorIgnoreFiles = true
+	matcher, err := NewRepositoryMatcherWithConfig(filepath.Join(tmpDir, "services", "api"), config)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	// Only the ancestors' patterns that match a name at any depth are kept
+	expected := []string{
+		"- .git",
+		"- /dist",
+		"- *.tmp",
+		"+ keep.tmp",
+		"- *.log",
+	}
+	if rules := matcher.RsyncFilterRules(); !reflect.DeepEqual(rules, expected) {
+		t.Errorf("Expected %q, got %q", expected, rules)
+	}
+}
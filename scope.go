@@ -0,0 +1,82 @@
+package dotignore
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Scope returns a Matcher for the subdirectory dir of the repository, given
+// as an absolute path or relative to the root. Paths passed to it are
+// relative to dir, or absolute paths inside it, and are matched against
+// every rule of the repository, including the ignore files of the
+// directories above dir:
+//
+//	pkg, err := repo.Scope("services/api")
+//	ignored, err := pkg.Matches("build/server") // services/api/build/server
+//
+// Paths outside dir are reported as errors. The returned matcher also has a
+// MatchesPath method, like RepositoryMatcher, and sees the effect of Reload
+// and Refresh on rm.
+func (rm *RepositoryMatcher) Scope(dir string) (Matcher, error) {
+	relDir, err := rm.relativePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	absDir := filepath.Join(rm.rootDir, filepath.FromSlash(relDir))
+
+	info, err := rm.fsys.Stat(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access directory %q: %w", absDir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%q is not a directory", absDir)
+	}
+	return &scopedMatcher{rm: rm, dir: absDir}, nil
+}
+
+// scopedMatcher matches paths relative to a subdirectory of a repository.
+type scopedMatcher struct {
+	rm  *RepositoryMatcher
+	dir string // absolute path of the subdirectory
+}
+
+var _ pathMatcher = (*scopedMatcher)(nil)
+
+// Matches reports whether path, relative to the scope directory, is ignored.
+func (s *scopedMatcher) Matches(path string) (bool, error) {
+	absPath, err := s.resolve(path)
+	if err != nil || absPath == "" {
+		return false, err
+	}
+	return s.rm.Matches(absPath)
+}
+
+// MatchesPath is like Matches for a path known to be a directory or not.
+func (s *scopedMatcher) MatchesPath(path string, isDir bool) (bool, error) {
+	absPath, err := s.resolve(path)
+	if err != nil || absPath == "" {
+		return false, err
+	}
+	return s.rm.MatchesPath(absPath, isDir)
+}
+
+// resolve converts path into an absolute path, checking that it lies within
+// the scope directory. An empty path resolves to "".
+func (s *scopedMatcher) resolve(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	var absPath string
+	if filepath.IsAbs(path) {
+		absPath = filepath.Clean(path)
+	} else {
+		absPath = filepath.Join(s.dir, path)
+	}
+	prefix := strings.TrimSuffix(s.dir, string(filepath.Separator)) + string(filepath.Separator)
+	if absPath != s.dir && !strings.HasPrefix(absPath, prefix) {
+		return "", fmt.Errorf("path %q is outside scope %q", path, s.dir)
+	}
+	return absPath, nil
+}
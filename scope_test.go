@@ -0,0 +1,73 @@
+package dotignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepositoryMatcher_Scope(t *testing.T) {
+	structure := map[string]string{
+		".gitignore":                  "*.log\n/dist/\n",
+		"services/api/.gitignore":     "build/\n!keep.log\n",
+		"services/api/main.go":        "",
+		"services/api/cmd/.gitignore": "*.out\n",
+		"services/web/index.html":     "",
+	}
+
+	tmpDir := createTestRepo(t, structure)
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcher() failed: %v", err)
+	}
+	scope, err := matcher.Scope("services/api")
+	if err != nil {
+		t.Fatalf("Scope() failed: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"app.log", true},   // ancestor rule
+		{"keep.log", false}, // re-included by the scope's own file
+		{"build/server", true},
+		{"cmd/tool.out", true},
+		{"dist/app.js", false}, // /dist/ is anchored to the repository root
+		{"main.go", false},
+		{filepath.Join(tmpDir, "services", "api", "debug.log"), true},
+		{"", false},
+	}
+	for _, tt := range tests {
+		result, err := scope.Matches(tt.path)
+		if err != nil {
+			t.Fatalf("Matches(%q) failed: %v", tt.path, err)
+		}
+		if result != tt.expected {
+			t.Errorf("Matches(%q) = %v, want %v", tt.path, result, tt.expected)
+		}
+	}
+
+	if result, err := scope.(pathMatcher).MatchesPath("build", false); err != nil || result {
+		t.Errorf("MatchesPath(build, false) = %v, %v, want false", result, err)
+	}
+
+	// Paths must stay inside the scope
+	for _, path := range []string{"../web/index.html", filepath.Join(tmpDir, "app.log")} {
+		if _, err := scope.Matches(path); err == nil {
+			t.Errorf("Matches(%q) should fail for a path outside the scope", path)
+		}
+	}
+
+	// The scope must be an existing directory inside the repository
+	for _, dir := range []string{"services/missing", "services/api/main.go", filepath.Dir(tmpDir)} {
+		if _, err := matcher.Scope(dir); err == nil {
+			t.Errorf("Scope(%q) should fail", dir)
+		}
+	}
+	if _, err := matcher.Scope(filepath.Join(tmpDir, "services")); err != nil {
+		t.Errorf("Scope() with an absolute path failed: %v", err)
+	}
+}
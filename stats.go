@@ -0,0 +1,152 @@
+package dotignore
+
+import (
+	"strings"
+	"unsafe"
+)
+
+// regexpOverhead and regexpBytesPerChar estimate the memory held by a
+// compiled regular expression from the length of its source. The compiled
+// program takes several instructions per character of the expression.
+const (
+	regexpOverhead     = 512
+	regexpBytesPerChar = 64
+)
+
+// Stats summarizes the patterns held by a matcher. A pattern may count
+// towards several of the kinds, e.g. "!/build/" is a negation, a directory
+// pattern and a root-relative pattern at once.
+type Stats struct {
+	// IgnoreFiles is the number of ignore files loaded by a
+	// RepositoryMatcher, and 0 for a PatternMatcher.
+	IgnoreFiles int
+	// Patterns is the total number of patterns.
+	Patterns int
+	// Literal is the number of patterns without wildcards or character
+	// classes.
+	Literal int
+	// Wildcard is the number of patterns containing *, ? or a character
+	// class.
+	Wildcard int
+	// Negation is the number of patterns starting with "!".
+	Negation int
+	// DirOnly is the number of patterns ending with "/".
+	DirOnly int
+	// RootRelative is the number of patterns anchored to the directory of
+	// their ignore file.
+	RootRelative int
+	// Regexps is the number of patterns compiled to a regular expression
+	// because the faster glob engine cannot handle them.
+	Regexps int
+	// MemoryBytes approximates the memory held by the parsed patterns and
+	// their index, in bytes.
+	MemoryBytes int
+}
+
+// add accumulates other into s.
+func (s *Stats) add(other Stats) {
+	s.IgnoreFiles += other.IgnoreFiles
+	s.Patterns += other.Patterns
+	s.Literal += other.Literal
+	s.Wildcard += other.Wildcard
+	s.Negation += other.Negation
+	s.DirOnly += other.DirOnly
+	s.RootRelative += other.RootRelative
+	s.Regexps += other.Regexps
+	s.MemoryBytes += other.MemoryBytes
+}
+
+// Stats returns counts of the patterns of the matcher by kind and an
+// estimate of their memory footprint.
+func (p *PatternMatcher) Stats() Stats {
+	rules := p.rules.Load()
+
+	stats := Stats{
+		Patterns:    len(rules.patterns),
+		MemoryBytes: int(unsafe.Sizeof(*p)) + cap(rules.patterns)*int(unsafe.Sizeof(ignorePattern{})),
+	}
+	for _, pattern := range rules.patterns {
+		if strings.ContainsAny(pattern.pattern, "*?[") {
+			stats.Wildcard++
+		} else {
+			stats.Literal++
+		}
+		if pattern.negate {
+			stats.Negation++
+		}
+		if pattern.isDirectory {
+			stats.DirOnly++
+		}
+		if pattern.isRootRelative {
+			stats.RootRelative++
+		}
+
+		stats.MemoryBytes += len(pattern.text) + len(pattern.pattern) + pattern.compiledSize()
+		if pattern.regexPattern != nil {
+			stats.Regexps++
+		}
+		if pattern.hits != nil {
+			stats.MemoryBytes += int(unsafe.Sizeof(*pattern.hits))
+		}
+	}
+	if rules.index != nil {
+		stats.MemoryBytes += rules.index.size()
+	}
+	return stats
+}
+
+// Stats returns the combined Stats of every loaded ignore file, the
+// override patterns and the overlays. It must not be called concurrently
+// with Reload.
+func (rm *RepositoryMatcher) Stats() Stats {
+	var stats Stats
+	for _, files := range rm.matchers {
+		for _, file := range files {
+			fileStats := file.matcher.Stats()
+			fileStats.IgnoreFiles = 1
+			fileStats.MemoryBytes += int(unsafe.Sizeof(*file)) + len(file.path)
+			stats.add(fileStats)
+		}
+	}
+	for _, matcher := range []*PatternMatcher{rm.underlay, rm.overrides, rm.overlay} {
+		if matcher != nil {
+			stats.add(matcher.Stats())
+		}
+	}
+	return stats
+}
+
+// parsedSize returns the approximate number of bytes of memory held by the
+// parsed pattern itself, apart from its compiled matcher.
+func (ip ignorePattern) parsedSize() int {
+	return int(unsafe.Sizeof(ip)) + len(ip.text) + len(ip.pattern)
+}
+
+// compiledSize returns the approximate number of bytes of memory held by the
+// compiled matcher of the pattern, which identical patterns share.
+func (ip ignorePattern) compiledSize() int {
+	if ip.glob != nil {
+		return ip.glob.Size()
+	}
+	if ip.regexPattern == nil {
+		return 0
+	}
+	return regexpOverhead + regexpBytesPerChar*len(ip.regexPattern.String())
+}
+
+// size returns the approximate number of bytes of memory held by the index.
+func (index *patternIndex) size() int {
+	return int(unsafe.Sizeof(*index)) + cap(index.unindexed)*int(unsafe.Sizeof(0)) + index.root.size()
+}
+
+// size returns the approximate number of bytes of memory held by the node's
+// children and pattern list.
+func (node *trieNode) size() int {
+	size := cap(node.patterns) * int(unsafe.Sizeof(0))
+	for segment, child := range node.children {
+		// Each map entry holds the key, a pointer and some bucket overhead
+		size += len(segment) + int(unsafe.Sizeof(segment)) + 2*int(unsafe.Sizeof(child))
+		size += int(unsafe.Sizeof(*child)) + child.size()
+	}
+	return size
+}
@@ -0,0 +1,82 @@
+package dotignore
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{
+		"# comment",
+		"*.log",
+		"!keep.log",
+		"/build/",
+		"node_modules/",
+		"[0-9]*.tmp",
+		"docs/**/*.md",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	stats := matcher.Stats()
+	expected := Stats{
+		Patterns:     6,
+		Literal:      3,
+		Wildcard:     3,
+		Negation:     1,
+		DirOnly:      2,
+		RootRelative: 1,
+	}
+	stats.MemoryBytes, expected.MemoryBytes = 0, 0
+	stats.Regexps, expected.Regexps = 0, 0
+	if stats != expected {
+		t.Errorf("Stats() = %+v, want %+v", stats, expected)
+	}
+
+	// Memory grows with the number of patterns
+	var many []string
+	for i := 0; i < 100; i++ {
+		many = append(many, fmt.Sprintf("/dir%d/*.bin", i))
+	}
+	large, err := NewPatternMatcher(many)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if small, big := matcher.Stats().MemoryBytes, large.Stats().MemoryBytes; small <= 0 || big <= small {
+		t.Errorf("MemoryBytes = %d for 6 patterns and %d for 100, want positive and growing", small, big)
+	}
+
+	// A pattern with more wildcards than the glob engine handles needs a regexp
+	regexpMatcher, err := NewPatternMatcher([]string{"*.log", strings.Repeat("?", 64)})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if regexps := regexpMatcher.Stats().Regexps; regexps != 1 {
+		t.Errorf("Regexps = %d, want 1", regexps)
+	}
+}
+
+func TestRepositoryMatcher_Stats(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":     "*.log\n/build/\n",
+		"app/.gitignore": "!keep.log\n",
+		"lib/.gitignore": "# nothing here\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository matcher: %v", err)
+	}
+
+	stats := matcher.Stats()
+	if stats.IgnoreFiles != 3 || stats.Patterns != 3 || stats.Negation != 1 || stats.RootRelative != 1 {
+		t.Errorf("Stats() = %+v, want 3 files with 3 patterns", stats)
+	}
+	if stats.MemoryBytes <= 0 {
+		t.Errorf("MemoryBytes = %d, want positive", stats.MemoryBytes)
+	}
+}
@@ -0,0 +1,89 @@
+package dotignore
+
+import (
+	"context"
+	"fmt"
+)
+
+// WalkResult is a value received from a streaming walk: the path of a file,
+// or the error that ended the walk.
+type WalkResult struct {
+	Path string
+	Err  error
+}
+
+// StreamFiles walks root in a new goroutine and sends the path of every
+// file that is not ignored, relative to root, on the returned channel in
+// lexical order, so that consumers can start working on the first files
+// while the walk goes on. At most buffer paths are queued; once the buffer
+// is full the walk waits for the consumer.
+//
+// The channel is closed when the walk ends. A walk that fails sends a final
+// WalkResult holding the error. To stop early, cancel ctx: the walk then
+// ends without sending anything more, and the channel is closed.
+func (p *PatternMatcher) StreamFiles(ctx context.Context, root string, buffer int) <-chan WalkResult {
+	return streamPaths(ctx, buffer, func(fn func(path string) error) error {
+		if err := p.walkFiles(ctx, root, false, fn); err != nil {
+			return fmt.Errorf("failed to walk %q: %w", root, err)
+		}
+		return nil
+	})
+}
+
+// StreamIgnoredFiles is like StreamFiles for the files beneath root that
+// are ignored.
+func (p *PatternMatcher) StreamIgnoredFiles(ctx context.Context, root string, buffer int) <-chan WalkResult {
+	return streamPaths(ctx, buffer, func(fn func(path string) error) error {
+		if err := p.walkFiles(ctx, root, true, fn); err != nil {
+			return fmt.Errorf("failed to walk %q: %w", root, err)
+		}
+		return nil
+	})
+}
+
+// StreamFiles streams the paths of WalkTrackedCandidates, relative to the
+// root directory, on the returned channel, as described for
+// PatternMatcher.StreamFiles.
+func (rm *RepositoryMatcher) StreamFiles(ctx context.Context, buffer int) <-chan WalkResult {
+	return streamPaths(ctx, buffer, func(fn func(path string) error) error {
+		return rm.WalkTrackedCandidates(ctx, fn)
+	})
+}
+
+// StreamIgnoredFiles streams the paths of WalkIgnoredFiles, relative to the
+// root directory, on the returned channel, as described for
+// PatternMatcher.StreamFiles.
+func (rm *RepositoryMatcher) StreamIgnoredFiles(ctx context.Context, buffer int) <-chan WalkResult {
+	return streamPaths(ctx, buffer, func(fn func(path string) error) error {
+		return rm.WalkIgnoredFiles(ctx, fn)
+	})
+}
+
+// streamPaths runs walk in a new goroutine, sending each path it reports on
+// a channel with the given buffer size, followed by the error it returns,
+// if any. Sends give up once ctx is done.
+func streamPaths(ctx context.Context, buffer int, walk func(fn func(path string) error) error) <-chan WalkResult {
+	if buffer < 0 {
+		buffer = 0
+	}
+	results := make(chan WalkResult, buffer)
+
+	go func() {
+		defer close(results)
+		err := walk(func(path string) error {
+			select {
+			case results <- WalkResult{Path: path}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			select {
+			case results <- WalkResult{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return results
+}
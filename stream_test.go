@@ -0,0 +1,99 @@
+package dotignore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStreamFiles(t *testing.T) {
+	root := createTestRepo(t, map[string]string{
+		".gitignore":    "*.log\nbuild/\n",
+		"main.go":       "",
+		"app.log":       "",
+		"build/out.bin": "",
+		"src/lib.go":    "",
+	})
+	defer os.RemoveAll(root)
+
+	patterns, err := NewPatternMatcher([]string{"*.log", "build/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	repo, err := NewRepositoryMatcher(root)
+	if err != nil {
+		t.Fatalf("NewRepositoryMatcher() failed: %v", err)
+	}
+
+	collect := func(results <-chan WalkResult) []string {
+		var paths []string
+		for result := range results {
+			if result.Err != nil {
+				t.Fatalf("walk failed: %v", result.Err)
+			}
+			paths = append(paths, filepath.ToSlash(result.Path))
+		}
+		return paths
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		results  <-chan WalkResult
+		expected []string
+	}{
+		{"PatternMatcher.StreamFiles", patterns.StreamFiles(ctx, root, 0), []string{".gitignore", "main.go", "src/lib.go"}},
+		{"PatternMatcher.StreamIgnoredFiles", patterns.StreamIgnoredFiles(ctx, root, 1), []string{"app.log", "build/out.bin"}},
+		{"RepositoryMatcher.StreamFiles", repo.StreamFiles(ctx, 4), []string{".gitignore", "main.go", "src/lib.go"}},
+		{"RepositoryMatcher.StreamIgnoredFiles", repo.StreamIgnoredFiles(ctx, -1), []string{"app.log", "build/out.bin"}},
+	}
+	for _, tt := range tests {
+		if paths := collect(tt.results); !reflect.DeepEqual(paths, tt.expected) {
+			t.Errorf("%s = %v, want %v", tt.name, paths, tt.expected)
+		}
+	}
+
+	// Errors arrive as the last result
+	var last WalkResult
+	for result := range patterns.StreamFiles(ctx, filepath.Join(root, "missing"), 0) {
+		last = result
+	}
+	if last.Err == nil {
+		t.Error("StreamFiles() of a missing directory did not report an error")
+	}
+}
+
+func TestStreamFilesCancel(t *testing.T) {
+	root := createTestRepo(t, map[string]string{
+		"a.go": "", "b.go": "", "c.go": "", "d.go": "",
+	})
+	defer os.RemoveAll(root)
+
+	matcher, err := NewPatternMatcher(nil)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	// The walk waits for the consumer and ends once ctx is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	results := matcher.StreamFiles(ctx, root, 1)
+	if first := <-results; first.Path != "a.go" {
+		t.Errorf("first result = %+v, want a.go", first)
+	}
+	cancel()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-results:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("channel was not closed after cancelling the walk")
+		}
+	}
+}
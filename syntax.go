@@ -0,0 +1,269 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Syntax selects the ignore file dialect used to interpret patterns.
+type Syntax int
+
+const (
+	// SyntaxGit interprets patterns following the gitignore specification.
+	// This is the default.
+	SyntaxGit Syntax = iota
+
+	// SyntaxDocker interprets patterns following .dockerignore rules:
+	//   - every pattern is anchored to the root of the build context
+	//   - patterns are cleaned, so a trailing / carries no special meaning
+	//   - a pattern matching a directory also excludes everything beneath it
+	//   - "**" matches any number of directories, including none
+	//   - lines starting with ! are exceptions that re-include paths
+	SyntaxDocker
+
+	// SyntaxHelm interprets patterns following .helmignore rules, as applied
+	// by "helm package":
+	//   - a pattern without a slash matches the base name of a path, and one
+	//     with a slash matches the whole path from the chart root
+	//   - "**" is not supported
+	//   - a pattern ending in / only matches directories, and a path is
+	//     taken to be a file unless it is known to be a directory
+	//   - a path is ignored if any pattern ignores it or one of its parent
+	//     directories; there is no re-inclusion
+	//   - a pattern starting with ! ignores every path it does not match
+	//
+	// Helm also ignores templates/.?* in every chart, which is not part of
+	// the .helmignore file.
+	SyntaxHelm
+
+	// SyntaxGcloud interprets patterns following .gcloudignore rules, which
+	// are gitignore rules plus the "#!include:FILE" directive. The directive
+	// is replaced by the patterns of FILE, which may include further files.
+	// Relative names are resolved against the directory of the ignore file
+	// when it is read with NewPatternMatcherFromFile or
+	// NewPatternMatcherFromFS, and against the current directory otherwise;
+	// names in an included file are resolved against its own directory.
+	// Line numbers of included patterns refer to the file they come from.
+	SyntaxGcloud
+)
+
+// String returns the name of the syntax.
+func (s Syntax) String() string {
+	switch s {
+	case SyntaxGit:
+		return "git"
+	case SyntaxDocker:
+		return "docker"
+	case SyntaxHelm:
+		return "helm"
+	case SyntaxGcloud:
+		return "gcloud"
+	default:
+		return fmt.Sprintf("Syntax(%d)", int(s))
+	}
+}
+
+// WithSyntax selects the dialect used to parse and evaluate patterns.
+// The default is SyntaxGit.
+func WithSyntax(syntax Syntax) Option {
+	return func(o *matcherOptions) {
+		o.syntax = syntax
+	}
+}
+
+// parsePatterns builds ignore patterns using the dialect selected in options.
+func parsePatterns(patterns []string, options matcherOptions) ([]ignorePattern, error) {
+	patterns, err := options.checkPatternLengths(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var ignorePatterns []ignorePattern
+	switch options.syntax {
+	case SyntaxGit:
+		ignorePatterns, err = buildIgnorePatterns(patterns, options)
+	case SyntaxDocker:
+		ignorePatterns, err = buildDockerPatterns(patterns, options)
+	case SyntaxHelm:
+		ignorePatterns, err = buildHelmPatterns(patterns, options)
+	case SyntaxGcloud:
+		ignorePatterns, err = buildGcloudPatterns(patterns, options)
+	default:
+		return nil, fmt.Errorf("unsupported syntax %v", options.syntax)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := options.checkPatternCount(len(ignorePatterns)); err != nil {
+		return nil, err
+	}
+
+	options.attachHitCounters(ignorePatterns)
+	return ignorePatterns, nil
+}
+
+// buildDockerPatterns parses patterns using the same normalization Docker applies
+// when reading a .dockerignore file.
+func buildDockerPatterns(patterns []string, options matcherOptions) ([]ignorePattern, error) {
+	return buildPatterns(patterns, options, parseDockerPattern)
+}
+
+// parseDockerPattern parses a single .dockerignore line found at the given
+// line number. It reports false for lines that hold no pattern.
+func parseDockerPattern(raw string, line int, options matcherOptions) (ignorePattern, bool, error) {
+	pattern := raw
+	// Docker recognizes comments before trimming whitespace
+	if strings.HasPrefix(pattern, "#") {
+		return ignorePattern{}, false, nil
+	}
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return ignorePattern{}, false, nil
+	}
+	text := pattern
+
+	isNegation := false
+	if pattern[0] == '!' {
+		if len(pattern) == 1 {
+			return ignorePattern{}, false, newParseError(raw, line, ErrInvalidNegation)
+		}
+		pattern = strings.TrimSpace(pattern[1:])
+		isNegation = true
+	}
+
+	// Docker cleans every pattern and treats them all as relative to the context root
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	if len(pattern) > 1 && pattern[0] == '/' {
+		pattern = pattern[1:]
+	}
+	if pattern == "." {
+		return ignorePattern{}, false, nil
+	}
+
+	if options.caseInsensitive {
+		pattern = strings.ToLower(pattern)
+	}
+
+	if _, err := path.Match(pattern, "."); err != nil {
+		return ignorePattern{}, false, newParseError(raw, line, fmt.Errorf("malformed pattern: %w", err))
+	}
+
+	glob, regexPattern, err := compilePattern(pattern, SyntaxDocker)
+	if err != nil {
+		return ignorePattern{}, false, newParseError(raw, line, fmt.Errorf("malformed pattern: %w", err))
+	}
+
+	return ignorePattern{
+		text:           text,
+		line:           line,
+		pattern:        pattern,
+		glob:           glob,
+		regexPattern:   regexPattern,
+		negate:         isNegation,
+		hasWildcard:    strings.ContainsAny(pattern, "*?["),
+		isRootRelative: true,
+	}, true, nil
+}
+
+// matchDockerPattern reports whether file, or the ancestor directory of file with
+// as many path components as the pattern, matches a .dockerignore pattern.
+func matchDockerPattern(file string, pattern ignorePattern) bool {
+	if pattern.matchString(file) {
+		return true
+	}
+
+	// A pattern with N components may match the N-component parent of file,
+	// which excludes the whole directory
+	components := strings.Count(pattern.pattern, "/") + 1
+	for i := 0; i < len(file); i++ {
+		if file[i] != '/' {
+			continue
+		}
+		components--
+		if components == 0 {
+			return pattern.matchString(file[:i])
+		}
+	}
+	return false
+}
+
+// buildHelmPatterns parses patterns the way Helm reads a .helmignore file.
+func buildHelmPatterns(patterns []string, options matcherOptions) ([]ignorePattern, error) {
+	return buildPatterns(patterns, options, parseHelmPattern)
+}
+
+// parseHelmPattern parses a single .helmignore line found at the given line
+// number. It reports false for blank lines and comments.
+func parseHelmPattern(raw string, line int, options matcherOptions) (ignorePattern, bool, error) {
+	pattern := strings.TrimSpace(raw)
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return ignorePattern{}, false, nil
+	}
+	text := pattern
+
+	if strings.Contains(pattern, "**") {
+		return ignorePattern{}, false, newParseError(raw, line, errors.New("double-star (**) syntax is not supported"))
+	}
+	if _, err := path.Match(pattern, "abc"); err != nil {
+		return ignorePattern{}, false, newParseError(raw, line, fmt.Errorf("malformed pattern: %w", err))
+	}
+
+	inverted := strings.HasPrefix(pattern, "!")
+	pattern = strings.TrimPrefix(pattern, "!")
+	isDirectory := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	isRootRelative := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return ignorePattern{}, false, newParseError(raw, line, ErrEmptyPattern)
+	}
+
+	if options.caseInsensitive {
+		pattern = strings.ToLower(pattern)
+	}
+
+	glob, regexPattern, err := compilePattern(pattern, SyntaxHelm)
+	if err != nil {
+		return ignorePattern{}, false, newParseError(raw, line, fmt.Errorf("malformed pattern: %w", err))
+	}
+
+	return ignorePattern{
+		text:           text,
+		line:           line,
+		pattern:        pattern,
+		glob:           glob,
+		regexPattern:   regexPattern,
+		isDirectory:    isDirectory,
+		hasWildcard:    strings.ContainsAny(pattern, "*?["),
+		isRootRelative: isRootRelative,
+		inverted:       inverted,
+	}, true, nil
+}
+
+// matchHelmPattern reports whether a .helmignore pattern ignores file or one
+// of its parent directories.
+func matchHelmPattern(file string, kind pathKind, pattern ignorePattern) bool {
+	// Helm does not descend into a directory it ignores
+	for i := 0; i < len(file); i++ {
+		if file[i] == '/' && matchHelmRule(file[:i], true, pattern) {
+			return true
+		}
+	}
+	return matchHelmRule(file, kind == kindDir, pattern)
+}
+
+// matchHelmRule applies a single .helmignore pattern to a path, without
+// considering its parent directories.
+func matchHelmRule(file string, isDir bool, pattern ignorePattern) bool {
+	if pattern.isDirectory && !isDir {
+		return pattern.inverted
+	}
+	name := file
+	if !pattern.isRootRelative {
+		name = path.Base(file)
+	}
+	return pattern.matchString(name) != pattern.inverted
+}
@@ -0,0 +1,164 @@
+package dotignore
+
+import (
+	"testing"
+)
+
+func TestDockerSyntax(t *testing.T) {
+	patterns := []string{
+		"# comment",
+		"*/temp*",
+		"*/*/temp*",
+		"temp?",
+		"/node_modules/",
+		"**/*.go",
+		"*.md",
+		"!README.md",
+		"dist",
+		"!dist/keep",
+	}
+
+	matcher, err := NewPatternMatcher(patterns, WithSyntax(SyntaxDocker))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file     string
+		expected bool
+		reason   string
+	}{
+		{"somedir/temporary.txt", true, "*/temp* matches one level below the root"},
+		{"somedir/temp", true, "*/temp* matches one level below the root"},
+		{"temp", false, "*/temp* does not match at the root"},
+		{"somedir/subdir/temporary.txt", true, "*/*/temp* matches two levels below the root"},
+		{"tempa", true, "temp? matches a single character"},
+		{"tempab", false, "temp? matches exactly one character"},
+		{"node_modules", true, "patterns are cleaned, so the trailing slash is dropped"},
+		{"node_modules/pkg/index.js", true, "matching a directory excludes its contents"},
+		{"app/node_modules/pkg/index.js", false, "patterns are anchored to the root"},
+		{"main.go", true, "**/*.go matches at the root"},
+		{"cmd/tool/main.go", true, "**/*.go matches at any depth"},
+		{"CHANGELOG.md", true, "*.md matches at the root"},
+		{"docs/guide.md", false, "*.md is anchored to the root"},
+		{"README.md", false, "re-included by !README.md"},
+		{"dist/app.js", true, "dist excludes its contents"},
+		{"dist/keep", false, "re-included by !dist/keep"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			result, err := matcher.Matches(tt.file)
+			if err != nil {
+				t.Fatalf("Error matching file %s: %v", tt.file, err)
+			}
+			if result != tt.expected {
+				t.Errorf("File %q: expected %v, got %v (%s)", tt.file, tt.expected, result, tt.reason)
+			}
+		})
+	}
+}
+
+func TestDockerSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+	}{
+		{"Single exclamation", []string{"!"}},
+		{"Malformed character class", []string{"[a-"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPatternMatcher(tt.patterns, WithSyntax(SyntaxDocker)); err == nil {
+				t.Errorf("Expected error for patterns %q", tt.patterns)
+			}
+		})
+	}
+}
+
+func TestHelmSyntax(t *testing.T) {
+	patterns := []string{
+		"# comment",
+		"*.tgz",
+		"/secrets.yaml",
+		"docs/*.md",
+		"ci/",
+		"!values.yaml",
+	}
+
+	matcher, err := NewPatternMatcher(patterns, WithSyntax(SyntaxHelm))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file     string
+		expected bool
+		reason   string
+	}{
+		{"chart-1.0.0.tgz", true, "*.tgz matches the base name"},
+		{"charts/dep-1.0.0.tgz", true, "*.tgz matches the base name at any depth"},
+		{"secrets.yaml", true, "/secrets.yaml matches at the root"},
+		{"templates/secrets.yaml", true, "!values.yaml ignores every file it does not match"},
+		{"docs/guide.md", true, "docs/*.md matches the whole path"},
+		{"ci/lint.yaml", true, "ci/ matches the parent directory"},
+		{"ci", true, "ci/ does not match a file, so !values.yaml ignores it"},
+		{"ci/", true, "ci/ matches a path ending in a slash as a directory"},
+		{"values.yaml", false, "values.yaml matches !values.yaml"},
+		{"sub/values.yaml", true, "the parent directory sub does not match !values.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			result, err := matcher.Matches(tt.file)
+			if err != nil {
+				t.Fatalf("Error matching file %s: %v", tt.file, err)
+			}
+			if result != tt.expected {
+				t.Errorf("File %q: expected %v, got %v (%s)", tt.file, tt.expected, result, tt.reason)
+			}
+		})
+	}
+
+	// Without negation, directories are only skipped when a pattern ignores them
+	matcher, err = NewPatternMatcher([]string{"ci/", "*.tgz"}, WithSyntax(SyntaxHelm))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	for dir, expected := range map[string]bool{"ci": true, "templates": false, "old.tgz": true} {
+		if skip, err := matcher.CanSkipDir(dir); err != nil || skip != expected {
+			t.Errorf("CanSkipDir(%q) = %v, %v, want %v", dir, skip, err, expected)
+		}
+	}
+	for file, expected := range map[string]bool{"ci": false, "ci/": true, "ci/lint.yaml": true, "templates/ci": false} {
+		if result, err := matcher.Matches(file); err != nil || result != expected {
+			t.Errorf("Matches(%q) = %v, %v, want %v", file, result, err, expected)
+		}
+	}
+}
+
+func TestHelmSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+	}{
+		{"Double star", []string{"**/*.yaml"}},
+		{"Malformed character class", []string{"[a-"}},
+		{"Empty pattern", []string{"/"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPatternMatcher(tt.patterns, WithSyntax(SyntaxHelm)); err == nil {
+				t.Errorf("Expected error for patterns %q", tt.patterns)
+			}
+		})
+	}
+}
+
+func TestUnsupportedSyntax(t *testing.T) {
+	if _, err := NewPatternMatcher([]string{"*.log"}, WithSyntax(Syntax(99))); err == nil {
+		t.Error("Expected error for unsupported syntax")
+	}
+}
@@ -0,0 +1,70 @@
+package dotignore
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed templates/*.gitignore
+var templateFS embed.FS
+
+// Templates returns the names of the built-in .gitignore templates in
+// alphabetical order, such as "go", "macos" and "node". The templates are
+// based on those collected in github.com/github/gitignore.
+func Templates() []string {
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".gitignore"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Template returns the contents of the built-in template with the given
+// name, which is not case-sensitive.
+func Template(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("unknown template %q", name)
+	}
+	content, err := templateFS.ReadFile(path.Join("templates", strings.ToLower(name)+".gitignore"))
+	if err != nil {
+		return "", fmt.Errorf("unknown template %q", name)
+	}
+	return string(content), nil
+}
+
+// RenderTemplates concatenates the named templates into the contents of a
+// .gitignore file, each under a "### name ###" heading, in the order given.
+func RenderTemplates(names ...string) (string, error) {
+	var b strings.Builder
+	for i, name := range names {
+		content, err := Template(name)
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "### %s ###\n", strings.ToLower(name))
+		b.WriteString(content)
+	}
+	return b.String(), nil
+}
+
+// NewPatternMatcherFromTemplates returns a PatternMatcher for the patterns
+// of the named built-in templates, applied in the order given.
+func NewPatternMatcherFromTemplates(names ...string) (*PatternMatcher, error) {
+	content, err := RenderTemplates(names...)
+	if err != nil {
+		return nil, err
+	}
+	return NewPatternMatcherFromReader(strings.NewReader(content))
+}
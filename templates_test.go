@@ -0,0 +1,81 @@
+package dotignore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplates(t *testing.T) {
+	names := Templates()
+	for _, want := range []string{"go", "jetbrains", "macos", "node", "python"} {
+		found := false
+		for _, name := range names {
+			found = found || name == want
+		}
+		if !found {
+			t.Errorf("Templates() = %q, missing %q", names, want)
+		}
+	}
+
+	// Every template parses without errors or lint findings
+	for _, name := range names {
+		content, err := Template(name)
+		if err != nil {
+			t.Fatalf("Template(%q) failed: %v", name, err)
+		}
+		lines := strings.Split(content, "\n")
+		for _, issue := range Lint(lines) {
+			if issue.Kind == IssueInvalid || issue.Kind == IssueNeverMatches {
+				t.Errorf("Template %q line %d: %s", name, issue.Line, issue.Message)
+			}
+		}
+	}
+
+	for _, name := range []string{"", "unknown", "../go", "templates/go"} {
+		if _, err := Template(name); err == nil {
+			t.Errorf("Template(%q) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestRenderTemplates(t *testing.T) {
+	content, err := RenderTemplates("Go", "macos")
+	if err != nil {
+		t.Fatalf("RenderTemplates failed: %v", err)
+	}
+	if !strings.HasPrefix(content, "### go ###\n") || !strings.Contains(content, "\n\n### macos ###\n") {
+		t.Errorf("RenderTemplates did not add a heading per template:\n%s", content)
+	}
+
+	if _, err := RenderTemplates("go", "unknown"); err == nil {
+		t.Error("RenderTemplates with an unknown template succeeded")
+	}
+}
+
+func TestNewPatternMatcherFromTemplates(t *testing.T) {
+	matcher, err := NewPatternMatcherFromTemplates("go", "node", "macos")
+	if err != nil {
+		t.Fatalf("NewPatternMatcherFromTemplates failed: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"main.go", false},
+		{"pkg.test", true},
+		{"web/node_modules/react/index.js", true},
+		{".env.example", false},
+		{"docs/.DS_Store", true},
+		{"package.json", false},
+	}
+	for _, tt := range tests {
+		result, err := matcher.Matches(tt.path)
+		if err != nil {
+			t.Fatalf("Matches(%q) failed: %v", tt.path, err)
+		}
+		if result != tt.expected {
+			t.Errorf("Matches(%q) = %v, want %v", tt.path, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package dotignore
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codeglyph/go-dotignore/v2/internal"
+)
+
+// TokenKind identifies the kind of a Token.
+type TokenKind int
+
+const (
+	// TokenLiteral matches its Value exactly. Path separators are part of
+	// literal tokens.
+	TokenLiteral TokenKind = iota + 1
+
+	// TokenAnyChar ("?") matches one character other than '/'.
+	TokenAnyChar
+
+	// TokenStar ("*") matches any run of characters other than '/',
+	// including none.
+	TokenStar
+
+	// TokenAnyDirs ("**/") matches any number of leading directories,
+	// including none, so "a/**/b" matches both "a/b" and "a/x/y/b".
+	TokenAnyDirs
+
+	// TokenAnything ("**") matches any run of characters, including '/'.
+	TokenAnything
+
+	// TokenClass ("[...]") matches one character from the set written between
+	// the brackets, which Value holds, e.g. "a-z0-9".
+	TokenClass
+)
+
+// String returns the name of the token kind.
+func (k TokenKind) String() string {
+	switch k {
+	case TokenLiteral:
+		return "literal"
+	case TokenAnyChar:
+		return "any-char"
+	case TokenStar:
+		return "star"
+	case TokenAnyDirs:
+		return "any-dirs"
+	case TokenAnything:
+		return "anything"
+	case TokenClass:
+		return "class"
+	default:
+		return fmt.Sprintf("TokenKind(%d)", int(k))
+	}
+}
+
+// Token is one element of the compiled form of a pattern, as returned by
+// Pattern.Tokens. A sequence of tokens matches a path when each token
+// matches the next part of it and the whole path is consumed.
+type Token struct {
+	Kind  TokenKind
+	Value string // The text for TokenLiteral and the set for TokenClass
+}
+
+// Tokens returns the glob of the pattern in a structured form suitable for
+// translating it into another matching engine, such as a SQL LIKE filter.
+// Consecutive literal characters are merged into one TokenLiteral.
+//
+// Like Regexp, the tokens describe a match of the whole path; see Regexp
+// for how a pattern is applied to the parts of a path.
+func (p Pattern) Tokens() []Token {
+	var tokens []Token
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			tokens = append(tokens, Token{Kind: TokenLiteral, Value: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	glob := p.glob
+	for i := 0; i < len(glob); {
+		switch char := glob[i]; char {
+		case '*':
+			flush()
+			if i+1 >= len(glob) || glob[i+1] != '*' {
+				tokens = append(tokens, Token{Kind: TokenStar})
+				i++
+				continue
+			}
+			i += 2
+			hasSlash := i < len(glob) && glob[i] == '/'
+			if hasSlash {
+				i++
+			}
+			// Docker also treats "**" followed by more text as "**/"
+			if hasSlash || (p.syntax == SyntaxDocker && i < len(glob)) {
+				tokens = append(tokens, Token{Kind: TokenAnyDirs})
+			} else {
+				tokens = append(tokens, Token{Kind: TokenAnything})
+			}
+		case '?':
+			flush()
+			tokens = append(tokens, Token{Kind: TokenAnyChar})
+			i++
+		case '[':
+			_, size, err := internal.ParseClass(glob[i:])
+			if size == 0 || err != nil {
+				// An unterminated class is a literal '['
+				literal.WriteByte('[')
+				i++
+				continue
+			}
+			flush()
+			tokens = append(tokens, Token{Kind: TokenClass, Value: glob[i+1 : i+size-1]})
+			i += size
+		case '\\':
+			if i+1 == len(glob) {
+				literal.WriteByte('\\')
+				i++
+				continue
+			}
+			_, size := utf8.DecodeRuneInString(glob[i+1:])
+			literal.WriteString(glob[i+1 : i+1+size])
+			i += 1 + size
+		default:
+			literal.WriteByte(char)
+			i++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// Regexp returns the source of a regular expression equivalent to the glob
+// of the pattern. It matches a whole slash-separated path, relative to the
+// directory of the pattern's ignore file.
+//
+// Matches applies the expression to more than the path itself: a pattern
+// without a slash is tried against every component of the path, and a
+// pattern matching a directory also covers everything below it.
+func (p Pattern) Regexp() string {
+	buildRegex := internal.BuildRegex
+	if p.syntax == SyntaxDocker || p.syntax == SyntaxHelm {
+		buildRegex = internal.BuildDockerRegex
+	}
+	regex, err := buildRegex(p.glob)
+	if err != nil {
+		return ""
+	}
+	return regex.String()
+}
@@ -0,0 +1,34 @@
+package dotignore
+
+import "time"
+
+// TraceEvent describes the evaluation of one pattern against one path, as
+// reported to the function passed to WithTraceFunc.
+type TraceEvent struct {
+	// Path is the normalized, slash-separated path the pattern was tested
+	// against. With WithStrictNegation this may be a parent directory of the
+	// path passed to Matches.
+	Path string
+	// Pattern is the pattern as written, including any leading "!".
+	Pattern string
+	// Line is the 1-based line number of the pattern.
+	Line int
+	// Matched reports whether the pattern matched Path.
+	Matched bool
+	// Negate reports whether the pattern is a negation, so that a match
+	// re-includes Path instead of ignoring it.
+	Negate bool
+	// Duration is the time taken to evaluate the pattern.
+	Duration time.Duration
+}
+
+// WithTraceFunc calls trace after every evaluation of a pattern against a
+// path, for profiling and debugging. Patterns that the index rules out are
+// not evaluated and not reported. trace may be called concurrently when the
+// matcher is used from several goroutines. Without this option no events are
+// built and matching pays no cost for tracing.
+func WithTraceFunc(trace func(TraceEvent)) Option {
+	return func(o *matcherOptions) {
+		o.trace = trace
+	}
+}
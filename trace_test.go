@@ -0,0 +1,45 @@
+package dotignore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithTraceFunc(t *testing.T) {
+	var events []TraceEvent
+	matcher, err := NewPatternMatcher([]string{"*.log", "!keep.log", "/build/"}, WithTraceFunc(func(event TraceEvent) {
+		if event.Duration < 0 {
+			t.Errorf("Negative duration for %+v", event)
+		}
+		event.Duration = 0
+		events = append(events, event)
+	}))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	if _, err := matcher.Matches("keep.log"); err != nil {
+		t.Fatalf("Matches failed: %v", err)
+	}
+
+	// The root-relative pattern is ruled out by the index
+	expected := []TraceEvent{
+		{Path: "keep.log", Pattern: "*.log", Line: 1, Matched: true},
+		{Path: "keep.log", Pattern: "!keep.log", Line: 2, Matched: true, Negate: true},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Trace events = %+v, want %+v", events, expected)
+	}
+
+	events = nil
+	if _, err := matcher.Matches("build/app.go"); err != nil {
+		t.Fatalf("Matches failed: %v", err)
+	}
+	// The basename index rules out the patterns for other names
+	expected = []TraceEvent{
+		{Path: "build/app.go", Pattern: "/build/", Line: 3, Matched: true},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Trace events = %+v, want %+v", events, expected)
+	}
+}
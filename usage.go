@@ -0,0 +1,207 @@
+package dotignore
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// UsageTotals counts files and their sizes.
+type UsageTotals struct {
+	// Files is the number of files.
+	Files int
+	// Bytes is the total size of the files, as reported by Lstat.
+	Bytes int64
+}
+
+// add counts a file of the given size.
+func (t *UsageTotals) add(size int64) {
+	t.Files++
+	t.Bytes += size
+}
+
+// DirUsage is the usage of one top-level directory of a repository.
+type DirUsage struct {
+	// Dir is the name of the directory, or "." for the files directly in
+	// the root directory.
+	Dir string
+	// Kept and Ignored count the files beneath Dir that are kept and
+	// ignored.
+	Kept, Ignored UsageTotals
+}
+
+// PatternUsage is the usage of the files ignored by one pattern.
+type PatternUsage struct {
+	PatternLocation
+	// Pattern is the pattern as written.
+	Pattern string
+	UsageTotals
+}
+
+// DiskUsage reports how the files of a repository divide between kept and
+// ignored content, as returned by RepositoryMatcher.DiskUsage.
+type DiskUsage struct {
+	// Kept and Ignored count all files that are kept and ignored.
+	Kept, Ignored UsageTotals
+	// Dirs lists the top-level directories, those with the most ignored
+	// bytes first, and by name among equals.
+	Dirs []DirUsage
+	// Patterns lists the patterns that ignore at least one file, those
+	// ignoring the most bytes first. Files ignored by built-in rules, such
+	// as those of npm, are counted in Ignored but not listed here.
+	Patterns []PatternUsage
+}
+
+// DiskUsage walks the repository and adds up the number and size of the
+// files that are kept and ignored, by top-level directory and by the
+// pattern that ignores them, which is the pattern MatchDetail reports. It
+// shows what takes up the space in a working tree, such as build output or
+// a vendored dependency. Directories, and the contents of .git directories
+// unless RepositoryConfig.IncludeGitDir is set, are not counted.
+//
+// Directories that CanSkipDir reports as ignored in full are counted
+// without matching each file inside them; their files are attributed to the
+// pattern that ignores the directory. The walk stops with ctx.Err() once
+// ctx is done.
+func (rm *RepositoryMatcher) DiskUsage(ctx context.Context) (DiskUsage, error) {
+	usage, err := rm.diskUsage(ctx)
+	if err != nil {
+		return DiskUsage{}, fmt.Errorf("failed to compute disk usage: %w", err)
+	}
+	return usage, nil
+}
+
+// diskUsage implements DiskUsage.
+func (rm *RepositoryMatcher) diskUsage(ctx context.Context) (DiskUsage, error) {
+	var usage DiskUsage
+	dirs := make(map[string]*DirUsage)
+	patterns := make(map[PatternLocation]*PatternUsage)
+
+	count := func(path string, d fs.DirEntry, result MatchResult) error {
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(rm.rootDir, path)
+		if err != nil {
+			return err
+		}
+		top, _, found := strings.Cut(filepath.ToSlash(relPath), "/")
+		if !found {
+			top = "."
+		}
+		dir := dirs[top]
+		if dir == nil {
+			dir = &DirUsage{Dir: top}
+			dirs[top] = dir
+		}
+
+		if !result.Ignored {
+			usage.Kept.add(info.Size())
+			dir.Kept.add(info.Size())
+			return nil
+		}
+		usage.Ignored.add(info.Size())
+		dir.Ignored.add(info.Size())
+		if result.Matched {
+			location := PatternLocation{Source: result.Source, Line: result.Line}
+			pattern := patterns[location]
+			if pattern == nil {
+				pattern = &PatternUsage{PatternLocation: location, Pattern: result.Pattern}
+				patterns[location] = pattern
+			}
+			pattern.add(info.Size())
+		}
+		return nil
+	}
+
+	var progress Progress
+	err := rm.fsys.WalkDir(rm.rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rm.visited(&progress, d)
+		if path == rm.rootDir {
+			return nil
+		}
+
+		if !d.IsDir() {
+			result, err := rm.MatchDetail(path)
+			if err != nil {
+				return err
+			}
+			return count(path, d, result)
+		}
+
+		if d.Name() == ".git" && !rm.config.IncludeGitDir {
+			return filepath.SkipDir
+		}
+		skip, err := rm.CanSkipDir(path)
+		if err != nil || !skip {
+			return err
+		}
+		// Everything beneath the directory is ignored by the same pattern
+		result, err := rm.MatchDetail(path)
+		if err != nil {
+			return err
+		}
+		err = rm.fsys.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if file == path {
+				return nil
+			}
+			rm.visited(&progress, d)
+			if d.IsDir() {
+				if d.Name() == ".git" && !rm.config.IncludeGitDir {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			return count(file, d, result)
+		})
+		if err != nil {
+			return err
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return DiskUsage{}, err
+	}
+
+	for _, dir := range dirs {
+		usage.Dirs = append(usage.Dirs, *dir)
+	}
+	sort.Slice(usage.Dirs, func(i, j int) bool {
+		a, b := usage.Dirs[i], usage.Dirs[j]
+		if a.Ignored.Bytes != b.Ignored.Bytes {
+			return a.Ignored.Bytes > b.Ignored.Bytes
+		}
+		return a.Dir < b.Dir
+	})
+
+	for _, pattern := range patterns {
+		usage.Patterns = append(usage.Patterns, *pattern)
+	}
+	sort.Slice(usage.Patterns, func(i, j int) bool {
+		a, b := usage.Patterns[i], usage.Patterns[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Line < b.Line
+	})
+	return usage, nil
+}
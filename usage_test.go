@@ -0,0 +1,65 @@
+package dotignore
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestRepositoryMatcher_DiskUsage(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":         "*.log\nbuild/\n",
+		"main.go":            "package main\n",
+		"debug.log":          "1234567890",
+		"build/out.bin":      "12345678901234567890",
+		"build/sub/obj.o":    "12345",
+		"app/.gitignore":     "*.tmp\n!keep.log\n",
+		"app/app.go":         "package app\n",
+		"app/keep.log":       "kept",
+		"app/trace.log":      "123",
+		"app/scratch.tmp":    "1234567",
+		"app/nested/old.tmp": "12",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	matcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	usage, err := matcher.DiskUsage(context.Background())
+	if err != nil {
+		t.Fatalf("DiskUsage() failed: %v", err)
+	}
+
+	if want := (UsageTotals{Files: 5, Bytes: 13 + 13 + 16 + 12 + 4}); usage.Kept != want {
+		t.Errorf("Kept = %+v, want %+v", usage.Kept, want)
+	}
+	if want := (UsageTotals{Files: 6, Bytes: 10 + 20 + 5 + 3 + 7 + 2}); usage.Ignored != want {
+		t.Errorf("Ignored = %+v, want %+v", usage.Ignored, want)
+	}
+
+	wantDirs := []DirUsage{
+		{Dir: "build", Ignored: UsageTotals{Files: 2, Bytes: 25}},
+		{Dir: "app", Kept: UsageTotals{Files: 3, Bytes: 16 + 12 + 4}, Ignored: UsageTotals{Files: 3, Bytes: 12}},
+		{Dir: ".", Kept: UsageTotals{Files: 2, Bytes: 13 + 13}, Ignored: UsageTotals{Files: 1, Bytes: 10}},
+	}
+	if !reflect.DeepEqual(usage.Dirs, wantDirs) {
+		t.Errorf("Dirs = %+v, want %+v", usage.Dirs, wantDirs)
+	}
+
+	wantPatterns := []PatternUsage{
+		{PatternLocation{".gitignore", 2}, "build/", UsageTotals{Files: 2, Bytes: 25}},
+		{PatternLocation{".gitignore", 1}, "*.log", UsageTotals{Files: 2, Bytes: 13}},
+		{PatternLocation{"app/.gitignore", 1}, "*.tmp", UsageTotals{Files: 2, Bytes: 9}},
+	}
+	if !reflect.DeepEqual(usage.Patterns, wantPatterns) {
+		t.Errorf("Patterns = %+v, want %+v", usage.Patterns, wantPatterns)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := matcher.DiskUsage(ctx); err == nil {
+		t.Error("Expected error for a cancelled context")
+	}
+}
@@ -0,0 +1,68 @@
+package dotignore
+
+import (
+	"errors"
+	"sort"
+)
+
+// Validate checks every line of patterns and returns an IssueInvalid issue
+// for each one that cannot be parsed, in line order. Unlike the matcher
+// constructors, it does not stop at the first invalid line. Options select
+// the syntax and limits to validate against; problems not tied to a line,
+// such as exceeding WithMaxPatterns, are reported with a Line of 0.
+//
+// Validate does not report patterns that are valid but have no effect; use
+// Lint for those.
+func Validate(patterns []string, opts ...Option) []Issue {
+	var issues []Issue
+	options := buildOptions(opts)
+	options.lenient = true
+	options.onInvalid = func(issue Issue) {
+		issues = append(issues, issue)
+	}
+
+	if _, err := parsePatterns(patterns, options); err != nil {
+		issues = append(issues, Issue{Kind: IssueInvalid, Message: err.Error()})
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+	return issues
+}
+
+// WithSkipInvalidPatterns makes matchers skip lines that cannot be parsed,
+// as Git does, instead of failing on the first one. If report is not nil, it
+// is called with an IssueInvalid issue for every skipped line.
+//
+// The option applies to every method that parses patterns, such as
+// AddPatterns and SetPatterns, so report must be safe for concurrent use if
+// they are called concurrently.
+func WithSkipInvalidPatterns(report func(Issue)) Option {
+	return func(o *matcherOptions) {
+		o.lenient = true
+		o.onInvalid = report
+	}
+}
+
+// skipInvalid reports whether the error found while parsing a line should be
+// skipped, reporting the line if so.
+func (o matcherOptions) skipInvalid(err error) bool {
+	var parseErr *ParseError
+	if !o.lenient || !errors.As(err, &parseErr) {
+		return false
+	}
+	if o.onInvalid != nil {
+		o.onInvalid(invalidIssue(parseErr))
+	}
+	return true
+}
+
+// invalidIssue returns the Issue describing a line that cannot be parsed.
+func invalidIssue(err *ParseError) Issue {
+	return Issue{
+		Line:    err.Line,
+		Pattern: err.Pattern,
+		Kind:    IssueInvalid,
+		Message: err.Reason,
+	}
+}
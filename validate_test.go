@@ -0,0 +1,111 @@
+package dotignore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		patterns  []string
+		opts      []Option
+		wantLines []int
+	}{
+		{
+			name:     "Valid",
+			patterns: []string{"# deps", "node_modules/", "*.log", "!important.log"},
+		},
+		{
+			name:      "Every invalid line",
+			patterns:  []string{"!", "*.log", "/", "[z-a]", "dist/"},
+			wantLines: []int{1, 3, 4},
+		},
+		{
+			name:      "Docker syntax",
+			patterns:  []string{"*.log", "!", "[z-a]"},
+			opts:      []Option{WithSyntax(SyntaxDocker)},
+			wantLines: []int{2, 3},
+		},
+		{
+			name:      "Helm syntax",
+			patterns:  []string{"**/*.log", "*.tmp"},
+			opts:      []Option{WithSyntax(SyntaxHelm)},
+			wantLines: []int{1},
+		},
+		{
+			name:      "Long patterns in line order",
+			patterns:  []string{"!", "/" + strings.Repeat("a", 20), "*.log"},
+			opts:      []Option{WithMaxPatternLength(10)},
+			wantLines: []int{1, 2},
+		},
+		{
+			name:      "Too many patterns",
+			patterns:  []string{"*.log", "*.tmp", "!"},
+			opts:      []Option{WithMaxPatterns(1)},
+			wantLines: []int{0, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lines []int
+			for _, issue := range Validate(tt.patterns, tt.opts...) {
+				if issue.Kind != IssueInvalid {
+					t.Errorf("Issue %+v has kind %v, want %v", issue, issue.Kind, IssueInvalid)
+				}
+				lines = append(lines, issue.Line)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("Validate() reported lines %v, want %v", lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestWithSkipInvalidPatterns(t *testing.T) {
+	var issues []Issue
+	matcher, err := NewPatternMatcher([]string{"*.log", "!", "[z-a]", "!debug.log"}, WithSkipInvalidPatterns(func(issue Issue) {
+		issues = append(issues, issue)
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []Issue{
+		{Line: 2, Pattern: "!", Kind: IssueInvalid, Message: ErrInvalidNegation.Error()},
+		{Line: 3, Pattern: "[z-a]", Kind: IssueInvalid},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("Got issues %+v, want %d", issues, len(want))
+	}
+	for i := range want {
+		if issues[i].Line != want[i].Line || issues[i].Pattern != want[i].Pattern || issues[i].Kind != want[i].Kind {
+			t.Errorf("Issue %d = %+v, want %+v", i, issues[i], want[i])
+		}
+	}
+	if issues[0].Message != want[0].Message {
+		t.Errorf("Issue message = %q, want %q", issues[0].Message, want[0].Message)
+	}
+
+	tests := map[string]bool{"app.log": true, "debug.log": false, "main.go": false}
+	for file, want := range tests {
+		if got, err := matcher.Matches(file); err != nil || got != want {
+			t.Errorf("Matches(%q) = %v, %v; want %v", file, got, err, want)
+		}
+	}
+
+	// Later changes are parsed the same way
+	if err := matcher.AddPatterns([]string{"/", "*.tmp"}); err != nil {
+		t.Fatalf("AddPatterns() failed: %v", err)
+	}
+	if len(issues) != 3 || issues[2].Pattern != "/" {
+		t.Errorf("Expected AddPatterns to report the invalid line, got %+v", issues)
+	}
+
+	// A nil report function still skips invalid lines
+	if _, err := NewPatternMatcher([]string{"!", "*.log"}, WithSkipInvalidPatterns(nil)); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
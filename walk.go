@@ -0,0 +1,156 @@
+package dotignore
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// WalkDirFunc wraps next so that filepath.WalkDir (or fs.WalkDir) only
+// passes it paths that are not ignored. Ignored files are skipped and
+// fs.SkipDir is returned for ignored directories, so the walk never enters
+// them. The root of the walk is always passed to next.
+//
+// Paths are matched relative to the root of the walk, like MatchesPath with
+// the type of their directory entry:
+//
+//	err := filepath.WalkDir("./project", matcher.WalkDirFunc(func(path string, d fs.DirEntry, err error) error {
+//	    // path is not ignored
+//	    return err
+//	}))
+//
+// Errors reported by the walk are passed to next unchanged. An error from
+// matching a path is returned without calling next and stops the walk.
+//
+// The returned function takes the root from the first path it is called
+// with, so it serves a single walk, or repeated walks of the same root.
+// Create a new one for each other walk.
+func (p *PatternMatcher) WalkDirFunc(next fs.WalkDirFunc) fs.WalkDirFunc {
+	var root string
+	started := false
+	return walkDirFunc(next, func(path string, isDir bool) (bool, error) {
+		if !started {
+			root, started = path, true
+			return false, nil
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return false, err
+		}
+		return p.MatchesPath(relPath, isDir)
+	})
+}
+
+// WalkDirFunc wraps next so that filepath.WalkDir only passes it paths that
+// are not ignored, as described for PatternMatcher.WalkDirFunc. Paths are
+// resolved to absolute paths and matched like MatchesPath against the ignore
+// files of the repository, so the walk may start in any directory inside it. As the
+// root of the walk is recognized as the first path, the returned function
+// serves a single walk.
+func (rm *RepositoryMatcher) WalkDirFunc(next fs.WalkDirFunc) fs.WalkDirFunc {
+	started := false
+	return walkDirFunc(next, func(path string, isDir bool) (bool, error) {
+		if !started {
+			started = true
+			return false, nil
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return false, err
+		}
+		return rm.MatchesPath(absPath, isDir)
+	})
+}
+
+// MatchesEntry reports whether the directory entry d, found in the
+// directory parent, is ignored. It joins parent and the name of d and
+// matches the result like MatchesPath, taking from d whether it is a
+// directory, which suits loops over os.ReadDir or fs.WalkDir. An
+// fs.FileInfo can be passed through fs.FileInfoToDirEntry.
+func (p *PatternMatcher) MatchesEntry(parent string, d fs.DirEntry) (bool, error) {
+	if d == nil {
+		return false, errors.New("directory entry cannot be nil")
+	}
+	return p.MatchesPath(filepath.Join(parent, d.Name()), d.IsDir())
+}
+
+// MatchesEntry reports whether the directory entry d, found in the
+// directory parent, is ignored, as described for PatternMatcher.MatchesEntry.
+// parent may be absolute or relative to the repository root. The type of d
+// is used instead of looking the path up, even if CheckFileTypes is set.
+func (rm *RepositoryMatcher) MatchesEntry(parent string, d fs.DirEntry) (bool, error) {
+	if d == nil {
+		return false, errors.New("directory entry cannot be nil")
+	}
+	return rm.MatchesPath(filepath.Join(parent, d.Name()), d.IsDir())
+}
+
+// walkDirFunc implements WalkDirFunc for a function reporting whether a path
+// visited by the walk, which is a directory if isDir is set, is ignored.
+func walkDirFunc(next fs.WalkDirFunc, ignored func(path string, isDir bool) (bool, error)) fs.WalkDirFunc {
+	return func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return next(path, d, err)
+		}
+
+		skip, err := ignored(path, d.IsDir())
+		if err != nil {
+			return err
+		}
+		if skip {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return next(path, d, nil)
+	}
+}
+
+// walkFiles calls fn with the path, relative to root, of every file beneath
+// root that is ignored, or of every file that is not if ignored is false.
+// The walk stops with ctx.Err() once ctx is done.
+func (p *PatternMatcher) walkFiles(ctx context.Context, root string, ignored bool, fn func(path string) error) error {
+	skipped := "" // directory whose files are all ignored, if inside one
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+
+		if skipped != "" && strings.HasPrefix(relPath, skipped+string(filepath.Separator)) {
+			if d.IsDir() {
+				return nil
+			}
+			return fn(relPath)
+		}
+		skipped = ""
+
+		if d.IsDir() {
+			skip, err := p.CanSkipDir(relPath)
+			if err != nil || !skip {
+				return err
+			}
+			if !ignored {
+				return fs.SkipDir
+			}
+			// Everything beneath the directory is ignored
+			skipped = relPath
+			return nil
+		}
+
+		isIgnored, err := p.MatchesPath(relPath, false)
+		if err != nil || isIgnored != ignored {
+			return err
+		}
+		return fn(relPath)
+	})
+}
@@ -0,0 +1,156 @@
+package dotignore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWalkDirFunc(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":                   "*.log\nnode_modules/\n",
+		"main.go":                      "",
+		"debug.log":                    "",
+		"web/index.js":                 "",
+		"web/node_modules/lib/main.js": "",
+		"web/.gitignore":               "dist/\n",
+		"web/dist/bundle.js":           "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	collect := func(root string, wrap func(fs.WalkDirFunc) fs.WalkDirFunc) []string {
+		var visited []string
+		err := filepath.WalkDir(root, wrap(func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			relPath, err := filepath.Rel(tmpDir, path)
+			if err != nil {
+				return err
+			}
+			visited = append(visited, filepath.ToSlash(relPath))
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("WalkDir failed: %v", err)
+		}
+		return visited
+	}
+
+	t.Run("PatternMatcher", func(t *testing.T) {
+		matcher, err := NewPatternMatcher([]string{"*.log", "node_modules/", "/web/dist/"})
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+		expected := []string{".", ".gitignore", "main.go", "web", "web/.gitignore", "web/index.js"}
+		if visited := collect(tmpDir, matcher.WalkDirFunc); !reflect.DeepEqual(visited, expected) {
+			t.Errorf("Expected %v, got %v", expected, visited)
+		}
+	})
+
+	t.Run("Strict directory patterns", func(t *testing.T) {
+		// Directories are matched as directories, so they are not entered
+		matcher, err := NewPatternMatcher([]string{"*.log", "node_modules/", "/web/dist/"}, WithStrictDirectoryPatterns())
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+		expected := []string{".", ".gitignore", "main.go", "web", "web/.gitignore", "web/index.js"}
+		if visited := collect(tmpDir, matcher.WalkDirFunc); !reflect.DeepEqual(visited, expected) {
+			t.Errorf("Expected %v, got %v", expected, visited)
+		}
+	})
+
+	t.Run("RepositoryMatcher", func(t *testing.T) {
+		matcher, err := NewRepositoryMatcher(tmpDir)
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+		expected := []string{".", ".gitignore", "main.go", "web", "web/.gitignore", "web/index.js"}
+		if visited := collect(tmpDir, matcher.WalkDirFunc); !reflect.DeepEqual(visited, expected) {
+			t.Errorf("Expected %v, got %v", expected, visited)
+		}
+
+		// Walking a subdirectory still applies the repository's rules
+		expected = []string{"web", "web/.gitignore", "web/index.js"}
+		if visited := collect(filepath.Join(tmpDir, "web"), matcher.WalkDirFunc); !reflect.DeepEqual(visited, expected) {
+			t.Errorf("Expected %v, got %v", expected, visited)
+		}
+	})
+
+	t.Run("Walk errors", func(t *testing.T) {
+		matcher, err := NewPatternMatcher([]string{"*.log"})
+		if err != nil {
+			t.Fatalf("Failed to create matcher: %v", err)
+		}
+		var got error
+		walkErr := filepath.WalkDir(filepath.Join(tmpDir, "missing"), matcher.WalkDirFunc(func(path string, d fs.DirEntry, err error) error {
+			got = err
+			return err
+		}))
+		if !errors.Is(got, fs.ErrNotExist) || !errors.Is(walkErr, fs.ErrNotExist) {
+			t.Errorf("Expected the walk error to be passed to the callback, got %v", got)
+		}
+	})
+}
+
+func TestMatchesEntry(t *testing.T) {
+	tmpDir := createTestRepo(t, map[string]string{
+		".gitignore":     "build/\n*.log\n",
+		"build":          "a file, not a directory",
+		"web/build/a.js": "",
+		"web/app.log":    "",
+		"web/index.js":   "",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	patternMatcher, err := NewPatternMatcher([]string{"build/", "*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	repoMatcher, err := NewRepositoryMatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	expected := map[string]bool{
+		".gitignore":   false,
+		"build":        false,
+		"web":          false,
+		"web/build":    true,
+		"web/app.log":  true,
+		"web/index.js": false,
+	}
+
+	for _, dir := range []string{".", "web"} {
+		entries, err := os.ReadDir(filepath.Join(tmpDir, dir))
+		if err != nil {
+			t.Fatalf("ReadDir failed: %v", err)
+		}
+		for _, entry := range entries {
+			path := filepath.ToSlash(filepath.Join(dir, entry.Name()))
+
+			result, err := patternMatcher.MatchesEntry(dir, entry)
+			if err != nil {
+				t.Fatalf("PatternMatcher.MatchesEntry(%q) failed: %v", path, err)
+			}
+			if result != expected[path] {
+				t.Errorf("PatternMatcher.MatchesEntry(%q) = %v, want %v", path, result, expected[path])
+			}
+
+			result, err = repoMatcher.MatchesEntry(filepath.Join(tmpDir, dir), entry)
+			if err != nil {
+				t.Fatalf("RepositoryMatcher.MatchesEntry(%q) failed: %v", path, err)
+			}
+			if result != expected[path] {
+				t.Errorf("RepositoryMatcher.MatchesEntry(%q) = %v, want %v", path, result, expected[path])
+			}
+		}
+	}
+
+	if _, err := patternMatcher.MatchesEntry(".", nil); err == nil {
+		t.Error("MatchesEntry() with a nil entry should fail")
+	}
+}